@@ -0,0 +1,323 @@
+// Package compensation derives the statements which
+// reverse mutations executed against providers, for
+// replay under compensating rollback.
+package compensation
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/stackql/stackql-parser/go/vt/sqlparser"
+)
+
+var (
+	_ PreImage      = (*deletePreImage)(nil)
+	_ InsertInverse = (*insertInverse)(nil)
+)
+
+const (
+	requestBodyColumnPrefix = "data__"
+)
+
+// PreImage captures what is needed to re-create the
+// resources removed by a DELETE: the query which reads
+// them beforehand and the parameters identifying them.
+type PreImage interface {
+	GetTableName() string
+	GetQuery() string
+	// RecreateStatements renders one INSERT per row read by
+	// the pre image query.  Row columns are supplied as request
+	// body fields and the identifying parameters of the DELETE
+	// as parameters, wherever a row does not already supply them.
+	// This is best effort: read only fields are included and
+	// a provider may well reject them.
+	RecreateStatements(rows []map[string]any) []string
+}
+
+type deletePreImage struct {
+	tableName string
+	query     string
+	params    map[string]string
+}
+
+func NewDeletePreImage(stmt *sqlparser.Delete) (PreImage, error) {
+	if len(stmt.TableExprs) != 1 {
+		return nil, fmt.Errorf("cannot derive pre image for DELETE from %d tables", len(stmt.TableExprs))
+	}
+	aliased, isAliased := stmt.TableExprs[0].(*sqlparser.AliasedTableExpr)
+	if !isAliased {
+		return nil, fmt.Errorf("cannot derive pre image for DELETE from '%T'", stmt.TableExprs[0])
+	}
+	tableName, isTableName := aliased.Expr.(sqlparser.TableName)
+	if !isTableName || tableName.IsEmpty() {
+		return nil, fmt.Errorf("cannot derive pre image for DELETE from '%T'", aliased.Expr)
+	}
+	rv := &deletePreImage{
+		tableName: renderTableName(tableName),
+		params:    make(map[string]string),
+	}
+	rv.query = fmt.Sprintf("SELECT * FROM %s", rv.tableName)
+	if stmt.Where != nil && stmt.Where.Expr != nil {
+		rv.query = fmt.Sprintf("%s WHERE %s", rv.query, sqlparser.String(stmt.Where.Expr))
+		collectEqualityParams(stmt.Where.Expr, rv.params)
+	}
+	return rv, nil
+}
+
+func (p *deletePreImage) GetTableName() string {
+	return p.tableName
+}
+
+func (p *deletePreImage) GetQuery() string {
+	return p.query
+}
+
+func (p *deletePreImage) RecreateStatements(rows []map[string]any) []string {
+	var rv []string
+	for _, row := range rows {
+		var columns, values []string
+		for _, k := range sortedKeys(p.params) {
+			if _, isSupplied := row[k]; isSupplied {
+				continue
+			}
+			columns = append(columns, quoteIdentifier(k))
+			values = append(values, p.params[k])
+		}
+		for _, k := range sortedKeys(row) {
+			if row[k] == nil {
+				continue
+			}
+			columns = append(columns, quoteIdentifier(requestBodyColumnPrefix+k))
+			values = append(values, renderLiteral(row[k]))
+		}
+		if len(columns) == 0 {
+			continue
+		}
+		rv = append(rv, fmt.Sprintf(
+			"INSERT INTO %s(%s) SELECT %s",
+			p.tableName,
+			strings.Join(columns, ", "),
+			strings.Join(values, ", "),
+		))
+	}
+	return rv
+}
+
+// InsertInverse captures the DELETE statements reversing an
+// INSERT: one per row inserted, identifying the resource by the
+// parameters and request body fields it was inserted with.
+type InsertInverse interface {
+	GetTableName() string
+	// DeleteStatements renders one DELETE per row inserted.
+	// This is best effort: a provider may well identify
+	// resources for deletion by other parameters.
+	DeleteStatements() []string
+}
+
+type insertInverse struct {
+	tableName string
+	// The identifying terms of each row, by column.
+	rows []map[string]string
+}
+
+// NewInsertInverse derives the DELETE statements reversing stmt,
+// whose rows must be literal values, as those of stackql INSERTs
+// are; request parameters not given as literals cannot be
+// identified, so that no DELETE is derived.
+func NewInsertInverse(stmt *sqlparser.Insert) (InsertInverse, error) {
+	if stmt.Table.IsEmpty() {
+		return nil, fmt.Errorf("cannot derive DELETE compensating INSERT without table")
+	}
+	tuples, err := literalRows(stmt.Rows)
+	if err != nil {
+		return nil, err
+	}
+	rv := &insertInverse{
+		tableName: renderTableName(stmt.Table),
+	}
+	for _, tuple := range tuples {
+		if len(tuple) != len(stmt.Columns) {
+			return nil, fmt.Errorf(
+				"cannot derive DELETE compensating INSERT of %d values into %d columns", len(tuple), len(stmt.Columns))
+		}
+		terms := make(map[string]string)
+		for i, col := range stmt.Columns {
+			name := col.GetRawVal()
+			field, isBodyField := strings.CutPrefix(name, requestBodyColumnPrefix)
+			isLiteral := isLiteralExpr(tuple[i])
+			switch {
+			case isLiteral && isBodyField:
+				terms[field] = sqlparser.String(tuple[i])
+			case isLiteral:
+				terms[name] = sqlparser.String(tuple[i])
+			case !isBodyField:
+				if _, isNull := tuple[i].(*sqlparser.NullVal); !isNull {
+					return nil, fmt.Errorf("cannot derive DELETE compensating INSERT with parameter '%s' of non literal value", name)
+				}
+			}
+		}
+		if len(terms) == 0 {
+			return nil, fmt.Errorf("cannot derive DELETE compensating INSERT of a row without literal values")
+		}
+		rv.rows = append(rv.rows, terms)
+	}
+	return rv, nil
+}
+
+// literalRows returns the value tuples of rows, which
+// may be VALUES or a SELECT of values alone.
+func literalRows(rows sqlparser.InsertRows) ([][]sqlparser.Expr, error) {
+	switch node := rows.(type) {
+	case sqlparser.Values:
+		rv := make([][]sqlparser.Expr, 0, len(node))
+		for _, tuple := range node {
+			rv = append(rv, tuple)
+		}
+		return rv, nil
+	case *sqlparser.Select:
+		if node.Where != nil || len(node.GroupBy) > 0 || !isFromDual(node.From) {
+			return nil, fmt.Errorf("cannot derive DELETE compensating INSERT of rows selected from tables")
+		}
+		tuple := make([]sqlparser.Expr, 0, len(node.SelectExprs))
+		for _, expr := range node.SelectExprs {
+			aliased, isAliased := expr.(*sqlparser.AliasedExpr)
+			if !isAliased {
+				return nil, fmt.Errorf("cannot derive DELETE compensating INSERT of '%s'", sqlparser.String(expr))
+			}
+			tuple = append(tuple, aliased.Expr)
+		}
+		return [][]sqlparser.Expr{tuple}, nil
+	default:
+		return nil, fmt.Errorf("cannot derive DELETE compensating INSERT of rows '%T'", rows)
+	}
+}
+
+func isLiteralExpr(expr sqlparser.Expr) bool {
+	switch expr.(type) {
+	case *sqlparser.SQLVal, sqlparser.BoolVal:
+		return true
+	default:
+		return false
+	}
+}
+
+func isFromDual(from sqlparser.TableExprs) bool {
+	if len(from) == 0 {
+		return true
+	}
+	if len(from) != 1 {
+		return false
+	}
+	aliased, isAliased := from[0].(*sqlparser.AliasedTableExpr)
+	if !isAliased {
+		return false
+	}
+	tableName, isTableName := aliased.Expr.(sqlparser.TableName)
+	return isTableName && tableName.Qualifier.IsEmpty() && tableName.Name.GetRawVal() == "dual"
+}
+
+func (i *insertInverse) GetTableName() string {
+	return i.tableName
+}
+
+func (i *insertInverse) DeleteStatements() []string {
+	rv := make([]string, 0, len(i.rows))
+	for _, terms := range i.rows {
+		conditions := make([]string, 0, len(terms))
+		for _, k := range sortedKeys(terms) {
+			conditions = append(conditions, fmt.Sprintf("%s = %s", quoteIdentifier(k), terms[k]))
+		}
+		rv = append(rv, fmt.Sprintf(
+			"DELETE FROM %s WHERE %s",
+			i.tableName,
+			strings.Join(conditions, " AND "),
+		))
+	}
+	return rv
+}
+
+func renderTableName(tableName sqlparser.TableName) string {
+	var parts []string
+	for _, ident := range []sqlparser.TableIdent{
+		tableName.QualifierThird,
+		tableName.QualifierSecond,
+		tableName.Qualifier,
+		tableName.Name,
+	} {
+		if !ident.IsEmpty() {
+			parts = append(parts, ident.GetRawVal())
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// collectEqualityParams gathers `column = literal` terms
+// from a conjunction; anything else is disregarded.
+func collectEqualityParams(expr sqlparser.Expr, params map[string]string) {
+	switch node := expr.(type) {
+	case *sqlparser.AndExpr:
+		collectEqualityParams(node.Left, params)
+		collectEqualityParams(node.Right, params)
+	case *sqlparser.ComparisonExpr:
+		if node.Operator != sqlparser.EqualStr {
+			return
+		}
+		col, isCol := node.Left.(*sqlparser.ColName)
+		val, isVal := node.Right.(*sqlparser.SQLVal)
+		if !isCol || !isVal {
+			col, isCol = node.Right.(*sqlparser.ColName)
+			val, isVal = node.Left.(*sqlparser.SQLVal)
+		}
+		if isCol && isVal {
+			params[col.Name.GetRawVal()] = sqlparser.String(val)
+		}
+	}
+}
+
+func renderLiteral(v any) string {
+	switch val := v.(type) {
+	case string:
+		return quote(val)
+	case []byte:
+		return quote(string(val))
+	case bool:
+		if val {
+			return "true"
+		}
+		return "false"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, json.Number:
+		return fmt.Sprintf("%v", val)
+	default:
+		b, err := json.Marshal(val)
+		if err != nil {
+			return quote(fmt.Sprintf("%v", val))
+		}
+		return quote(string(b))
+	}
+}
+
+// quoteIdentifier double quotes column names, as taken from
+// provider responses, other than those which the parser's
+// own formatting leaves bare: neither keywords nor containing
+// characters beyond those of identifiers.
+func quoteIdentifier(s string) string {
+	if sqlparser.String(sqlparser.NewColIdent(s)) == s {
+		return s
+	}
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	rv := make([]string, 0, len(m))
+	for k := range m {
+		rv = append(rv, k)
+	}
+	sort.Strings(rv)
+	return rv
+}
@@ -0,0 +1,126 @@
+package compensation_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stackql/stackql-parser/go/vt/sqlparser"
+
+	. "github.com/stackql/stackql/internal/stackql/acid/compensation"
+)
+
+func TestDeletePreImage(t *testing.T) {
+	stmt, err := sqlparser.Parse(
+		"DELETE FROM google.compute.networks WHERE project = 'my-project' AND network = 'my-network'")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	del, isDelete := stmt.(*sqlparser.Delete)
+	if !isDelete {
+		t.Fatalf("expected DELETE, got %T", stmt)
+	}
+	preImage, err := NewDeletePreImage(del)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if preImage.GetTableName() != "google.compute.networks" {
+		t.Fatalf("unexpected table name: %s", preImage.GetTableName())
+	}
+	expectedQuery := "SELECT * FROM google.compute.networks WHERE project = 'my-project' and network = 'my-network'"
+	if preImage.GetQuery() != expectedQuery {
+		t.Fatalf("unexpected pre image query: %s", preImage.GetQuery())
+	}
+	recreates := preImage.RecreateStatements([]map[string]any{
+		{
+			"name":                  "my-network",
+			"autoCreateSubnetworks": true,
+			"description":           "it's mine",
+			"peerings":              nil,
+		},
+	})
+	expected := "INSERT INTO google.compute.networks(network, project, data__autoCreateSubnetworks, " +
+		"data__description, data__name) SELECT 'my-network', 'my-project', true, 'it''s mine', 'my-network'"
+	if len(recreates) != 1 || recreates[0] != expected {
+		t.Fatalf("unexpected re-create statements: %v", recreates)
+	}
+}
+
+func TestDeletePreImageQuotesColumns(t *testing.T) {
+	stmt, err := sqlparser.Parse("DELETE FROM a.b.c WHERE project = 'p' AND id = 'x'")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	preImage, err := NewDeletePreImage(stmt.(*sqlparser.Delete))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	recreates := preImage.RecreateStatements([]map[string]any{
+		{
+			"id":                       "x",
+			"display name":             "d",
+			`a") SELECT 1; DELETE ("b`: "y",
+		},
+	})
+	if len(recreates) != 1 {
+		t.Fatalf("unexpected re-create statements: %v", recreates)
+	}
+	recreate, err := sqlparser.Parse(recreates[0])
+	if err != nil {
+		t.Fatalf("expected a single parsable statement, got %s: %v", recreates[0], err)
+	}
+	var columns []string
+	for _, col := range recreate.(*sqlparser.Insert).Columns {
+		columns = append(columns, col.GetRawVal())
+	}
+	expected := []string{"project", `data__a") SELECT 1; DELETE ("b`, "data__display name", "data__id"}
+	if strings.Join(columns, "|") != strings.Join(expected, "|") {
+		t.Fatalf("unexpected columns: %q", columns)
+	}
+}
+
+func TestInsertInverse(t *testing.T) {
+	stmt, err := sqlparser.Parse(
+		"INSERT INTO google.compute.networks(project, data__name, data__autoCreateSubnetworks, data__labels) " +
+			"SELECT 'my-project', 'my-network', true, json_object('a', 'b')")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	inverse, err := NewInsertInverse(stmt.(*sqlparser.Insert))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	deletes := inverse.DeleteStatements()
+	expected := "DELETE FROM google.compute.networks WHERE autoCreateSubnetworks = true AND name = 'my-network' AND project = 'my-project'"
+	if len(deletes) != 1 || deletes[0] != expected {
+		t.Fatalf("unexpected delete statements: %v", deletes)
+	}
+	if _, err = sqlparser.Parse(deletes[0]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stmt, err = sqlparser.Parse("INSERT INTO a.b.c(project, data__name) VALUES ('p', 'x'), ('p', 'y')")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	inverse, err = NewInsertInverse(stmt.(*sqlparser.Insert))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	deletes = inverse.DeleteStatements()
+	if len(deletes) != 2 || deletes[1] != "DELETE FROM a.b.c WHERE name = 'y' AND project = 'p'" {
+		t.Fatalf("unexpected delete statements: %v", deletes)
+	}
+
+	for _, query := range []string{
+		"INSERT INTO a.b.c(project, data__name) SELECT project, name FROM d.e.f",
+		"INSERT INTO a.b.c(project, data__name) SELECT concat('p', '1'), 'x'",
+	} {
+		stmt, err = sqlparser.Parse(query)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err = NewInsertInverse(stmt.(*sqlparser.Insert)); err == nil {
+			t.Fatalf("%s: expected no inverse to be derived", query)
+		}
+	}
+}
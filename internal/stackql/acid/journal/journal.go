@@ -0,0 +1,461 @@
+// Package journal implements a durable, write ahead record of
+// transactions, such that mutations executed by a process which
+// dies mid transaction can later be identified and recovered.
+//
+// Each transaction is journaled to its own file of JSON lines,
+// every record of which is fsync'd before the operation it
+// describes proceeds.  The file of a transaction is removed once
+// the transaction ends.  The process running a transaction holds
+// an exclusive lock upon it until then, which the operating system
+// releases should the process die; files remaining unlocked are
+// those of incomplete transactions.
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofrs/flock"
+)
+
+var (
+	_ Journal = (*fileJournal)(nil)
+	_ Journal = (*nopJournal)(nil)
+)
+
+//nolint:gochecknoglobals // process wide sequence
+var txnSequence atomic.Int64
+
+var (
+	ErrTxnNotFound = errors.New("journaled transaction not found")
+	// ErrTxnInProgress is returned upon claiming a transaction
+	// which a live process is running or recovering.
+	ErrTxnInProgress = errors.New("journaled transaction is held by a live process")
+)
+
+const (
+	// DirName is the journal directory, relative to the approot.
+	DirName       = "txn_journal"
+	fileExtension = ".journal"
+	lockExtension = ".lock"
+	dirMode       = 0o700
+	fileMode      = 0o600
+)
+
+type Kind string
+
+const (
+	KindTransaction Kind = "transaction"
+	KindStatement   Kind = "statement"
+)
+
+type Status string
+
+const (
+	StatusBegun     Status = "begun"
+	StatusPending   Status = "pending"
+	StatusExecuting Status = "executing"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	// StatusCompensated is that of a statement
+	// whose effects were reversed upon recovery.
+	StatusCompensated Status = "compensated"
+	StatusCommitted   Status = "committed"
+	StatusRolledBack  Status = "rolled_back"
+	StatusRecovered   Status = "recovered"
+	StatusDiscarded   Status = "discarded"
+)
+
+// IsFinal reports whether a transaction
+// with status s requires no recovery.
+func (s Status) IsFinal() bool {
+	switch s { //nolint:exhaustive // all others are not final
+	case StatusCommitted, StatusRolledBack, StatusRecovered, StatusDiscarded:
+		return true
+	default:
+		return false
+	}
+}
+
+// Payload is the serialised form of a redo or undo log entry.
+type Payload struct {
+	HumanReadable []string `json:"human_readable,omitempty"`
+	Raw           string   `json:"raw,omitempty"`
+}
+
+type Record struct {
+	TxnID  string    `json:"txn_id"`
+	Kind   Kind      `json:"kind"`
+	Seq    int       `json:"seq,omitempty"`
+	Query  string    `json:"query,omitempty"`
+	Status Status    `json:"status"`
+	Redo   *Payload  `json:"redo,omitempty"`
+	Undo   *Payload  `json:"undo,omitempty"`
+	Error  string    `json:"error,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// StatementState is the latest journaled state of a statement.
+type StatementState struct {
+	Seq    int
+	Query  string
+	Status Status
+	Redo   *Payload
+	Undo   *Payload
+	Error  string
+}
+
+// Transaction is the state of a transaction, as
+// reconstructed from its journal.
+type Transaction struct {
+	ID         string
+	Started    time.Time
+	Status     Status
+	Statements []StatementState
+}
+
+type Journal interface {
+	// Begin journals a new transaction, returning its ID.
+	Begin() (string, error)
+	// Append durably journals a record.
+	Append(Record) error
+	// End journals the final status of a transaction,
+	// which is thereafter no longer retained.
+	End(txnID string, status Status) error
+	// List returns the incomplete transactions: those
+	// not ended, whose processes are no longer live.
+	List() ([]Transaction, error)
+	Get(txnID string) (Transaction, error)
+	// Claim locks the incomplete transaction txnID for recovery
+	// by this process, until it is ended or released.
+	Claim(txnID string) (Transaction, error)
+	// Release gives up the lock upon a transaction
+	// claimed but not ended, so that it may be retried.
+	Release(txnID string)
+}
+
+type fileJournal struct {
+	mu  sync.Mutex
+	dir string
+	// The locks held by this process, by transaction.
+	locks map[string]*flock.Flock
+}
+
+// NewFileJournal returns a journal persisted beneath dir,
+// which is created if it does not exist.
+func NewFileJournal(dir string) (Journal, error) {
+	if err := os.MkdirAll(dir, dirMode); err != nil {
+		return nil, fmt.Errorf("cannot create transaction journal directory: %w", err)
+	}
+	return &fileJournal{
+		dir:   dir,
+		locks: make(map[string]*flock.Flock),
+	}, nil
+}
+
+func newTxnID() string {
+	return fmt.Sprintf(
+		"%s-%d-%d",
+		time.Now().UTC().Format("20060102T150405"),
+		os.Getpid(),
+		txnSequence.Add(1),
+	)
+}
+
+func (j *fileJournal) path(txnID string) string {
+	return filepath.Join(j.dir, txnID+fileExtension)
+}
+
+func (j *fileJournal) lockPath(txnID string) string {
+	return filepath.Join(j.dir, txnID+lockExtension)
+}
+
+// tryLock takes the lock upon txnID, reporting
+// false if another process, or this one, holds it.
+func (j *fileJournal) tryLock(txnID string) (*flock.Flock, bool, error) {
+	j.mu.Lock()
+	_, isHeld := j.locks[txnID]
+	j.mu.Unlock()
+	if isHeld {
+		return nil, false, nil
+	}
+	lock := flock.New(j.lockPath(txnID))
+	isLocked, err := lock.TryLock()
+	if err != nil {
+		return nil, false, fmt.Errorf("cannot lock transaction journal: %w", err)
+	}
+	return lock, isLocked, nil
+}
+
+func (j *fileJournal) hold(txnID string, lock *flock.Flock) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.locks[txnID] = lock
+}
+
+func (j *fileJournal) Begin() (string, error) {
+	txnID := newTxnID()
+	// The lock is taken before the journal file exists,
+	// so that the transaction is never seen unlocked.
+	lock, isLocked, err := j.tryLock(txnID)
+	if err != nil {
+		return "", err
+	}
+	if !isLocked {
+		return "", fmt.Errorf("%w: '%s'", ErrTxnInProgress, txnID)
+	}
+	j.hold(txnID, lock)
+	if err = j.Append(Record{TxnID: txnID, Kind: KindTransaction, Status: StatusBegun}); err != nil {
+		j.Release(txnID)
+		return "", err
+	}
+	// Make the new directory entry itself durable.
+	if err := syncDir(j.dir); err != nil {
+		return "", err
+	}
+	return txnID, nil
+}
+
+func (j *fileJournal) Append(record Record) error {
+	if record.Time.IsZero() {
+		record.Time = time.Now().UTC()
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	f, err := os.OpenFile(j.path(record.TxnID), os.O_APPEND|os.O_CREATE|os.O_WRONLY, fileMode)
+	if err != nil {
+		return fmt.Errorf("cannot open transaction journal: %w", err)
+	}
+	if _, err = f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("cannot write transaction journal: %w", err)
+	}
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("cannot sync transaction journal: %w", err)
+	}
+	return f.Close()
+}
+
+func (j *fileJournal) End(txnID string, status Status) error {
+	if err := j.Append(Record{TxnID: txnID, Kind: KindTransaction, Status: status}); err != nil {
+		return err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := os.Remove(j.path(txnID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	// The journal file is gone before the lock is given up,
+	// so that any process next to take it finds nothing to do.
+	if lock, isHeld := j.locks[txnID]; isHeld {
+		delete(j.locks, txnID)
+		os.Remove(j.lockPath(txnID)) //nolint:errcheck // best effort
+		return lock.Close()
+	}
+	return nil
+}
+
+func (j *fileJournal) Release(txnID string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if lock, isHeld := j.locks[txnID]; isHeld {
+		delete(j.locks, txnID)
+		lock.Close() //nolint:errcheck // best effort
+	}
+}
+
+func (j *fileJournal) Claim(txnID string) (Transaction, error) {
+	if _, err := j.Get(txnID); err != nil {
+		return Transaction{}, err
+	}
+	lock, isLocked, err := j.tryLock(txnID)
+	if err != nil {
+		return Transaction{}, err
+	}
+	if !isLocked {
+		return Transaction{}, fmt.Errorf("%w: '%s'", ErrTxnInProgress, txnID)
+	}
+	j.hold(txnID, lock)
+	// Read afresh, as the transaction may have
+	// progressed or ended before the lock was taken.
+	txn, err := j.Get(txnID)
+	if err != nil {
+		j.Release(txnID)
+		return Transaction{}, err
+	}
+	return txn, nil
+}
+
+// isLive reports whether a live process holds the lock upon txnID.
+func (j *fileJournal) isLive(txnID string) (bool, error) {
+	lock, isLocked, err := j.tryLock(txnID)
+	if err != nil || !isLocked {
+		return err == nil, err
+	}
+	return false, lock.Close()
+}
+
+func (j *fileJournal) Get(txnID string) (Transaction, error) {
+	if strings.ContainsAny(txnID, `/\`) {
+		return Transaction{}, fmt.Errorf("%w: '%s'", ErrTxnNotFound, txnID)
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return readTransaction(j.path(txnID), txnID)
+}
+
+func (j *fileJournal) List() ([]Transaction, error) {
+	entries, err := os.ReadDir(j.dir)
+	if err != nil {
+		return nil, err
+	}
+	var rv []Transaction
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), fileExtension) {
+			continue
+		}
+		txnID := strings.TrimSuffix(entry.Name(), fileExtension)
+		isLive, liveErr := j.isLive(txnID)
+		if liveErr != nil {
+			return nil, liveErr
+		}
+		if isLive {
+			continue
+		}
+		txn, readErr := j.Get(txnID)
+		if errors.Is(readErr, ErrTxnNotFound) {
+			// Ended since the directory was read.
+			continue
+		}
+		if readErr != nil {
+			return nil, readErr
+		}
+		// A process which dies between journaling
+		// the final status and removing the file
+		// leaves a complete transaction behind.
+		if txn.Status.IsFinal() {
+			continue
+		}
+		rv = append(rv, txn)
+	}
+	sort.Slice(rv, func(i, k int) bool {
+		return rv[i].Started.Before(rv[k].Started)
+	})
+	return rv, nil
+}
+
+// readTransaction folds the records of a journal file into the
+// latest state of the transaction and its statements.  A torn final
+// line, as left by a crash mid write, is disregarded.
+func readTransaction(path string, txnID string) (Transaction, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Transaction{}, fmt.Errorf("%w: '%s'", ErrTxnNotFound, txnID)
+	}
+	if err != nil {
+		return Transaction{}, err
+	}
+	defer f.Close()
+	rv := Transaction{ID: txnID}
+	statements := make(map[int]*StatementState)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), 1<<24) //nolint:mnd // generous limit for payloads
+	for scanner.Scan() {
+		var record Record
+		if json.Unmarshal(scanner.Bytes(), &record) != nil {
+			continue
+		}
+		switch record.Kind {
+		case KindTransaction:
+			if record.Status == StatusBegun {
+				rv.Started = record.Time
+			}
+			rv.Status = record.Status
+		case KindStatement:
+			state, ok := statements[record.Seq]
+			if !ok {
+				state = &StatementState{Seq: record.Seq}
+				statements[record.Seq] = state
+			}
+			state.Status = record.Status
+			if record.Query != "" {
+				state.Query = record.Query
+			}
+			if record.Redo != nil {
+				state.Redo = record.Redo
+			}
+			if record.Undo != nil {
+				state.Undo = record.Undo
+			}
+			state.Error = record.Error
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return rv, err
+	}
+	for _, state := range statements {
+		rv.Statements = append(rv.Statements, *state)
+	}
+	sort.Slice(rv.Statements, func(i, k int) bool {
+		return rv.Statements[i].Seq < rv.Statements[k].Seq
+	})
+	return rv, nil
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	// Not all platforms support syncing directories.
+	d.Sync() //nolint:errcheck // best effort
+	return nil
+}
+
+type nopJournal struct{}
+
+// NewNopJournal returns a journal which retains nothing.
+func NewNopJournal() Journal {
+	return &nopJournal{}
+}
+
+func (j *nopJournal) Begin() (string, error) {
+	return newTxnID(), nil
+}
+
+func (j *nopJournal) Append(Record) error {
+	return nil
+}
+
+func (j *nopJournal) End(string, Status) error {
+	return nil
+}
+
+func (j *nopJournal) List() ([]Transaction, error) {
+	return nil, nil
+}
+
+func (j *nopJournal) Get(txnID string) (Transaction, error) {
+	return Transaction{}, fmt.Errorf("%w: '%s'", ErrTxnNotFound, txnID)
+}
+
+func (j *nopJournal) Claim(txnID string) (Transaction, error) {
+	return j.Get(txnID)
+}
+
+func (j *nopJournal) Release(string) {}
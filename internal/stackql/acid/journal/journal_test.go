@@ -0,0 +1,179 @@
+package journal_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/stackql/stackql/internal/stackql/acid/journal"
+)
+
+func TestJournalListsIncompleteTransactions(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), DirName)
+	j, err := NewFileJournal(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	incomplete, err := j.Begin()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	complete, err := j.Begin()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	records := []Record{
+		{TxnID: incomplete, Kind: KindStatement, Seq: 1, Query: "insert into a.b.c select 1;", Status: StatusPending},
+		{TxnID: incomplete, Kind: KindStatement, Seq: 1, Status: StatusExecuting},
+		{
+			TxnID: incomplete, Kind: KindStatement, Seq: 1, Status: StatusSucceeded,
+			Undo: &Payload{HumanReadable: []string{"delete from a.b.c where id = 1;"}, Raw: "delete from a.b.c where id = 1;"},
+		},
+		{TxnID: incomplete, Kind: KindStatement, Seq: 2, Query: "delete from a.b.c;", Status: StatusPending},
+		{TxnID: complete, Kind: KindStatement, Seq: 1, Query: "delete from a.b.c;", Status: StatusSucceeded},
+	}
+	for _, r := range records {
+		if err := j.Append(r); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := j.End(complete, StatusCommitted); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// As if the process died mid transaction.
+	j.Release(incomplete)
+	recovering, err := NewFileJournal(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	txns, err := recovering.List()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(txns) != 1 || txns[0].ID != incomplete {
+		t.Fatalf("expected only the incomplete transaction, got: %+v", txns)
+	}
+	txn := txns[0]
+	if txn.Status != StatusBegun || txn.Started.IsZero() {
+		t.Fatalf("unexpected transaction state: %+v", txn)
+	}
+	if len(txn.Statements) != 2 {
+		t.Fatalf("expected 2 statements, got: %+v", txn.Statements)
+	}
+	first := txn.Statements[0]
+	if first.Status != StatusSucceeded || first.Query != "insert into a.b.c select 1;" || first.Undo == nil {
+		t.Fatalf("unexpected first statement: %+v", first)
+	}
+	if txn.Statements[1].Status != StatusPending {
+		t.Fatalf("unexpected second statement: %+v", txn.Statements[1])
+	}
+	if _, err := j.Get(complete); !errors.Is(err, ErrTxnNotFound) {
+		t.Fatalf("expected ended transaction to be gone, got: %v", err)
+	}
+}
+
+func TestJournalToleratesTornRecord(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), DirName)
+	j, err := NewFileJournal(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	txnID, err := j.Begin()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := j.Append(Record{TxnID: txnID, Kind: KindStatement, Seq: 1, Query: "exec a.b.c.d;", Status: StatusExecuting}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f, err := os.OpenFile(filepath.Join(dir, txnID+".journal"), os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f.WriteString(`{"txn_id":"` + txnID + `","kind":"statem`) //nolint:errcheck // test
+	f.Close()
+	txn, err := j.Get(txnID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(txn.Statements) != 1 || txn.Statements[0].Status != StatusExecuting {
+		t.Fatalf("unexpected statements: %+v", txn.Statements)
+	}
+}
+
+func TestJournalIgnoresUnremovedCompleteTransaction(t *testing.T) {
+	j, err := NewFileJournal(filepath.Join(t.TempDir(), DirName))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	txnID, err := j.Begin()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// As if the process died after journaling the rollback.
+	if err := j.Append(Record{TxnID: txnID, Kind: KindTransaction, Status: StatusRolledBack}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	j.Release(txnID)
+	txns, err := j.List()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(txns) != 0 {
+		t.Fatalf("expected no incomplete transactions, got: %+v", txns)
+	}
+}
+
+func TestJournalClaimsTransactionsOfExitedProcessesOnly(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), DirName)
+	running, err := NewFileJournal(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	txnID, err := running.Begin()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	recovering, err := NewFileJournal(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	txns, err := recovering.List()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(txns) != 0 {
+		t.Fatalf("expected a running transaction not to be listed, got: %+v", txns)
+	}
+	if _, err := recovering.Claim(txnID); !errors.Is(err, ErrTxnInProgress) {
+		t.Fatalf("expected a running transaction not to be claimed, got: %v", err)
+	}
+	// As if the running process died.
+	running.Release(txnID)
+	if txns, err = recovering.List(); err != nil || len(txns) != 1 {
+		t.Fatalf("expected the transaction to be listed, got: %+v, %v", txns, err)
+	}
+	if _, err := recovering.Claim(txnID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	concurrent, err := NewFileJournal(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := concurrent.Claim(txnID); !errors.Is(err, ErrTxnInProgress) {
+		t.Fatalf("expected a transaction under recovery not to be claimed again, got: %v", err)
+	}
+	if txns, err = concurrent.List(); err != nil || len(txns) != 0 {
+		t.Fatalf("expected a transaction under recovery not to be listed, got: %+v, %v", txns, err)
+	}
+	if err := recovering.End(txnID, StatusRecovered); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected no files left once ended, got %d", len(entries))
+	}
+}
@@ -41,6 +41,8 @@ type Operation interface {
 	SetInputAlias(alias string, id int64) error
 	//
 	IsReadOnly() bool
+	// Get the plan level description of the underlying primitive.
+	GetDescriptor() (primitive.Descriptor, bool)
 }
 
 type reversibleOperation struct {
@@ -85,6 +87,10 @@ func (op *reversibleOperation) GetUndoLog() (binlog.LogEntry, bool) {
 	return op.pr.GetUndoLog()
 }
 
+func (op *reversibleOperation) GetDescriptor() (primitive.Descriptor, bool) {
+	return op.pr.GetDescriptor()
+}
+
 type irreversibleOperation struct {
 	pr primitive.IPrimitive
 }
@@ -127,6 +133,10 @@ func (op *irreversibleOperation) GetUndoLog() (binlog.LogEntry, bool) {
 	return nil, false
 }
 
+func (op *irreversibleOperation) GetDescriptor() (primitive.Descriptor, bool) {
+	return op.pr.GetDescriptor()
+}
+
 func NewReversibleOperation(pr primitive.IPrimitive) Operation {
 	return &reversibleOperation{
 		pr: pr,
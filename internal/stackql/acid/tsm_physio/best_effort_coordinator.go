@@ -27,6 +27,9 @@ type basicBestEffortTransactionCoordinator struct {
 	maxTxnDepth       int
 	outputs           []internaldto.ExecutorOutput
 	isExecuted        bool
+	lockOwner         int64
+	isCompensating    bool
+	compensations     []*compensationRecord
 	// redoGraphs        []primitivegraph.PrimitiveGraph
 	// undoGraphs        []primitivegraph.PrimitiveGraph
 }
@@ -36,12 +39,15 @@ func newBasicBestEffortTransactionCoordinator(
 	handlerCtx handler.HandlerContext,
 	parent Coordinator,
 	maxTxnDepth int,
+	lockOwner int64,
 ) Coordinator {
 	return &basicBestEffortTransactionCoordinator{
-		tsmInstance: tsmInstance,
-		handlerCtx:  handlerCtx,
-		parent:      parent,
-		maxTxnDepth: maxTxnDepth,
+		tsmInstance:    tsmInstance,
+		handlerCtx:     handlerCtx,
+		parent:         parent,
+		maxTxnDepth:    maxTxnDepth,
+		lockOwner:      lockOwner,
+		isCompensating: handlerCtx != nil && handlerCtx.IsCompensatingRollback(),
 	}
 }
 
@@ -149,6 +155,11 @@ func (m *basicBestEffortTransactionCoordinator) votingPhase() ([]internaldto.Exe
 	}()
 	var rv []internaldto.ExecutorOutput
 	for _, stmt := range m.statementSequence {
+		// Statements are executed eagerly upon enqueue
+		// by the best effort orchestrator.
+		if stmt.IsExecuted() {
+			continue
+		}
 		coDomain := stmt.Execute()
 		rv = append(rv, coDomain)
 		err := coDomain.GetError()
@@ -175,7 +186,7 @@ func (m *basicBestEffortTransactionCoordinator) Begin() (Coordinator, error) {
 	if m.maxTxnDepth >= 0 && m.Depth() >= m.maxTxnDepth {
 		return nil, fmt.Errorf("cannot begin nested transaction of depth = %d", m.Depth()+1)
 	}
-	return newBasicBestEffortTransactionCoordinator(m.tsmInstance, m.handlerCtx, m, m.maxTxnDepth), nil
+	return newBasicBestEffortTransactionCoordinator(m.tsmInstance, m.handlerCtx, m, m.maxTxnDepth, m.childLockOwner()), nil
 }
 
 func (m *basicBestEffortTransactionCoordinator) Commit() acid_dto.CommitCoDomain {
@@ -189,6 +200,9 @@ func (m *basicBestEffortTransactionCoordinator) Commit() acid_dto.CommitCoDomain
 
 // Rollback is best effort and runs in reverse order.
 func (m *basicBestEffortTransactionCoordinator) Rollback() acid_dto.CommitCoDomain {
+	if m.isCompensating {
+		return m.compensate()
+	}
 	var coDomains []internaldto.ExecutorOutput
 	for i := len(m.statementGraphs) - 1; i >= 0; i-- {
 		stmt := m.statementGraphs[i]
@@ -230,7 +244,38 @@ func (m *basicBestEffortTransactionCoordinator) Rollback() acid_dto.CommitCoDoma
 	)
 }
 
+// compensate replays the compensation of every executed
+// statement, in reverse order, regardless of failures.
+// Compensations are replayed at most once.
+func (m *basicBestEffortTransactionCoordinator) compensate() acid_dto.CommitCoDomain {
+	compensations := m.compensations
+	m.compensations = nil
+	output, err := replayCompensations(m.handlerCtx, compensations)
+	return acid_dto.NewCommitCoDomain(
+		[]internaldto.ExecutorOutput{output},
+		nil,
+		err,
+	)
+}
+
+// enqueueCompensable records the compensation for stmt,
+// which must precede execution so that a DELETE can
+// capture whatever it is about to remove.
+func (m *basicBestEffortTransactionCoordinator) enqueueCompensable(stmt Statement) error {
+	record := newCompensationRecord(m.handlerCtx, stmt)
+	m.compensations = append(m.compensations, record)
+	m.undoLogs = append(m.undoLogs, record.getUndoLog())
+	if journaled, isJournaled := stmt.(*journaledStatement); isJournaled {
+		journaled.setUndoLog(record.getUndoLog())
+	}
+	m.statementSequence = append(m.statementSequence, stmt)
+	return nil
+}
+
 func (m *basicBestEffortTransactionCoordinator) Enqueue(stmt Statement) error {
+	if m.isCompensating {
+		return m.enqueueCompensable(stmt)
+	}
 	graphHolder, graphHolderExists := stmt.GetPrimitiveGraphHolder()
 	if !graphHolderExists {
 		return fmt.Errorf("cannot enqueue statement without primitive graph holder")
@@ -261,3 +306,27 @@ func (m *basicBestEffortTransactionCoordinator) depth() int {
 	}
 	return 0
 }
+
+func (m *basicBestEffortTransactionCoordinator) GetLockOwner() int64 {
+	return m.lockOwner
+}
+
+// childLockOwner returns the lock owner for a
+// transaction begun within this one.  The root
+// coordinator only ever holds locks for the
+// duration of a single autocommit statement,
+// so each top level transaction is a new owner.
+func (m *basicBestEffortTransactionCoordinator) childLockOwner() int64 {
+	if m.IsRoot() {
+		return newLockOwner()
+	}
+	return m.lockOwner
+}
+
+func (m *basicBestEffortTransactionCoordinator) GetLockRequests(isolationLevel string) []LockRequest {
+	var rv []LockRequest
+	for _, stmt := range m.statementSequence {
+		rv = append(rv, stmt.GetLockRequests(isolationLevel)...)
+	}
+	return rv
+}
@@ -0,0 +1,146 @@
+package tsm_physio //nolint:testpackage,revive,stylecheck // exercise unexported coordinator
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stackql/stackql-parser/go/vt/sqlparser"
+	"github.com/stackql/stackql/internal/stackql/handler"
+	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
+	"github.com/stackql/stackql/internal/stackql/primitive"
+	"github.com/stackql/stackql/internal/stackql/primitivegraph"
+)
+
+type compensatingHandlerContext struct {
+	handler.HandlerContext
+}
+
+func (hc *compensatingHandlerContext) IsCompensatingRollback() bool { return true }
+func (hc *compensatingHandlerContext) GetOutfile() io.Writer        { return io.Discard }
+func (hc *compensatingHandlerContext) GetOutErrFile() io.Writer     { return io.Discard }
+
+// recordingGraph is an inverse graph which
+// records its replay under the given name.
+type recordingGraph struct {
+	primitivegraph.PrimitiveGraph
+	name     string
+	replayed *[]string
+	err      error
+}
+
+func (g *recordingGraph) Size() int       { return 1 }
+func (g *recordingGraph) Optimise() error { return nil }
+
+func (g *recordingGraph) Execute(primitive.IPrimitiveCtx) internaldto.ExecutorOutput {
+	*g.replayed = append(*g.replayed, g.name)
+	if g.err != nil {
+		return internaldto.NewErroneousExecutorOutput(g.err)
+	}
+	return internaldto.NewEmptyExecutorOutput()
+}
+
+type recordingGraphHolder struct {
+	primitivegraph.PrimitiveGraphHolder
+	inverse primitivegraph.PrimitiveGraph
+}
+
+func (h *recordingGraphHolder) GetInversePrimitiveGraph() primitivegraph.PrimitiveGraph {
+	return h.inverse
+}
+
+type fakeStatement struct {
+	Statement
+	query       string
+	ast         sqlparser.Statement
+	graphHolder primitivegraph.PrimitiveGraphHolder
+	isExecuted  bool
+}
+
+func (s *fakeStatement) GetQuery() string                     { return s.query }
+func (s *fakeStatement) GetAST() (sqlparser.Statement, bool)  { return s.ast, s.ast != nil }
+func (s *fakeStatement) IsExecuted() bool                     { return s.isExecuted }
+func (s *fakeStatement) GetLockRequests(string) []LockRequest { return nil }
+func (s *fakeStatement) GetPrimitiveGraphHolder() (primitivegraph.PrimitiveGraphHolder, bool) {
+	return s.graphHolder, s.graphHolder != nil
+}
+
+func newUpdateStatement(t *testing.T, name string, replayed *[]string, isExecuted bool, err error) Statement {
+	t.Helper()
+	query := "UPDATE a.b.c SET data__description = '" + name + "' WHERE project = 'p'"
+	ast, parseErr := sqlparser.Parse(query)
+	if parseErr != nil {
+		t.Fatalf("unexpected error: %v", parseErr)
+	}
+	return &fakeStatement{
+		query: query,
+		ast:   ast,
+		graphHolder: &recordingGraphHolder{
+			inverse: &recordingGraph{name: name, replayed: replayed, err: err},
+		},
+		isExecuted: isExecuted,
+	}
+}
+
+func TestCompensatingRollbackReplaysInReverseOrder(t *testing.T) {
+	var replayed []string
+	coordinator := newBasicBestEffortTransactionCoordinator(
+		nil, &compensatingHandlerContext{}, nil, -1, newLockOwner())
+	statements := []Statement{
+		newUpdateStatement(t, "first", &replayed, true, nil),
+		newUpdateStatement(t, "second", &replayed, true, errors.New("upstream refused")),
+		newUpdateStatement(t, "third", &replayed, true, nil),
+		// As though the transaction failed before its execution.
+		newUpdateStatement(t, "unexecuted", &replayed, false, nil),
+	}
+	for _, stmt := range statements {
+		if err := coordinator.Enqueue(stmt); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	result := coordinator.Rollback()
+	if strings.Join(replayed, ", ") != "third, second, first" {
+		t.Fatalf("expected compensations replayed in reverse order, past failures, got: %v", replayed)
+	}
+	if err, hasErr := result.GetError(); !hasErr || err == nil {
+		t.Fatalf("expected the failed compensation to be reported")
+	}
+	outputs := result.GetExecutorOutput()
+	if len(outputs) != 1 {
+		t.Fatalf("expected a single output, got %d", len(outputs))
+	}
+	messages := outputs[0].GetMessages()
+	if len(messages) != 4 ||
+		!strings.Contains(messages[0], "skipped (statement was not executed)") ||
+		!strings.Contains(messages[2], "upstream refused") {
+		t.Fatalf("unexpected messages: %v", messages)
+	}
+	replayed = nil
+	coordinator.Rollback()
+	if len(replayed) != 0 {
+		t.Fatalf("expected compensations replayed at most once, got: %v", replayed)
+	}
+}
+
+func TestCompensatingInsertRecordsDelete(t *testing.T) {
+	coordinator := newBasicBestEffortTransactionCoordinator(
+		nil, &compensatingHandlerContext{}, nil, -1, newLockOwner())
+	query := "INSERT INTO google.compute.networks(project, data__name) SELECT 'my-project', 'my-network'"
+	ast, err := sqlparser.Parse(query)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Without a declared inverse operation.
+	if err = coordinator.Enqueue(&fakeStatement{query: query, ast: ast}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	undoLog, hasUndoLog := coordinator.GetUndoLog()
+	if !hasUndoLog {
+		t.Fatalf("expected an undo log")
+	}
+	expected := "DELETE FROM google.compute.networks WHERE name = 'my-network' AND project = 'my-project';\n"
+	if string(undoLog.GetRaw()) != expected {
+		t.Fatalf("unexpected undo log: %q", undoLog.GetRaw())
+	}
+}
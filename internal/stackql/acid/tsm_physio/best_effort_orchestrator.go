@@ -4,7 +4,9 @@ import (
 	"fmt"
 
 	"github.com/stackql/stackql-parser/go/vt/sqlparser"
+	"github.com/stackql/stackql/internal/stackql/acid/acid_dto"
 	"github.com/stackql/stackql/internal/stackql/acid/binlog"
+	"github.com/stackql/stackql/internal/stackql/acid/journal"
 	"github.com/stackql/stackql/internal/stackql/acid/tsm"
 	"github.com/stackql/stackql/internal/stackql/acid/txn_context"
 	"github.com/stackql/stackql/internal/stackql/handler"
@@ -29,6 +31,9 @@ type bestEffortOrchestrator struct {
 	redoLogs       []binlog.LogEntry
 	redoGraphs     []primitivegraph.PrimitiveGraph
 	undoGraphs     []primitivegraph.PrimitiveGraph
+	locking        *sessionLocking
+	journaling     *sessionJournaling
+	isCompensating bool
 }
 
 func (orc *bestEffortOrchestrator) ProcessQueryOrQueries(
@@ -37,6 +42,22 @@ func (orc *bestEffortOrchestrator) ProcessQueryOrQueries(
 	return orc.processQueryOrQueries(handlerCtx)
 }
 
+func (orc *bestEffortOrchestrator) Terminate(
+	handlerCtx handler.HandlerContext,
+) []internaldto.ExecutorOutput {
+	var retVal []internaldto.ExecutorOutput
+	for !orc.txnCoordinator.IsRoot() {
+		open := orc.txnCoordinator
+		response, _ := orc.processQuery(handlerCtx, rollbackStatement)
+		retVal = append(retVal, response...)
+		if orc.txnCoordinator == open {
+			break
+		}
+	}
+	orc.txnCoordinator = orc.locking.releaseSession(orc.txnCoordinator)
+	return retVal
+}
+
 func (orc *bestEffortOrchestrator) processQueryOrQueries(
 	handlerCtx handler.HandlerContext,
 ) ([]internaldto.ExecutorOutput, bool) {
@@ -54,6 +75,16 @@ func (orc *bestEffortOrchestrator) processQueryOrQueries(
 
 func (orc *bestEffortOrchestrator) undo(precedingMessages []string) ([]internaldto.ExecutorOutput, bool) {
 	rollbackREsponse := orc.txnCoordinator.Rollback()
+	if orc.isCompensating {
+		return orc.reportCompensation(
+			[]internaldto.ExecutorOutput{
+				internaldto.NewNopEmptyExecutorOutput(
+					precedingMessages,
+				),
+			},
+			rollbackREsponse,
+		), true
+	}
 	rollbackErr, rollbackErrExists := rollbackREsponse.GetError()
 	if rollbackErrExists {
 		return []internaldto.ExecutorOutput{
@@ -92,13 +123,24 @@ func (orc *bestEffortOrchestrator) processQuery(
 			internaldto.NewErroneousExecutorOutput(prepareErr),
 		}, true
 	}
+	if isolationErr := orc.locking.applyIsolationLevel(handlerCtx, orc.txnCoordinator, transactStatement); isolationErr != nil {
+		return []internaldto.ExecutorOutput{
+			internaldto.NewErroneousExecutorOutput(isolationErr),
+		}, true
+	}
 	isReadOnly := transactStatement.IsReadOnly()
 	// TODO: implement eager execution for non-mutating statements
 	//       and lazy execution for mutating statements.
 	// TODO: implement transaction stack.
 	if transactStatement.IsBegin() { //nolint:gocritic,nestif // TODO: review
+		if journalErr := orc.journaling.begin(orc.txnCoordinator); journalErr != nil {
+			return []internaldto.ExecutorOutput{
+				internaldto.NewErroneousExecutorOutput(journalErr),
+			}, true
+		}
 		txnCoordinator, beginErr := orc.txnCoordinator.Begin()
 		if beginErr != nil {
+			orc.journaling.discard()
 			return []internaldto.ExecutorOutput{
 				internaldto.NewErroneousExecutorOutput(beginErr),
 			}, true
@@ -109,13 +151,18 @@ func (orc *bestEffortOrchestrator) processQuery(
 		}, true
 	} else if transactStatement.IsCommit() {
 		commitCoDomain := orc.txnCoordinator.Commit()
+		orc.locking.releaseTransaction(handlerCtx, orc.txnCoordinator)
 		commitErr, commitErrExists := commitCoDomain.GetError()
 		if commitErrExists {
+			orc.journaling.fail(commitErr) //nolint:errcheck // commit error takes precedence
 			return orc.undo([]string{
 				commitErr.Error(),
 			})
 		}
 		retVal := commitCoDomain.GetExecutorOutput()
+		if journalErr := orc.journaling.end(orc.txnCoordinator, journal.StatusCommitted); journalErr != nil {
+			retVal = append(retVal, endError(journalErr))
+		}
 		parent, hasParent := orc.txnCoordinator.GetParent()
 		if hasParent {
 			orc.txnCoordinator = parent
@@ -128,8 +175,25 @@ func (orc *bestEffortOrchestrator) processQuery(
 	} else if transactStatement.IsRollback() {
 		var retVal []internaldto.ExecutorOutput
 		rollbackREsponse := orc.txnCoordinator.Rollback()
+		orc.locking.releaseTransaction(handlerCtx, orc.txnCoordinator)
+		if orc.isCompensating {
+			retVal = orc.reportCompensation(retVal, rollbackREsponse)
+			if rollbackErr, rollbackErrExists := rollbackREsponse.GetError(); rollbackErrExists {
+				orc.journaling.fail(rollbackErr) //nolint:errcheck // rollback error takes precedence
+			}
+			if journalErr := orc.journaling.end(orc.txnCoordinator, journal.StatusRolledBack); journalErr != nil {
+				retVal = append(retVal, endError(journalErr))
+			}
+			parent, hasParent := orc.txnCoordinator.GetParent()
+			if !hasParent {
+				return append(retVal, internaldto.NewErroneousExecutorOutput(fmt.Errorf("%s", noParentMessage))), true
+			}
+			orc.txnCoordinator = parent
+			return retVal, true
+		}
 		rollbackErr, rollbackErrExists := rollbackREsponse.GetError()
 		if rollbackErrExists {
+			orc.journaling.fail(rollbackErr) //nolint:errcheck // rollback error takes precedence
 			retVal = append(retVal, internaldto.NewErroneousExecutorOutput(rollbackErr))
 			retVal = append(retVal, internaldto.NewErroneousExecutorOutput(
 				fmt.Errorf("Rollback failed")))
@@ -137,16 +201,22 @@ func (orc *bestEffortOrchestrator) processQuery(
 		}
 		parent, hasParent := orc.txnCoordinator.GetParent()
 		if hasParent {
+			completed := orc.txnCoordinator
 			orc.txnCoordinator = parent
 			for _, g := range orc.undoGraphs {
 				undoOutput := g.Execute(nil)
 				if undoOutput.GetError() != nil {
+					orc.journaling.fail(undoOutput.GetError())              //nolint:errcheck // undo error takes precedence
+					orc.journaling.end(completed, journal.StatusRolledBack) //nolint:errcheck // retained for recovery
 					retVal = append(retVal, internaldto.NewErroneousExecutorOutput(undoOutput.GetError()))
 					retVal = append(retVal, internaldto.NewErroneousExecutorOutput(
 						fmt.Errorf("Rollback failed")))
 					return retVal, true
 				}
 			}
+			if journalErr := orc.journaling.end(completed, journal.StatusRolledBack); journalErr != nil {
+				retVal = append(retVal, endError(journalErr))
+			}
 			retVal = append(retVal, internaldto.NewNopEmptyExecutorOutput([]string{"Rollback OK"}))
 			return retVal, true
 		}
@@ -158,7 +228,14 @@ func (orc *bestEffortOrchestrator) processQuery(
 		return retVal, true
 	}
 	if isReadOnly || orc.txnCoordinator.IsRoot() {
+		acquired, lockErr := orc.locking.acquire(orc.txnCoordinator, transactStatement)
+		if lockErr != nil {
+			return []internaldto.ExecutorOutput{
+				internaldto.NewErroneousExecutorOutput(lockErr),
+			}, true
+		}
 		stmtOutput := transactStatement.Execute()
+		orc.locking.releaseStatement(orc.txnCoordinator, acquired)
 		return []internaldto.ExecutorOutput{
 			stmtOutput,
 		}, true
@@ -176,12 +253,28 @@ func (orc *bestEffortOrchestrator) processQuery(
 		})
 	}
 
+	if _, lockErr := orc.locking.acquire(orc.txnCoordinator, transactStatement); lockErr != nil {
+		// bail
+		return orc.undo([]string{
+			lockErr.Error(),
+		})
+	}
+
+	transactStatement, journalErr := orc.journaling.wrap(transactStatement)
+	if journalErr != nil {
+		// bail
+		return orc.undo([]string{
+			journalErr.Error(),
+		})
+	}
+
 	enqueueError := orc.txnCoordinator.Enqueue(transactStatement)
 
 	// Before bailing on eager execution error,
-	// first assemble undo graph.
+	// first assemble undo graph.  Compensating
+	// rollback instead reports the absence of one.
 	undoGraphSize := primitiveGraphHolder.GetInversePrimitiveGraph().Size()
-	if undoGraphSize == 0 {
+	if undoGraphSize == 0 && !orc.isCompensating {
 		// bail
 		undoMessage := "undo graph does not exist"
 		if query != "" {
@@ -212,3 +305,17 @@ func (orc *bestEffortOrchestrator) processQuery(
 	}
 	return []internaldto.ExecutorOutput{output}, true
 }
+
+// reportCompensation appends the outcome of each
+// compensation, and the overall error if any, to retVal.
+func (orc *bestEffortOrchestrator) reportCompensation(
+	retVal []internaldto.ExecutorOutput,
+	rollbackResponse acid_dto.CommitCoDomain,
+) []internaldto.ExecutorOutput {
+	retVal = append(retVal, rollbackResponse.GetExecutorOutput()...)
+	if rollbackErr, rollbackErrExists := rollbackResponse.GetError(); rollbackErrExists {
+		retVal = append(retVal, internaldto.NewErroneousExecutorOutput(rollbackErr))
+		return retVal
+	}
+	return append(retVal, internaldto.NewNopEmptyExecutorOutput([]string{"Rollback OK"}))
+}
@@ -0,0 +1,215 @@
+package tsm_physio //nolint:stylecheck,revive // prefer this nomenclature
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/stackql/stackql-parser/go/vt/sqlparser"
+	"github.com/stackql/stackql/internal/stackql/acid/binlog"
+	"github.com/stackql/stackql/internal/stackql/acid/compensation"
+	"github.com/stackql/stackql/internal/stackql/handler"
+	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
+	"github.com/stackql/stackql/internal/stackql/primitivegraph"
+)
+
+// compensationRecord is the means of reversing a single
+// mutating statement executed within a transaction under
+// compensating rollback.  Exactly one of inverse, statements
+// or unavailable is populated.
+type compensationRecord struct {
+	statement   Statement
+	description string
+	// inverse is the inverse primitive graph derived
+	// from the provider's declared inverse operation.
+	inverse primitivegraph.PrimitiveGraph
+	// statements are those re-creating whatever a DELETE
+	// removed, as read beforehand, or deleting whatever
+	// an INSERT created.
+	statements []string
+	// unavailable explains the absence of a compensation.
+	unavailable string
+}
+
+func (c *compensationRecord) getUndoLog() binlog.LogEntry {
+	var raw []byte
+	if len(c.statements) > 0 {
+		raw = []byte(strings.Join(c.statements, ";\n") + ";\n")
+	}
+	return binlog.NewSimpleLogEntry(raw, []string{c.description})
+}
+
+func newCompensationRecord(handlerCtx handler.HandlerContext, stmt Statement) *compensationRecord {
+	ast, _ := stmt.GetAST()
+	switch node := ast.(type) {
+	case *sqlparser.Delete:
+		return newDeleteCompensationRecord(handlerCtx, stmt, node)
+	case *sqlparser.Insert:
+		return newInsertCompensationRecord(stmt, node)
+	default:
+		return newInverseCompensationRecord(stmt, fmt.Sprintf("inverse of '%s'", stmt.GetQuery()))
+	}
+}
+
+func describeTarget(stmt Statement) string {
+	requests := stmt.GetLockRequests(defaultIsolationLevel)
+	for _, r := range requests {
+		if r.Mode == LockExclusive {
+			return r.Resource
+		}
+	}
+	return "unknown resource"
+}
+
+func newInverseCompensationRecord(stmt Statement, description string) *compensationRecord {
+	rv := &compensationRecord{
+		statement:   stmt,
+		description: description,
+	}
+	graphHolder, graphHolderExists := stmt.GetPrimitiveGraphHolder()
+	if !graphHolderExists || graphHolder.GetInversePrimitiveGraph() == nil ||
+		graphHolder.GetInversePrimitiveGraph().Size() < 1 {
+		rv.unavailable = "no inverse operation is declared"
+		return rv
+	}
+	rv.inverse = graphHolder.GetInversePrimitiveGraph()
+	return rv
+}
+
+// newInsertCompensationRecord deletes whatever the INSERT creates,
+// identified by its values.  Failing that, the provider's declared
+// inverse operation, if any, is taken.
+func newInsertCompensationRecord(stmt Statement, node *sqlparser.Insert) *compensationRecord {
+	description := fmt.Sprintf("DELETE compensating INSERT INTO %s", describeTarget(stmt))
+	inverse, inverseErr := compensation.NewInsertInverse(node)
+	if inverseErr != nil {
+		rv := newInverseCompensationRecord(stmt, description)
+		if rv.unavailable != "" {
+			rv.unavailable = fmt.Sprintf("%s, and %s", inverseErr.Error(), rv.unavailable)
+		}
+		return rv
+	}
+	return &compensationRecord{
+		statement:   stmt,
+		description: description,
+		statements:  inverse.DeleteStatements(),
+	}
+}
+
+// newDeleteCompensationRecord reads the resources about
+// to be deleted, so that they may later be re-created.
+// It must therefore be called prior to execution.
+func newDeleteCompensationRecord(
+	handlerCtx handler.HandlerContext,
+	stmt Statement,
+	node *sqlparser.Delete,
+) *compensationRecord {
+	rv := &compensationRecord{
+		statement:   stmt,
+		description: fmt.Sprintf("re-create compensating DELETE FROM %s", describeTarget(stmt)),
+	}
+	preImage, preImageErr := compensation.NewDeletePreImage(node)
+	if preImageErr != nil {
+		rv.unavailable = preImageErr.Error()
+		return rv
+	}
+	rows, readErr := readPreImage(handlerCtx, preImage.GetQuery())
+	if readErr != nil {
+		rv.unavailable = fmt.Sprintf("cannot read resources prior to deletion: %s", readErr.Error())
+		return rv
+	}
+	rv.statements = preImage.RecreateStatements(rows)
+	if len(rv.statements) == 0 {
+		rv.unavailable = "no resources matched prior to deletion"
+	}
+	return rv
+}
+
+func readPreImage(handlerCtx handler.HandlerContext, query string) ([]map[string]any, error) {
+	stmt := NewStatement(query, handlerCtx, nil)
+	if err := stmt.Prepare(); err != nil {
+		return nil, err
+	}
+	output := stmt.Execute()
+	if output.GetError() != nil {
+		return nil, output.GetError()
+	}
+	stream := output.GetSQLResult()
+	if stream == nil {
+		return nil, nil
+	}
+	var rv []map[string]any
+	for {
+		res, err := stream.Read()
+		if res != nil {
+			rv = append(rv, res.ToArr()...)
+		}
+		if errors.Is(err, io.EOF) {
+			return rv, nil
+		}
+		if err != nil {
+			return rv, err
+		}
+	}
+}
+
+// replay executes the compensation, returning
+// a human readable outcome and any error.
+func (c *compensationRecord) replay(handlerCtx handler.HandlerContext) (string, error) {
+	if !c.statement.IsExecuted() {
+		return "skipped (statement was not executed)", nil
+	}
+	if c.unavailable != "" {
+		return fmt.Sprintf("skipped (%s)", c.unavailable), nil
+	}
+	if c.inverse != nil {
+		if err := c.inverse.Optimise(); err != nil {
+			return "failed", err
+		}
+		pc := internaldto.NewBasicPrimitiveContext(
+			nil,
+			handlerCtx.GetOutfile(),
+			handlerCtx.GetOutErrFile(),
+		)
+		if output := c.inverse.Execute(pc); output.GetError() != nil {
+			return "failed", output.GetError()
+		}
+		return "succeeded", nil
+	}
+	for _, query := range c.statements {
+		stmt := NewStatement(query, handlerCtx, nil)
+		if err := stmt.Prepare(); err != nil {
+			return "failed", err
+		}
+		if output := stmt.Execute(); output.GetError() != nil {
+			return "failed", output.GetError()
+		}
+	}
+	return "succeeded", nil
+}
+
+// replayCompensations replays records in reverse order,
+// pressing on past failures, and reports each outcome.
+func replayCompensations(
+	handlerCtx handler.HandlerContext,
+	records []*compensationRecord,
+) (internaldto.ExecutorOutput, error) {
+	var messages []string
+	var failed int
+	for i := len(records) - 1; i >= 0; i-- {
+		record := records[i]
+		outcome, err := record.replay(handlerCtx)
+		message := fmt.Sprintf("compensation %d of %d %s: %s", len(records)-i, len(records), outcome, record.description)
+		if err != nil {
+			failed++
+			message = fmt.Sprintf("%s: %s", message, err.Error())
+		}
+		messages = append(messages, message)
+	}
+	output := internaldto.NewNopEmptyExecutorOutput(messages)
+	if failed > 0 {
+		return output, fmt.Errorf("%d of %d compensations failed", failed, len(records))
+	}
+	return output, nil
+}
@@ -8,14 +8,17 @@ import (
 )
 
 func newCoordinator(tsmInstance tsm.TSM, handlerCtx handler.HandlerContext, maxTxnDepth int) Coordinator {
+	if handlerCtx.IsCompensatingRollback() {
+		return newBasicBestEffortTransactionCoordinator(tsmInstance, handlerCtx, nil, maxTxnDepth, newLockOwner())
+	}
 	rollbackType := handlerCtx.GetRollbackType()
 	switch rollbackType {
 	case constants.NopRollback:
-		return newBasicLazyTransactionCoordinator(tsmInstance, nil, maxTxnDepth)
+		return newBasicLazyTransactionCoordinator(tsmInstance, nil, maxTxnDepth, newLockOwner())
 	case constants.EagerRollback:
-		return newBasicBestEffortTransactionCoordinator(tsmInstance, handlerCtx, nil, maxTxnDepth)
+		return newBasicBestEffortTransactionCoordinator(tsmInstance, handlerCtx, nil, maxTxnDepth, newLockOwner())
 	default:
-		return newBasicLazyTransactionCoordinator(tsmInstance, nil, maxTxnDepth)
+		return newBasicLazyTransactionCoordinator(tsmInstance, nil, maxTxnDepth, newLockOwner())
 	}
 }
 
@@ -42,4 +45,8 @@ type Coordinator interface {
 	GetParent() (Coordinator, bool)
 	//
 	IsRoot() bool
+	// Get the lock owner identity of the transaction.
+	// Nested transactions share the identity of
+	// their outermost enclosing transaction.
+	GetLockOwner() int64
 }
@@ -24,13 +24,20 @@ type basicLazyTransactionCoordinator struct {
 	maxTxnDepth       int
 	outputs           []internaldto.ExecutorOutput
 	isExecuted        bool
+	lockOwner         int64
 }
 
-func newBasicLazyTransactionCoordinator(tsmInstance tsm.TSM, parent Coordinator, maxTxnDepth int) Coordinator {
+func newBasicLazyTransactionCoordinator(
+	tsmInstance tsm.TSM,
+	parent Coordinator,
+	maxTxnDepth int,
+	lockOwner int64,
+) Coordinator {
 	return &basicLazyTransactionCoordinator{
 		tsmInstance: tsmInstance,
 		parent:      parent,
 		maxTxnDepth: maxTxnDepth,
+		lockOwner:   lockOwner,
 	}
 }
 
@@ -164,7 +171,7 @@ func (m *basicLazyTransactionCoordinator) Begin() (Coordinator, error) {
 	if m.maxTxnDepth >= 0 && m.Depth() >= m.maxTxnDepth {
 		return nil, fmt.Errorf("cannot begin nested transaction of depth = %d", m.Depth()+1)
 	}
-	return newBasicLazyTransactionCoordinator(m.tsmInstance, m, m.maxTxnDepth), nil
+	return newBasicLazyTransactionCoordinator(m.tsmInstance, m, m.maxTxnDepth, m.childLockOwner()), nil
 }
 
 func (m *basicLazyTransactionCoordinator) Commit() acid_dto.CommitCoDomain {
@@ -206,3 +213,27 @@ func (m *basicLazyTransactionCoordinator) depth() int {
 	}
 	return 0
 }
+
+func (m *basicLazyTransactionCoordinator) GetLockOwner() int64 {
+	return m.lockOwner
+}
+
+// childLockOwner returns the lock owner for a
+// transaction begun within this one.  The root
+// coordinator only ever holds locks for the
+// duration of a single autocommit statement,
+// so each top level transaction is a new owner.
+func (m *basicLazyTransactionCoordinator) childLockOwner() int64 {
+	if m.IsRoot() {
+		return newLockOwner()
+	}
+	return m.lockOwner
+}
+
+func (m *basicLazyTransactionCoordinator) GetLockRequests(isolationLevel string) []LockRequest {
+	var rv []LockRequest
+	for _, stmt := range m.statementSequence {
+		rv = append(rv, stmt.GetLockRequests(isolationLevel)...)
+	}
+	return rv
+}
@@ -1,11 +1,297 @@
 package tsm_physio //nolint:stylecheck,revive // prefer this nomenclature
 
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
 var (
 	_ LockManager = (*lockManager)(nil)
 )
 
+//nolint:gochecknoglobals // singleton pattern
+var (
+	lockManagerOnce      sync.Once
+	lockManagerSingleton LockManager
+	lockOwnerSequence    atomic.Int64
+)
+
+var (
+	ErrLockTimeout = errors.New("lock wait timeout exceeded")
+	ErrDeadlock    = errors.New("deadlock detected")
+)
+
+const (
+	defaultLockTimeout = 30 * time.Second
+)
+
+type LockMode int
+
+const (
+	LockShared LockMode = iota
+	LockExclusive
+)
+
+func (m LockMode) String() string {
+	switch m {
+	case LockShared:
+		return "shared"
+	case LockExclusive:
+		return "exclusive"
+	default:
+		return "unknown"
+	}
+}
+
+// LockRequest identifies a resource, for example
+// a provider resource or a materialized view, and
+// the mode in which it is to be locked.
+type LockRequest struct {
+	Resource string
+	Mode     LockMode
+}
+
+// LockManager implements strict two phase locking
+// over named resources, shared across all sessions
+// within the process.
+//
+// Owners are transactions, identified by the ID
+// returned from newLockOwner().  Locks are reentrant
+// and a shared lock is upgraded in place if its
+// owner is the only holder.  A waiter that would
+// close a cycle in the waits-for graph fails
+// immediately with ErrDeadlock; any other waiter
+// fails with ErrLockTimeout once the timeout expires.
 type LockManager interface {
-	//
+	Acquire(owner int64, request LockRequest) error
+	// AcquireAll acquires the requests in a canonical
+	// order; on failure, any locks newly acquired by
+	// the call are released.
+	AcquireAll(owner int64, requests []LockRequest) ([]LockRequest, error)
+	Release(owner int64, resource string)
+	ReleaseAll(owner int64)
+	GetHeld(owner int64) []LockRequest
+}
+
+type lockEntry struct {
+	holders map[int64]LockMode
+}
+
+type lockManager struct {
+	mu       sync.Mutex
+	timeout  time.Duration
+	locks    map[string]*lockEntry
+	held     map[int64]map[string]struct{}
+	waitsFor map[int64]map[int64]struct{}
+	// released is closed, and replaced, upon every
+	// release; it is the wake up signal for waiters.
+	released chan struct{}
+}
+
+func NewLockManager(timeout time.Duration) LockManager {
+	if timeout <= 0 {
+		timeout = defaultLockTimeout
+	}
+	return &lockManager{
+		timeout:  timeout,
+		locks:    make(map[string]*lockEntry),
+		held:     make(map[int64]map[string]struct{}),
+		waitsFor: make(map[int64]map[int64]struct{}),
+		released: make(chan struct{}),
+	}
+}
+
+func getLockManager() LockManager {
+	lockManagerOnce.Do(func() {
+		lockManagerSingleton = NewLockManager(defaultLockTimeout)
+	})
+	return lockManagerSingleton
+}
+
+func newLockOwner() int64 {
+	return lockOwnerSequence.Add(1)
+}
+
+// blockers returns the owners which prevent
+// owner from acquiring resource in mode.
+func (lm *lockManager) blockers(owner int64, resource string, mode LockMode) []int64 {
+	entry, ok := lm.locks[resource]
+	if !ok {
+		return nil
+	}
+	var rv []int64
+	for holder, heldMode := range entry.holders {
+		if holder == owner {
+			continue
+		}
+		if mode == LockExclusive || heldMode == LockExclusive {
+			rv = append(rv, holder)
+		}
+	}
+	return rv
+}
+
+// isDeadlocked reports whether owner is
+// reachable from itself in the waits-for graph.
+func (lm *lockManager) isDeadlocked(owner int64) bool {
+	visited := make(map[int64]struct{})
+	stack := []int64{owner}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for next := range lm.waitsFor[current] {
+			if next == owner {
+				return true
+			}
+			if _, seen := visited[next]; !seen {
+				visited[next] = struct{}{}
+				stack = append(stack, next)
+			}
+		}
+	}
+	return false
+}
+
+func (lm *lockManager) grant(owner int64, resource string, mode LockMode) {
+	entry, ok := lm.locks[resource]
+	if !ok {
+		entry = &lockEntry{holders: make(map[int64]LockMode)}
+		lm.locks[resource] = entry
+	}
+	if heldMode, isHeld := entry.holders[owner]; !isHeld || heldMode < mode {
+		entry.holders[owner] = mode
+	}
+	if _, ok := lm.held[owner]; !ok {
+		lm.held[owner] = make(map[string]struct{})
+	}
+	lm.held[owner][resource] = struct{}{}
+}
+
+func (lm *lockManager) Acquire(owner int64, request LockRequest) error {
+	deadline := time.NewTimer(lm.timeout)
+	defer deadline.Stop()
+	for {
+		lm.mu.Lock()
+		blockers := lm.blockers(owner, request.Resource, request.Mode)
+		if len(blockers) == 0 {
+			delete(lm.waitsFor, owner)
+			lm.grant(owner, request.Resource, request.Mode)
+			lm.mu.Unlock()
+			return nil
+		}
+		waits := make(map[int64]struct{}, len(blockers))
+		for _, b := range blockers {
+			waits[b] = struct{}{}
+		}
+		lm.waitsFor[owner] = waits
+		if lm.isDeadlocked(owner) {
+			delete(lm.waitsFor, owner)
+			lm.mu.Unlock()
+			return fmt.Errorf("%w: %s lock on '%s'", ErrDeadlock, request.Mode, request.Resource)
+		}
+		released := lm.released
+		lm.mu.Unlock()
+		select {
+		case <-released:
+		case <-deadline.C:
+			lm.mu.Lock()
+			delete(lm.waitsFor, owner)
+			lm.mu.Unlock()
+			return fmt.Errorf(
+				"%w: %s lock on '%s' after %s", ErrLockTimeout, request.Mode, request.Resource, lm.timeout)
+		}
+	}
+}
+
+// canonicalLockRequests merges duplicate resources, retaining
+// the strongest mode, and sorts by resource name so that owners
+// locking the same set never deadlock amongst themselves.
+func canonicalLockRequests(requests []LockRequest) []LockRequest {
+	merged := make(map[string]LockMode, len(requests))
+	for _, r := range requests {
+		if existing, ok := merged[r.Resource]; !ok || existing < r.Mode {
+			merged[r.Resource] = r.Mode
+		}
+	}
+	rv := make([]LockRequest, 0, len(merged))
+	for resource, mode := range merged {
+		rv = append(rv, LockRequest{Resource: resource, Mode: mode})
+	}
+	sort.Slice(rv, func(i, j int) bool {
+		return rv[i].Resource < rv[j].Resource
+	})
+	return rv
+}
+
+func (lm *lockManager) AcquireAll(owner int64, requests []LockRequest) ([]LockRequest, error) {
+	var acquired []LockRequest
+	for _, r := range canonicalLockRequests(requests) {
+		lm.mu.Lock()
+		_, alreadyHeld := lm.held[owner][r.Resource]
+		lm.mu.Unlock()
+		if err := lm.Acquire(owner, r); err != nil {
+			for _, a := range acquired {
+				lm.Release(owner, a.Resource)
+			}
+			return nil, err
+		}
+		if !alreadyHeld {
+			acquired = append(acquired, r)
+		}
+	}
+	return acquired, nil
+}
+
+func (lm *lockManager) release(owner int64, resource string) {
+	if entry, ok := lm.locks[resource]; ok {
+		delete(entry.holders, owner)
+		if len(entry.holders) == 0 {
+			delete(lm.locks, resource)
+		}
+	}
+	if held, ok := lm.held[owner]; ok {
+		delete(held, resource)
+		if len(held) == 0 {
+			delete(lm.held, owner)
+		}
+	}
+}
+
+func (lm *lockManager) signal() {
+	close(lm.released)
+	lm.released = make(chan struct{})
+}
+
+func (lm *lockManager) Release(owner int64, resource string) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	lm.release(owner, resource)
+	lm.signal()
 }
 
-type lockManager struct{}
+func (lm *lockManager) ReleaseAll(owner int64) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	for resource := range lm.held[owner] {
+		lm.release(owner, resource)
+	}
+	delete(lm.waitsFor, owner)
+	lm.signal()
+}
+
+func (lm *lockManager) GetHeld(owner int64) []LockRequest {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	var rv []LockRequest
+	for resource := range lm.held[owner] {
+		rv = append(rv, LockRequest{Resource: resource, Mode: lm.locks[resource].holders[owner]})
+	}
+	sort.Slice(rv, func(i, j int) bool {
+		return rv[i].Resource < rv[j].Resource
+	})
+	return rv
+}
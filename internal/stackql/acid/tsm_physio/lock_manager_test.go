@@ -0,0 +1,112 @@
+package tsm_physio_test //nolint:revive,stylecheck // prefer this nomenclature
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/stackql/stackql/internal/stackql/acid/tsm_physio"
+)
+
+const (
+	testLockTimeout = 200 * time.Millisecond
+)
+
+func TestLockManagerSharedLocksAreCompatible(t *testing.T) {
+	lm := NewLockManager(testLockTimeout)
+	if err := lm.Acquire(1, LockRequest{Resource: "google.compute.instances", Mode: LockShared}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := lm.Acquire(2, LockRequest{Resource: "google.compute.instances", Mode: LockShared}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestLockManagerExclusiveTimeout(t *testing.T) {
+	lm := NewLockManager(testLockTimeout)
+	if err := lm.Acquire(1, LockRequest{Resource: "mv", Mode: LockShared}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err := lm.Acquire(2, LockRequest{Resource: "mv", Mode: LockExclusive})
+	if !errors.Is(err, ErrLockTimeout) {
+		t.Fatalf("expected lock timeout, got: %v", err)
+	}
+}
+
+func TestLockManagerReleaseWakesWaiter(t *testing.T) {
+	lm := NewLockManager(5 * time.Second)
+	if err := lm.Acquire(1, LockRequest{Resource: "mv", Mode: LockExclusive}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- lm.Acquire(2, LockRequest{Resource: "mv", Mode: LockExclusive})
+	}()
+	time.Sleep(20 * time.Millisecond)
+	lm.ReleaseAll(1)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("waiter was not woken upon release")
+	}
+	held := lm.GetHeld(2)
+	if len(held) != 1 || held[0].Mode != LockExclusive {
+		t.Fatalf("unexpected held locks: %v", held)
+	}
+}
+
+func TestLockManagerUpgrade(t *testing.T) {
+	lm := NewLockManager(testLockTimeout)
+	if err := lm.Acquire(1, LockRequest{Resource: "r", Mode: LockShared}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := lm.Acquire(1, LockRequest{Resource: "r", Mode: LockExclusive}); err != nil {
+		t.Fatalf("sole holder should upgrade in place: %v", err)
+	}
+	if held := lm.GetHeld(1); len(held) != 1 || held[0].Mode != LockExclusive {
+		t.Fatalf("unexpected held locks: %v", held)
+	}
+}
+
+func TestLockManagerDeadlock(t *testing.T) {
+	lm := NewLockManager(5 * time.Second)
+	if err := lm.Acquire(1, LockRequest{Resource: "a", Mode: LockExclusive}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := lm.Acquire(2, LockRequest{Resource: "b", Mode: LockExclusive}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- lm.Acquire(1, LockRequest{Resource: "b", Mode: LockExclusive})
+	}()
+	time.Sleep(20 * time.Millisecond)
+	err := lm.Acquire(2, LockRequest{Resource: "a", Mode: LockExclusive})
+	if !errors.Is(err, ErrDeadlock) {
+		t.Fatalf("expected deadlock, got: %v", err)
+	}
+	lm.ReleaseAll(2)
+	if err := <-done; err != nil {
+		t.Fatalf("surviving owner should acquire once the victim releases: %v", err)
+	}
+}
+
+func TestLockManagerAcquireAllRollsBack(t *testing.T) {
+	lm := NewLockManager(testLockTimeout)
+	if err := lm.Acquire(1, LockRequest{Resource: "b", Mode: LockExclusive}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err := lm.AcquireAll(2, []LockRequest{
+		{Resource: "a", Mode: LockShared},
+		{Resource: "b", Mode: LockShared},
+	})
+	if !errors.Is(err, ErrLockTimeout) {
+		t.Fatalf("expected lock timeout, got: %v", err)
+	}
+	if held := lm.GetHeld(2); len(held) != 0 {
+		t.Fatalf("expected no locks retained after failure, got: %v", held)
+	}
+}
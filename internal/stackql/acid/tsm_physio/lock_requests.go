@@ -0,0 +1,145 @@
+package tsm_physio //nolint:stylecheck,revive // prefer this nomenclature
+
+import (
+	"strings"
+
+	"github.com/stackql/stackql-parser/go/vt/sqlparser"
+)
+
+const (
+	defaultIsolationLevel = sqlparser.ReadCommitted
+)
+
+func joinTableIdents(idents ...sqlparser.TableIdent) string {
+	var parts []string
+	for _, ident := range idents {
+		if !ident.IsEmpty() {
+			parts = append(parts, strings.ToLower(ident.GetRawVal()))
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// lockResourceName canonicalises a relation or provider
+// resource name, eg `google.compute.instances`.
+func lockResourceName(tableName sqlparser.TableName) string {
+	return joinTableIdents(
+		tableName.QualifierThird,
+		tableName.QualifierSecond,
+		tableName.Qualifier,
+		tableName.Name,
+	)
+}
+
+// execLockResourceName strips the method from the
+// fully qualified name of an EXEC target, yielding
+// that of the resource upon which it acts.
+func execLockResourceName(methodName sqlparser.TableName) string {
+	return joinTableIdents(
+		methodName.QualifierThird,
+		methodName.QualifierSecond,
+		methodName.Qualifier,
+	)
+}
+
+func appendReadLocks(requests []LockRequest, node sqlparser.SQLNode) []LockRequest {
+	if node == nil {
+		return requests
+	}
+	//nolint:errcheck // visitor never errors
+	sqlparser.Walk(func(n sqlparser.SQLNode) (bool, error) {
+		if aliased, isAliased := n.(*sqlparser.AliasedTableExpr); isAliased {
+			tableName, isTableName := aliased.Expr.(sqlparser.TableName)
+			if isTableName && !tableName.IsEmpty() && lockResourceName(tableName) != "dual" {
+				requests = append(requests, LockRequest{Resource: lockResourceName(tableName), Mode: LockShared})
+			}
+		}
+		return true, nil
+	}, node)
+	return requests
+}
+
+func appendWriteLocks(requests []LockRequest, tableExprs sqlparser.TableExprs) []LockRequest {
+	for _, expr := range tableExprs {
+		aliased, isAliased := expr.(*sqlparser.AliasedTableExpr)
+		if !isAliased {
+			continue
+		}
+		if tableName, isTableName := aliased.Expr.(sqlparser.TableName); isTableName && !tableName.IsEmpty() {
+			requests = append(requests, LockRequest{Resource: lockResourceName(tableName), Mode: LockExclusive})
+		}
+	}
+	return requests
+}
+
+// inferLockRequests derives the resource locks required
+// to execute stmt: exclusive for mutation targets and
+// shared for everything read.  Read locks are omitted
+// under READ UNCOMMITTED.
+func inferLockRequests(stmt sqlparser.Statement, isolationLevel string) []LockRequest {
+	var rv []LockRequest
+	isReadLocked := isolationLevel != sqlparser.ReadUncommitted
+	switch node := stmt.(type) {
+	case *sqlparser.Insert:
+		rv = append(rv, LockRequest{Resource: lockResourceName(node.Table), Mode: LockExclusive})
+		if isReadLocked {
+			rv = appendReadLocks(rv, node.Rows)
+		}
+	case *sqlparser.Update:
+		rv = appendWriteLocks(rv, node.TableExprs)
+		if isReadLocked {
+			rv = appendReadLocks(rv, node.From)
+			if node.Where != nil {
+				rv = appendReadLocks(rv, node.Where)
+			}
+		}
+	case *sqlparser.Delete:
+		rv = appendWriteLocks(rv, node.TableExprs)
+		if isReadLocked && node.Where != nil {
+			rv = appendReadLocks(rv, node.Where)
+		}
+	case *sqlparser.Exec:
+		rv = append(rv, LockRequest{Resource: execLockResourceName(node.MethodName), Mode: LockExclusive})
+	case *sqlparser.RefreshMaterializedView:
+		rv = append(rv, LockRequest{Resource: lockResourceName(node.ViewName), Mode: LockExclusive})
+		if isReadLocked {
+			rv = appendReadLocks(rv, node.ImplicitSelect)
+		}
+	case *sqlparser.DDL:
+		for _, tableName := range append(append(sqlparser.TableNames{node.Table}, node.FromTables...), node.ToTables...) {
+			if !tableName.IsEmpty() {
+				rv = append(rv, LockRequest{Resource: lockResourceName(tableName), Mode: LockExclusive})
+			}
+		}
+	case sqlparser.SelectStatement:
+		if isReadLocked {
+			rv = appendReadLocks(rv, node)
+		}
+	}
+	return rv
+}
+
+// isReadLockRetained reports whether shared locks are held
+// until the end of the transaction, rather than being
+// released as soon as the reading statement completes.
+func isReadLockRetained(isolationLevel string) bool {
+	return isolationLevel == sqlparser.RepeatableRead || isolationLevel == sqlparser.Serializable
+}
+
+// inferIsolationLevel extracts the isolation
+// level from a SET TRANSACTION statement, and
+// whether it is the default of the session
+// rather than that of the current transaction.
+func inferIsolationLevel(stmt sqlparser.Statement) (string, bool, bool) {
+	setTxn, isSetTxn := stmt.(*sqlparser.SetTransaction)
+	if !isSetTxn {
+		return "", false, false
+	}
+	isSessionScoped := setTxn.Scope == sqlparser.SessionStr || setTxn.Scope == sqlparser.GlobalStr
+	for _, characteristic := range setTxn.Characteristics {
+		if level, isLevel := characteristic.(*sqlparser.IsolationLevel); isLevel {
+			return strings.ToLower(level.Level), isSessionScoped, true
+		}
+	}
+	return "", false, false
+}
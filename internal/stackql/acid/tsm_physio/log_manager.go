@@ -1,7 +1,10 @@
 package tsm_physio //nolint:revive,stylecheck // prefer this nomenclature
 import (
+	"path/filepath"
 	"sync"
 
+	"github.com/stackql/any-sdk/pkg/logging"
+	"github.com/stackql/stackql/internal/stackql/acid/journal"
 	"github.com/stackql/stackql/internal/stackql/handler"
 )
 
@@ -15,19 +18,44 @@ var (
 	walSingleton LogManager
 )
 
+// LogManager owns the durable transaction
+// journal, shared across all sessions within
+// the process.
 type LogManager interface {
-	//
+	GetJournal() journal.Journal
 }
 
-type walManager struct{}
+type walManager struct {
+	journal journal.Journal
+}
+
+// newWalManager journals beneath the approot.  Absent
+// an approot, or if it is not writable, transactions
+// proceed unjournaled rather than not at all.
+func newWalManager(handlerCtx handler.HandlerContext) LogManager {
+	appRoot := handlerCtx.GetRuntimeContext().ApplicationFilesRootPath
+	if appRoot == "" {
+		return &walManager{journal: journal.NewNopJournal()}
+	}
+	j, err := journal.NewFileJournal(filepath.Join(appRoot, journal.DirName))
+	if err != nil {
+		logging.GetLogger().Warnf("transactions will not be journaled: %v", err)
+		return &walManager{journal: journal.NewNopJournal()}
+	}
+	return &walManager{journal: j}
+}
 
-func getWalManager(_ handler.HandlerContext) (LogManager, error) {
+func getWalManager(handlerCtx handler.HandlerContext) (LogManager, error) {
 	var err error
 	walOnce.Do(func() {
 		if err != nil {
 			return
 		}
-		walSingleton = &walManager{}
+		walSingleton = newWalManager(handlerCtx)
 	})
 	return walSingleton, err
 }
+
+func (wm *walManager) GetJournal() journal.Journal {
+	return wm.journal
+}
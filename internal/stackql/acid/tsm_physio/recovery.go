@@ -0,0 +1,223 @@
+package tsm_physio //nolint:stylecheck,revive // prefer this nomenclature
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/stackql/stackql-parser/go/vt/sqlparser"
+	"github.com/stackql/stackql/internal/stackql/acid/journal"
+	"github.com/stackql/stackql/internal/stackql/handler"
+)
+
+type RecoveryMode string
+
+const (
+	// RecoveryReplay re-executes, in order, every statement not
+	// known to have succeeded.  Those in doubt, having been executing
+	// at the time of failure, are replayed only where requested.
+	RecoveryReplay RecoveryMode = "replay"
+	// RecoveryCompensate executes, in reverse order, the
+	// journaled undo of every statement that succeeded or
+	// may have; where there is none, manual action is reported.
+	RecoveryCompensate RecoveryMode = "compensate"
+	// RecoveryDiscard forgets the transaction, for instance
+	// once it has been resolved by hand.
+	RecoveryDiscard RecoveryMode = "discard"
+)
+
+// ErrStatementsInDoubt is returned upon replaying a transaction
+// some statement of which may or may not have taken effect.
+var ErrStatementsInDoubt = errors.New("transaction has statements in doubt")
+
+func ParseRecoveryMode(s string) (RecoveryMode, error) {
+	switch mode := RecoveryMode(strings.ToLower(s)); mode {
+	case RecoveryReplay, RecoveryCompensate, RecoveryDiscard:
+		return mode, nil
+	default:
+		return "", fmt.Errorf(
+			"unsupported recovery mode '%s', expected one of: %s, %s, %s",
+			s, RecoveryReplay, RecoveryCompensate, RecoveryDiscard)
+	}
+}
+
+func getRecoveryJournal(handlerCtx handler.HandlerContext) (journal.Journal, error) {
+	logManager, err := getWalManager(handlerCtx)
+	if err != nil {
+		return nil, err
+	}
+	return logManager.GetJournal(), nil
+}
+
+// ListIncompleteTransactions returns those journaled transactions
+// which neither committed nor rolled back cleanly, and whose
+// processes have exited.
+func ListIncompleteTransactions(handlerCtx handler.HandlerContext) ([]journal.Transaction, error) {
+	j, err := getRecoveryJournal(handlerCtx)
+	if err != nil {
+		return nil, err
+	}
+	return j.List()
+}
+
+// RecoverTransaction resolves the incomplete transaction txnID
+// per mode, returning a message per action taken.  The transaction
+// is claimed for the duration, failing if its process is live or
+// another is recovering it.  It is ended in the journal only if
+// it is fully resolved; otherwise progress is journaled and
+// recovery may be resumed.  Statements in doubt are replayed
+// only if isReplayInDoubt.
+func RecoverTransaction(
+	handlerCtx handler.HandlerContext,
+	txnID string,
+	mode RecoveryMode,
+	isReplayInDoubt bool,
+) ([]string, error) {
+	j, err := getRecoveryJournal(handlerCtx)
+	if err != nil {
+		return nil, err
+	}
+	txn, err := j.Claim(txnID)
+	if err != nil {
+		return nil, err
+	}
+	defer j.Release(txn.ID)
+	switch mode {
+	case RecoveryReplay:
+		return replayTransaction(handlerCtx, j, txn, isReplayInDoubt)
+	case RecoveryCompensate:
+		return compensateTransaction(handlerCtx, j, txn)
+	case RecoveryDiscard:
+		if endErr := j.End(txn.ID, journal.StatusDiscarded); endErr != nil {
+			return nil, endErr
+		}
+		return []string{fmt.Sprintf("transaction %s discarded", txn.ID)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported recovery mode '%s'", mode)
+	}
+}
+
+// executeRecoveryQuery executes each statement of query in autocommit.
+func executeRecoveryQuery(handlerCtx handler.HandlerContext, query string) error {
+	pieces, err := sqlparser.SplitStatementToPieces(query)
+	if err != nil {
+		return err
+	}
+	for _, piece := range pieces {
+		if strings.TrimSpace(piece) == "" {
+			continue
+		}
+		clonedCtx := handlerCtx.Clone()
+		clonedCtx.SetQuery(piece)
+		stmt := NewStatement(piece, clonedCtx, nil)
+		if prepareErr := stmt.Prepare(); prepareErr != nil {
+			return prepareErr
+		}
+		if execErr := stmt.Execute().GetError(); execErr != nil {
+			return execErr
+		}
+	}
+	return nil
+}
+
+func replayTransaction(
+	handlerCtx handler.HandlerContext,
+	j journal.Journal,
+	txn journal.Transaction,
+	isReplayInDoubt bool,
+) ([]string, error) {
+	var messages []string
+	if !isReplayInDoubt {
+		for _, stmt := range txn.Statements {
+			if stmt.Status == journal.StatusExecuting {
+				messages = append(messages, fmt.Sprintf(
+					"statement %d is in doubt, having been executing at the time of failure: %s", stmt.Seq, stmt.Query))
+			}
+		}
+		if len(messages) > 0 {
+			return messages, fmt.Errorf(
+				"%w: %s; replay them only once known not to have taken effect", ErrStatementsInDoubt, txn.ID)
+		}
+	}
+	for _, stmt := range txn.Statements {
+		if stmt.Status == journal.StatusSucceeded {
+			continue
+		}
+		record := journal.Record{
+			TxnID:  txn.ID,
+			Kind:   journal.KindStatement,
+			Seq:    stmt.Seq,
+			Status: journal.StatusSucceeded,
+		}
+		execErr := executeRecoveryQuery(handlerCtx, stmt.Query)
+		if execErr != nil {
+			record.Status = journal.StatusFailed
+			record.Error = execErr.Error()
+		}
+		if err := j.Append(record); err != nil {
+			return messages, err
+		}
+		if execErr != nil {
+			messages = append(messages, fmt.Sprintf("statement %d replay failed: %s", stmt.Seq, stmt.Query))
+			return messages, execErr
+		}
+		messages = append(messages, fmt.Sprintf("statement %d replayed: %s", stmt.Seq, stmt.Query))
+	}
+	if err := j.End(txn.ID, journal.StatusRecovered); err != nil {
+		return messages, err
+	}
+	return append(messages, fmt.Sprintf("transaction %s replayed", txn.ID)), nil
+}
+
+// isPossiblyApplied reports whether a statement in
+// the given state may have taken effect; those that
+// were executing at the time of a crash are in doubt.
+func isPossiblyApplied(status journal.Status) bool {
+	return status == journal.StatusSucceeded || status == journal.StatusExecuting
+}
+
+func compensateTransaction(
+	handlerCtx handler.HandlerContext,
+	j journal.Journal,
+	txn journal.Transaction,
+) ([]string, error) {
+	var messages []string
+	isManualActionRequired := false
+	for i := len(txn.Statements) - 1; i >= 0; i-- {
+		stmt := txn.Statements[i]
+		if !isPossiblyApplied(stmt.Status) {
+			continue
+		}
+		if stmt.Undo == nil || stmt.Undo.Raw == "" {
+			isManualActionRequired = true
+			suggestion := "none available"
+			if stmt.Undo != nil && len(stmt.Undo.HumanReadable) > 0 {
+				suggestion = strings.Join(stmt.Undo.HumanReadable, "; ")
+			}
+			messages = append(messages, fmt.Sprintf(
+				"statement %d requires manual compensation: %s; suggested undo: %s", stmt.Seq, stmt.Query, suggestion))
+			continue
+		}
+		if execErr := executeRecoveryQuery(handlerCtx, stmt.Undo.Raw); execErr != nil {
+			messages = append(messages, fmt.Sprintf("statement %d compensation failed: %s", stmt.Seq, stmt.Query))
+			return messages, execErr
+		}
+		if err := j.Append(journal.Record{
+			TxnID:  txn.ID,
+			Kind:   journal.KindStatement,
+			Seq:    stmt.Seq,
+			Status: journal.StatusCompensated,
+		}); err != nil {
+			return messages, err
+		}
+		messages = append(messages, fmt.Sprintf("statement %d compensated: %s", stmt.Seq, stmt.Query))
+	}
+	if isManualActionRequired {
+		return append(messages, fmt.Sprintf(
+			"transaction %s remains incomplete; discard it once manually compensated", txn.ID)), nil
+	}
+	if err := j.End(txn.ID, journal.StatusRolledBack); err != nil {
+		return messages, err
+	}
+	return append(messages, fmt.Sprintf("transaction %s compensated", txn.ID)), nil
+}
@@ -17,6 +17,10 @@ type Statement interface {
 	GetPrimitiveGraphHolder() (primitivegraph.PrimitiveGraphHolder, bool)
 	GetUndoLog() (binlog.LogEntry, bool)
 	GetRedoLog() (binlog.LogEntry, bool)
+	// GetLockRequests returns the resource locks
+	// required to execute the statement at the
+	// supplied isolation level.
+	GetLockRequests(isolationLevel string) []LockRequest
 	IsReadOnly() bool
 	IsBegin() bool
 	IsCommit() bool
@@ -124,3 +128,11 @@ func (st *basicStatement) GetAST() (sqlparser.Statement, bool) {
 func (st *basicStatement) GetPrimitiveGraphHolder() (primitivegraph.PrimitiveGraphHolder, bool) {
 	return st.querySubmitter.GetPrimitiveGraphHolder()
 }
+
+func (st *basicStatement) GetLockRequests(isolationLevel string) []LockRequest {
+	ast, hasAst := st.GetAST()
+	if !hasAst {
+		return nil
+	}
+	return inferLockRequests(ast, isolationLevel)
+}
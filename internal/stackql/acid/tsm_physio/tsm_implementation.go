@@ -1,6 +1,7 @@
 package tsm_physio //nolint:stylecheck,revive // prefer this nomenclature
 
 import (
+	"github.com/stackql/stackql/internal/stackql/acid/journal"
 	"github.com/stackql/stackql/internal/stackql/acid/tsm"
 	"github.com/stackql/stackql/internal/stackql/handler"
 )
@@ -19,6 +20,37 @@ func GetTSM(handlerCtx handler.HandlerContext) (tsm.TSM, error) {
 		return nil, walErr
 	}
 	return &tsmImplementation{
-		logManager: walManager,
+		logManager:  walManager,
+		lockManager: getLockManager(),
 	}, nil
 }
+
+func (t *tsmImplementation) GetLockManager() LockManager {
+	return t.lockManager
+}
+
+// lockManagerOf returns the lock manager of tsmInstance,
+// falling back to the process wide lock manager.
+func lockManagerOf(tsmInstance tsm.TSM) LockManager {
+	if holder, isHolder := tsmInstance.(interface{ GetLockManager() LockManager }); isHolder {
+		if lm := holder.GetLockManager(); lm != nil {
+			return lm
+		}
+	}
+	return getLockManager()
+}
+
+func (t *tsmImplementation) GetLogManager() LogManager {
+	return t.logManager
+}
+
+// journalOf returns the transaction journal of
+// tsmInstance, if any, else one retaining nothing.
+func journalOf(tsmInstance tsm.TSM) journal.Journal {
+	if holder, isHolder := tsmInstance.(interface{ GetLogManager() LogManager }); isHolder {
+		if lm := holder.GetLogManager(); lm != nil {
+			return lm.GetJournal()
+		}
+	}
+	return journal.NewNopJournal()
+}
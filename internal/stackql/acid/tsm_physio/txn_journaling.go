@@ -0,0 +1,188 @@
+package tsm_physio //nolint:stylecheck,revive // prefer this nomenclature
+
+import (
+	"fmt"
+
+	"github.com/stackql/stackql/internal/stackql/acid/binlog"
+	"github.com/stackql/stackql/internal/stackql/acid/journal"
+	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
+)
+
+var (
+	_ Statement = (*journaledStatement)(nil)
+)
+
+// sessionJournaling applies the write ahead protocol on
+// behalf of a session's orchestrator.  Each top level
+// transaction is journaled, as is every statement queued
+// within it, such that a transaction interrupted by a crash
+// or left failed can later be listed and recovered.
+//
+// A failed transaction, any statement of which was at
+// least attempted, is not ended in the journal upon
+// rollback; it remains for recovery.
+type sessionJournaling struct {
+	journal     journal.Journal
+	txnID       string
+	seq         int
+	isAttempted bool
+	isFailed    bool
+}
+
+func newSessionJournaling(j journal.Journal) *sessionJournaling {
+	return &sessionJournaling{
+		journal: j,
+	}
+}
+
+func isTopLevel(coordinator Coordinator) bool {
+	parent, hasParent := coordinator.GetParent()
+	return hasParent && parent.IsRoot()
+}
+
+// begin journals a new transaction if
+// the one being begun, from within
+// current, is top level.
+func (sj *sessionJournaling) begin(current Coordinator) error {
+	if !current.IsRoot() {
+		return nil
+	}
+	txnID, err := sj.journal.Begin()
+	if err != nil {
+		return err
+	}
+	sj.txnID = txnID
+	sj.seq = 0
+	sj.isAttempted = false
+	sj.isFailed = false
+	return nil
+}
+
+// wrap journals stmt as pending and returns
+// it decorated so as to journal its execution.
+func (sj *sessionJournaling) wrap(stmt Statement) (Statement, error) {
+	if sj.txnID == "" {
+		return stmt, nil
+	}
+	sj.seq++
+	if err := sj.journal.Append(journal.Record{
+		TxnID:  sj.txnID,
+		Kind:   journal.KindStatement,
+		Seq:    sj.seq,
+		Query:  stmt.GetQuery(),
+		Status: journal.StatusPending,
+	}); err != nil {
+		return nil, err
+	}
+	return &journaledStatement{
+		Statement:  stmt,
+		journaling: sj,
+		txnID:      sj.txnID,
+		seq:        sj.seq,
+	}, nil
+}
+
+// fail journals the failure of the current transaction.
+func (sj *sessionJournaling) fail(err error) error {
+	sj.isFailed = true
+	if sj.txnID == "" {
+		return nil
+	}
+	return sj.journal.Append(journal.Record{
+		TxnID:  sj.txnID,
+		Kind:   journal.KindTransaction,
+		Status: journal.StatusFailed,
+		Error:  err.Error(),
+	})
+}
+
+// discard ends the current transaction, which
+// never began, as discarded.
+func (sj *sessionJournaling) discard() {
+	if sj.txnID == "" {
+		return
+	}
+	sj.journal.End(sj.txnID, journal.StatusDiscarded) //nolint:errcheck // nothing was journaled beyond the begin record
+	sj.txnID = ""
+}
+
+// end journals the final status of the completed
+// transaction, if it is top level.
+func (sj *sessionJournaling) end(completed Coordinator, status journal.Status) error {
+	if !isTopLevel(completed) || sj.txnID == "" {
+		return nil
+	}
+	txnID := sj.txnID
+	sj.txnID = ""
+	if status == journal.StatusRolledBack && sj.isFailed && sj.isAttempted {
+		return nil
+	}
+	return sj.journal.End(txnID, status)
+}
+
+// endError wraps a failure to end the journaled transaction.
+func endError(err error) internaldto.ExecutorOutput {
+	return internaldto.NewErroneousExecutorOutput(
+		fmt.Errorf("transaction completed but could not be journaled as such: %w", err))
+}
+
+type journaledStatement struct {
+	Statement
+	journaling *sessionJournaling
+	txnID      string
+	seq        int
+	// undoLog, where known before execution, supersedes
+	// that reported by the executed statement.
+	undoLog binlog.LogEntry
+}
+
+func (st *journaledStatement) setUndoLog(undoLog binlog.LogEntry) {
+	st.undoLog = undoLog
+}
+
+func toPayload(entry binlog.LogEntry, exists bool) *journal.Payload {
+	if !exists || entry == nil || (len(entry.GetRaw()) == 0 && len(entry.GetHumanReadable()) == 0) {
+		return nil
+	}
+	return &journal.Payload{
+		HumanReadable: entry.GetHumanReadable(),
+		Raw:           string(entry.GetRaw()),
+	}
+}
+
+// Execute journals the statement as executing
+// before doing so; if that record cannot be made
+// durable, the statement is not executed.
+func (st *journaledStatement) Execute() internaldto.ExecutorOutput {
+	if err := st.journaling.journal.Append(journal.Record{
+		TxnID:  st.txnID,
+		Kind:   journal.KindStatement,
+		Seq:    st.seq,
+		Status: journal.StatusExecuting,
+	}); err != nil {
+		return internaldto.NewErroneousExecutorOutput(err)
+	}
+	st.journaling.isAttempted = true
+	output := st.Statement.Execute()
+	record := journal.Record{
+		TxnID:  st.txnID,
+		Kind:   journal.KindStatement,
+		Seq:    st.seq,
+		Status: journal.StatusSucceeded,
+		Redo:   toPayload(output.GetRedoLog()),
+		Undo:   toPayload(output.GetUndoLog()),
+	}
+	if st.undoLog != nil {
+		record.Undo = toPayload(st.undoLog, true)
+	}
+	if execErr := output.GetError(); execErr != nil {
+		st.journaling.isFailed = true
+		record.Status = journal.StatusFailed
+		record.Error = execErr.Error()
+	}
+	if err := st.journaling.journal.Append(record); err != nil {
+		return internaldto.NewErroneousExecutorOutput(
+			fmt.Errorf("statement executed but its outcome could not be journaled: %w", err))
+	}
+	return output
+}
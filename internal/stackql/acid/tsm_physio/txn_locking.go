@@ -0,0 +1,119 @@
+package tsm_physio //nolint:stylecheck,revive // prefer this nomenclature
+
+import (
+	"github.com/stackql/stackql/internal/stackql/handler"
+)
+
+// sessionLocking applies the locking protocol on behalf
+// of a session's orchestrator:
+//   - Autocommit statements hold their locks only
+//     for the duration of the statement.
+//   - Within a transaction, exclusive locks are held
+//     until commit or rollback.  So too are shared locks
+//     at REPEATABLE READ or SERIALIZABLE; otherwise they
+//     are released once the reading statement completes.
+//
+// As in postgres, SET TRANSACTION sets the isolation level
+// of the current transaction only, whereas SET SESSION
+// TRANSACTION sets the default of the session.
+type sessionLocking struct {
+	lockManager           LockManager
+	isolationLevel        string
+	sessionIsolationLevel string
+}
+
+func newSessionLocking(lockManager LockManager) *sessionLocking {
+	return &sessionLocking{
+		lockManager:           lockManager,
+		isolationLevel:        defaultIsolationLevel,
+		sessionIsolationLevel: defaultIsolationLevel,
+	}
+}
+
+// applyIsolationLevel records the isolation level
+// of a SET TRANSACTION statement, if stmt is one.
+// Outside a transaction, that of the transaction
+// alone has no effect.
+func (sl *sessionLocking) applyIsolationLevel(
+	handlerCtx handler.HandlerContext,
+	current Coordinator,
+	stmt Statement,
+) error {
+	ast, hasAst := stmt.GetAST()
+	if !hasAst {
+		return nil
+	}
+	isolationLevel, isSessionScoped, isSetTxn := inferIsolationLevel(ast)
+	if !isSetTxn {
+		return nil
+	}
+	if isSessionScoped {
+		sl.sessionIsolationLevel = isolationLevel
+	}
+	// A session default awaits the end of any current
+	// transaction, and that of a transaction needs one.
+	if current.IsRoot() != isSessionScoped {
+		return nil
+	}
+	return sl.setIsolationLevel(handlerCtx, isolationLevel)
+}
+
+func (sl *sessionLocking) setIsolationLevel(handlerCtx handler.HandlerContext, isolationLevel string) error {
+	if err := handlerCtx.UpdateIsolationLevel(isolationLevel); err != nil {
+		return err
+	}
+	sl.isolationLevel = isolationLevel
+	return nil
+}
+
+func (sl *sessionLocking) acquire(coordinator Coordinator, stmt Statement) ([]LockRequest, error) {
+	return sl.lockManager.AcquireAll(coordinator.GetLockOwner(), stmt.GetLockRequests(sl.isolationLevel))
+}
+
+// releaseStatement releases those locks acquired for
+// a single statement that are not retained until the
+// end of the enclosing transaction.
+func (sl *sessionLocking) releaseStatement(coordinator Coordinator, acquired []LockRequest) {
+	owner := coordinator.GetLockOwner()
+	if coordinator.IsRoot() {
+		sl.lockManager.ReleaseAll(owner)
+		return
+	}
+	if isReadLockRetained(sl.isolationLevel) {
+		return
+	}
+	for _, r := range acquired {
+		if r.Mode == LockShared {
+			sl.lockManager.Release(owner, r.Resource)
+		}
+	}
+}
+
+// releaseTransaction releases all locks held by the
+// completed transaction, unless it is nested inside
+// another transaction sharing the same owner, and
+// restores the isolation level of the session.
+func (sl *sessionLocking) releaseTransaction(handlerCtx handler.HandlerContext, completed Coordinator) {
+	parent, hasParent := completed.GetParent()
+	if hasParent && !parent.IsRoot() {
+		return
+	}
+	sl.lockManager.ReleaseAll(completed.GetLockOwner())
+	//nolint:errcheck // the session level was accepted when set
+	sl.setIsolationLevel(handlerCtx, sl.sessionIsolationLevel)
+}
+
+// releaseSession releases all locks held on behalf of
+// current and its enclosing transactions, returning the
+// root coordinator.  It is for sessions which end with
+// a transaction that could not be rolled back.
+func (sl *sessionLocking) releaseSession(current Coordinator) Coordinator {
+	for {
+		sl.lockManager.ReleaseAll(current.GetLockOwner())
+		parent, hasParent := current.GetParent()
+		if !hasParent {
+			return current
+		}
+		current = parent
+	}
+}
@@ -0,0 +1,85 @@
+package tsm_physio //nolint:testpackage,revive,stylecheck // exercise unexported locking
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stackql/stackql-parser/go/vt/sqlparser"
+	"github.com/stackql/stackql/internal/stackql/handler"
+)
+
+type isolationHandlerContext struct {
+	handler.HandlerContext
+	isolationLevel string
+}
+
+func (hc *isolationHandlerContext) UpdateIsolationLevel(isolationLevel string) error {
+	hc.isolationLevel = isolationLevel
+	return nil
+}
+
+func (hc *isolationHandlerContext) IsCompensatingRollback() bool { return false }
+
+func applySetTransaction(t *testing.T, sl *sessionLocking, hc handler.HandlerContext, current Coordinator, query string) {
+	t.Helper()
+	ast, err := sqlparser.Parse(query)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = sl.applyIsolationLevel(hc, current, &fakeStatement{query: query, ast: ast}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestTransactionIsolationLevelEndsWithTransaction(t *testing.T) {
+	hc := &isolationHandlerContext{}
+	sl := newSessionLocking(NewLockManager(time.Second))
+	root := newBasicBestEffortTransactionCoordinator(nil, hc, nil, -1, newLockOwner())
+	txn, err := root.Begin()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	applySetTransaction(t, sl, hc, txn, "SET TRANSACTION ISOLATION LEVEL SERIALIZABLE")
+	if sl.isolationLevel != sqlparser.Serializable || hc.isolationLevel != sqlparser.Serializable {
+		t.Fatalf("expected the transaction serializable, got %q", sl.isolationLevel)
+	}
+	sl.releaseTransaction(hc, txn)
+	if sl.isolationLevel != defaultIsolationLevel || hc.isolationLevel != defaultIsolationLevel {
+		t.Fatalf("expected the session default restored, got %q", sl.isolationLevel)
+	}
+	applySetTransaction(t, sl, hc, root, "SET SESSION TRANSACTION ISOLATION LEVEL REPEATABLE READ")
+	if sl.isolationLevel != sqlparser.RepeatableRead {
+		t.Fatalf("expected the session default applied, got %q", sl.isolationLevel)
+	}
+	if txn, err = root.Begin(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	applySetTransaction(t, sl, hc, txn, "SET TRANSACTION ISOLATION LEVEL SERIALIZABLE")
+	sl.releaseTransaction(hc, txn)
+	if sl.isolationLevel != sqlparser.RepeatableRead {
+		t.Fatalf("expected the session default restored, got %q", sl.isolationLevel)
+	}
+}
+
+func TestReleaseSessionReleasesOpenTransaction(t *testing.T) {
+	lm := NewLockManager(50 * time.Millisecond)
+	sl := newSessionLocking(lm)
+	root := newBasicBestEffortTransactionCoordinator(nil, &isolationHandlerContext{}, nil, -1, newLockOwner())
+	txn, err := root.Begin()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	nested, err := txn.Begin()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = lm.Acquire(nested.GetLockOwner(), LockRequest{Resource: "a.b.c", Mode: LockExclusive}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if current := sl.releaseSession(nested); current != root {
+		t.Fatalf("expected the root coordinator")
+	}
+	if err = lm.Acquire(newLockOwner(), LockRequest{Resource: "a.b.c", Mode: LockExclusive}); err != nil {
+		t.Fatalf("expected the lock released, got: %v", err)
+	}
+}
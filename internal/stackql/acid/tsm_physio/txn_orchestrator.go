@@ -5,6 +5,7 @@ import (
 
 	"github.com/stackql/any-sdk/pkg/constants"
 	"github.com/stackql/stackql-parser/go/vt/sqlparser"
+	"github.com/stackql/stackql/internal/stackql/acid/journal"
 	"github.com/stackql/stackql/internal/stackql/acid/tsm"
 	"github.com/stackql/stackql/internal/stackql/acid/txn_context"
 	"github.com/stackql/stackql/internal/stackql/handler"
@@ -15,12 +16,24 @@ type Orchestrator interface {
 	ProcessQueryOrQueries(
 		handlerCtx handler.HandlerContext,
 	) ([]internaldto.ExecutorOutput, bool)
+	// Terminate ends the session, as when its client
+	// disconnects: any open transaction is rolled back
+	// and every lock held on the session's behalf is
+	// released.
+	Terminate(handlerCtx handler.HandlerContext) []internaldto.ExecutorOutput
 }
 
+// rollbackStatement rolls back the open
+// transaction of a terminating session.
+const rollbackStatement = "ROLLBACK"
+
 func newTxnOrchestrator(
 	tsmInstance tsm.TSM,
 	handlerCtx handler.HandlerContext,
 	txnCoordinator Coordinator) (Orchestrator, error) {
+	if handlerCtx.IsCompensatingRollback() {
+		return newBestEffortTxnOrchestrator(tsmInstance, handlerCtx, txnCoordinator)
+	}
 	rollbackType := handlerCtx.GetRollbackType()
 	switch rollbackType {
 	case constants.NopRollback:
@@ -39,22 +52,29 @@ func newStdTxnOrchestrator(
 	return &standardOrchestrator{
 		tsmInstance:    tsmInstance,
 		txnCoordinator: txnCoordinator,
+		locking:        newSessionLocking(lockManagerOf(tsmInstance)),
+		journaling:     newSessionJournaling(journalOf(tsmInstance)),
 	}, nil
 }
 
 func newBestEffortTxnOrchestrator(
 	tsmInstance tsm.TSM,
-	_ handler.HandlerContext,
+	handlerCtx handler.HandlerContext,
 	txnCoordinator Coordinator) (Orchestrator, error) {
 	return &bestEffortOrchestrator{
 		tsmInstance:    tsmInstance,
 		txnCoordinator: txnCoordinator,
+		locking:        newSessionLocking(lockManagerOf(tsmInstance)),
+		journaling:     newSessionJournaling(journalOf(tsmInstance)),
+		isCompensating: handlerCtx.IsCompensatingRollback(),
 	}, nil
 }
 
 type standardOrchestrator struct {
 	tsmInstance    tsm.TSM
 	txnCoordinator Coordinator
+	locking        *sessionLocking
+	journaling     *sessionJournaling
 }
 
 func (orc *standardOrchestrator) ProcessQueryOrQueries(
@@ -63,6 +83,22 @@ func (orc *standardOrchestrator) ProcessQueryOrQueries(
 	return orc.processQueryOrQueries(handlerCtx)
 }
 
+func (orc *standardOrchestrator) Terminate(
+	handlerCtx handler.HandlerContext,
+) []internaldto.ExecutorOutput {
+	var retVal []internaldto.ExecutorOutput
+	for !orc.txnCoordinator.IsRoot() {
+		open := orc.txnCoordinator
+		response, _ := orc.processQuery(handlerCtx, rollbackStatement)
+		retVal = append(retVal, response...)
+		if orc.txnCoordinator == open {
+			break
+		}
+	}
+	orc.txnCoordinator = orc.locking.releaseSession(orc.txnCoordinator)
+	return retVal
+}
+
 func (orc *standardOrchestrator) processQueryOrQueries(
 	handlerCtx handler.HandlerContext,
 ) ([]internaldto.ExecutorOutput, bool) {
@@ -95,13 +131,24 @@ func (orc *standardOrchestrator) processQuery(
 			internaldto.NewErroneousExecutorOutput(prepareErr),
 		}, true
 	}
+	if isolationErr := orc.locking.applyIsolationLevel(handlerCtx, orc.txnCoordinator, transactStatement); isolationErr != nil {
+		return []internaldto.ExecutorOutput{
+			internaldto.NewErroneousExecutorOutput(isolationErr),
+		}, true
+	}
 	isReadOnly := transactStatement.IsReadOnly()
 	// TODO: implement eager execution for non-mutating statements
 	//       and lazy execution for mutating statements.
 	// TODO: implement transaction stack.
 	if transactStatement.IsBegin() { //nolint:gocritic,nestif // TODO: review
+		if journalErr := orc.journaling.begin(orc.txnCoordinator); journalErr != nil {
+			return []internaldto.ExecutorOutput{
+				internaldto.NewErroneousExecutorOutput(journalErr),
+			}, true
+		}
 		txnCoordinator, beginErr := orc.txnCoordinator.Begin()
 		if beginErr != nil {
+			orc.journaling.discard()
 			return []internaldto.ExecutorOutput{
 				internaldto.NewErroneousExecutorOutput(beginErr),
 			}, true
@@ -112,11 +159,15 @@ func (orc *standardOrchestrator) processQuery(
 		}, true
 	} else if transactStatement.IsCommit() {
 		commitCoDomain := orc.txnCoordinator.Commit()
+		orc.locking.releaseTransaction(handlerCtx, orc.txnCoordinator)
 		commitErr, commitErrExists := commitCoDomain.GetError()
 		if commitErrExists {
 			retVal := []internaldto.ExecutorOutput{
 				internaldto.NewErroneousExecutorOutput(commitErr),
 			}
+			if journalErr := orc.journaling.fail(commitErr); journalErr != nil {
+				retVal = append(retVal, internaldto.NewErroneousExecutorOutput(journalErr))
+			}
 			undoLog, undoLogExists := commitCoDomain.GetUndoLog()
 			if undoLogExists && undoLog != nil {
 				humanReadable := undoLog.GetHumanReadable()
@@ -131,6 +182,9 @@ func (orc *standardOrchestrator) processQuery(
 			return retVal, true
 		}
 		retVal := commitCoDomain.GetExecutorOutput()
+		if journalErr := orc.journaling.end(orc.txnCoordinator, journal.StatusCommitted); journalErr != nil {
+			retVal = append(retVal, endError(journalErr))
+		}
 		parent, hasParent := orc.txnCoordinator.GetParent()
 		if hasParent {
 			orc.txnCoordinator = parent
@@ -143,9 +197,14 @@ func (orc *standardOrchestrator) processQuery(
 	} else if transactStatement.IsRollback() {
 		var retVal []internaldto.ExecutorOutput
 		rollbackREsponse := orc.txnCoordinator.Rollback()
+		orc.locking.releaseTransaction(handlerCtx, orc.txnCoordinator)
 		rollbackErr, rollbackErrExists := rollbackREsponse.GetError()
 		if rollbackErrExists {
 			retVal = append(retVal, internaldto.NewErroneousExecutorOutput(rollbackErr))
+			orc.journaling.fail(rollbackErr) //nolint:errcheck // rollback error takes precedence
+		}
+		if journalErr := orc.journaling.end(orc.txnCoordinator, journal.StatusRolledBack); journalErr != nil {
+			retVal = append(retVal, endError(journalErr))
 		}
 		parent, hasParent := orc.txnCoordinator.GetParent()
 		if hasParent {
@@ -161,12 +220,30 @@ func (orc *standardOrchestrator) processQuery(
 		return retVal, true
 	}
 	if isReadOnly || orc.txnCoordinator.IsRoot() {
+		acquired, lockErr := orc.locking.acquire(orc.txnCoordinator, transactStatement)
+		if lockErr != nil {
+			return []internaldto.ExecutorOutput{
+				internaldto.NewErroneousExecutorOutput(lockErr),
+			}, true
+		}
 		stmtOutput := transactStatement.Execute()
+		orc.locking.releaseStatement(orc.txnCoordinator, acquired)
 		return []internaldto.ExecutorOutput{
 			stmtOutput,
 		}, true
 	}
-	orc.txnCoordinator.Enqueue(transactStatement) //nolint:errcheck // TODO: investigate
+	if _, lockErr := orc.locking.acquire(orc.txnCoordinator, transactStatement); lockErr != nil {
+		return []internaldto.ExecutorOutput{
+			internaldto.NewErroneousExecutorOutput(lockErr),
+		}, true
+	}
+	journaledStatement, journalErr := orc.journaling.wrap(transactStatement)
+	if journalErr != nil {
+		return []internaldto.ExecutorOutput{
+			internaldto.NewErroneousExecutorOutput(journalErr),
+		}, true
+	}
+	orc.txnCoordinator.Enqueue(journaledStatement) //nolint:errcheck // TODO: investigate
 	return []internaldto.ExecutorOutput{
 		internaldto.NewNopEmptyExecutorOutput([]string{"mutating statement queued"}),
 	}, true
@@ -0,0 +1,45 @@
+package txn_context //nolint:revive,stylecheck // meaning of package name is clear
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+const (
+	// CompensatingRollbackStr is the rollback type under which
+	// each mutation executed within a transaction records a
+	// compensating statement, replayed upon failure or ROLLBACK.
+	// It extends eager rollback, which is what the session
+	// context proper is configured with.
+	CompensatingRollbackStr = "compensating"
+	eagerRollbackStr        = "eager"
+	rollbackTypeKey         = "rollback_type"
+)
+
+func IsCompensatingRollbackStr(rollbackTypeStr string) bool {
+	return strings.EqualFold(strings.TrimSpace(rollbackTypeStr), CompensatingRollbackStr)
+}
+
+// NormaliseSessionConfig rewrites a raw session config requesting
+// compensating rollback as one requesting eager rollback, and
+// reports whether it did so.  Anything else, including malformed
+// config, is returned verbatim for the session context to judge.
+func NormaliseSessionConfig(raw string) (string, bool) {
+	if strings.TrimSpace(raw) == "" {
+		return raw, false
+	}
+	var cfg map[string]any
+	if err := json.Unmarshal([]byte(raw), &cfg); err != nil {
+		return raw, false
+	}
+	rollbackType, isString := cfg[rollbackTypeKey].(string)
+	if !isString || !IsCompensatingRollbackStr(rollbackType) {
+		return raw, false
+	}
+	cfg[rollbackTypeKey] = eagerRollbackStr
+	normalised, err := json.Marshal(cfg)
+	if err != nil {
+		return raw, false
+	}
+	return string(normalised), true
+}
@@ -0,0 +1,39 @@
+package txn_context_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/stackql/stackql/internal/stackql/acid/txn_context"
+)
+
+func TestNormaliseSessionConfig(t *testing.T) {
+	normalised, isCompensating := NormaliseSessionConfig(`{"rollback_type": "Compensating", "other": 1}`)
+	if !isCompensating {
+		t.Fatalf("expected compensating rollback")
+	}
+	var cfg map[string]any
+	if err := json.Unmarshal([]byte(normalised), &cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg["rollback_type"] != "eager" || cfg["other"] != float64(1) {
+		t.Fatalf("unexpected normalised config: %s", normalised)
+	}
+	for _, raw := range []string{``, `{"rollback_type":"eager"}`, `not json`} {
+		rv, isCompensating := NormaliseSessionConfig(raw)
+		if isCompensating || rv != raw {
+			t.Fatalf("expected '%s' to be returned verbatim", raw)
+		}
+	}
+}
+
+func TestTransactionCoordinatorContextCompensatingRollback(t *testing.T) {
+	tcc := NewTransactionCoordinatorContext(1)
+	if tcc.IsCompensatingRollback() {
+		t.Fatalf("expected compensating rollback to be off by default")
+	}
+	tcc = tcc.WithCompensatingRollback(true)
+	if !tcc.IsCompensatingRollback() || tcc.GetMaxStackDepth() != 1 {
+		t.Fatalf("unexpected coordinator context state")
+	}
+}
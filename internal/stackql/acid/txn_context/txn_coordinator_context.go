@@ -6,10 +6,15 @@ var (
 
 type ITransactionCoordinatorContext interface {
 	GetMaxStackDepth() int
+	// IsCompensatingRollback reports whether sessions
+	// default to compensating rollback.
+	IsCompensatingRollback() bool
+	WithCompensatingRollback(bool) ITransactionCoordinatorContext
 }
 
 type transactionCoordinatorContext struct {
-	maxStackDepth int
+	maxStackDepth          int
+	isCompensatingRollback bool
 }
 
 func NewTransactionCoordinatorContext(
@@ -23,3 +28,14 @@ func NewTransactionCoordinatorContext(
 func (tc *transactionCoordinatorContext) GetMaxStackDepth() int {
 	return tc.maxStackDepth
 }
+
+func (tc *transactionCoordinatorContext) IsCompensatingRollback() bool {
+	return tc.isCompensatingRollback
+}
+
+func (tc *transactionCoordinatorContext) WithCompensatingRollback(isCompensating bool) ITransactionCoordinatorContext {
+	return &transactionCoordinatorContext{
+		maxStackDepth:          tc.maxStackDepth,
+		isCompensatingRollback: isCompensating,
+	}
+}
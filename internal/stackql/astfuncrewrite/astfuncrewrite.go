@@ -10,6 +10,7 @@ import (
 
 const (
 	funcJSONExtractPostgresArgLen = 2
+	funcJSONExtractDuckDB         = "json_extract_string"
 )
 
 type ASTFuncRewriter interface {
@@ -20,6 +21,10 @@ func GetPostgresASTFuncRewriter() ASTFuncRewriter {
 	return &postgresFuncRewriter{}
 }
 
+func GetDuckDBASTFuncRewriter() ASTFuncRewriter {
+	return &duckDBFuncRewriter{}
+}
+
 func GetNopFuncRewriter() ASTFuncRewriter {
 	return &nopFuncRewriter{}
 }
@@ -102,3 +107,26 @@ func (fr *postgresFuncRewriter) RewriteFunc(funcExpr *sqlparser.FuncExpr) (*sqlp
 	}
 	return funcExpr, nil
 }
+
+// duckDBFuncRewriter reconciles SQLite JSON semantics with DuckDB,
+// whose `json_extract` yields JSON rather than a SQL scalar.
+//
+// The rewrite to `json_extract_string` is not type preserving: DuckDB
+// types a column statically, so every extracted scalar is VARCHAR.
+// Strings arrive unquoted as in SQLite, but numbers and booleans arrive
+// as their text (`2`, `true`) and objects and arrays as JSON text.
+// Numeric comparison or arithmetic therefore needs an explicit CAST,
+// lest `'10' > '9'` compare as text.
+type duckDBFuncRewriter struct{}
+
+func (fr *duckDBFuncRewriter) RewriteFunc(funcExpr *sqlparser.FuncExpr) (*sqlparser.FuncExpr, error) {
+	if funcExpr == nil {
+		//nolint:nilnil // consistent with postgres
+		return nil, nil
+	}
+	funcNameLowered := strings.ToLower(funcExpr.Name.GetRawVal())
+	if funcNameLowered == constants.SQLFuncJSONExtractConformed {
+		funcExpr.Name = sqlparser.NewColIdent(funcJSONExtractDuckDB)
+	}
+	return funcExpr, nil
+}
@@ -63,6 +63,14 @@ func (pr *AsyncHTTPMonitorPrimitive) WithDebugName(_ string) primitive.IPrimitiv
 	return pr
 }
 
+func (pr *AsyncHTTPMonitorPrimitive) GetDescriptor() (primitive.Descriptor, bool) {
+	return nil, false
+}
+
+func (pr *AsyncHTTPMonitorPrimitive) WithDescriptor(_ primitive.Descriptor) primitive.IPrimitive {
+	return pr
+}
+
 func (pr *AsyncHTTPMonitorPrimitive) SetUndoLog(_ binlog.LogEntry) {
 }
 
@@ -0,0 +1,254 @@
+// Package bindparam binds the parameters of statements prepared over the
+// extended query protocol, addressed by $n or ? placeholders, as typed
+// values rather than as text pasted into the query.
+package bindparam
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stackql/stackql-parser/go/vt/sqlparser"
+	"github.com/stackql/stackql/internal/stackql/plancache"
+)
+
+// Style is the placeholder syntax of a SQL backend.
+type Style int
+
+const (
+	// StyleDollar addresses parameters as $1, $2, ..., as postgres does.
+	StyleDollar Style = iota
+	// StyleNumberedQuestion addresses parameters as ?1, ?2, ..., as
+	// SQLite does; SQLite reads $1 as a named parameter, numbered by
+	// first appearance rather than by its digits.
+	StyleNumberedQuestion
+)
+
+//nolint:gochecknoglobals // immutable lookup
+var (
+	dollarNameRegex = regexp.MustCompile(`^\$([1-9][0-9]*)$`)
+	valArgRegex     = regexp.MustCompile(`^:v([1-9][0-9]*)$`)
+)
+
+// Count returns how many parameters query addresses: the highest $n, or
+// the number of ? placeholders where that is greater.
+func Count(query string) int {
+	var rv, positional int
+	scan(query, func(p placeholder) {
+		if p.isPositional {
+			positional++
+			rv = max(rv, positional)
+			return
+		}
+		rv = max(rv, p.index)
+	})
+	return rv
+}
+
+// Native rewrites the placeholders of query to the syntax of a SQL
+// backend, so that it runs with the bound values as arguments.
+func Native(query string, style Style) string {
+	var b strings.Builder
+	var last, positional int
+	scan(query, func(p placeholder) {
+		index := p.index
+		if p.isPositional {
+			positional++
+			index = positional
+		}
+		b.WriteString(query[last:p.start])
+		if style == StyleNumberedQuestion {
+			b.WriteString("?")
+		} else {
+			b.WriteString("$")
+		}
+		b.WriteString(strconv.Itoa(index))
+		last = p.end
+	})
+	if last == 0 {
+		return query
+	}
+	b.WriteString(query[last:])
+	return b.String()
+}
+
+// Bind replaces the placeholders of stmt with literals of the bound
+// values, so that analysis sees them as it would values written into the
+// query.  stmt is rewritten in place; the result is the rewritten root,
+// and the slot of each parameter: the one literal node in place of all
+// its placeholders, nil for NULL, booleans and parameters not addressed.
+func Bind(stmt sqlparser.Statement, params []any) (sqlparser.Statement, []*sqlparser.SQLVal, error) {
+	literals := make([]sqlparser.Expr, len(params))
+	slots := make([]*sqlparser.SQLVal, len(params))
+	var bindErr error
+	rv := sqlparser.Rewrite(stmt, func(cursor *sqlparser.Cursor) bool {
+		index, isPlaceholder := placeholderIndex(cursor.Node())
+		if !isPlaceholder {
+			return bindErr == nil
+		}
+		if index > len(params) {
+			bindErr = fmt.Errorf("could not bind parameter $%d: %d parameters supplied", index, len(params))
+			return false
+		}
+		if literals[index-1] == nil {
+			literal, literalErr := Literal(params[index-1])
+			if literalErr != nil {
+				bindErr = fmt.Errorf("could not bind parameter $%d: %w", index, literalErr)
+				return false
+			}
+			literals[index-1] = literal
+			slots[index-1], _ = literal.(*sqlparser.SQLVal)
+		}
+		cursor.Replace(literals[index-1])
+		return false
+	}, nil)
+	if bindErr != nil {
+		return nil, nil, bindErr
+	}
+	boundStmt, isStmt := rv.(sqlparser.Statement)
+	if !isStmt {
+		return nil, nil, fmt.Errorf("could not bind parameters: unexpected root %T", rv)
+	}
+	return boundStmt, slots, nil
+}
+
+// Shape returns the plan template shape of query with params bound, so
+// that every binding of a prepared statement shares one plan.  The shape
+// is keyed by the query and the literal type of each value, NULLs and
+// booleans keying by value, and each parameter binds a slot of its own.
+// The slots themselves are those which Bind returns, once the statement
+// is planned.
+func Shape(query string, params []any) (plancache.Shape, error) {
+	var b strings.Builder
+	b.WriteString(query)
+	values := make([]plancache.Value, len(params))
+	for i, p := range params {
+		literal, err := Literal(p)
+		if err != nil {
+			return plancache.Shape{}, fmt.Errorf("could not bind parameter $%d: %w", i+1, err)
+		}
+		if val, isVal := literal.(*sqlparser.SQLVal); isVal {
+			values[i] = plancache.Value{Type: val.Type, Val: val.Val}
+			fmt.Fprintf(&b, "\x00%d", val.Type)
+			continue
+		}
+		fmt.Fprintf(&b, "\x00%s", sqlparser.String(literal))
+	}
+	return plancache.Shape{Key: b.String(), Values: values}, nil
+}
+
+// placeholderIndex reports the parameter which node addresses, if any:
+// $n is parsed as a column name, ? as the value argument :vn.
+func placeholderIndex(node sqlparser.SQLNode) (int, bool) {
+	var matches []string
+	switch node := node.(type) {
+	case *sqlparser.ColName:
+		if !node.Qualifier.IsEmpty() {
+			return 0, false
+		}
+		matches = dollarNameRegex.FindStringSubmatch(node.Name.String())
+	case *sqlparser.SQLVal:
+		if node.Type != sqlparser.ValArg {
+			return 0, false
+		}
+		matches = valArgRegex.FindStringSubmatch(string(node.Val))
+	}
+	if matches == nil {
+		return 0, false
+	}
+	index, err := strconv.Atoi(matches[1])
+	if err != nil {
+		return 0, false
+	}
+	return index, true
+}
+
+// Literal returns the expression of a bound value.
+func Literal(v any) (sqlparser.Expr, error) {
+	switch v := v.(type) {
+	case nil:
+		return &sqlparser.NullVal{}, nil
+	case bool:
+		return sqlparser.BoolVal(v), nil
+	case int64:
+		return sqlparser.NewIntVal([]byte(strconv.FormatInt(v, 10))), nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return sqlparser.NewStrVal([]byte(strconv.FormatFloat(v, 'f', -1, 64))), nil
+		}
+		return sqlparser.NewFloatVal([]byte(strconv.FormatFloat(v, 'f', -1, 64))), nil
+	case string:
+		return sqlparser.NewStrVal([]byte(v)), nil
+	case []byte:
+		return sqlparser.NewStrVal(v), nil
+	case time.Time:
+		return sqlparser.NewStrVal([]byte(v.Format("2006-01-02 15:04:05.999999Z07:00"))), nil
+	default:
+		return nil, fmt.Errorf("unsupported parameter type %T", v)
+	}
+}
+
+// placeholder is one $n or ? of a query.
+type placeholder struct {
+	start, end   int
+	index        int
+	isPositional bool
+}
+
+// scan calls visit with each placeholder of query, outside literals,
+// quoted identifiers and comments.
+//
+//nolint:gocognit // a small scanner
+func scan(query string, visit func(placeholder)) {
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"':
+			for i++; i < len(query); i++ {
+				if query[i] != c {
+					continue
+				}
+				if i+1 < len(query) && query[i+1] == c {
+					i++
+					continue
+				}
+				break
+			}
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				return
+			}
+			i += end
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				return
+			}
+			i += end + 3 //nolint:mnd // both delimiters, less the loop's increment
+		case c == '$' && (i == 0 || !isIdentChar(query[i-1])):
+			end := i + 1
+			for end < len(query) && query[end] >= '0' && query[end] <= '9' {
+				end++
+			}
+			if end == i+1 || (end < len(query) && isIdentChar(query[end])) {
+				continue
+			}
+			index, err := strconv.Atoi(query[i+1 : end])
+			if err != nil || index == 0 {
+				continue
+			}
+			visit(placeholder{start: i, end: end, index: index})
+			i = end - 1
+		case c == '?':
+			visit(placeholder{start: i, end: i + 1, isPositional: true})
+		}
+	}
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,150 @@
+package bindparam_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stackql/stackql-parser/go/vt/sqlparser"
+	"github.com/stackql/stackql/internal/stackql/bindparam"
+	"github.com/stackql/stackql/internal/stackql/parser"
+	"github.com/stackql/stackql/internal/stackql/plancache"
+)
+
+func TestCount(t *testing.T) {
+	for query, expected := range map[string]int{
+		"SELECT 1": 0,
+		"SELECT * FROM t WHERE a = $1 AND b = $3":    3,
+		"SELECT * FROM t WHERE a = ? AND b = ?":      2,
+		"SELECT '$1', \"$2\" FROM t -- $3\nWHERE $1": 1,
+		"SELECT $$body$$, a$1 FROM t /* $9 */":       0,
+	} {
+		if received := bindparam.Count(query); received != expected {
+			t.Errorf("%s: expected %d, received %d", query, expected, received)
+		}
+	}
+}
+
+func TestNative(t *testing.T) {
+	for _, tc := range []struct {
+		query    string
+		style    bindparam.Style
+		expected string
+	}{
+		{"SELECT * FROM t WHERE a = $2 AND b = $1", bindparam.StyleNumberedQuestion, "SELECT * FROM t WHERE a = ?2 AND b = ?1"},
+		{"SELECT * FROM t WHERE a = ? AND b = ?", bindparam.StyleDollar, "SELECT * FROM t WHERE a = $1 AND b = $2"},
+		{"SELECT '?' FROM t WHERE a = $1", bindparam.StyleDollar, "SELECT '?' FROM t WHERE a = $1"},
+		{"SELECT 1", bindparam.StyleNumberedQuestion, "SELECT 1"},
+	} {
+		if received := bindparam.Native(tc.query, tc.style); received != tc.expected {
+			t.Errorf("%s: expected %s, received %s", tc.query, tc.expected, received)
+		}
+	}
+}
+
+func TestBind(t *testing.T) {
+	p, err := parser.NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		query    string
+		params   []any
+		expected string
+	}{
+		{
+			"select name from google.compute.instances where project = $1 and zone = $2",
+			[]any{"it's", "us-east1-b"},
+			`select name from "google.compute.instances" where project = 'it\'s' and zone = 'us-east1-b'`,
+		},
+		{
+			"select * from t where a = $2 and b = $1 and c = $1",
+			[]any{int64(-3), 1.5},
+			`select * from "t" where a = 1.5 and b = -3 and c = -3`,
+		},
+		{
+			"select * from t where a = ? and b = ?",
+			[]any{true, nil},
+			`select * from "t" where a = true and b = null`,
+		},
+		{
+			"select * from t where t.$1 = $1",
+			[]any{math.Inf(1)},
+			`select * from "t" where "t".$1 = '+Inf'`,
+		},
+	} {
+		stmt, parseErr := p.ParseQuery(tc.query)
+		if parseErr != nil {
+			t.Fatalf("%s: %v", tc.query, parseErr)
+		}
+		bound, _, bindErr := bindparam.Bind(stmt, tc.params)
+		if bindErr != nil {
+			t.Fatalf("%s: %v", tc.query, bindErr)
+		}
+		if received := sqlparser.String(bound); received != tc.expected {
+			t.Errorf("%s:\nexpected %s\nreceived %s", tc.query, tc.expected, received)
+		}
+	}
+	stmt, _ := p.ParseQuery("select * from t where a = $2")
+	if _, _, bindErr := bindparam.Bind(stmt, []any{"x"}); bindErr == nil {
+		t.Errorf("expected an unbound parameter to be reported")
+	}
+	stmt, _ = p.ParseQuery("select * from t where a = $1")
+	if _, _, bindErr := bindparam.Bind(stmt, []any{struct{}{}}); bindErr == nil {
+		t.Errorf("expected an unsupported value to be reported")
+	}
+}
+
+func TestBindSlots(t *testing.T) {
+	p, err := parser.NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt, _ := p.ParseQuery("select * from t where a = $1 and b = $3 and c = $1")
+	_, slots, err := bindparam.Bind(stmt, []any{"x", "unused", nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(slots) != 3 || slots[0] == nil || slots[1] != nil || slots[2] != nil {
+		t.Fatalf("unexpected slots %v", slots)
+	}
+	// Rebinding the slot of $1 reaches both its placeholders.
+	slots[0].Val = []byte("y")
+	if received := sqlparser.String(stmt); received != `select * from "t" where a = 'y' and b = null and c = 'y'` {
+		t.Errorf("unexpected rebound statement %s", received)
+	}
+}
+
+func TestShape(t *testing.T) {
+	query := "select * from t where a = $1 and b = $2"
+	a, err := bindparam.Shape(query, []any{"x", int64(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := bindparam.Shape(query, []any{"y", int64(2)})
+	if a.Key != b.Key || string(b.Values[0].Val) != "y" || b.Values[1].Type != sqlparser.IntVal {
+		t.Errorf("expected bindings of one type to share a shape: %q %q %v", a.Key, b.Key, b.Values)
+	}
+	for _, params := range [][]any{{int64(1), int64(1)}, {"x", nil}, {"x", int64(1), "z"}} {
+		if c, _ := bindparam.Shape(query, params); c.Key == a.Key {
+			t.Errorf("%v: expected differently typed bindings to key apart", params)
+		}
+	}
+	if d, _ := bindparam.Shape(query, []any{true, nil}); d.Key == a.Key {
+		t.Errorf("expected booleans to key by value")
+	}
+	if e, f := mustShape(t, query, true), mustShape(t, query, false); e.Key == f.Key {
+		t.Errorf("expected booleans of different values to key apart")
+	}
+	if _, err = bindparam.Shape(query, []any{struct{}{}}); err == nil {
+		t.Errorf("expected an unsupported value to be reported")
+	}
+}
+
+func mustShape(t *testing.T, query string, params ...any) plancache.Shape {
+	t.Helper()
+	shape, err := bindparam.Shape(query, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return shape
+}
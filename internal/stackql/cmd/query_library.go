@@ -0,0 +1,73 @@
+/*
+Copyright © 2025 stackql info@stackql.io
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stackql/stackql/internal/stackql/iqlerror"
+	"github.com/stackql/stackql/pkg/mcp_server"
+)
+
+//nolint:gochecknoglobals // cobra pattern
+var queryLibraryCmd = &cobra.Command{
+	Use:   "query-library",
+	Short: "Validate and index a local MCP query library.  Usage: stackql query-library {subcommand} {dir}",
+	Long: `
+	Validate and index a local query library, as mounted by the MCP server
+	through query_library.local_dirs.  Usage: stackql query-library {subcommand} {dir}
+	Currently supported subcommands:
+	  - validate {dir}: check every queries/**/*.json document (params, enum
+	    values, defaults, examples, and that the template parses) and that
+	    index.json and manifest.json agree with them
+	  - index {dir}: validate the documents, then (re)write index.json and
+	    manifest.json
+	`,
+	Run: func(cmd *cobra.Command, args []string) {
+		usagemsg := cmd.Long + "\n\n" + cmd.UsageString()
+		if len(args) != 2 { //nolint:mnd // subcommand and directory
+			iqlerror.PrintErrorAndExitOneWithMessage(usagemsg)
+		}
+		dir := args[1]
+		var problems []mcp_server.QueryLibraryProblem
+		var err error
+		var success string
+		switch strings.ToLower(args[0]) {
+		case "validate":
+			problems, err = mcp_server.ValidateQueryLibrary(dir)
+			success = fmt.Sprintf("query library %s is valid", dir)
+		case "index":
+			var n int
+			n, problems, err = mcp_server.IndexQueryLibrary(dir, time.Now())
+			success = fmt.Sprintf("indexed %d queries in %s", n, dir)
+		default:
+			iqlerror.PrintErrorAndExitOneWithMessage(usagemsg)
+		}
+		iqlerror.PrintErrorAndExitOneIfError(err)
+		for _, p := range problems {
+			fmt.Fprintln(os.Stderr, p.String())
+		}
+		if defects := mcp_server.CountQueryLibraryDefects(problems); defects > 0 {
+			iqlerror.PrintErrorAndExitOneWithMessage(fmt.Sprintf("%d problem(s) found in query library %s", defects, dir))
+		}
+		fmt.Fprintln(os.Stdout, success)
+	},
+}
@@ -20,18 +20,16 @@ import (
 	"fmt"
 	"io"
 	"runtime"
-	"strconv"
 	"strings"
 
 	"github.com/stackql/any-sdk/pkg/dto"
-	"github.com/stackql/any-sdk/pkg/logging"
-	"github.com/stackql/stackql-parser/go/vt/sqlparser"
 	"github.com/stackql/stackql/internal/stackql/buildinfo"
 	"github.com/stackql/stackql/internal/stackql/config"
 	"github.com/stackql/stackql/internal/stackql/driver"
 	"github.com/stackql/stackql/internal/stackql/entryutil"
 	"github.com/stackql/stackql/internal/stackql/handler"
 	"github.com/stackql/stackql/internal/stackql/iqlerror"
+	"github.com/stackql/stackql/internal/stackql/metacommand"
 	"github.com/stackql/stackql/internal/stackql/presentation"
 	"github.com/stackql/stackql/internal/stackql/provider"
 	"github.com/stackql/stackql/internal/stackql/writer"
@@ -122,7 +120,6 @@ var shellCmd = &cobra.Command{
 
 		outErrFile, _ := writer.GetDecoratedOutputWriter(writer.StdErrStr, cd)
 
-		var sb strings.Builder
 		fmt.Fprintln(outErrFile, getShellIntroLong())
 
 		inputBundle, err := entryutil.BuildInputBundle(runtimeCtx)
@@ -179,6 +176,7 @@ var shellCmd = &cobra.Command{
 			HistoryFile:          config.GetReadlineFilePath(handlerCtx.GetRuntimeContext()),
 			HistorySearchFold:    true,
 			HistoryExternalWrite: true,
+			AutoComplete:         metacommand.NewCompleter(newRegistryCatalogue(handlerCtx)),
 		}
 
 		sessionRunnerInstance, sessionErr := newSessionRunner(
@@ -194,6 +192,9 @@ var shellCmd = &cobra.Command{
 		}
 		defer l.Close()
 
+		session := newShellSession(handlerCtx, sessionRunnerInstance, l, outErrFile)
+		defer session.close()
+
 		fmt.Fprintln(
 			outErrFile,
 			getIntroAuthMsg(authCtx, prov),
@@ -213,47 +214,10 @@ var shellCmd = &cobra.Command{
 				break
 			}
 
-			line := strings.TrimSpace(rawLine)
-			switch {
-			case line == "help":
-				usage(outErrFile)
-			case line == "clear":
-				readline.ClearScreen(l.Stdout()) //nolint:errcheck // TODO: investigate
-			case line == "exit" || line == `\q` || line == "quit":
-				goto exit
-			case line == "":
-			default:
-				logging.GetLogger().Debugln("you said:", strconv.Quote(line))
-				inlineCommentIdx := strings.Index(line, "--")
-				if inlineCommentIdx > -1 {
-					line = line[:inlineCommentIdx]
-				}
-				hasRHSSemiColon := strings.HasSuffix(strings.TrimSpace(line), ";")
-				splitQueries, _ := sqlparser.SplitStatementToPieces(line)
-				if len(splitQueries) > 0 {
-					for i, s := range splitQueries {
-						if i == len(splitQueries)-1 && !hasRHSSemiColon {
-							// Last piece has no trailing semicolon;
-							// accumulate for multi-line continuation.
-							sb.WriteString(" " + s)
-							continue
-						}
-						sb.WriteString(" " + s + ";")
-						rawQuery := sb.String()
-						queryToExecute, qErr := entryutil.PreprocessInline(runtimeCtx, rawQuery)
-						if qErr != nil {
-							io.WriteString(outErrFile, "\r\n"+qErr.Error()+"\r\n") //nolint:errcheck // TODO: investigate
-						}
-						l.WriteToHistory(rawQuery) //nolint:errcheck // TODO: investigate
-						sessionRunnerInstance.RunCommand(queryToExecute)
-						sb.Reset()
-					}
-				} else {
-					sb.WriteString(" " + line)
-				}
+			if session.processLine(strings.TrimSpace(rawLine), true) {
+				break
 			}
 		}
-	exit:
 		fmt.Fprintln(
 			outErrFile,
 			"goodbye",
@@ -0,0 +1,348 @@
+/*
+Copyright © 2025 stackql info@stackql.io
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chzyer/readline"
+	"github.com/stackql/any-sdk/pkg/logging"
+	"github.com/stackql/stackql-parser/go/vt/sqlparser"
+	"github.com/stackql/stackql/internal/stackql/entryutil"
+	"github.com/stackql/stackql/internal/stackql/handler"
+	"github.com/stackql/stackql/internal/stackql/metacommand"
+	"github.com/stackql/stackql/internal/stackql/output"
+	"github.com/stackql/stackql/internal/stackql/provider"
+)
+
+// maxIncludeDepth bounds the nesting of \i, so that a script including
+// itself fails rather than recursing without end.
+const maxIncludeDepth = 16
+
+// shellSession holds the state of an interactive shell which outlives a
+// single statement: the statement being accumulated over lines, and the
+// settings of the meta commands.
+type shellSession struct {
+	handlerCtx   handler.HandlerContext
+	runner       sessionRunner
+	rl           *readline.Instance
+	outfile      io.Writer
+	outErrFile   io.Writer
+	redirect     *os.File
+	vars         metacommand.Vars
+	outputFormat string
+	isTiming     bool
+	isExpanded   bool
+	includeDepth int
+	sb           strings.Builder
+}
+
+func newShellSession(
+	handlerCtx handler.HandlerContext,
+	runner sessionRunner,
+	rl *readline.Instance,
+	outErrFile io.Writer,
+) *shellSession {
+	return &shellSession{
+		handlerCtx:   handlerCtx,
+		runner:       runner,
+		rl:           rl,
+		outfile:      handlerCtx.GetOutfile(),
+		outErrFile:   outErrFile,
+		vars:         metacommand.NewVars(),
+		outputFormat: handlerCtx.GetRuntimeContext().OutputFormat,
+	}
+}
+
+// processLine runs one line of input, reporting whether it ends the
+// shell.  Statements may span lines, and run once terminated by a
+// semicolon; meta commands run at once.
+func (s *shellSession) processLine(line string, isInteractive bool) bool {
+	switch {
+	case line == "help":
+		usage(s.outErrFile)
+	case line == "clear":
+		readline.ClearScreen(s.rl.Stdout()) //nolint:errcheck // TODO: investigate
+	case line == "exit" || line == `\q` || line == "quit":
+		return true
+	case line == "":
+	case strings.HasPrefix(line, `\`):
+		return s.runMetaCommand(line)
+	default:
+		logging.GetLogger().Debugln("you said:", strconv.Quote(line))
+		inlineCommentIdx := strings.Index(line, "--")
+		if inlineCommentIdx > -1 {
+			line = line[:inlineCommentIdx]
+		}
+		hasRHSSemiColon := strings.HasSuffix(strings.TrimSpace(line), ";")
+		splitQueries, _ := sqlparser.SplitStatementToPieces(line)
+		if len(splitQueries) == 0 {
+			s.sb.WriteString(" " + line)
+			return false
+		}
+		for i, q := range splitQueries {
+			if i == len(splitQueries)-1 && !hasRHSSemiColon {
+				// Last piece has no trailing semicolon;
+				// accumulate for multi-line continuation.
+				s.sb.WriteString(" " + q)
+				continue
+			}
+			s.sb.WriteString(" " + q + ";")
+			rawQuery := s.sb.String()
+			s.sb.Reset()
+			s.runQuery(rawQuery, isInteractive)
+		}
+	}
+	return false
+}
+
+// runQuery renders the templating of rawQuery and runs it.  Templates
+// read the variables of \set directly, and jsonnet blocks receive them
+// after those of --var.
+func (s *shellSession) runQuery(rawQuery string, isInteractive bool) {
+	rtCtx := runtimeCtx
+	rtCtx.VarList = s.vars.VarList(runtimeCtx.VarList)
+	queryToExecute, qErr := entryutil.PreprocessInline(rtCtx, rawQuery, s.vars.Values())
+	if qErr != nil {
+		io.WriteString(s.outErrFile, "\r\n"+qErr.Error()+"\r\n") //nolint:errcheck // TODO: investigate
+	}
+	if isInteractive {
+		s.rl.WriteToHistory(rawQuery) //nolint:errcheck // TODO: investigate
+	}
+	s.execute(queryToExecute)
+}
+
+func (s *shellSession) execute(query string) {
+	start := time.Now()
+	s.runner.RunCommand(query)
+	if s.isTiming {
+		fmt.Fprintf(s.outErrFile, "Time: %.3f ms\r\n", float64(time.Since(start).Microseconds())/1000) //nolint:mnd // µs to ms
+	}
+}
+
+//nolint:gocyclo,cyclop // dispatch on the command
+func (s *shellSession) runMetaCommand(line string) bool {
+	c, _ := metacommand.Parse(line)
+	var err error
+	switch c.Name {
+	case metacommand.Quit:
+		return true
+	case metacommand.Help:
+		io.WriteString(s.outErrFile, metacommand.HelpText) //nolint:errcheck // TODO: investigate
+	case metacommand.Describe, metacommand.Tables, metacommand.Views, metacommand.MaterializedViews:
+		var query string
+		query, err = metacommand.ListingQuery(c)
+		if err == nil {
+			s.execute(query)
+		}
+	case metacommand.Output:
+		err = s.setOutput(c.Arg(0))
+	case metacommand.Timing:
+		s.isTiming, err = metacommand.ParseToggle(c, s.isTiming)
+		if err == nil {
+			fmt.Fprintf(s.outErrFile, "Timing is %s.\r\n", onOff(s.isTiming))
+		}
+	case metacommand.Expanded:
+		s.isExpanded, err = metacommand.ParseToggle(c, s.isExpanded)
+		if err == nil {
+			format := s.outputFormat
+			if s.isExpanded {
+				format = output.ExpandedStr
+			}
+			s.handlerCtx.SetOutputFormat(format)
+			fmt.Fprintf(s.outErrFile, "Expanded display is %s.\r\n", onOff(s.isExpanded))
+		}
+	case metacommand.Include:
+		var isExit bool
+		isExit, err = s.include(c.Arg(0))
+		if isExit {
+			return true
+		}
+	case metacommand.Set:
+		if len(c.Args) == 0 {
+			for _, name := range s.vars.Names() {
+				fmt.Fprintf(s.outErrFile, "%s = '%s'\r\n", name, s.vars.Get(name))
+			}
+			break
+		}
+		err = s.vars.Set(c.Arg(0), strings.Join(c.Args[1:], ""))
+	case metacommand.Unset:
+		if c.Arg(0) == "" {
+			err = fmt.Errorf("%s: missing required argument", c.Name)
+			break
+		}
+		s.vars.Unset(c.Arg(0))
+	default:
+		err = fmt.Errorf(`invalid command %s; try \? for help`, c.Name)
+	}
+	if err != nil {
+		io.WriteString(s.outErrFile, err.Error()+"\r\n") //nolint:errcheck // TODO: investigate
+	}
+	return false
+}
+
+// setOutput sends query output to the file at path, truncating it, or,
+// where path is empty, back to where it went at start up.
+func (s *shellSession) setOutput(path string) error {
+	s.closeRedirect()
+	if path == "" {
+		return nil
+	}
+	f, err := os.Create(path) //nolint:gosec // the operator nominates the file
+	if err != nil {
+		return err
+	}
+	s.redirect = f
+	s.handlerCtx.SetOutfile(f)
+	return nil
+}
+
+func (s *shellSession) closeRedirect() {
+	s.handlerCtx.SetOutfile(s.outfile)
+	if s.redirect != nil {
+		s.redirect.Close() //nolint:errcheck // best effort
+		s.redirect = nil
+	}
+}
+
+// include runs the lines of the file at path as though typed, reporting
+// whether one of them ends the shell.
+func (s *shellSession) include(path string) (bool, error) {
+	if path == "" {
+		return false, fmt.Errorf("%s: missing required argument", metacommand.Include)
+	}
+	if s.includeDepth >= maxIncludeDepth {
+		return false, fmt.Errorf("%s: scripts nested too deeply", metacommand.Include)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	s.includeDepth++
+	defer func() { s.includeDepth-- }()
+	for _, line := range strings.Split(string(content), "\n") {
+		if s.processLine(strings.TrimSpace(line), false) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *shellSession) close() {
+	s.closeRedirect()
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+// registryCatalogue lists the provider hierarchy for tab completion, from
+// the providers available locally.  Each level is listed once, as listing
+// services loads the provider's documents.
+type registryCatalogue struct {
+	handlerCtx handler.HandlerContext
+	providers  []string
+	services   map[string][]string
+	resources  map[string][]string
+}
+
+func newRegistryCatalogue(handlerCtx handler.HandlerContext) metacommand.Catalogue {
+	return &registryCatalogue{
+		handlerCtx: handlerCtx,
+		services:   make(map[string][]string),
+		resources:  make(map[string][]string),
+	}
+}
+
+func (c *registryCatalogue) Providers() []string {
+	if c.providers != nil {
+		return c.providers
+	}
+	providers, err := c.handlerCtx.GetSupportedProviders(false)
+	if err != nil {
+		return nil
+	}
+	c.providers = make([]string, 0, len(providers))
+	for name := range providers {
+		c.providers = append(c.providers, name)
+	}
+	sort.Strings(c.providers)
+	return c.providers
+}
+
+// provider returns the named provider where it is available locally, so
+// that completion never pulls a provider.
+func (c *registryCatalogue) provider(name string) (provider.IProvider, bool) {
+	for _, p := range c.Providers() {
+		if p == name {
+			prov, err := c.handlerCtx.GetProvider(name)
+			return prov, err == nil
+		}
+	}
+	return nil, false
+}
+
+func (c *registryCatalogue) Services(providerName string) []string {
+	if rv, isListed := c.services[providerName]; isListed {
+		return rv
+	}
+	var rv []string
+	if prov, isAvailable := c.provider(providerName); isAvailable {
+		rtCtx := c.handlerCtx.GetRuntimeContext()
+		services, err := prov.GetProviderServicesRedacted(rtCtx, false)
+		if err == nil {
+			seen := make(map[string]struct{})
+			for _, svc := range services {
+				if !rtCtx.UseNonPreferredAPIs && !svc.IsPreferred() {
+					continue
+				}
+				if _, isSeen := seen[svc.GetName()]; !isSeen {
+					seen[svc.GetName()] = struct{}{}
+					rv = append(rv, svc.GetName())
+				}
+			}
+		}
+	}
+	c.services[providerName] = rv
+	return rv
+}
+
+func (c *registryCatalogue) Resources(providerName, serviceName string) []string {
+	key := providerName + "." + serviceName
+	if rv, isListed := c.resources[key]; isListed {
+		return rv
+	}
+	var rv []string
+	if prov, isAvailable := c.provider(providerName); isAvailable {
+		resources, err := prov.GetResourcesMap(serviceName, c.handlerCtx.GetRuntimeContext())
+		if err == nil {
+			for name := range resources {
+				rv = append(rv, name)
+			}
+		}
+	}
+	c.resources[key] = rv
+	return rv
+}
@@ -17,10 +17,12 @@ package cmd
 
 import (
 	"github.com/spf13/cobra"
+	"github.com/stackql/psql-wire/pkg/sqlbackend"
 
 	"github.com/stackql/stackql/internal/stackql/driver"
 	"github.com/stackql/stackql/internal/stackql/entryutil"
 	"github.com/stackql/stackql/internal/stackql/iqlerror"
+	"github.com/stackql/stackql/internal/stackql/pgauth"
 	"github.com/stackql/stackql/internal/stackql/psqlwire"
 )
 
@@ -29,6 +31,9 @@ const MAX = 100
 
 const DEFAULT_PORT_NO = 3406 //nolint:revive,stylecheck // legacy
 
+//nolint:gochecknoglobals // cobra pattern
+var pgSrvUsersFile string // overwritten by flag
+
 //nolint:gochecknoglobals // cobra pattern
 var srvCmd = &cobra.Command{
 	Use:   "srv",
@@ -45,8 +50,21 @@ var srvCmd = &cobra.Command{
 		iqlerror.PrintErrorAndExitOneIfError(err)
 		handlerCtx, err := entryutil.BuildHandlerContext(runtimeCtx, nil, queryCache, inputBundle, false)
 		iqlerror.PrintErrorAndExitOneIfError(err)
-		sbe := driver.NewStackQLDriverFactory(handlerCtx, runtimeCtx.PGSrvIsDebugNoticesEnabled)
-		server, err := psqlwire.MakeWireServer(sbe, runtimeCtx)
+		var server psqlwire.IWireServer
+		if pgSrvUsersFile != "" {
+			users, usersErr := pgauth.LoadUsers(pgSrvUsersFile)
+			iqlerror.PrintErrorAndExitOneIfError(usersErr)
+			server, err = psqlwire.MakeUserWireServer(
+				runtimeCtx,
+				users,
+				func(user pgauth.User) (sqlbackend.SQLBackendFactory, error) {
+					return driver.NewStackQLDriverFactoryForUser(handlerCtx, runtimeCtx.PGSrvIsDebugNoticesEnabled, user)
+				},
+			)
+		} else {
+			sbe := driver.NewStackQLDriverFactory(handlerCtx, runtimeCtx.PGSrvIsDebugNoticesEnabled)
+			server, err = psqlwire.MakeWireServer(sbe, runtimeCtx)
+		}
 		iqlerror.PrintErrorAndExitOneIfError(err)
 		if mcpServerType != "" {
 			go runMCPServer(handlerCtx.Clone()) //nolint:errcheck // TODO: investigate
@@ -0,0 +1,124 @@
+/*
+Copyright © 2025 stackql info@stackql.io
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stackql/stackql/internal/stackql/acid/journal"
+	"github.com/stackql/stackql/internal/stackql/acid/tsm_physio"
+	"github.com/stackql/stackql/internal/stackql/entryutil"
+	"github.com/stackql/stackql/internal/stackql/iqlerror"
+)
+
+//nolint:gochecknoglobals // overwritten by flag
+var txnReplayInDoubt bool
+
+//nolint:gochecknoglobals // cobra pattern
+var txnCmd = &cobra.Command{
+	Use:   "txn",
+	Short: "Inspection and recovery of transactions journaled in the approot.  Usage: stackql txn {subcommand} [{arg}]", //nolint:lll // long string
+	Long: `
+	Inspection and recovery of transactions journaled in the approot. Usage: stackql txn {subcommand}
+	Currently supported subcommands:
+	  - recover
+	      lists incomplete transactions, those of processes which have exited.
+	  - recover {txn_id}
+	      lists the statements of an incomplete transaction.
+	  - recover {txn_id} {replay|compensate|discard}
+	      replay re-executes, in order, those statements not known to have succeeded,
+	        refusing those in doubt, which were executing at the time of failure,
+	        unless --replay-in-doubt is passed;
+	      compensate executes, in reverse order, the undo of those that succeeded;
+	      discard forgets the transaction, once resolved by hand.
+	`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		flagErr := dependentFlagHandler(&runtimeCtx)
+		iqlerror.PrintErrorAndExitOneIfError(flagErr)
+
+		usagemsg := cmd.Long + "\n\n" + cmd.UsageString()
+		if len(args) < 1 || strings.ToLower(args[0]) != "recover" || len(args) > 3 { //nolint:mnd // TODO: investigate
+			iqlerror.PrintErrorAndExitOneWithMessage(usagemsg)
+		}
+
+		inputBundle, err := entryutil.BuildInputBundle(runtimeCtx)
+		iqlerror.PrintErrorAndExitOneIfError(err)
+		handlerCtx, err := entryutil.BuildHandlerContext(runtimeCtx, bytes.NewReader(nil), queryCache, inputBundle, true)
+		iqlerror.PrintErrorAndExitOneIfError(err)
+		iqlerror.PrintErrorAndExitOneIfNil(handlerCtx, "Handler context error")
+		outfile := handlerCtx.GetOutfile()
+
+		switch len(args) {
+		case 1:
+			txns, listErr := tsm_physio.ListIncompleteTransactions(handlerCtx)
+			iqlerror.PrintErrorAndExitOneIfError(listErr)
+			printIncompleteTransactions(outfile, txns)
+		case 2: //nolint:mnd // TODO: investigate
+			txns, listErr := tsm_physio.ListIncompleteTransactions(handlerCtx)
+			iqlerror.PrintErrorAndExitOneIfError(listErr)
+			for _, txn := range txns {
+				if txn.ID == args[1] {
+					printJournaledStatements(outfile, txn)
+					return
+				}
+			}
+			iqlerror.PrintErrorAndExitOneWithMessage(
+				fmt.Sprintf("no incomplete transaction with id '%s'", args[1]))
+		default:
+			mode, modeErr := tsm_physio.ParseRecoveryMode(args[2])
+			iqlerror.PrintErrorAndExitOneIfError(modeErr)
+			messages, recoverErr := tsm_physio.RecoverTransaction(handlerCtx, args[1], mode, txnReplayInDoubt)
+			for _, m := range messages {
+				fmt.Fprintln(outfile, m)
+			}
+			iqlerror.PrintErrorAndExitOneIfError(recoverErr)
+		}
+	},
+}
+
+func printIncompleteTransactions(w io.Writer, txns []journal.Transaction) {
+	if len(txns) == 0 {
+		fmt.Fprintln(w, "no incomplete transactions")
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0) //nolint:mnd // padding
+	fmt.Fprintln(tw, "TXN_ID\tSTARTED\tSTATUS\tSTATEMENTS")
+	for _, txn := range txns {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\n", txn.ID, txn.Started.Format(time.RFC3339), txn.Status, len(txn.Statements))
+	}
+	tw.Flush()
+}
+
+func printJournaledStatements(w io.Writer, txn journal.Transaction) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0) //nolint:mnd // padding
+	fmt.Fprintln(tw, "SEQ\tSTATUS\tQUERY\tUNDO\tERROR")
+	for _, stmt := range txn.Statements {
+		var undo string
+		if stmt.Undo != nil {
+			undo = strings.Join(stmt.Undo.HumanReadable, "; ")
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", stmt.Seq, stmt.Status, stmt.Query, undo, stmt.Error)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,206 @@
+// Package cursor recognises the SQL cursor statements served over the
+// wire protocol: DECLARE, FETCH, MOVE and CLOSE.  Cursors scan forward
+// only; the rows they yield are held by the session's driver.
+package cursor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Kind is the kind of a cursor statement.
+type Kind int
+
+const (
+	KindDeclare Kind = iota + 1
+	KindFetch
+	KindMove
+	KindClose
+)
+
+// All, as a count, takes every remaining row; as the name of a CLOSE, it
+// closes every cursor.
+const All = -1
+
+// Statement is a parsed cursor statement.
+type Statement struct {
+	Kind Kind
+	// Name is the cursor's name, folded to lower case unless quoted.  A
+	// CLOSE ALL has the empty name.
+	Name string
+	// Count is the number of rows a FETCH or MOVE takes, All for every
+	// remaining row.
+	Count int
+	// Query is the query of a DECLARE.
+	Query string
+}
+
+// Parse recognises a cursor statement, reporting false for any other
+// statement.  Directions other than forward ones are an error.
+func Parse(stmt string) (Statement, bool, error) {
+	s := &scanner{src: strings.TrimSuffix(strings.TrimSpace(stmt), ";")}
+	keyword := strings.ToUpper(s.word())
+	switch keyword {
+	case "DECLARE":
+		return s.declare()
+	case "FETCH":
+		return s.fetch(KindFetch)
+	case "MOVE":
+		return s.fetch(KindMove)
+	case "CLOSE":
+		return s.closeCursor()
+	default:
+		return Statement{}, false, nil
+	}
+}
+
+func (s *scanner) declare() (Statement, bool, error) {
+	name, err := s.name()
+	if err != nil {
+		return Statement{}, true, err
+	}
+	for word := strings.ToUpper(s.word()); word != "CURSOR"; word = strings.ToUpper(s.word()) {
+		switch word {
+		case "BINARY", "ASENSITIVE", "INSENSITIVE", "SCROLL", "NO":
+		case "":
+			return Statement{}, true, fmt.Errorf("syntax error in DECLARE: expected CURSOR")
+		default:
+			// DECLARE is also a keyword of procedural languages; only
+			// DECLARE name ... CURSOR is ours.
+			return Statement{}, false, nil
+		}
+	}
+	word := strings.ToUpper(s.word())
+	if word == "WITH" || word == "WITHOUT" {
+		if !strings.EqualFold(s.word(), "HOLD") {
+			return Statement{}, true, fmt.Errorf("syntax error in DECLARE: expected HOLD")
+		}
+		word = strings.ToUpper(s.word())
+	}
+	if word != "FOR" {
+		return Statement{}, true, fmt.Errorf("syntax error in DECLARE: expected FOR")
+	}
+	query := strings.TrimSpace(s.rest())
+	if query == "" {
+		return Statement{}, true, fmt.Errorf("syntax error in DECLARE: expected a query")
+	}
+	return Statement{Kind: KindDeclare, Name: name, Query: query}, true, nil
+}
+
+func (s *scanner) fetch(kind Kind) (Statement, bool, error) {
+	count := 1
+	mark := s.pos
+	switch word := strings.ToUpper(s.word()); word {
+	case "NEXT":
+	case "ALL":
+		count = All
+	case "FORWARD":
+		mark = s.pos
+		switch next := strings.ToUpper(s.word()); {
+		case next == "ALL":
+			count = All
+		case isCount(next):
+			count, _ = strconv.Atoi(next)
+		default:
+			s.pos = mark
+		}
+	case "PRIOR", "FIRST", "LAST", "ABSOLUTE", "RELATIVE", "BACKWARD":
+		return Statement{}, true, fmt.Errorf("cursor can only scan forward")
+	default:
+		if isCount(word) {
+			count, _ = strconv.Atoi(word)
+		} else if strings.HasPrefix(word, "-") {
+			return Statement{}, true, fmt.Errorf("cursor can only scan forward")
+		} else {
+			s.pos = mark
+		}
+	}
+	mark = s.pos
+	if word := strings.ToUpper(s.word()); word != "FROM" && word != "IN" {
+		s.pos = mark
+	}
+	name, err := s.name()
+	if err != nil {
+		return Statement{}, true, err
+	}
+	if rest := strings.TrimSpace(s.rest()); rest != "" {
+		return Statement{}, true, fmt.Errorf("syntax error at or near \"%s\"", rest)
+	}
+	return Statement{Kind: kind, Name: name, Count: count}, true, nil
+}
+
+func (s *scanner) closeCursor() (Statement, bool, error) {
+	mark := s.pos
+	if strings.EqualFold(s.word(), "ALL") && strings.TrimSpace(s.rest()) == "" {
+		return Statement{Kind: KindClose}, true, nil
+	}
+	s.pos = mark
+	name, err := s.name()
+	if err != nil {
+		return Statement{}, true, err
+	}
+	if rest := strings.TrimSpace(s.rest()); rest != "" {
+		return Statement{}, true, fmt.Errorf("syntax error at or near \"%s\"", rest)
+	}
+	return Statement{Kind: KindClose, Name: name}, true, nil
+}
+
+func isCount(word string) bool {
+	n, err := strconv.Atoi(word)
+	return err == nil && n >= 0
+}
+
+// scanner reads the words of a statement in turn.
+type scanner struct {
+	src string
+	pos int
+}
+
+func (s *scanner) skipSpace() {
+	for s.pos < len(s.src) && unicode.IsSpace(rune(s.src[s.pos])) {
+		s.pos++
+	}
+}
+
+// word returns the next unquoted word, empty at the end.
+func (s *scanner) word() string {
+	s.skipSpace()
+	start := s.pos
+	for s.pos < len(s.src) && !unicode.IsSpace(rune(s.src[s.pos])) && s.src[s.pos] != ';' {
+		s.pos++
+	}
+	return s.src[start:s.pos]
+}
+
+// name returns the next identifier, unquoted, else folded to lower case.
+func (s *scanner) name() (string, error) {
+	s.skipSpace()
+	if s.pos < len(s.src) && s.src[s.pos] == '"' {
+		var b strings.Builder
+		for i := s.pos + 1; i < len(s.src); i++ {
+			if s.src[i] != '"' {
+				b.WriteByte(s.src[i])
+				continue
+			}
+			if i+1 < len(s.src) && s.src[i+1] == '"' {
+				b.WriteByte('"')
+				i++
+				continue
+			}
+			s.pos = i + 1
+			return b.String(), nil
+		}
+		return "", fmt.Errorf("unterminated quoted identifier")
+	}
+	word := s.word()
+	if word == "" {
+		return "", fmt.Errorf("syntax error: expected a cursor name")
+	}
+	return strings.ToLower(word), nil
+}
+
+func (s *scanner) rest() string {
+	return s.src[s.pos:]
+}
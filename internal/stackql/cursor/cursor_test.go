@@ -0,0 +1,63 @@
+package cursor_test
+
+import (
+	"testing"
+
+	"github.com/stackql/stackql/internal/stackql/cursor"
+)
+
+func TestParse(t *testing.T) {
+	for stmt, expected := range map[string]cursor.Statement{
+		"DECLARE c CURSOR FOR SELECT 1": {
+			Kind: cursor.KindDeclare, Name: "c", Query: "SELECT 1",
+		},
+		`declare "Big One" binary no scroll cursor with hold for select * from t;`: {
+			Kind: cursor.KindDeclare, Name: "Big One", Query: "select * from t",
+		},
+		"FETCH c":                  {Kind: cursor.KindFetch, Name: "c", Count: 1},
+		"FETCH NEXT FROM c":        {Kind: cursor.KindFetch, Name: "c", Count: 1},
+		"fetch 100 from C":         {Kind: cursor.KindFetch, Name: "c", Count: 100},
+		"FETCH FORWARD 5 IN c":     {Kind: cursor.KindFetch, Name: "c", Count: 5},
+		"FETCH FORWARD c":          {Kind: cursor.KindFetch, Name: "c", Count: 1},
+		"FETCH ALL c;":             {Kind: cursor.KindFetch, Name: "c", Count: cursor.All},
+		"FETCH FORWARD ALL FROM c": {Kind: cursor.KindFetch, Name: "c", Count: cursor.All},
+		"MOVE 10 IN c":             {Kind: cursor.KindMove, Name: "c", Count: 10},
+		"CLOSE c":                  {Kind: cursor.KindClose, Name: "c"},
+		"CLOSE ALL":                {Kind: cursor.KindClose},
+	} {
+		parsed, ok, err := cursor.Parse(stmt)
+		if err != nil || !ok || parsed != expected {
+			t.Errorf("%s: expected %+v, received %+v %v %v", stmt, expected, parsed, ok, err)
+		}
+	}
+}
+
+func TestParse_NotCursorStatements(t *testing.T) {
+	for _, stmt := range []string{
+		"SELECT 1",
+		"SHOW PROVIDERS",
+		"DECLARE x INTEGER",
+		"",
+	} {
+		if _, ok, err := cursor.Parse(stmt); ok || err != nil {
+			t.Errorf("%s: expected no cursor statement, received %v %v", stmt, ok, err)
+		}
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, stmt := range []string{
+		"FETCH PRIOR FROM c",
+		"FETCH BACKWARD 2 c",
+		"FETCH -1 c",
+		"MOVE ABSOLUTE 3 c",
+		"DECLARE c CURSOR",
+		"DECLARE c CURSOR WITH FOR SELECT 1",
+		"FETCH 2 FROM c d",
+		`CLOSE "c`,
+	} {
+		if _, ok, err := cursor.Parse(stmt); !ok || err == nil {
+			t.Errorf("%s: expected an error", stmt)
+		}
+	}
+}
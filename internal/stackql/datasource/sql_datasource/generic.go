@@ -4,7 +4,9 @@ import (
 	"database/sql"
 	"fmt"
 
+	_ "github.com/go-sql-driver/mysql"     //nolint:revive,nolintlint // this is a DB driver pattern
 	_ "github.com/jackc/pgx/v5/stdlib"     //nolint:revive,nolintlint // this is a DB driver pattern
+	_ "github.com/mattn/go-sqlite3"        //nolint:revive,nolintlint // this is a DB driver pattern
 	_ "github.com/snowflakedb/gosnowflake" //nolint:revive,nolintlint // this is a DB driver pattern
 
 	"github.com/stackql/any-sdk/pkg/constants"
@@ -71,18 +73,3 @@ func (ds *genericSQLDataSource) Begin() (*sql.Tx, error) {
 func (ds *genericSQLDataSource) GetTableMetadata(args ...string) (sqltable.SQLTable, error) {
 	return nil, fmt.Errorf("could not obtain sql data source table metadata for args = '%v'", args)
 }
-
-// func (ds *genericSQLDataSource) getPostgresTableMetadata(schemaName, tableName string) (sqltable.SQLTable, error) {
-// 	queryTemplate := `
-// 	SELECT
-// 		column_name,
-// 		data_type
-// 	FROM
-// 		information_schema.columns
-// 	WHERE
-// 		table_name = ?
-// 		AND
-// 		table_schema = ?;
-// 	`
-// 	return nil, fmt.Errorf("could not obtain sql data source table metadata for table = '%s'", tableName)
-// }
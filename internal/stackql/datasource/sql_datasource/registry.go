@@ -0,0 +1,83 @@
+package sql_datasource //nolint:stylecheck,revive // package name is helpful
+
+import (
+	"strings"
+
+	"github.com/stackql/any-sdk/pkg/dto"
+	"github.com/stackql/stackql/internal/stackql/datasource/sqltable"
+	"github.com/stackql/stackql/internal/stackql/typing"
+
+	"github.com/stackql/stackql/pkg/sqldatasource"
+)
+
+var (
+	_ sqldatasource.DataSource = &exportedDataSource{}
+	_ SQLDataSource            = &importedDataSource{}
+)
+
+func exportOpenFunc(genericSQL GenericSQLDataSourceFunc) sqldatasource.OpenFunc {
+	return func(authCtx *dto.AuthCtx, sqlDriverName string, dbName string) (sqldatasource.DataSource, error) {
+		ds, err := genericSQL(authCtx, sqlDriverName, dbName)
+		if err != nil {
+			return nil, err
+		}
+		return exportDataSource(ds), nil
+	}
+}
+
+// exportedDataSource presents a data source of this package
+// through the public interface.
+type exportedDataSource struct {
+	SQLDataSource
+}
+
+func exportDataSource(ds SQLDataSource) sqldatasource.DataSource {
+	if ds == nil {
+		return nil
+	}
+	if imported, isImported := ds.(*importedDataSource); isImported {
+		return imported.DataSource
+	}
+	return &exportedDataSource{SQLDataSource: ds}
+}
+
+func (ds *exportedDataSource) GetTableColumns(args ...string) ([]sqldatasource.Column, error) {
+	tbl, err := ds.GetTableMetadata(args...)
+	if err != nil {
+		return nil, err
+	}
+	columns := tbl.GetColumns()
+	rv := make([]sqldatasource.Column, 0, len(columns))
+	for _, col := range columns {
+		rv = append(rv, sqldatasource.Column{Name: col.GetName(), Type: col.GetType()})
+	}
+	return rv, nil
+}
+
+// importedDataSource presents a data source of a registered
+// driver through the interface of this package.
+type importedDataSource struct {
+	sqldatasource.DataSource
+}
+
+func importDataSource(ds sqldatasource.DataSource) SQLDataSource {
+	if ds == nil {
+		return nil
+	}
+	if exported, isExported := ds.(*exportedDataSource); isExported {
+		return exported.SQLDataSource
+	}
+	return &importedDataSource{DataSource: ds}
+}
+
+func (ds *importedDataSource) GetTableMetadata(args ...string) (sqltable.SQLTable, error) {
+	columns, err := ds.GetTableColumns(args...)
+	if err != nil {
+		return nil, err
+	}
+	relationalColumns := make([]typing.RelationalColumn, 0, len(columns))
+	for _, col := range columns {
+		relationalColumns = append(relationalColumns, typing.NewRelationalColumn(col.Name, strings.ToLower(col.Type)))
+	}
+	return sqltable.NewStandardSQLTable(relationalColumns)
+}
@@ -0,0 +1,129 @@
+package sql_datasource //nolint:testpackage,stylecheck // test package
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stackql/any-sdk/pkg/dto"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stackql/stackql/pkg/sqldatasource"
+)
+
+// embeddedDataSource stands for the data source of a driver
+// registered by an embedder, through the public registry.
+type embeddedDataSource struct {
+	sqldatasource.DataSource
+}
+
+func (ds *embeddedDataSource) GetTableColumns(...string) ([]sqldatasource.Column, error) {
+	return []sqldatasource.Column{{Name: "hostname", Type: "VARCHAR"}}, nil
+}
+
+func TestRegisterDriver(t *testing.T) {
+	t.Run("publicly registered driver is selected by auth type", func(t *testing.T) {
+		called := false
+		err := sqldatasource.RegisterDriver("test_registered",
+			func(_ *dto.AuthCtx, open sqldatasource.OpenFunc) (sqldatasource.DataSource, error) {
+				called = true
+				assert.NotNil(t, open)
+				return &embeddedDataSource{}, nil
+			})
+		assert.NoError(t, err)
+		ds, err := NewDataSource(&dto.AuthCtx{Type: AuthType("test_registered")}, nil)
+		assert.NoError(t, err)
+		assert.True(t, called)
+		tbl, err := ds.GetTableMetadata("hosts")
+		assert.NoError(t, err)
+		assert.Len(t, tbl.GetColumns(), 1)
+		assert.Equal(t, "varchar", tbl.GetColumns()[0].GetType())
+	})
+
+	t.Run("builtin drivers are publicly registered", func(t *testing.T) {
+		err := sqldatasource.RegisterDriver("postgres",
+			func(*dto.AuthCtx, sqldatasource.OpenFunc) (sqldatasource.DataSource, error) {
+				return nil, nil
+			})
+		assert.Error(t, err)
+		assert.Subset(t, sqldatasource.GetRegisteredDrivers(), []string{"mysql", "postgres", "snowflake", "sqlite"})
+	})
+
+	t.Run("builtin drivers select database/sql drivers", func(t *testing.T) {
+		for name, expected := range map[string]string{
+			"snowflake": "snowflake",
+			"postgres":  "pgx",
+			"mysql":     "mysql",
+			"sqlite":    "sqlite3",
+		} {
+			var driverName string
+			_, err := NewDataSource(
+				&dto.AuthCtx{Type: AuthType(name)},
+				func(_ *dto.AuthCtx, d string, _ string) (SQLDataSource, error) {
+					driverName = d
+					return &SQLDataSourceMock{}, nil
+				},
+			)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, driverName, name)
+		}
+	})
+}
+
+func newMockedGenericSQL(db *sql.DB) GenericSQLDataSourceFunc {
+	return func(_ *dto.AuthCtx, _ string, dbName string) (SQLDataSource, error) {
+		return &genericSQLDataSource{db: db, dbName: dbName}, nil
+	}
+}
+
+func TestMySQLGetTableMetadata(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	ds, err := NewDataSource(&dto.AuthCtx{Type: AuthType("mysql")}, newMockedGenericSQL(db))
+	assert.NoError(t, err)
+
+	mock.ExpectQuery("FROM\\s+information_schema.columns").
+		WithArgs("cmdb", "hosts").
+		WillReturnRows(sqlmock.NewRows([]string{"column_name", "data_type"}).
+			AddRow("hostname", "VARCHAR").
+			AddRow("rack", "int"))
+	tbl, err := ds.GetTableMetadata("cmdb", "hosts")
+	assert.NoError(t, err)
+	columns := tbl.GetColumns()
+	assert.Len(t, columns, 2)
+	assert.Equal(t, "hostname", columns[0].GetName())
+	assert.Equal(t, "varchar", columns[0].GetType())
+
+	mock.ExpectQuery("FROM\\s+information_schema.columns").
+		WithArgs("", "absent").
+		WillReturnRows(sqlmock.NewRows([]string{"column_name", "data_type"}))
+	_, err = ds.GetTableMetadata("absent")
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSQLiteGetTableMetadata(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	ds, err := NewDataSource(&dto.AuthCtx{Type: AuthType("sqlite")}, newMockedGenericSQL(db))
+	assert.NoError(t, err)
+
+	mock.ExpectQuery("FROM\\s+pragma_table_info").
+		WithArgs("hosts", "main").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "type"}).
+			AddRow("hostname", "TEXT"))
+	tbl, err := ds.GetTableMetadata("hosts")
+	assert.NoError(t, err)
+	assert.Len(t, tbl.GetColumns(), 1)
+	assert.Equal(t, "text", tbl.GetColumns()[0].GetType())
+
+	_, err = ds.GetTableMetadata("a", "b", "c")
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -3,10 +3,13 @@ package sql_datasource //nolint:stylecheck,revive // package name is helpful
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 
 	"github.com/stackql/any-sdk/pkg/constants"
 	"github.com/stackql/any-sdk/pkg/dto"
 	"github.com/stackql/stackql/internal/stackql/datasource/sqltable"
+
+	"github.com/stackql/stackql/pkg/sqldatasource"
 )
 
 type SQLDataSource interface {
@@ -14,32 +17,47 @@ type SQLDataSource interface {
 	Exec(string, ...interface{}) (sql.Result, error)
 	Query(string, ...interface{}) (*sql.Rows, error)
 	QueryRow(string, ...any) *sql.Row
+	// GetTableMetadata describes the table
+	// identified by args: `{schema}, {table}`
+	// or simply `{table}`.
 	GetTableMetadata(...string) (sqltable.SQLTable, error)
 	GetSchemaType() string
 	GetDBName() string
 }
 
-type genericSQLDataSourceFunc func(*dto.AuthCtx, string, string) (SQLDataSource, error)
+// GenericSQLDataSourceFunc opens a data source
+// of the supplied database/sql driver name and
+// database name, eg `pgx` and `postgres`.
+type GenericSQLDataSourceFunc func(*dto.AuthCtx, string, string) (SQLDataSource, error)
 
-func NewDataSource(authCtx *dto.AuthCtx, genericSQL genericSQLDataSourceFunc) (SQLDataSource, error) {
-	if authCtx == nil {
-		return nil, fmt.Errorf("cannot create sql data source from nil auth context")
-	}
-	if authCtx.Type == fmt.Sprintf(
+// AuthType returns the auth type which selects
+// the data source driver registered as driverName,
+// eg `sql_data_source::postgres`.
+func AuthType(driverName string) string {
+	return fmt.Sprintf(
 		"%s%s%s",
 		constants.AuthTypeSQLDataSourcePrefix,
 		constants.AuthTypeDelimiter,
-		"snowflake",
-	) {
-		return genericSQL(authCtx, "snowflake", "snowflake")
+		driverName,
+	)
+}
+
+func NewDataSource(authCtx *dto.AuthCtx, genericSQL GenericSQLDataSourceFunc) (SQLDataSource, error) {
+	if authCtx == nil {
+		return nil, fmt.Errorf("cannot create sql data source from nil auth context")
 	}
-	if authCtx.Type == fmt.Sprintf(
-		"%s%s%s",
-		constants.AuthTypeSQLDataSourcePrefix,
-		constants.AuthTypeDelimiter,
-		"postgres",
-	) {
-		return genericSQL(authCtx, "pgx", "postgres")
+	driverName, isSQLDataSource := strings.CutPrefix(
+		authCtx.Type,
+		constants.AuthTypeSQLDataSourcePrefix+constants.AuthTypeDelimiter,
+	)
+	if isSQLDataSource {
+		if driver, ok := sqldatasource.GetDriver(driverName); ok {
+			ds, err := driver(authCtx, exportOpenFunc(genericSQL))
+			if err != nil {
+				return nil, err
+			}
+			return importDataSource(ds), nil
+		}
 	}
 	return nil, fmt.Errorf("sql data source of type '%s' not supported", authCtx.Type)
 }
@@ -67,6 +67,8 @@ type standardDependencyPlanner struct {
 	//
 	dataflowToEdges map[int][]int
 	nodeIDIdxMap    map[int64]int
+	//
+	currentComponent int
 }
 
 func NewStandardDependencyPlanner(
@@ -176,8 +178,9 @@ func (dp *standardDependencyPlanner) Plan() error {
 	}
 	// TODO: lift this restriction once all traversal algorithms are adequate
 	weaklyConnectedComponentCount := 0
-	for _, u := range units {
+	for unitIdx, u := range units {
 		unit := u
+		dp.currentComponent = unitIdx
 		switch unit := unit.(type) {
 		case dataflow.Vertex:
 			inDegree := dp.dataflowCollection.InDegree(unit)
@@ -647,6 +650,8 @@ func (dp *standardDependencyPlanner) orchestrate(
 			annotationCtx.GetTableMeta(),
 		)
 		bldrInput.SetIsAwait(false) // returning hardcoded to false for now
+		bldrInput.SetDataflowComponent(dp.currentComponent)
+		bldrInput.SetIsDataflowDependent(annotationCtx.IsDynamic())
 		setAcquirePushdownPlan(bldrInput, annotationCtx, dp.sqlStatement)
 		builder = primitivebuilder.NewSingleSelectAcquire(
 			dp.primitiveComposer.GetGraphHolder(),
@@ -0,0 +1,144 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/stackql/psql-wire/pkg/sqldata"
+	"github.com/stackql/stackql/internal/stackql/cursor"
+)
+
+// resultCursor yields the rows of a result stream a bounded number at a
+// time.  It backs both suspended portals and named cursors.  The streams
+// of the driver are materialised, the query having run to completion,
+// so the cursor bounds the rows sent per fetch, not those held.
+type resultCursor struct {
+	stream  sqldata.ISQLResultStream
+	columns []sqldata.ISQLColumn
+	pending []sqldata.ISQLRow
+	isEOF   bool
+}
+
+func newResultCursor(stream sqldata.ISQLResultStream) *resultCursor {
+	return &resultCursor{stream: stream}
+}
+
+// fill reads the stream until n rows are pending, or it ends.
+func (rc *resultCursor) fill(n int) error {
+	for !rc.isEOF && (n == cursor.All || len(rc.pending) < n) {
+		res, err := rc.stream.Read()
+		if res != nil {
+			if rc.columns == nil {
+				rc.columns = res.GetColumns()
+			}
+			rc.pending = append(rc.pending, res.GetRows()...)
+		}
+		if errors.Is(err, io.EOF) {
+			rc.isEOF = true
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fetch returns up to n rows, every remaining one for cursor.All.  A row
+// beyond those is read ahead, so that isExhausted is exact.
+func (rc *resultCursor) fetch(n int) (sqldata.ISQLResult, error) {
+	want := n
+	if n != cursor.All {
+		want = n + 1
+	}
+	if err := rc.fill(want); err != nil {
+		return nil, err
+	}
+	take := len(rc.pending)
+	if n != cursor.All && n < take {
+		take = n
+	}
+	rows := rc.pending[:take:take]
+	rc.pending = rc.pending[take:]
+	return sqldata.NewSQLResult(rc.columns, 0, 0, rows), nil
+}
+
+// move discards up to n rows, returning how many it discarded.
+func (rc *resultCursor) move(n int) (int, error) {
+	res, err := rc.fetch(n)
+	if err != nil {
+		return 0, err
+	}
+	return len(res.GetRows()), nil
+}
+
+func (rc *resultCursor) isExhausted() bool {
+	return rc.isEOF && len(rc.pending) == 0
+}
+
+func (rc *resultCursor) close() error {
+	rc.pending = nil
+	rc.isEOF = true
+	return rc.stream.Close()
+}
+
+// handleCursorStatement serves DECLARE, FETCH, MOVE and CLOSE against the
+// session's named cursors.
+func (dr *basicStackQLDriver) handleCursorStatement(
+	ctx context.Context, stmt cursor.Statement,
+) (sqldata.ISQLResultStream, error) {
+	switch stmt.Kind {
+	case cursor.KindDeclare:
+		if _, exists := dr.cursors[stmt.Name]; exists {
+			return nil, fmt.Errorf(`cursor "%s" already exists`, stmt.Name)
+		}
+		stream, err := dr.HandleSimpleQuery(ctx, stmt.Query)
+		if err != nil {
+			return nil, err
+		}
+		dr.cursors[stmt.Name] = newResultCursor(stream)
+		return emptyResultStream(), nil
+	case cursor.KindFetch, cursor.KindMove:
+		rc, exists := dr.cursors[stmt.Name]
+		if !exists {
+			return nil, fmt.Errorf(`cursor "%s" does not exist`, stmt.Name)
+		}
+		if stmt.Kind == cursor.KindMove {
+			if _, err := rc.move(stmt.Count); err != nil {
+				return nil, err
+			}
+			return emptyResultStream(), nil
+		}
+		res, err := rc.fetch(stmt.Count)
+		if err != nil {
+			return nil, err
+		}
+		return sqldata.NewSimpleSQLResultStream(res), nil
+	case cursor.KindClose:
+		if stmt.Name == "" {
+			dr.closeCursors()
+			return emptyResultStream(), nil
+		}
+		rc, exists := dr.cursors[stmt.Name]
+		if !exists {
+			return nil, fmt.Errorf(`cursor "%s" does not exist`, stmt.Name)
+		}
+		delete(dr.cursors, stmt.Name)
+		return emptyResultStream(), rc.close()
+	default:
+		return nil, fmt.Errorf("unsupported cursor statement")
+	}
+}
+
+func (dr *basicStackQLDriver) closeCursors() {
+	for name, rc := range dr.cursors {
+		rc.close() //nolint:errcheck // closing regardless
+		delete(dr.cursors, name)
+	}
+}
+
+func emptyResultStream() sqldata.ISQLResultStream {
+	return sqldata.NewSimpleSQLResultStream(sqldata.NewSQLResult(nil, 0, 0, nil))
+}
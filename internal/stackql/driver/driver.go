@@ -4,18 +4,26 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/lib/pq/oid"
+	"github.com/stackql/any-sdk/pkg/dto"
 	"github.com/stackql/any-sdk/pkg/logging"
 	"github.com/stackql/any-sdk/public/sqlengine"
 	"github.com/stackql/psql-wire/pkg/sqldata"
 	"github.com/stackql/stackql/internal/stackql/acid/tsm_physio"
+	"github.com/stackql/stackql/internal/stackql/bindparam"
+	"github.com/stackql/stackql/internal/stackql/cursor"
 	"github.com/stackql/stackql/internal/stackql/handler"
 	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
 	"github.com/stackql/stackql/internal/stackql/paramdecoder"
+	"github.com/stackql/stackql/internal/stackql/pgauth"
+	"github.com/stackql/stackql/internal/stackql/querycancel"
 	"github.com/stackql/stackql/internal/stackql/queryshape"
 	"github.com/stackql/stackql/internal/stackql/responsehandler"
 	"github.com/stackql/stackql/internal/stackql/util"
 	"github.com/stackql/stackql/pkg/txncounter"
+	"gopkg.in/yaml.v2"
 
 	sqlbackend "github.com/stackql/psql-wire/pkg/sqlbackend"
 )
@@ -34,6 +42,7 @@ type StackQLDriverFactory interface {
 type basicStackQLDriverFactory struct {
 	isCaptureDebug bool
 	handlerCtx     handler.HandlerContext
+	mode           string
 }
 
 func (sdf *basicStackQLDriverFactory) NewSQLBackend() (sqlbackend.ISQLBackend, error) {
@@ -73,6 +82,8 @@ func (sdf *basicStackQLDriverFactory) newSQLDriver() (StackQLDriver, error) {
 		paramDecoder:    paramdecoder.NewDecoder(),
 		stmtCache:       make(map[string]*stmtMeta),
 		portalCache:     make(map[string]*portalMeta),
+		cursors:         make(map[string]*resultCursor),
+		mode:            sdf.mode,
 	}
 	return rv, nil
 }
@@ -84,6 +95,27 @@ func NewStackQLDriverFactory(handlerCtx handler.HandlerContext, isCaptureDebug b
 	}
 }
 
+// NewStackQLDriverFactoryForUser returns a factory for the sessions of an
+// authenticated wire protocol user, holding the user's auth contexts, where
+// the users file sets them, and mode.
+func NewStackQLDriverFactoryForUser(
+	handlerCtx handler.HandlerContext, isCaptureDebug bool, user pgauth.User,
+) (sqlbackend.SQLBackendFactory, error) {
+	userCtx := handlerCtx.Clone()
+	if rawAuth := user.GetRawAuth(); rawAuth != "" {
+		ac := make(map[string]*dto.AuthCtx)
+		if err := yaml.Unmarshal([]byte(rawAuth), ac); err != nil {
+			return nil, fmt.Errorf("error unmarshalling auth for user '%s': %w", user.GetName(), err)
+		}
+		userCtx.SetAuthContexts(ac)
+	}
+	return &basicStackQLDriverFactory{
+		isCaptureDebug: isCaptureDebug,
+		handlerCtx:     userCtx,
+		mode:           user.GetMode(),
+	}, nil
+}
+
 func getTxnCounterManager(sqlEngine sqlengine.SQLEngine) (txncounter.Manager, error) {
 	genID, err := sqlEngine.GetCurrentGenerationID()
 	if err != nil {
@@ -140,6 +172,10 @@ type stmtMeta struct {
 
 type portalMeta struct {
 	stmtName string
+	// params are the typed values bound to the statement's placeholders.
+	params []any
+	// cursor holds the rows of a suspended portal, nil otherwise.
+	cursor *resultCursor
 }
 
 type basicStackQLDriver struct {
@@ -150,6 +186,15 @@ type basicStackQLDriver struct {
 	paramDecoder    paramdecoder.Decoder
 	stmtCache       map[string]*stmtMeta
 	portalCache     map[string]*portalMeta
+	// cursors are the session's named cursors, from DECLARE.
+	cursors map[string]*resultCursor
+	// mode is the mode of the authenticated user, empty if unauthenticated.
+	mode string
+	// cancelProcessID is the process ID of the session's cancel key, zero
+	// until the session's first tagged query.
+	cancelProcessID uint32
+	// statementTimeout is the session's statement_timeout, zero if none.
+	statementTimeout time.Duration
 }
 
 func (dr *basicStackQLDriver) GetDebugStr() string {
@@ -162,13 +207,56 @@ func (dr *basicStackQLDriver) GetDebugStr() string {
 func (dr *basicStackQLDriver) CloneSQLBackend() sqlbackend.ISQLBackend {
 	return &basicStackQLDriver{
 		handlerCtx: dr.handlerCtx.Clone(),
+		mode:       dr.mode,
+		cursors:    make(map[string]*resultCursor),
 	}
 }
 
 //nolint:revive // TODO: review
 func (dr *basicStackQLDriver) HandleSimpleQuery(ctx context.Context, query string) (sqldata.ISQLResultStream, error) {
+	return dr.runQuery(ctx, dr.untag(query), nil)
+}
+
+// runQuery runs query with params bound to its placeholders, if any.
+func (dr *basicStackQLDriver) runQuery(
+	ctx context.Context, query string, params []any,
+) (sqldata.ISQLResultStream, error) {
+	if stmt, isCursorStmt, cursorErr := cursor.Parse(query); isCursorStmt {
+		if cursorErr != nil {
+			return nil, cursorErr
+		}
+		return dr.handleCursorStatement(ctx, stmt)
+	}
+	statements, _ := dr.SplitCompoundQuery(query)
+	if dr.mode != "" {
+		if modeErr := pgauth.CheckMode(dr.mode, statements); modeErr != nil {
+			return nil, modeErr
+		}
+	}
+	for _, stmt := range statements {
+		timeout, isSetting, timeoutErr := querycancel.StatementTimeoutSetting(stmt)
+		if timeoutErr != nil {
+			return nil, timeoutErr
+		}
+		if isSetting {
+			dr.statementTimeout = timeout
+		}
+	}
+	queryCtx, release := dr.queryContext(ctx)
+	defer release()
+	dr.handlerCtx.SetContext(queryCtx)
+	defer dr.handlerCtx.SetContext(nil)
+	dr.handlerCtx.SetBindParams(params)
+	defer dr.handlerCtx.SetBindParams(nil)
 	dr.handlerCtx.SetRawQuery(query)
 	res, ok := dr.processQueryOrQueries(dr.handlerCtx)
+	if queryCtx.Err() != nil {
+		// Reclaim whatever the aborted statement left behind.
+		if gcErr := dr.handlerCtx.GetGarbageCollector().Collect(); gcErr != nil {
+			logging.GetLogger().Warnf("garbage collection after cancellation failed: %v", gcErr)
+		}
+		return nil, context.Cause(queryCtx)
+	}
 	if !ok {
 		return nil, fmt.Errorf("no SQLresults available")
 	}
@@ -216,12 +304,76 @@ func NewStackQLDriver(handlerCtx handler.HandlerContext) (StackQLDriver, error)
 		paramDecoder:    paramdecoder.NewDecoder(),
 		stmtCache:       make(map[string]*stmtMeta),
 		portalCache:     make(map[string]*portalMeta),
+		cursors:         make(map[string]*resultCursor),
 	}, nil
 }
 
+// untag strips the cancel tag of the front end from query, noting the
+// session's process ID.
+func (dr *basicStackQLDriver) untag(query string) string {
+	untagged, processID, isTagged := querycancel.Untag(query)
+	if !isTagged {
+		return query
+	}
+	if processID != dr.cancelProcessID {
+		querycancel.DefaultRegistry().OnRelease(processID, dr.terminate)
+	}
+	dr.cancelProcessID = processID
+	return untagged
+}
+
+// terminate ends the session once its cancel key is released, as its
+// client disconnects: cursors are closed, any open transaction is rolled
+// back and the session's locks are released.
+func (dr *basicStackQLDriver) terminate() {
+	for portalName := range dr.portalCache {
+		dr.closePortalCursor(portalName)
+	}
+	dr.closeCursors()
+	if dr.txnOrchestrator == nil {
+		return
+	}
+	for _, output := range dr.txnOrchestrator.Terminate(dr.handlerCtx) {
+		if output.GetError() != nil {
+			logging.GetLogger().Warnf("rollback upon session end failed: %v", output.GetError())
+		}
+	}
+}
+
+// queryContext derives the context of one query: cancelled by the
+// session's cancel key and bounded by its statement_timeout.
+func (dr *basicStackQLDriver) queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	releaseCancel := func() {}
+	if dr.cancelProcessID != 0 {
+		ctx, releaseCancel = querycancel.DefaultRegistry().Bind(ctx, dr.cancelProcessID)
+	}
+	if dr.statementTimeout <= 0 {
+		return ctx, releaseCancel
+	}
+	ctx, releaseTimeout := context.WithTimeoutCause(ctx, dr.statementTimeout, querycancel.ErrStatementTimeout)
+	return ctx, func() {
+		releaseTimeout()
+		releaseCancel()
+	}
+}
+
 func (dr *basicStackQLDriver) HandleParse(
 	ctx context.Context, stmtName string, query string, paramOIDs []uint32,
 ) ([]uint32, error) {
+	query = dr.untag(query)
+	// Parameters the client left untyped are described as text, which
+	// Bind then types as the statement's OIDs allow.
+	if n := bindparam.Count(query); n > len(paramOIDs) {
+		padded := make([]uint32, n)
+		copy(padded, paramOIDs)
+		for i := len(paramOIDs); i < n; i++ {
+			padded[i] = uint32(oid.T_text)
+		}
+		paramOIDs = padded
+	}
 	// Infer result columns at parse time and cache for Describe/Execute.
 	columns := dr.shapeInferrer.InferResultColumns(query)
 	dr.stmtCache[stmtName] = &stmtMeta{
@@ -236,12 +388,34 @@ func (dr *basicStackQLDriver) HandleBind(
 	ctx context.Context, portalName string, stmtName string,
 	paramFormats []int16, paramValues [][]byte, resultFormats []int16,
 ) error {
+	dr.closePortalCursor(portalName)
+	params, err := dr.decodeParams(stmtName, paramFormats, paramValues)
+	if err != nil {
+		return err
+	}
 	dr.portalCache[portalName] = &portalMeta{
 		stmtName: stmtName,
+		params:   params,
 	}
 	return nil
 }
 
+// decodeParams types the values bound to a statement, as its parameter
+// OIDs direct.
+func (dr *basicStackQLDriver) decodeParams(
+	stmtName string, paramFormats []int16, paramValues [][]byte,
+) ([]any, error) {
+	var paramOIDs []uint32
+	if cached, stmtFound := dr.stmtCache[stmtName]; stmtFound {
+		paramOIDs = cached.paramOIDs
+	}
+	params, err := dr.paramDecoder.DecodeTypedParams(paramOIDs, paramFormats, paramValues)
+	if err != nil {
+		return nil, fmt.Errorf("parameter decoding error: %w", err)
+	}
+	return params, nil
+}
+
 func (dr *basicStackQLDriver) HandleDescribeStatement(
 	ctx context.Context, stmtName string, query string, paramOIDs []uint32,
 ) ([]uint32, []sqldata.ISQLColumn, error) {
@@ -268,20 +442,53 @@ func (dr *basicStackQLDriver) HandleExecute(
 	ctx context.Context, portalName string, stmtName string, query string,
 	paramFormats []int16, paramValues [][]byte, resultFormats []int16, maxRows int32,
 ) (sqldata.ISQLResultStream, error) {
-	// Look up cached param OIDs for format-aware decoding.
-	var paramOIDs []uint32
-	if portal, portalFound := dr.portalCache[portalName]; portalFound {
-		if cached, stmtFound := dr.stmtCache[portal.stmtName]; stmtFound {
-			paramOIDs = cached.paramOIDs
+	portal, portalFound := dr.portalCache[portalName]
+	if portalFound && portal.cursor != nil {
+		return portal.resume(maxRows)
+	}
+	// The values bound at Bind travel typed into the plan, rather than
+	// pasted into the query.
+	var params []any
+	if portalFound {
+		params = portal.params
+	} else {
+		var err error
+		if params, err = dr.decodeParams(stmtName, paramFormats, paramValues); err != nil {
+			return nil, err
 		}
 	}
-	// Decode params (handles both text and binary formats).
-	decodedStrings, err := dr.paramDecoder.DecodeParams(paramOIDs, paramFormats, paramValues)
+	stream, err := dr.runQuery(ctx, dr.untag(query), params)
+	if err != nil || maxRows <= 0 || !portalFound {
+		return stream, err
+	}
+	// A row limit suspends the portal, which later executes resume.
+	portal.cursor = newResultCursor(stream)
+	return portal.resume(maxRows)
+}
+
+// resume returns up to maxRows further rows of a suspended portal, every
+// remaining row where maxRows is not positive.
+func (pm *portalMeta) resume(maxRows int32) (sqldata.ISQLResultStream, error) {
+	n := int(maxRows)
+	if n <= 0 {
+		n = cursor.All
+	}
+	res, err := pm.cursor.fetch(n)
+	if err != nil || pm.cursor.isExhausted() {
+		pm.cursor.close() //nolint:errcheck // the fetch error is the one worth reporting
+		pm.cursor = nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("parameter decoding error: %w", err)
+		return nil, err
+	}
+	return sqldata.NewSimpleSQLResultStream(res), nil
+}
+
+func (dr *basicStackQLDriver) closePortalCursor(portalName string) {
+	if portal, portalFound := dr.portalCache[portalName]; portalFound && portal.cursor != nil {
+		portal.cursor.close() //nolint:errcheck // closing regardless
+		portal.cursor = nil
 	}
-	resolved := queryshape.SubstituteDecodedParams(query, decodedStrings)
-	return dr.HandleSimpleQuery(ctx, resolved)
 }
 
 func (dr *basicStackQLDriver) HandleCloseStatement(ctx context.Context, stmtName string) error {
@@ -290,6 +497,7 @@ func (dr *basicStackQLDriver) HandleCloseStatement(ctx context.Context, stmtName
 }
 
 func (dr *basicStackQLDriver) HandleClosePortal(ctx context.Context, portalName string) error {
+	dr.closePortalCursor(portalName)
 	delete(dr.portalCache, portalName)
 	return nil
 }
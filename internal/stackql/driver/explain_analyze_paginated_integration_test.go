@@ -0,0 +1,85 @@
+package driver_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	lrucache "github.com/stackql/stackql-parser/go/cache"
+
+	. "github.com/stackql/stackql/internal/stackql/driver"
+
+	"github.com/stackql/stackql/internal/stackql/handler"
+
+	"github.com/stackql/stackql/internal/test/stackqltestutil"
+	"github.com/stackql/stackql/internal/test/testobjects"
+)
+
+// TestExplainAnalyzePaginatedCountsEachPageOnce analyzes a select over
+// three pages of responses: the acquiring node reports one page per HTTP
+// response, and no retries.
+func TestExplainAnalyzePaginatedCountsEachPageOnce(t *testing.T) {
+	runtimeCtx, err := stackqltestutil.GetRuntimeCtx(testobjects.GetGoogleProviderString(), "text", "TestExplainAnalyzePaginatedCountsEachPageOnce")
+	if err != nil {
+		t.Fatalf("Test failed: %v", err)
+	}
+	runtimeCtx.HTTPMaxResults = 5
+	stackqltestutil.SetupSimpleSelectGoogleComputeDisksPaginated(t)
+	inputBundle, err := stackqltestutil.BuildInputBundle(*runtimeCtx)
+	if err != nil {
+		t.Fatalf("Test failed: %v", err)
+	}
+	handlerCtx, err := handler.NewHandlerCtx(
+		"", *runtimeCtx, lrucache.NewLRUCache(int64(runtimeCtx.QueryCacheSize)),
+		inputBundle, "v0.1.1")
+	if err != nil {
+		t.Fatalf("Test failed: %v", err)
+	}
+	dr, err := NewStackQLDriver(handlerCtx)
+	if err != nil {
+		t.Fatalf("Test failed: %v", err)
+	}
+
+	stream, err := dr.HandleSimpleQuery(
+		context.Background(),
+		"EXPLAIN ANALYZE "+testobjects.SelectGoogleComputeDisksOrderCreationTmstpAsc,
+	)
+	if err != nil {
+		t.Fatalf("Test failed: %v", err)
+	}
+	var acquisitions int
+	for {
+		res, readErr := stream.Read()
+		isEOF := errors.Is(readErr, io.EOF)
+		if readErr != nil && !isEOF {
+			t.Fatalf("Test failed: %v", readErr)
+		}
+		if res != nil {
+			colz := res.GetColumns()
+			for _, row := range res.GetRows() {
+				values := make(map[string]string, len(colz))
+				for i, v := range row.GetRowDataNaive() {
+					if b, isBytes := v.([]byte); isBytes {
+						v = string(b)
+					}
+					values[colz[i].GetName()] = fmt.Sprintf("%v", v)
+				}
+				if values["http_requests"] == "0" {
+					continue
+				}
+				acquisitions++
+				if values["http_requests"] != "3" || values["pages"] != "3" || values["retries"] != "0" {
+					t.Fatalf("expected 3 requests and 3 pages without retries, got %v", values)
+				}
+			}
+		}
+		if isEOF || res == nil {
+			break
+		}
+	}
+	if acquisitions != 1 {
+		t.Fatalf("expected a single acquiring node, got %d", acquisitions)
+	}
+}
@@ -0,0 +1,136 @@
+package driver_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/stackql/psql-wire/pkg/sqlbackend"
+	lrucache "github.com/stackql/stackql-parser/go/cache"
+
+	. "github.com/stackql/stackql/internal/stackql/driver"
+
+	"github.com/stackql/stackql/internal/stackql/handler"
+	"github.com/stackql/stackql/internal/stackql/planbuilder"
+	"github.com/stackql/stackql/internal/stackql/provider"
+
+	"github.com/stackql/stackql/internal/test/stackqltestutil"
+	"github.com/stackql/stackql/internal/test/testhttpapi"
+	"github.com/stackql/stackql/internal/test/testobjects"
+)
+
+// TestPlanTemplateHitSendsReboundValue runs two queries of one shape: the
+// second, served by the template of the first, must request its own zone.
+func TestPlanTemplateHitSendsReboundValue(t *testing.T) {
+	defer func(enabled string) { planbuilder.PlanCacheEnabled = enabled }(planbuilder.PlanCacheEnabled)
+	planbuilder.PlanCacheEnabled = "true"
+	runtimeCtx, err := stackqltestutil.GetRuntimeCtx(testobjects.GetGoogleProviderString(), "text", "TestPlanTemplateHitSendsReboundValue")
+	if err != nil {
+		t.Fatalf("Test failed: %v", err)
+	}
+	runtimeCtx.QueryCacheSize = 10
+	exp := testhttpapi.NewExpectationStore(2)
+	var keys []string
+	for _, zone := range []string{"australia-southeast1-a", "australia-southeast1-b"} {
+		path := "/compute/v1/projects/testing-project/zones/" + zone + "/instances"
+		ex := testhttpapi.NewHTTPRequestExpectations(nil, nil, "GET", &url.URL{Path: path}, "compute.googleapis.com", testobjects.SimpleSelectGoogleComputeInstanceResponse, nil)
+		exp.Put("compute.googleapis.com"+path, ex)
+		keys = append(keys, "compute.googleapis.com"+path)
+	}
+	testhttpapi.StartServer(t, exp)
+	provider.DummyAuth = true
+
+	inputBundle, err := stackqltestutil.BuildInputBundle(*runtimeCtx)
+	if err != nil {
+		t.Fatalf("Test failed: %v", err)
+	}
+	handlerCtx, err := handler.NewHandlerCtx(
+		"", *runtimeCtx, lrucache.NewLRUCache(int64(runtimeCtx.QueryCacheSize)),
+		inputBundle, "v0.1.1")
+	if err != nil {
+		t.Fatalf("Test failed: %v", err)
+	}
+	dr, err := NewStackQLDriver(handlerCtx)
+	if err != nil {
+		t.Fatalf("Test failed: %v", err)
+	}
+
+	dr.ProcessQuery("select name, zone from google.compute.instances where zone = 'australia-southeast1-a' AND project = 'testing-project';")
+	dr.ProcessQuery("select name, zone from google.compute.instances where zone = 'australia-southeast1-b' AND project = 'testing-project';")
+
+	if stats := handlerCtx.GetPlanTemplateCache().Stats(); stats.Misses != 1 || stats.Hits != 1 {
+		t.Fatalf("expected the second query to be served by the template of the first, stats = %+v", stats)
+	}
+	for _, key := range keys {
+		if exp.HasKey(key) {
+			t.Errorf("expected a request to %s", key)
+		}
+	}
+}
+
+// TestPreparedStatementExecutesShareOnePlan executes a prepared statement
+// twice with different bindings: it is planned once, and each execution
+// requests the zone bound to it.
+func TestPreparedStatementExecutesShareOnePlan(t *testing.T) {
+	defer func(enabled string) { planbuilder.PlanCacheEnabled = enabled }(planbuilder.PlanCacheEnabled)
+	planbuilder.PlanCacheEnabled = "true"
+	runtimeCtx, err := stackqltestutil.GetRuntimeCtx(testobjects.GetGoogleProviderString(), "text", "TestPreparedStatementExecutesShareOnePlan")
+	if err != nil {
+		t.Fatalf("Test failed: %v", err)
+	}
+	runtimeCtx.QueryCacheSize = 10
+	exp := testhttpapi.NewExpectationStore(2)
+	zones := []string{"australia-southeast1-a", "australia-southeast1-b"}
+	var keys []string
+	for _, zone := range zones {
+		path := "/compute/v1/projects/testing-project/zones/" + zone + "/instances"
+		ex := testhttpapi.NewHTTPRequestExpectations(nil, nil, "GET", &url.URL{Path: path}, "compute.googleapis.com", testobjects.SimpleSelectGoogleComputeInstanceResponse, nil)
+		exp.Put("compute.googleapis.com"+path, ex)
+		keys = append(keys, "compute.googleapis.com"+path)
+	}
+	testhttpapi.StartServer(t, exp)
+	provider.DummyAuth = true
+
+	inputBundle, err := stackqltestutil.BuildInputBundle(*runtimeCtx)
+	if err != nil {
+		t.Fatalf("Test failed: %v", err)
+	}
+	handlerCtx, err := handler.NewHandlerCtx(
+		"", *runtimeCtx, lrucache.NewLRUCache(int64(runtimeCtx.QueryCacheSize)),
+		inputBundle, "v0.1.1")
+	if err != nil {
+		t.Fatalf("Test failed: %v", err)
+	}
+	dr, err := NewStackQLDriver(handlerCtx)
+	if err != nil {
+		t.Fatalf("Test failed: %v", err)
+	}
+	backend, isExtended := dr.(sqlbackend.IExtendedQueryBackend)
+	if !isExtended {
+		t.Fatalf("expected the driver to serve the extended query protocol")
+	}
+
+	ctx := context.Background()
+	query := "select name, zone from google.compute.instances where zone = $1 AND project = $2"
+	if _, err = backend.HandleParse(ctx, "stmt", query, nil); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	for _, zone := range zones {
+		values := [][]byte{[]byte(zone), []byte("testing-project")}
+		if err = backend.HandleBind(ctx, "", "stmt", nil, values, nil); err != nil {
+			t.Fatalf("Bind: %v", err)
+		}
+		if _, err = backend.HandleExecute(ctx, "", "stmt", query, nil, nil, nil, 0); err != nil {
+			t.Fatalf("Execute %s: %v", zone, err)
+		}
+	}
+
+	if stats := handlerCtx.GetPlanTemplateCache().Stats(); stats.Misses != 1 || stats.Hits != 1 {
+		t.Fatalf("expected the second execution to share the plan of the first, stats = %+v", stats)
+	}
+	for _, key := range keys {
+		if exp.HasKey(key) {
+			t.Errorf("expected a request to %s", key)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package driver_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	lrucache "github.com/stackql/stackql-parser/go/cache"
+
+	. "github.com/stackql/stackql/internal/stackql/driver"
+
+	"github.com/stackql/stackql/internal/stackql/handler"
+	"github.com/stackql/stackql/internal/stackql/provider"
+	"github.com/stackql/stackql/internal/stackql/querycancel"
+
+	"github.com/stackql/stackql/internal/test/stackqltestutil"
+	"github.com/stackql/stackql/internal/test/testobjects"
+)
+
+const sessionTerminationInsert = `INSERT INTO google.compute.networks(project, data__name) SELECT 'testing-project', 'my-network';`
+
+// TestSessionEndReleasesTransactionLocks disconnects a session holding the
+// exclusive lock of a mutation queued in its open transaction: another
+// session must then take the same lock without waiting out its timeout.
+func TestSessionEndReleasesTransactionLocks(t *testing.T) {
+	runtimeCtx, err := stackqltestutil.GetRuntimeCtx(testobjects.GetGoogleProviderString(), "text", "TestSessionEndReleasesTransactionLocks")
+	if err != nil {
+		t.Fatalf("Test failed: %v", err)
+	}
+	provider.DummyAuth = true
+	inputBundle, err := stackqltestutil.BuildInputBundle(*runtimeCtx)
+	if err != nil {
+		t.Fatalf("Test failed: %v", err)
+	}
+	newDriver := func() StackQLDriver {
+		handlerCtx, ctxErr := handler.NewHandlerCtx(
+			"", *runtimeCtx, lrucache.NewLRUCache(int64(runtimeCtx.QueryCacheSize)),
+			inputBundle, "v0.1.1")
+		if ctxErr != nil {
+			t.Fatalf("Test failed: %v", ctxErr)
+		}
+		dr, drErr := NewStackQLDriver(handlerCtx)
+		if drErr != nil {
+			t.Fatalf("Test failed: %v", drErr)
+		}
+		return dr
+	}
+	ctx := context.Background()
+
+	key, err := querycancel.DefaultRegistry().Issue()
+	if err != nil {
+		t.Fatalf("Test failed: %v", err)
+	}
+	first := newDriver()
+	for _, query := range []string{"BEGIN", sessionTerminationInsert} {
+		if _, err = first.HandleSimpleQuery(ctx, querycancel.Tag(query, key.ProcessID)); err != nil {
+			t.Fatalf("Test failed: %v", err)
+		}
+	}
+	// The client disconnects mid-transaction.
+	querycancel.DefaultRegistry().Release(key.ProcessID)
+
+	second := newDriver()
+	if _, err = second.HandleSimpleQuery(ctx, "BEGIN"); err != nil {
+		t.Fatalf("Test failed: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, insertErr := second.HandleSimpleQuery(ctx, sessionTerminationInsert)
+		done <- insertErr
+	}()
+	select {
+	case err = <-done:
+		if err != nil {
+			t.Fatalf("expected the lock of the ended session released, got: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the lock of the ended session released, still waiting")
+	}
+	if _, err = second.HandleSimpleQuery(ctx, "ROLLBACK"); err != nil {
+		t.Fatalf("Test failed: %v", err)
+	}
+}
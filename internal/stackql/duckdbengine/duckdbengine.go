@@ -0,0 +1,209 @@
+// Package duckdbengine provides the embedded DuckDB SQL engine, backing
+// the `duckdb` SQL system.  It satisfies the SQL engine interface of
+// any-sdk, which knows nothing of DuckDB.
+package duckdbengine
+
+import (
+	"database/sql"
+	_ "embed" // setup DDL
+	"fmt"
+	"os"
+
+	_ "github.com/marcboeker/go-duckdb" //nolint:revive // canonical driver pattern
+)
+
+const (
+	// DriverName is that under which the DuckDB driver registers.
+	DriverName = "duckdb"
+)
+
+var (
+	//go:embed setup.sql
+	setupDDL string //nolint:gochecknoglobals // embedded
+
+	_ Engine = &duckDBEngine{}
+)
+
+// Engine is the method set of the any-sdk SQL engine.
+type Engine interface {
+	GetDB() (*sql.DB, error)
+	GetTx() (*sql.Tx, error)
+	GetNextGenerationID() (int, error)
+	GetCurrentGenerationID() (int, error)
+	GetNextDiscoveryGenerationID(string) (int, error)
+	GetCurrentDiscoveryGenerationID(string) (int, error)
+	GetNextSessionID(int) (int, error)
+	GetCurrentSessionID(int) (int, error)
+	GetNextTransactionID() (int, error)
+	GetCurrentTransactionID() (int, error)
+	CacheStoreGet(string) ([]byte, error)
+	CacheStorePut(string, []byte, string, int) error
+	IsMemory() bool
+	Exec(string, ...interface{}) (sql.Result, error)
+	ExecInTxn(queries []string) error
+	Query(string, ...interface{}) (*sql.Rows, error)
+	QueryRow(string, ...interface{}) *sql.Row
+}
+
+// GetSetupDDL returns the DDL of the control tables,
+// which is idempotent.
+func GetSetupDDL() string {
+	return setupDDL
+}
+
+// NewEngine opens the DuckDB database at path dsn,
+// or an in memory database if dsn is empty, and
+// creates the control tables.
+func NewEngine(dsn string) (Engine, error) {
+	db, err := sql.Open(DriverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = db.Exec(setupDDL); err != nil {
+		//nolint:errcheck // TODO: merge variadic error(s) into one
+		db.Close()
+		return nil, fmt.Errorf("cannot set up duckdb engine: %w", err)
+	}
+	return &duckDBEngine{
+		db:       db,
+		isMemory: dsn == "" || dsn == ":memory:",
+	}, nil
+}
+
+type duckDBEngine struct {
+	db       *sql.DB
+	isMemory bool
+}
+
+func (se *duckDBEngine) GetDB() (*sql.DB, error) {
+	return se.db, nil
+}
+
+func (se *duckDBEngine) GetTx() (*sql.Tx, error) {
+	return se.db.Begin()
+}
+
+func (se *duckDBEngine) IsMemory() bool {
+	return se.isMemory
+}
+
+func (se *duckDBEngine) Exec(query string, varArgs ...interface{}) (sql.Result, error) {
+	return se.db.Exec(query, varArgs...)
+}
+
+func (se *duckDBEngine) ExecInTxn(queries []string) error {
+	txn, err := se.db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, query := range queries {
+		if _, err = txn.Exec(query); err != nil {
+			//nolint:errcheck // TODO: merge variadic error(s) into one
+			txn.Rollback()
+			return err
+		}
+	}
+	return txn.Commit()
+}
+
+func (se *duckDBEngine) Query(query string, varArgs ...interface{}) (*sql.Rows, error) {
+	return se.db.Query(query, varArgs...)
+}
+
+func (se *duckDBEngine) QueryRow(query string, varArgs ...interface{}) *sql.Row {
+	return se.db.QueryRow(query, varArgs...)
+}
+
+// DuckDB offers no last insert ID; surrogate
+// keys are returned from the insert instead.
+func (se *duckDBEngine) insertReturningID(query string, varArgs ...interface{}) (int, error) {
+	var rv int
+	err := se.db.QueryRow(query, varArgs...).Scan(&rv)
+	return rv, err
+}
+
+func (se *duckDBEngine) GetNextGenerationID() (int, error) {
+	return se.insertReturningID(
+		`INSERT INTO "__iql__.control.generation" (generation_description) VALUES ('') RETURNING iql_generation_id`,
+	)
+}
+
+func (se *duckDBEngine) GetCurrentGenerationID() (int, error) {
+	var rv int
+	err := se.db.QueryRow(
+		`SELECT iql_generation_id FROM "__iql__.control.generation" WHERE collected_dttm IS NULL ORDER BY iql_generation_id DESC LIMIT 1`, //nolint:lll // single query
+	).Scan(&rv)
+	return rv, err
+}
+
+func (se *duckDBEngine) GetNextDiscoveryGenerationID(discoveryName string) (int, error) {
+	return se.insertReturningID(
+		`INSERT INTO "__iql__.control.discovery_generation" (discovery_name) VALUES (?) RETURNING iql_discovery_generation_id`, //nolint:lll // single query
+		discoveryName,
+	)
+}
+
+func (se *duckDBEngine) GetCurrentDiscoveryGenerationID(discoveryName string) (int, error) {
+	var rv int
+	err := se.db.QueryRow(
+		`SELECT iql_discovery_generation_id FROM "__iql__.control.discovery_generation" WHERE discovery_name = ? AND collected_dttm IS NULL ORDER BY iql_discovery_generation_id DESC LIMIT 1`, //nolint:lll // single query
+		discoveryName,
+	).Scan(&rv)
+	return rv, err
+}
+
+func (se *duckDBEngine) GetNextSessionID(generationID int) (int, error) {
+	return se.insertReturningID(
+		`INSERT INTO "__iql__.control.session" (iql_generation_id, os_pid) VALUES (?, ?) RETURNING iql_session_id`,
+		generationID,
+		os.Getpid(),
+	)
+}
+
+func (se *duckDBEngine) GetCurrentSessionID(generationID int) (int, error) {
+	var rv int
+	err := se.db.QueryRow(
+		`SELECT iql_session_id FROM "__iql__.control.session" WHERE iql_generation_id = ? AND collected_dttm IS NULL ORDER BY iql_session_id DESC LIMIT 1`, //nolint:lll // single query
+		generationID,
+	).Scan(&rv)
+	return rv, err
+}
+
+func (se *duckDBEngine) GetNextTransactionID() (int, error) {
+	return se.insertReturningID(
+		`INSERT INTO "__iql__.control.txn" (transaction_description) VALUES ('') RETURNING iql_transaction_id`,
+	)
+}
+
+func (se *duckDBEngine) GetCurrentTransactionID() (int, error) {
+	var rv int
+	err := se.db.QueryRow(
+		`SELECT iql_transaction_id FROM "__iql__.control.txn" WHERE collected_dttm IS NULL ORDER BY iql_transaction_id DESC LIMIT 1`, //nolint:lll // single query
+	).Scan(&rv)
+	return rv, err
+}
+
+func (se *duckDBEngine) CacheStoreGet(key string) ([]byte, error) {
+	var rv []byte
+	err := se.db.QueryRow(`SELECT v FROM "__iql__.cache.key_val" WHERE k = ?`, key).Scan(&rv)
+	return rv, err
+}
+
+// CacheStorePut upserts, as DuckDB checks unique
+// constraints too eagerly to delete and reinsert
+// a key in one transaction.
+func (se *duckDBEngine) CacheStorePut(key string, val []byte, tablespace string, tablespaceID int) error {
+	_, err := se.db.Exec(
+		`
+		INSERT INTO "__iql__.cache.key_val" (k, v, tablespace, tablespace_id)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (k)
+		DO UPDATE SET v = EXCLUDED.v, tablespace = EXCLUDED.tablespace, tablespace_id = EXCLUDED.tablespace_id
+		`,
+		key,
+		val,
+		tablespace,
+		tablespaceID,
+	)
+	return err
+}
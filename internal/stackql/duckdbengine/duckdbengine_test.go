@@ -0,0 +1,120 @@
+package duckdbengine_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stackql/stackql/internal/stackql/duckdbengine"
+)
+
+func newEngine(t *testing.T, dsn string) duckdbengine.Engine {
+	t.Helper()
+	engine, err := duckdbengine.NewEngine(dsn)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() {
+		db, _ := engine.GetDB()
+		db.Close()
+	})
+	return engine
+}
+
+func TestGenerationIDs(t *testing.T) {
+	engine := newEngine(t, "")
+	if !engine.IsMemory() {
+		t.Fatalf("expected an in memory engine")
+	}
+	if _, err := engine.GetCurrentGenerationID(); err == nil {
+		t.Fatalf("expected no current generation of a fresh engine")
+	}
+	first, err := engine.GetNextGenerationID()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, _ := engine.GetNextGenerationID()
+	current, err := engine.GetCurrentGenerationID()
+	if err != nil || second <= first || current != second {
+		t.Fatalf("unexpected generations %d, %d, current %d: %v", first, second, current, err)
+	}
+	sessionID, err := engine.GetNextSessionID(current)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if currentSessionID, _ := engine.GetCurrentSessionID(current); currentSessionID != sessionID {
+		t.Fatalf("expected current session %d, got %d", sessionID, currentSessionID)
+	}
+	discoveryID, err := engine.GetNextDiscoveryGenerationID("google")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if currentDiscoveryID, _ := engine.GetCurrentDiscoveryGenerationID("google"); currentDiscoveryID != discoveryID {
+		t.Fatalf("expected current discovery generation %d, got %d", discoveryID, currentDiscoveryID)
+	}
+	if _, err = engine.GetCurrentDiscoveryGenerationID("aws"); err == nil {
+		t.Fatalf("expected no discovery generation of an undiscovered provider")
+	}
+	txnID, err := engine.GetNextTransactionID()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if currentTxnID, _ := engine.GetCurrentTransactionID(); currentTxnID != txnID {
+		t.Fatalf("expected current transaction %d, got %d", txnID, currentTxnID)
+	}
+}
+
+func TestCacheStore(t *testing.T) {
+	engine := newEngine(t, "")
+	if _, err := engine.CacheStoreGet("k"); err == nil {
+		t.Fatalf("expected a miss")
+	}
+	for _, v := range []string{"first", "second"} {
+		if err := engine.CacheStorePut("k", []byte(v), "", 0); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	v, err := engine.CacheStoreGet("k")
+	if err != nil || string(v) != "second" {
+		t.Fatalf("expected the value replaced, got %q: %v", v, err)
+	}
+}
+
+func TestExecInTxn(t *testing.T) {
+	engine := newEngine(t, "")
+	if _, err := engine.Exec(`CREATE TABLE "t.generation_1" (x INTEGER)`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err := engine.ExecInTxn([]string{
+		`INSERT INTO "t.generation_1" (x) VALUES (1)`,
+		`INSERT INTO "absent" (x) VALUES (2)`,
+	})
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	var ct int
+	if err = engine.QueryRow(`SELECT count(*) FROM "t.generation_1"`).Scan(&ct); err != nil || ct != 0 {
+		t.Fatalf("expected the transaction rolled back, got %d rows: %v", ct, err)
+	}
+}
+
+func TestSetupIsIdempotent(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "stackql.duckdb")
+	engine := newEngine(t, dsn)
+	if engine.IsMemory() {
+		t.Fatalf("expected a file backed engine")
+	}
+	genID, err := engine.GetNextGenerationID()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err = engine.Exec(duckdbengine.GetSetupDDL()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var ct int
+	if err = engine.QueryRow(`SELECT count(*) FROM "__iql__.control.gc.rings"`).Scan(&ct); err != nil || ct != 1 {
+		t.Fatalf("expected a single ring, got %d: %v", ct, err)
+	}
+	if current, _ := engine.GetCurrentGenerationID(); current != genID {
+		t.Fatalf("expected generation %d to survive setup, got %d", genID, current)
+	}
+}
@@ -0,0 +1,139 @@
+CREATE SEQUENCE IF NOT EXISTS "__iql__.control.generation_id_seq"
+;
+CREATE TABLE IF NOT EXISTS "__iql__.control.generation" (
+   iql_generation_id BIGINT PRIMARY KEY DEFAULT nextval('"__iql__.control.generation_id_seq"')
+  ,generation_description TEXT
+  ,created_dttm TIMESTAMP NOT NULL DEFAULT make_timestamp(epoch_us(now()))
+  ,collected_dttm TIMESTAMP DEFAULT NULL
+)
+;
+CREATE SEQUENCE IF NOT EXISTS "__iql__.control.discovery_generation_id_seq"
+;
+CREATE TABLE IF NOT EXISTS "__iql__.control.discovery_generation" (
+   iql_discovery_generation_id BIGINT PRIMARY KEY DEFAULT nextval('"__iql__.control.discovery_generation_id_seq"')
+  ,discovery_name TEXT NOT NULL
+  ,created_dttm TIMESTAMP NOT NULL DEFAULT make_timestamp(epoch_us(now()))
+  ,collected_dttm TIMESTAMP DEFAULT NULL
+)
+;
+CREATE SEQUENCE IF NOT EXISTS "__iql__.control.session_id_seq"
+;
+CREATE TABLE IF NOT EXISTS "__iql__.control.session" (
+   iql_session_id BIGINT PRIMARY KEY DEFAULT nextval('"__iql__.control.session_id_seq"')
+  ,iql_generation_id BIGINT NOT NULL
+  ,os_pid INTEGER
+  ,session_description TEXT
+  ,created_dttm TIMESTAMP NOT NULL DEFAULT make_timestamp(epoch_us(now()))
+  ,collected_dttm TIMESTAMP DEFAULT NULL
+)
+;
+CREATE SEQUENCE IF NOT EXISTS "__iql__.control.txn_id_seq"
+;
+CREATE TABLE IF NOT EXISTS "__iql__.control.txn" (
+   iql_transaction_id BIGINT PRIMARY KEY DEFAULT nextval('"__iql__.control.txn_id_seq"')
+  ,iql_session_id BIGINT
+  ,transaction_description TEXT
+  ,created_dttm TIMESTAMP NOT NULL DEFAULT make_timestamp(epoch_us(now()))
+  ,collected_dttm TIMESTAMP DEFAULT NULL
+)
+;
+CREATE TABLE IF NOT EXISTS "__iql__.control.gc.txn_table_x_ref" (
+   iql_generation_id BIGINT NOT NULL
+  ,iql_session_id BIGINT NOT NULL
+  ,iql_transaction_id BIGINT NOT NULL
+  ,table_name TEXT NOT NULL
+  ,created_dttm TIMESTAMP NOT NULL DEFAULT make_timestamp(epoch_us(now()))
+  ,UNIQUE(iql_generation_id, iql_session_id, iql_transaction_id, table_name)
+)
+;
+CREATE TABLE IF NOT EXISTS "__iql__.control.gc.rings" (
+   ring_name TEXT NOT NULL UNIQUE
+  ,current_value BIGINT NOT NULL
+  ,current_offset BIGINT NOT NULL
+  ,width_bits INTEGER NOT NULL
+  ,created_dttm TIMESTAMP NOT NULL DEFAULT make_timestamp(epoch_us(now()))
+)
+;
+INSERT OR IGNORE INTO "__iql__.control.gc.rings" (
+   ring_name
+  ,current_value
+  ,current_offset
+  ,width_bits
+) VALUES (
+   'transaction_id'
+  ,0
+  ,0
+  ,32
+)
+;
+CREATE TABLE IF NOT EXISTS "__iql__.cache.key_val" (
+   k TEXT NOT NULL UNIQUE
+  ,v BLOB NOT NULL
+  ,tablespace TEXT
+  ,tablespace_id INTEGER
+  ,created_dttm TIMESTAMP NOT NULL DEFAULT make_timestamp(epoch_us(now()))
+)
+;
+CREATE TABLE IF NOT EXISTS "__iql__.external.columns" (
+   connection_name TEXT NOT NULL
+  ,catalog_name TEXT NOT NULL
+  ,schema_name TEXT NOT NULL
+  ,table_name TEXT NOT NULL
+  ,column_name TEXT NOT NULL
+  ,column_type TEXT NOT NULL
+  ,ordinal_position INTEGER NOT NULL
+  ,"oid" INTEGER NOT NULL
+  ,column_width INTEGER NOT NULL DEFAULT 0
+  ,column_precision INTEGER NOT NULL DEFAULT 0
+  ,created_dttm TIMESTAMP NOT NULL DEFAULT make_timestamp(epoch_us(now()))
+  ,UNIQUE(connection_name, catalog_name, schema_name, table_name, column_name)
+)
+;
+CREATE TABLE IF NOT EXISTS "__iql__.views" (
+   view_name TEXT NOT NULL UNIQUE
+  ,view_ddl TEXT NOT NULL
+  ,required_params TEXT NOT NULL DEFAULT ''
+  ,created_dttm TIMESTAMP NOT NULL DEFAULT make_timestamp(epoch_us(now()))
+  ,deleted_dttm TIMESTAMP DEFAULT NULL
+)
+;
+CREATE TABLE IF NOT EXISTS "__iql__.materialized_views" (
+   view_name TEXT NOT NULL UNIQUE
+  ,view_ddl TEXT NOT NULL
+  ,translated_ddl TEXT NOT NULL
+  ,translated_inline_dml TEXT NOT NULL
+  ,created_dttm TIMESTAMP NOT NULL DEFAULT make_timestamp(epoch_us(now()))
+  ,deleted_dttm TIMESTAMP DEFAULT NULL
+)
+;
+CREATE TABLE IF NOT EXISTS "__iql__.materialized_views.columns" (
+   view_name TEXT NOT NULL
+  ,column_name TEXT NOT NULL
+  ,column_type TEXT NOT NULL
+  ,ordinal_position INTEGER NOT NULL
+  ,"oid" INTEGER NOT NULL
+  ,column_width INTEGER NOT NULL DEFAULT 0
+  ,column_precision INTEGER NOT NULL DEFAULT 0
+  ,created_dttm TIMESTAMP NOT NULL DEFAULT make_timestamp(epoch_us(now()))
+  ,UNIQUE(view_name, column_name)
+)
+;
+CREATE TABLE IF NOT EXISTS "__iql__.tables" (
+   table_name TEXT NOT NULL UNIQUE
+  ,table_ddl TEXT NOT NULL
+  ,created_dttm TIMESTAMP NOT NULL DEFAULT make_timestamp(epoch_us(now()))
+  ,deleted_dttm TIMESTAMP DEFAULT NULL
+)
+;
+CREATE TABLE IF NOT EXISTS "__iql__.tables.columns" (
+   table_name TEXT NOT NULL
+  ,column_name TEXT NOT NULL
+  ,column_type TEXT NOT NULL
+  ,ordinal_position INTEGER NOT NULL
+  ,"oid" INTEGER NOT NULL
+  ,column_width INTEGER NOT NULL DEFAULT 0
+  ,column_precision INTEGER NOT NULL DEFAULT 0
+  ,created_dttm TIMESTAMP NOT NULL DEFAULT make_timestamp(epoch_us(now()))
+  ,UNIQUE(table_name, column_name)
+)
+;
@@ -14,6 +14,7 @@ import (
 	"github.com/stackql/stackql/internal/stackql/bundle"
 	"github.com/stackql/stackql/internal/stackql/datasource/sql_datasource"
 	"github.com/stackql/stackql/internal/stackql/dbmsinternal"
+	"github.com/stackql/stackql/internal/stackql/duckdbengine"
 	"github.com/stackql/stackql/internal/stackql/garbagecollector"
 	"github.com/stackql/stackql/internal/stackql/gcexec"
 	"github.com/stackql/stackql/internal/stackql/handler"
@@ -62,12 +63,16 @@ func BuildInputBundle(runtimeCtx dto.RuntimeCtx) (bundle.Bundle, error) {
 	if err != nil {
 		return nil, err
 	}
+	sqlDataSources, err := initSQLDataSources(ac)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing SQL data sources: %w", err)
+	}
 	system, err := sql_system.NewSQLSystem(
 		se,
 		namespaces.GetAnalyticsCacheTableNamespaceConfigurator().GetLikeString(),
 		controlAttributes,
 		sqlCfg,
-		ac, typCfg, runtimeCtx.ExportAlias)
+		ac, sqlDataSources, typCfg, runtimeCtx.ExportAlias)
 	if err != nil {
 		return nil, err
 	}
@@ -96,19 +101,18 @@ func BuildInputBundle(runtimeCtx dto.RuntimeCtx) (bundle.Bundle, error) {
 	if err != nil {
 		return nil, err
 	}
-	sqlDataSources, err := initSQLDataSources(ac)
-	if err != nil {
-		return nil, fmt.Errorf("error initializing SQL data sources: %w", err)
-	}
 	txnCoordinatorCfg, err := dto.GetTxnCoordinatorCfgCfg(runtimeCtx.ACIDCfgRaw)
 	if err != nil {
 		return nil, fmt.Errorf("error initializing Transaction Coordinator config: %w", err)
 	}
-	sessionConfig, sessionConfigErr := dto.NewSessionContext(runtimeCtx.SessionCtxRaw)
+	sessionCtxRaw, isCompensatingRollback := txn_context.NormaliseSessionConfig(runtimeCtx.SessionCtxRaw)
+	sessionConfig, sessionConfigErr := dto.NewSessionContext(sessionCtxRaw)
 	if sessionConfigErr != nil {
 		return nil, fmt.Errorf("error initializing session config: %w", sessionConfigErr)
 	}
-	txnCoordinatorCtx := txn_context.NewTransactionCoordinatorContext(txnCoordinatorCfg.GetMaxTxnDepth())
+	txnCoordinatorCtx := txn_context.NewTransactionCoordinatorContext(
+		txnCoordinatorCfg.GetMaxTxnDepth(),
+	).WithCompensatingRollback(isCompensatingRollback)
 	return bundle.NewBundle(
 		gc,
 		namespaces,
@@ -156,6 +160,11 @@ func buildSQLEngine(
 	sqlCfg dto.SQLBackendCfg,
 	controlAttributes sqlcontrol.ControlAttributes,
 ) (sqlengine.SQLEngine, error) {
+	// DuckDB is unknown to any-sdk; its DSN
+	// is a database path, or empty for memory.
+	if strings.ToLower(sqlCfg.GetSQLDialect()) == typing.SQLDialectDuckDB {
+		return duckdbengine.NewEngine(sqlCfg.DSN)
+	}
 	return sqlengine.NewSQLEngine(sqlCfg, controlAttributes)
 }
 
@@ -191,22 +200,25 @@ func getTxnCounterManager(sqlEngine sqlengine.SQLEngine) (txncounter.Manager, er
 	return txncounter.NewTxnCounterManager(genID, sessionID), nil
 }
 
-func PreprocessInline(runtimeCtx dto.RuntimeCtx, s string) (string, error) {
+// PreprocessInline renders the templating of s, templates reading vars
+// directly where no declaration block defines the same key.
+func PreprocessInline(runtimeCtx dto.RuntimeCtx, s string, vars map[string]string) (string, error) {
 	rdr := strings.NewReader(s)
-	bt, err := assemblePreprocessor(runtimeCtx, rdr)
+	bt, err := assemblePreprocessor(runtimeCtx, rdr, vars)
 	if err != nil || bt == nil {
 		return s, err
 	}
 	return string(bt), nil
 }
 
-func assemblePreprocessor(runtimeCtx dto.RuntimeCtx, rdr io.Reader) ([]byte, error) {
+func assemblePreprocessor(runtimeCtx dto.RuntimeCtx, rdr io.Reader, vars map[string]string) ([]byte, error) {
 	var err error
 	var prepRd, externalTmplRdr io.Reader
 	pp := preprocessor.NewPreprocessor(preprocessor.TripleLessThanToken, preprocessor.TripleGreaterThanToken)
 	if pp == nil {
 		return nil, fmt.Errorf("preprocessor error")
 	}
+	pp.WithVars(vars)
 	if runtimeCtx.TemplateCtxFilePath == "" {
 		prepRd, err = pp.Prepare(rdr, runtimeCtx.InfilePath, runtimeCtx.VarList)
 		if err != nil {
@@ -250,7 +262,7 @@ func BuildHandlerContext(
 			"", runtimeCtx, lruCache,
 			inputBundle, "v0.1.1")
 	}
-	bb, err := assemblePreprocessor(runtimeCtx, rdr)
+	bb, err := assemblePreprocessor(runtimeCtx, rdr, nil)
 	iqlerror.PrintErrorAndExitOneIfError(err)
 	return handler.NewHandlerCtx(
 		strings.TrimSpace(string(bb)), runtimeCtx, lruCache,
@@ -0,0 +1,287 @@
+package execstats
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	_ Stats     = (*standardStats)(nil)
+	_ Stats     = (*nopStats)(nil)
+	_ Collector = (*standardCollector)(nil)
+)
+
+// Snapshot is a point in time copy of the
+// statistics gathered for a single primitive.
+type Snapshot struct {
+	Elapsed       time.Duration
+	HTTPRequests  int64
+	Pages         int64
+	BytesReceived int64
+	Rows          int64
+	Retries       int64
+	// RowsRecorded is false where the primitive
+	// did not report rows at all, as opposed to
+	// reporting zero rows.
+	RowsRecorded bool
+	// BytesRecorded is false where no response body
+	// was observed, as where the provider SDK reads
+	// responses itself.
+	BytesRecorded bool
+}
+
+// Stats accumulates runtime statistics for a single
+// primitive, as reported by EXPLAIN ANALYZE.
+// Implementations are safe for concurrent use.
+type Stats interface {
+	IsEnabled() bool
+	RecordElapsed(time.Duration)
+	RecordHTTPRequest()
+	RecordPage()
+	RecordBytes(int64)
+	RecordRows(int64)
+	RecordRetry()
+	Snapshot() Snapshot
+}
+
+// Carrier is implemented by primitive contexts
+// that carry statistics for the current primitive.
+type Carrier interface {
+	GetStats() Stats
+}
+
+// Collector owns the statistics of each primitive
+// node in a graph, keyed by node ID.
+type Collector interface {
+	ForNode(id int64) Stats
+	GetNode(id int64) (Snapshot, bool)
+}
+
+// FromContext returns the statistics carried by ctx, or
+// a disabled no-op implementation if there are none.
+func FromContext(ctx any) Stats {
+	if carrier, isCarrier := ctx.(Carrier); isCarrier {
+		if stats := carrier.GetStats(); stats != nil {
+			return stats
+		}
+	}
+	return NewNopStats()
+}
+
+type standardStats struct {
+	elapsed       atomic.Int64
+	httpRequests  atomic.Int64
+	pages         atomic.Int64
+	bytesReceived atomic.Int64
+	rows          atomic.Int64
+	retries       atomic.Int64
+	rowsRecorded  atomic.Bool
+	bytesRecorded atomic.Bool
+}
+
+func NewStats() Stats {
+	return &standardStats{}
+}
+
+func (s *standardStats) IsEnabled() bool {
+	return true
+}
+
+func (s *standardStats) RecordElapsed(d time.Duration) {
+	s.elapsed.Add(int64(d))
+}
+
+func (s *standardStats) RecordHTTPRequest() {
+	s.httpRequests.Add(1)
+}
+
+func (s *standardStats) RecordPage() {
+	s.pages.Add(1)
+}
+
+func (s *standardStats) RecordBytes(n int64) {
+	s.bytesRecorded.Store(true)
+	s.bytesReceived.Add(n)
+}
+
+func (s *standardStats) RecordRows(n int64) {
+	s.rowsRecorded.Store(true)
+	s.rows.Add(n)
+}
+
+func (s *standardStats) RecordRetry() {
+	s.retries.Add(1)
+}
+
+func (s *standardStats) Snapshot() Snapshot {
+	return Snapshot{
+		Elapsed:       time.Duration(s.elapsed.Load()),
+		HTTPRequests:  s.httpRequests.Load(),
+		Pages:         s.pages.Load(),
+		BytesReceived: s.bytesReceived.Load(),
+		Rows:          s.rows.Load(),
+		Retries:       s.retries.Load(),
+		RowsRecorded:  s.rowsRecorded.Load(),
+		BytesRecorded: s.bytesRecorded.Load(),
+	}
+}
+
+type nopStats struct{}
+
+func NewNopStats() Stats {
+	return &nopStats{}
+}
+
+func (s *nopStats) IsEnabled() bool               { return false }
+func (s *nopStats) RecordElapsed(_ time.Duration) {}
+func (s *nopStats) RecordHTTPRequest()            {}
+func (s *nopStats) RecordPage()                   {}
+func (s *nopStats) RecordBytes(_ int64)           {}
+func (s *nopStats) RecordRows(_ int64)            {}
+func (s *nopStats) RecordRetry()                  {}
+func (s *nopStats) Snapshot() Snapshot            { return Snapshot{} }
+
+type standardCollector struct {
+	mu    sync.Mutex
+	nodes map[int64]Stats
+}
+
+func NewCollector() Collector {
+	return &standardCollector{
+		nodes: make(map[int64]Stats),
+	}
+}
+
+func (c *standardCollector) ForNode(id int64) Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats, ok := c.nodes[id]
+	if !ok {
+		stats = NewStats()
+		c.nodes[id] = stats
+	}
+	return stats
+}
+
+func (c *standardCollector) GetNode(id int64) (Snapshot, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats, ok := c.nodes[id]
+	if !ok {
+		return Snapshot{}, false
+	}
+	return stats.Snapshot(), true
+}
+
+type (
+	contextKey        struct{}
+	trackerContextKey struct{}
+)
+
+// NewContext returns a copy of ctx carrying stats, against which
+// the requests of each request context derived from it are recorded.
+func NewContext(ctx context.Context, stats Stats) context.Context {
+	if stats == nil || !stats.IsEnabled() {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKey{}, stats)
+}
+
+// NewRequestContext returns a copy of ctx in which to make a single
+// logical request: its first attempt, any retries and the requests
+// for subsequent pages.  Every request made under the returned
+// context is recorded against the stats of ctx through an
+// httptrace.ClientTrace, which net/http transports honour however
+// the sending client was built, auth, proxy and TLS layers included.
+// A request sent before a page of the response to the previous one
+// was recorded is taken to be a retry of it.  Logical requests made
+// concurrently under the one query must each have their own context,
+// lest they be taken for retries of one another.
+func NewRequestContext(ctx context.Context) context.Context {
+	stats := StatsFromContext(ctx)
+	if !stats.IsEnabled() {
+		return ctx
+	}
+	tracker := &requestTracker{
+		stats: stats,
+	}
+	ctx = context.WithValue(ctx, trackerContextKey{}, tracker)
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		WroteRequest: tracker.wroteRequest,
+	})
+}
+
+// StatsFromContext returns the statistics carried by ctx, or
+// a disabled no-op implementation if there are none.
+func StatsFromContext(ctx context.Context) Stats {
+	if ctx != nil {
+		if stats, isStats := ctx.Value(contextKey{}).(Stats); isStats {
+			return stats
+		}
+	}
+	return NewNopStats()
+}
+
+// ObservePage records resp, a response to a request made under ctx,
+// as a page, and every byte subsequently read from its body.
+func ObservePage(ctx context.Context, resp *http.Response) {
+	stats := StatsFromContext(ctx)
+	if !stats.IsEnabled() || resp == nil {
+		return
+	}
+	if tracker, isTracked := ctx.Value(trackerContextKey{}).(*requestTracker); isTracked {
+		tracker.observePage()
+	}
+	stats.RecordPage()
+	if resp.Body != nil {
+		resp.Body = &countingReadCloser{
+			ReadCloser: resp.Body,
+			stats:      stats,
+		}
+	}
+}
+
+type requestTracker struct {
+	stats Stats
+	mu    sync.Mutex
+	// Whether a request was sent, the pages of the
+	// logical request and those as at its most recent
+	// request.
+	isSent    bool
+	pages     int64
+	lastPages int64
+}
+
+func (t *requestTracker) observePage() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pages++
+}
+
+func (t *requestTracker) wroteRequest(_ httptrace.WroteRequestInfo) {
+	t.mu.Lock()
+	isRetry := t.isSent && t.pages == t.lastPages
+	t.isSent = true
+	t.lastPages = t.pages
+	t.mu.Unlock()
+	t.stats.RecordHTTPRequest()
+	if isRetry {
+		t.stats.RecordRetry()
+	}
+}
+
+type countingReadCloser struct {
+	io.ReadCloser
+	stats Stats
+}
+
+func (c *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.stats.RecordBytes(int64(n))
+	return n, err
+}
@@ -0,0 +1,161 @@
+package execstats_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/stackql/stackql/internal/stackql/execstats"
+)
+
+type statsCarrier struct {
+	stats Stats
+}
+
+func (c *statsCarrier) GetStats() Stats {
+	return c.stats
+}
+
+func TestCollector(t *testing.T) {
+	collector := NewCollector()
+	stats := collector.ForNode(3)
+	if collector.ForNode(3) != stats {
+		t.Fatalf("expected the same stats for repeated node lookups")
+	}
+	stats.RecordElapsed(2 * time.Millisecond)
+	stats.RecordPage()
+	stats.RecordPage()
+	stats.RecordRows(5)
+	snapshot, ok := collector.GetNode(3)
+	if !ok {
+		t.Fatalf("expected stats for node 3")
+	}
+	if snapshot.Elapsed != 2*time.Millisecond || snapshot.Pages != 2 || snapshot.Rows != 5 || !snapshot.RowsRecorded || snapshot.BytesRecorded {
+		t.Fatalf("unexpected snapshot: %+v", snapshot)
+	}
+	if _, ok := collector.GetNode(4); ok {
+		t.Fatalf("expected no stats for node 4")
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if FromContext(nil).IsEnabled() {
+		t.Fatalf("expected disabled stats for nil context")
+	}
+	stats := NewStats()
+	if FromContext(&statsCarrier{stats: stats}) != stats {
+		t.Fatalf("expected carried stats")
+	}
+}
+
+// retryingTransport stands for the transports which
+// providers layer over net/http, retrying 503s itself.
+type retryingTransport struct {
+	base http.RoundTripper
+}
+
+func (rt *retryingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := rt.base.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusServiceUnavailable {
+		resp.Body.Close()
+		return rt.base.RoundTrip(req)
+	}
+	return resp, err
+}
+
+func TestNewContext(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"items":[]}`)) //nolint:errcheck // test
+	}))
+	defer server.Close()
+
+	stats := NewStats()
+	ctx := NewContext(context.Background(), stats)
+	if StatsFromContext(ctx) != stats {
+		t.Fatalf("expected carried stats")
+	}
+	if NewRequestContext(context.Background()) != context.Background() {
+		t.Fatalf("expected no request tracking absent stats")
+	}
+	ctx = NewRequestContext(ctx)
+	client := &http.Client{Transport: &retryingTransport{base: http.DefaultTransport}}
+	for _, path := range []string{"/a", "/b"} {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+path, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ObservePage(ctx, resp)
+		io.ReadAll(resp.Body) //nolint:errcheck // test
+		resp.Body.Close()
+	}
+	snapshot := stats.Snapshot()
+	if snapshot.HTTPRequests != 3 {
+		t.Fatalf("expected 3 requests, got %d", snapshot.HTTPRequests)
+	}
+	if snapshot.Retries != 1 {
+		t.Fatalf("expected 1 retry, got %d", snapshot.Retries)
+	}
+	if snapshot.Pages != 2 {
+		t.Fatalf("expected 2 pages, got %d", snapshot.Pages)
+	}
+	if !snapshot.BytesRecorded || snapshot.BytesReceived != int64(2*len(`{"items":[]}`)) {
+		t.Fatalf("unexpected bytes received: %d", snapshot.BytesReceived)
+	}
+	if snapshot.RowsRecorded {
+		t.Fatalf("expected rows not recorded")
+	}
+	if StatsFromContext(context.Background()).IsEnabled() {
+		t.Fatalf("expected disabled stats for a context without stats")
+	}
+}
+
+func TestNewRequestContextTracksConcurrentRequestsApart(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`{"items":[]}`)) //nolint:errcheck // test
+	}))
+	defer server.Close()
+
+	stats := NewStats()
+	queryCtx := NewContext(context.Background(), stats)
+	var responses []*http.Response
+	var requestCtxs []context.Context
+	// Both requests are sent before either page is recorded.
+	for _, path := range []string{"/a", "/b"} {
+		requestCtx := NewRequestContext(queryCtx)
+		req, err := http.NewRequestWithContext(requestCtx, http.MethodGet, server.URL+path, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		responses = append(responses, resp)
+		requestCtxs = append(requestCtxs, requestCtx)
+	}
+	for i, resp := range responses {
+		ObservePage(requestCtxs[i], resp)
+		io.ReadAll(resp.Body) //nolint:errcheck // test
+		resp.Body.Close()
+	}
+	snapshot := stats.Snapshot()
+	if snapshot.HTTPRequests != 2 || snapshot.Pages != 2 {
+		t.Fatalf("expected 2 requests and 2 pages, got %+v", snapshot)
+	}
+	if snapshot.Retries != 0 {
+		t.Fatalf("expected no retries, got %d", snapshot.Retries)
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/stackql/stackql-parser/go/vt/sqlparser"
 	"github.com/stackql/stackql/internal/stackql/acid/binlog"
 	"github.com/stackql/stackql/internal/stackql/drm"
+	"github.com/stackql/stackql/internal/stackql/execstats"
 	"github.com/stackql/stackql/internal/stackql/handler"
 	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/builder_input"
 	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
@@ -138,6 +139,7 @@ type monoValentExecution struct {
 	isAwait                    bool
 	defaultHTTPClient          *http.Client // for testing purposes only
 	bldrInput                  builder_input.BuilderInput
+	stats                      execstats.Stats
 }
 
 func NewMonoValentExecutorFactory(
@@ -183,6 +185,7 @@ func NewMonoValentExecutorFactory(
 		defaultHTTPClient:          defaultHTTPClient,
 		invoker:                    anysdkhttp.New(),
 		bldrInput:                  bldrInput,
+		stats:                      execstats.NewNopStats(),
 	}
 }
 
@@ -476,6 +479,7 @@ func newPagingState(
 }
 
 func page(
+	ctx context.Context,
 	res formulation.Response,
 	method formulation.OperationStore,
 	provider formulation.Provider,
@@ -500,6 +504,7 @@ func page(
 	if reqErr != nil {
 		return newPagingState(pageCount, true, nil, reqErr)
 	}
+	req = req.WithContext(ctx)
 	cc := formulation.NewAnySdkClientConfigurator(rtCtx, provider.GetName(), defaultHTTPClient)
 	response, apiErr := formulation.CallFromSignature(
 		cc, rtCtx, authCtx, authCtx.Type, false, outErrFile, provider,
@@ -549,6 +554,7 @@ func (mv *monoValentExecution) ActionInsertPreparation(
 	if iErr != nil {
 		return newActionInsertResult(housekeepingDone, iErr)
 	}
+	mv.stats.RecordRows(int64(len(iArr)))
 	streamErr := mv.stream.Write(iArr)
 	if streamErr != nil {
 		return newActionInsertResult(housekeepingDone, streamErr)
@@ -764,10 +770,14 @@ type PolyHandler interface {
 	LogHTTPResponseMap(target interface{})
 	MessageHandler([]string)
 	GetMessages() []string
+	// GetContext returns the context of the query, whose cancellation
+	// aborts upstream requests.
+	GetContext() context.Context
 }
 
 type standardPolyHandler struct {
 	handlerCtx handler.HandlerContext
+	ctx        context.Context
 	messages   []string
 }
 
@@ -783,9 +793,33 @@ func (sph *standardPolyHandler) GetMessages() []string {
 	return sph.messages
 }
 
-func NewStandardPolyHandler(handlerCtx handler.HandlerContext) PolyHandler {
+func (sph *standardPolyHandler) GetContext() context.Context {
+	return sph.ctx
+}
+
+// processorContext returns the query context carried by polyHandler.
+func processorContext(polyHandler PolyHandler) context.Context {
+	if polyHandler == nil {
+		return context.Background()
+	}
+	return polyHandler.GetContext()
+}
+
+// bindRequestContext binds the request of reqCtx to ctx, so that
+// cancelling the query aborts the request in flight.
+func bindRequestContext(ctx context.Context, reqCtx formulation.HTTPArmouryParameters) {
+	if req := reqCtx.GetRequest(); req != nil {
+		*req = *req.WithContext(ctx)
+	}
+}
+
+// NewStandardPolyHandler returns a PolyHandler whose context, that of
+// the query, carries stats, against which the upstream requests of its
+// processors are recorded, as EXPLAIN ANALYZE reports them.
+func NewStandardPolyHandler(handlerCtx handler.HandlerContext, stats execstats.Stats) PolyHandler {
 	return &standardPolyHandler{
 		handlerCtx: handlerCtx,
+		ctx:        execstats.NewContext(handlerCtx.GetContext(), stats),
 		messages:   []string{},
 	}
 }
@@ -1139,6 +1173,10 @@ func (sp *standardProcessor) Process() processorResponse {
 	reversalStream := formulation.NewHttpPreparatorStream()
 
 	reqCtx := armouryParams
+	// Processors run concurrently under the one query, so
+	// each has its own context in which to track its requests.
+	queryCtx := execstats.NewRequestContext(processorContext(polyHandler))
+	bindRequestContext(queryCtx, reqCtx)
 	paramsUsed, paramErr := reqCtx.ToFlatMap()
 	if paramErr != nil {
 		return newHTTPProcessorResponse(nil, reversalStream, false, paramErr)
@@ -1184,12 +1222,16 @@ func (sp *standardProcessor) Process() processorResponse {
 	nptRequest := inferNextPageRequestElement(provider, method)
 	pageCount := 1
 	for {
+		if cancelErr := context.Cause(queryCtx); cancelErr != nil {
+			return newHTTPProcessorResponse(nil, reversalStream, false, cancelErr)
+		}
 		if apiErr != nil {
 			return newHTTPProcessorResponse(nil, reversalStream, false, apiErr)
 		}
 		if httpResponseErr != nil {
 			return newHTTPProcessorResponse(nil, reversalStream, false, httpResponseErr)
 		}
+		execstats.ObservePage(queryCtx, httpResponse)
 		// TODO: add async monitor here
 		processed, resErr := method.ProcessResponse(httpResponse)
 		if resErr != nil {
@@ -1275,6 +1317,7 @@ func (sp *standardProcessor) Process() processorResponse {
 		}
 
 		pageResult := page(
+			queryCtx,
 			res,
 			method,
 			provider,
@@ -1389,6 +1432,7 @@ func (mv *monoValentExecution) GetExecutor() (func(pc primitive.IPrimitiveCtx) i
 		return nil, authCtxErr
 	}
 	ex := func(pc primitive.IPrimitiveCtx) internaldto.ExecutorOutput {
+		mv.stats = execstats.FromContext(pc)
 		requiredDepedencyKey, requiredKeyExists := mv.bldrInput.GetRequiredDataRequestKey()
 		// lateBindingData := map[int]map[string]any{}
 
@@ -1450,6 +1494,7 @@ func (mv *monoValentExecution) GetExecutor() (func(pc primitive.IPrimitiveCtx) i
 		mr := prov.InferMaxResultsElement(m)
 		polyHandler := NewStandardPolyHandler(
 			mv.handlerCtx,
+			mv.stats,
 		)
 		protocolType, protocolTypeErr := provider.GetProtocolType()
 		if protocolTypeErr != nil {
@@ -1492,6 +1537,8 @@ func (mv *monoValentExecution) GetExecutor() (func(pc primitive.IPrimitiveCtx) i
 			if exErr != nil {
 				return internaldto.NewErroneousExecutorOutput(exErr)
 			}
+			// The output of a local template is its single page.
+			mv.stats.RecordPage()
 			var backendMessages []string
 			stdOut, stdOutExists := resp.GetStdOut()
 			var stdoutStr string
@@ -1565,7 +1612,7 @@ func (mv *monoValentExecution) GetExecutor() (func(pc primitive.IPrimitiveCtx) i
 				nil,
 			)
 		case client.HTTP:
-			invRes, invErr := mv.invoker.Invoke(context.Background(), providerinvoker.Request{
+			invRes, invErr := mv.invoker.Invoke(polyHandler.GetContext(), providerinvoker.Request{
 				Payload: formulation.NewPayload(
 					armouryGenerator,
 					provider,
@@ -1949,6 +1996,14 @@ func (pr *asyncHTTPMonitorPrimitive) WithDebugName(_ string) primitive.IPrimitiv
 	return pr
 }
 
+func (pr *asyncHTTPMonitorPrimitive) GetDescriptor() (primitive.Descriptor, bool) {
+	return nil, false
+}
+
+func (pr *asyncHTTPMonitorPrimitive) WithDescriptor(_ primitive.Descriptor) primitive.IPrimitive {
+	return pr
+}
+
 func (pr *asyncHTTPMonitorPrimitive) SetUndoLog(_ binlog.LogEntry) {
 }
 
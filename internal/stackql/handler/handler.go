@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -27,6 +28,7 @@ import (
 	"github.com/stackql/stackql/internal/stackql/garbagecollector"
 	"github.com/stackql/stackql/internal/stackql/intrinsic"
 	"github.com/stackql/stackql/internal/stackql/kstore"
+	"github.com/stackql/stackql/internal/stackql/plancache"
 	"github.com/stackql/stackql/internal/stackql/provider"
 	"github.com/stackql/stackql/internal/stackql/sql_system"
 	"github.com/stackql/stackql/internal/stackql/tablenamespace"
@@ -62,11 +64,13 @@ type HandlerContext interface { //nolint:revive // don't mind stuttering this on
 	GetControlAttributes() sqlcontrol.ControlAttributes
 	GetCurrentProvider() string
 	GetAuthContexts() dto.AuthContexts
+	SetAuthContexts(dto.AuthContexts)
 	GetRegistry() formulation.RegistryAPI
 	GetErrorPresentation() string
 	GetOutfile() io.Writer
 	GetOutErrFile() io.Writer
 	GetLRUCache() *lrucache.LRUCache
+	GetPlanTemplateCache() plancache.Cache
 	GetSQLDataSource(name string) (sql_datasource.SQLDataSource, bool)
 	GetSQLEngine() sqlengine.SQLEngine
 	GetSQLSystem() sql_system.SQLSystem
@@ -84,7 +88,11 @@ type HandlerContext interface { //nolint:revive // don't mind stuttering this on
 	SetQuery(string)
 	SetRawQuery(string)
 	//
+	SetOutfile(io.Writer)
 	SetOutErrFile(io.Writer)
+	// SetOutputFormat changes the output format of the runtime context,
+	// as the shell's expanded display does.
+	SetOutputFormat(string)
 	//
 	GetTxnCoordinatorCtx() txn_context.ITransactionCoordinatorContext
 
@@ -94,6 +102,9 @@ type HandlerContext interface { //nolint:revive // don't mind stuttering this on
 
 	GetRollbackType() constants.RollbackType
 	UpdateRollbackType(rollbackTypeStr string) error
+	// Compensating rollback is layered atop eager rollback;
+	// see txn_context.CompensatingRollbackStr.
+	IsCompensatingRollback() bool
 
 	GetTSM() (tsm.TSM, bool)
 	SetTSM(tsm.TSM)
@@ -114,6 +125,16 @@ type HandlerContext interface { //nolint:revive // don't mind stuttering this on
 	IsStrictUpstreamErrors() bool
 	SetStrictUpstreamErrors(bool)
 
+	// GetContext returns the context of the query in flight, which
+	// upstream requests and local queries abandon once it is done.
+	GetContext() context.Context
+	SetContext(ctx context.Context)
+
+	// GetBindParams returns the typed values bound to the $n or ?
+	// placeholders of the statement in flight, nil where none are bound.
+	GetBindParams() []any
+	SetBindParams(params []any)
+
 	// for testing only
 	SetDefaultHTTPClient(client *http.Client)
 }
@@ -137,6 +158,7 @@ type standardHandlerContext struct {
 	outfile             io.Writer
 	outErrFile          io.Writer
 	lRUCache            *lrucache.LRUCache
+	planTemplateCache   plancache.Cache
 	sqlEngine           sqlengine.SQLEngine
 	sqlSystem           sql_system.SQLSystem
 	persistenceSystem   formulation.PersistenceSystem
@@ -150,12 +172,42 @@ type standardHandlerContext struct {
 	txnCoordinatorCtx   txn_context.ITransactionCoordinatorContext
 	typCfg              typing.Config
 	sessionContext      dto.SessionContext
+	isCompensating      *bool // shared across clones, as is sessionContext
 	walInstance         tsm.TSM
 	exportNamespace     string
 	stackqlSemver       string
 	defaultHTTPClient   *http.Client
 	// strictUpstreamErrors is documented on the HandlerContext interface.
 	strictUpstreamErrors bool
+	ctx                  context.Context //nolint:containedctx // the query in flight
+	bindParams           []any
+}
+
+func (hc *standardHandlerContext) GetContext() context.Context {
+	hc.sessionCtxMutex.Lock()
+	defer hc.sessionCtxMutex.Unlock()
+	if hc.ctx == nil {
+		return context.Background()
+	}
+	return hc.ctx
+}
+
+func (hc *standardHandlerContext) SetContext(ctx context.Context) {
+	hc.sessionCtxMutex.Lock()
+	defer hc.sessionCtxMutex.Unlock()
+	hc.ctx = ctx
+}
+
+func (hc *standardHandlerContext) GetBindParams() []any {
+	hc.sessionCtxMutex.Lock()
+	defer hc.sessionCtxMutex.Unlock()
+	return hc.bindParams
+}
+
+func (hc *standardHandlerContext) SetBindParams(params []any) {
+	hc.sessionCtxMutex.Lock()
+	defer hc.sessionCtxMutex.Unlock()
+	hc.bindParams = params
 }
 
 // for testing only.
@@ -237,9 +289,31 @@ func (hc *standardHandlerContext) GetAuthContexts() dto.AuthContexts {
 	return hc.authContexts
 }
 
+// SetAuthContexts replaces the auth contexts of this context only; clones
+// taken earlier keep theirs.
+func (hc *standardHandlerContext) SetAuthContexts(authContexts dto.AuthContexts) {
+	hc.authMapMutex.Lock()
+	defer hc.authMapMutex.Unlock()
+	hc.authContexts = authContexts
+}
+
 func (hc *standardHandlerContext) GetRegistry() formulation.RegistryAPI { return hc.registry }
 func (hc *standardHandlerContext) GetErrorPresentation() string         { return hc.errorPresentation }
-func (hc *standardHandlerContext) GetOutfile() io.Writer                { return hc.outfile }
+func (hc *standardHandlerContext) GetOutfile() io.Writer {
+	defer hc.sessionCtxMutex.Unlock()
+	hc.sessionCtxMutex.Lock()
+	return hc.outfile
+}
+func (hc *standardHandlerContext) SetOutfile(w io.Writer) {
+	defer hc.sessionCtxMutex.Unlock()
+	hc.sessionCtxMutex.Lock()
+	hc.outfile = w
+}
+func (hc *standardHandlerContext) SetOutputFormat(format string) {
+	defer hc.sessionCtxMutex.Unlock()
+	hc.sessionCtxMutex.Lock()
+	hc.runtimeContext.OutputFormat = format
+}
 func (hc *standardHandlerContext) GetOutErrFile() io.Writer {
 	defer hc.sessionCtxMutex.Unlock()
 	hc.sessionCtxMutex.Lock()
@@ -256,6 +330,9 @@ func (hc *standardHandlerContext) GetSQLSystem() sql_system.SQLSystem { return h
 func (hc *standardHandlerContext) GetPersistenceSystem() formulation.PersistenceSystem {
 	return hc.persistenceSystem
 }
+func (hc *standardHandlerContext) GetPlanTemplateCache() plancache.Cache {
+	return hc.planTemplateCache
+}
 func (hc *standardHandlerContext) GetGarbageCollector() garbagecollector.GarbageCollector {
 	return hc.garbageCollector
 }
@@ -476,9 +553,20 @@ func (hc *standardHandlerContext) GetIsolationLevel() constants.IsolationLevel {
 func (hc *standardHandlerContext) UpdateRollbackType(rollbackTypeStr string) error {
 	hc.sessionCtxMutex.Lock()
 	defer hc.sessionCtxMutex.Unlock()
+	if txn_context.IsCompensatingRollbackStr(rollbackTypeStr) {
+		*hc.isCompensating = true
+		return nil
+	}
+	*hc.isCompensating = false
 	return hc.sessionContext.UpdateIsolationLevel(rollbackTypeStr)
 }
 
+func (hc *standardHandlerContext) IsCompensatingRollback() bool {
+	hc.sessionCtxMutex.Lock()
+	defer hc.sessionCtxMutex.Unlock()
+	return *hc.isCompensating
+}
+
 func (hc *standardHandlerContext) GetRollbackType() constants.RollbackType {
 	hc.sessionCtxMutex.Lock()
 	defer hc.sessionCtxMutex.Unlock()
@@ -576,6 +664,7 @@ func (hc *standardHandlerContext) Clone() HandlerContext {
 		controlAttributes:    hc.controlAttributes,
 		errorPresentation:    hc.errorPresentation,
 		lRUCache:             hc.lRUCache,
+		planTemplateCache:    hc.planTemplateCache,
 		sqlEngine:            hc.sqlEngine,
 		sqlDataSources:       hc.sqlDataSources,
 		sqlSystem:            hc.sqlSystem,
@@ -591,10 +680,13 @@ func (hc *standardHandlerContext) Clone() HandlerContext {
 		txnCoordinatorCtx:    hc.txnCoordinatorCtx,
 		typCfg:               hc.typCfg,
 		sessionContext:       hc.sessionContext,
+		isCompensating:       hc.isCompensating,
 		exportNamespace:      hc.exportNamespace,
 		stackqlSemver:        hc.stackqlSemver,
 		defaultHTTPClient:    hc.defaultHTTPClient,
 		strictUpstreamErrors: hc.strictUpstreamErrors,
+		ctx:                  hc.ctx,
+		bindParams:           hc.bindParams,
 	}
 	return &rv
 }
@@ -644,6 +736,8 @@ func NewHandlerCtx(
 			return nil, fileErr
 		}
 	}
+	txnCoordinatorCtx := inputBundle.GetTxnCoordinatorContext()
+	isCompensatingRollback := txnCoordinatorCtx != nil && txnCoordinatorCtx.IsCompensatingRollback()
 	rv := standardHandlerContext{
 		authMapMutex:        &sync.Mutex{},
 		sessionCtxMutex:     &sync.Mutex{},
@@ -656,6 +750,7 @@ func NewHandlerCtx(
 		controlAttributes:   controlAttributes,
 		errorPresentation:   runtimeCtx.ErrorPresentation,
 		lRUCache:            lruCache,
+		planTemplateCache:   plancache.NewCache(runtimeCtx.QueryCacheSize),
 		sqlEngine:           sqlEngine,
 		sqlDataSources:      inputBundle.GetSQLDataSources(),
 		sqlSystem:           inputBundle.GetSQLSystem(),
@@ -667,9 +762,10 @@ func NewHandlerCtx(
 		formatter:           inputBundle.GetSQLSystem().GetASTFormatter(),
 		pgInternalRouter:    inputBundle.GetDBMSInternalRouter(),
 		currentProvider:     runtimeCtx.ProviderStr,
-		txnCoordinatorCtx:   inputBundle.GetTxnCoordinatorContext(),
+		txnCoordinatorCtx:   txnCoordinatorCtx,
 		typCfg:              inputBundle.GetTypingConfig(),
 		sessionContext:      inputBundle.GetSessionContext(),
+		isCompensating:      &isCompensatingRollback,
 		exportNamespace:     runtimeCtx.ExportAlias,
 		outfile:             outWriter,
 		outErrFile:          outErrWriter,
@@ -45,6 +45,10 @@ type BuilderInput interface {
 	GetRequiredDataRequestKey() (string, bool)
 	SetPushdownLimit(limit int)
 	GetPushdownLimit() (int, bool)
+	SetDataflowComponent(component int)
+	GetDataflowComponent() (int, bool)
+	IsDataflowDependent() bool
+	SetIsDataflowDependent(isDependent bool)
 	SetDependencyNode(dependencyNode primitivegraph.PrimitiveNode)
 	SetParserNode(node sqlparser.SQLNode)
 	SetParamMap(paramMap map[int]map[string]interface{})
@@ -90,6 +94,9 @@ type builderInput struct {
 	requiredDataRequestKey  string
 	pushdownLimit           int
 	pushdownLimitSet        bool
+	dataflowComponent       int
+	dataflowComponentSet    bool
+	isDataflowDependent     bool
 }
 
 func NewBuilderInput(
@@ -121,6 +128,25 @@ func (bi *builderInput) GetPushdownLimit() (int, bool) {
 	return bi.pushdownLimit, bi.pushdownLimitSet
 }
 
+// SetDataflowComponent records the data flow weakly connected component
+// to which the acquire belongs.  It is informational only (EXPLAIN).
+func (bi *builderInput) SetDataflowComponent(component int) {
+	bi.dataflowComponent = component
+	bi.dataflowComponentSet = true
+}
+
+func (bi *builderInput) GetDataflowComponent() (int, bool) {
+	return bi.dataflowComponent, bi.dataflowComponentSet
+}
+
+func (bi *builderInput) IsDataflowDependent() bool {
+	return bi.isDataflowDependent
+}
+
+func (bi *builderInput) SetIsDataflowDependent(isDependent bool) {
+	bi.isDataflowDependent = isDependent
+}
+
 func (bi *builderInput) SetRequiredDataRequestKey(key string) {
 	bi.requiredDataRequestKey = key
 }
@@ -317,5 +343,8 @@ func (bi *builderInput) Clone() BuilderInput {
 		requiredDataRequestKey: bi.requiredDataRequestKey,
 		pushdownLimit:          bi.pushdownLimit,
 		pushdownLimitSet:       bi.pushdownLimitSet,
+		dataflowComponent:      bi.dataflowComponent,
+		dataflowComponentSet:   bi.dataflowComponentSet,
+		isDataflowDependent:    bi.isDataflowDependent,
 	}
 }
@@ -4,10 +4,13 @@ import (
 	"io"
 
 	"github.com/stackql/any-sdk/pkg/dto"
+	"github.com/stackql/stackql/internal/stackql/execstats"
 )
 
 var (
 	_ BasicPrimitiveContext = &standardBasicPrimitiveContext{}
+	_ BasicPrimitiveContext = &analyzePrimitiveContext{}
+	_ execstats.Carrier     = &nodeAnalyzePrimitiveContext{}
 )
 
 type BasicPrimitiveContext interface {
@@ -45,3 +48,40 @@ func (bpp *standardBasicPrimitiveContext) GetWriter() io.Writer {
 func (bpp *standardBasicPrimitiveContext) GetErrWriter() io.Writer {
 	return bpp.errWriter
 }
+
+// NewAnalyzePrimitiveContext decorates base such that a graph
+// executed with it records per node statistics in collector,
+// as required by EXPLAIN ANALYZE.
+func NewAnalyzePrimitiveContext(
+	base BasicPrimitiveContext,
+	collector execstats.Collector,
+) BasicPrimitiveContext {
+	return &analyzePrimitiveContext{
+		BasicPrimitiveContext: base,
+		collector:             collector,
+	}
+}
+
+type analyzePrimitiveContext struct {
+	BasicPrimitiveContext
+	collector execstats.Collector
+}
+
+// ForNode returns the context for a single node.  The node context
+// deliberately does not itself support ForNode(), so that any nested
+// graph aggregates its statistics into those of the enclosing node.
+func (apc *analyzePrimitiveContext) ForNode(id int64) BasicPrimitiveContext {
+	return &nodeAnalyzePrimitiveContext{
+		BasicPrimitiveContext: apc.BasicPrimitiveContext,
+		stats:                 apc.collector.ForNode(id),
+	}
+}
+
+type nodeAnalyzePrimitiveContext struct {
+	BasicPrimitiveContext
+	stats execstats.Stats
+}
+
+func (npc *nodeAnalyzePrimitiveContext) GetStats() execstats.Stats {
+	return npc.stats
+}
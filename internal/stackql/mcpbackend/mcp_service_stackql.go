@@ -245,6 +245,8 @@ type stackqlMCPService struct {
 	// envFile is the --env.file dotenv path re-sourced by reload_credentials
 	// (issue #688); empty means none configured.
 	envFile string
+	// relationShapes memoises the column shapes of stored relations.
+	relationShapes relationShapeCache
 }
 
 func NewStackqlMCPBackendService(
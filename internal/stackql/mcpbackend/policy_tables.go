@@ -0,0 +1,26 @@
+package mcpbackend
+
+import (
+	"github.com/stackql/stackql-parser/go/vt/sqlparser"
+	"github.com/stackql/stackql/internal/stackql/astanalysis/annotatedast"
+	"github.com/stackql/stackql/internal/stackql/astvisit"
+	"github.com/stackql/stackql/pkg/mcp_server"
+)
+
+var (
+	_ mcp_server.PolicyTableExtractor = (*stackqlMCPService)(nil)
+)
+
+// ExtractPolicyTables supplies the MCP policy engine with the
+// tables cited by stmt, as extracted by the planner's own visitor.
+func (b *stackqlMCPService) ExtractPolicyTables(stmt sqlparser.Statement) (sqlparser.TableExprs, error) {
+	annotatedAST, err := annotatedast.NewAnnotatedAst(nil, stmt)
+	if err != nil {
+		return nil, err
+	}
+	tVis := astvisit.NewTableExtractAstVisitor(annotatedAST)
+	if err := tVis.Visit(stmt); err != nil {
+		return nil, err
+	}
+	return tVis.GetTables(), nil
+}
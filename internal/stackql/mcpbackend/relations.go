@@ -0,0 +1,79 @@
+package mcpbackend
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/lib/pq/oid"
+	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
+	"github.com/stackql/stackql/internal/stackql/queryshape"
+	"github.com/stackql/stackql/pkg/mcp_server"
+	"github.com/stackql/stackql/pkg/mcp_server/dto"
+)
+
+var (
+	_ mcp_server.RelationLister = (*stackqlMCPService)(nil)
+)
+
+// relationShapeCache memoises inferred columns by relation kind, name and
+// DDL, so that polling does not re-plan unchanged views.
+type relationShapeCache struct {
+	mu      sync.Mutex
+	columns map[string][]dto.RelationColumnDTO
+}
+
+func relationKind(r internaldto.RelationDTO) string {
+	switch {
+	case r.IsTable():
+		return "table"
+	case r.IsMaterialized():
+		return "materialized_view"
+	default:
+		return "view"
+	}
+}
+
+// ListRelations supplies the MCP server with the stored relations of the
+// SQL system, each with its column shape as inferred by queryshape.
+func (b *stackqlMCPService) ListRelations(_ context.Context) ([]dto.StoredRelationDTO, error) {
+	relations, err := b.handlerCtx.GetSQLSystem().ListRelations()
+	if err != nil {
+		return nil, err
+	}
+	inferrer := queryshape.NewInferrer(b.handlerCtx)
+	b.relationShapes.mu.Lock()
+	defer b.relationShapes.mu.Unlock()
+	live := make(map[string][]dto.RelationColumnDTO, len(relations))
+	rv := make([]dto.StoredRelationDTO, 0, len(relations))
+	for _, r := range relations {
+		kind := relationKind(r)
+		key := strings.Join([]string{kind, r.GetName(), r.GetRawQuery()}, "\x00")
+		columns, ok := b.relationShapes.columns[key]
+		if !ok {
+			columns = inferRelationColumns(inferrer, r.GetName())
+		}
+		live[key] = columns
+		rv = append(rv, dto.StoredRelationDTO{
+			Name:    r.GetName(),
+			Kind:    kind,
+			DDL:     r.GetRawQuery(),
+			Columns: columns,
+		})
+	}
+	b.relationShapes.columns = live
+	return rv, nil
+}
+
+func inferRelationColumns(inferrer queryshape.Inferrer, name string) []dto.RelationColumnDTO {
+	cols := inferrer.InferResultColumns(fmt.Sprintf("SELECT * FROM %s", name))
+	rv := make([]dto.RelationColumnDTO, 0, len(cols))
+	for _, col := range cols {
+		rv = append(rv, dto.RelationColumnDTO{
+			Name: col.GetName(),
+			Type: strings.ToLower(oid.TypeName[oid.Oid(col.GetObjectID())]),
+		})
+	}
+	return rv
+}
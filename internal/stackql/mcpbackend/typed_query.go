@@ -0,0 +1,199 @@
+package mcpbackend
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jackc/pgtype"
+	"github.com/lib/pq/oid"
+	"github.com/stackql/psql-wire/pkg/sqldata"
+	"github.com/stackql/stackql/internal/stackql/psqlwire"
+	"github.com/stackql/stackql/pkg/mcp_server"
+	"github.com/stackql/stackql/pkg/mcp_server/dto"
+)
+
+var (
+	_ mcp_server.TypedQueryRunner = (*stackqlMCPService)(nil)
+	_ mcp_server.TypedQueryRunner = (*stackqlMCPReverseProxyService)(nil)
+)
+
+// columnTypeForSQLType maps a postgres type name onto an MCP column type.
+func columnTypeForSQLType(name string) string {
+	switch strings.ToLower(name) {
+	case "int2", "int4", "int8", "oid":
+		return mcp_server.ColumnTypeInt64
+	case "float4", "float8", "numeric":
+		return mcp_server.ColumnTypeFloat64
+	case "bool":
+		return mcp_server.ColumnTypeBool
+	case "timestamp", "timestamptz":
+		return mcp_server.ColumnTypeTimestamp
+	case "date":
+		return mcp_server.ColumnTypeDate
+	case "json", "jsonb":
+		return mcp_server.ColumnTypeJSON
+	case "bytea":
+		return mcp_server.ColumnTypeBytes
+	default:
+		return mcp_server.ColumnTypeString
+	}
+}
+
+func columnInfos(colz []sqldata.ISQLColumn) []mcp_server.ColumnInfo {
+	rv := make([]mcp_server.ColumnInfo, 0, len(colz))
+	for _, col := range colz {
+		rv = append(rv, mcp_server.NewColumnInfo(
+			col.GetName(),
+			columnTypeForSQLType(oid.TypeName[oid.Oid(col.GetObjectID())]),
+			true,
+		))
+	}
+	return rv
+}
+
+// RunQueryTyped runs a SELECT, returning each value in its postgres text
+// encoding alongside the column types taken from the result OIDs.
+func (b *stackqlMCPService) RunQueryTyped(_ context.Context, input dto.QueryJSONInput) (mcp_server.QueryResult, error) {
+	q, qErr := b.interrogator.GetQueryJSON(input)
+	if qErr != nil {
+		return nil, qErr
+	}
+	r, ok := b.applyQuery(q)
+	if !ok {
+		return nil, fmt.Errorf("failed to extract query results")
+	}
+	ci := pgtype.NewConnInfo()
+	var columns []mcp_server.ColumnInfo
+	rows := [][]interface{}{}
+	for _, resp := range r {
+		if respErr := resp.GetError(); respErr != nil {
+			return nil, classifyBackendError(respErr)
+		}
+		sqlRowStream := resp.GetSQLResult()
+		if sqlRowStream == nil {
+			continue
+		}
+		var drainErr error
+		columns, rows, drainErr = drainTypedSQLRowStream(sqlRowStream, ci, columns, rows, input.RowLimit)
+		if drainErr != nil {
+			return nil, fmt.Errorf("failed to extract query results: %w", drainErr)
+		}
+	}
+	if columns == nil {
+		columns = []mcp_server.ColumnInfo{}
+	}
+	return mcp_server.NewQueryResult(columns, rows, 0, 0), nil
+}
+
+// drainTypedSQLRowStream is the typed counterpart of drainSQLRowStream.
+// The columns of the first result set describe the result.
+func drainTypedSQLRowStream(
+	stream sqldata.ISQLResultStream,
+	ci *pgtype.ConnInfo,
+	columns []mcp_server.ColumnInfo,
+	rows [][]interface{},
+	rowLimit int,
+) ([]mcp_server.ColumnInfo, [][]interface{}, error) {
+	for {
+		res, err := stream.Read()
+		isEOF := errors.Is(err, io.EOF)
+		if err != nil && !isEOF {
+			return columns, rows, err
+		}
+		if res != nil {
+			colz := res.GetColumns()
+			if columns == nil {
+				columns = columnInfos(colz)
+			}
+			for _, row := range res.GetRows() {
+				if rowLimit > 0 && len(rows) >= rowLimit {
+					return columns, rows, nil
+				}
+				values, decodeErr := decodeTypedRow(colz, row, ci)
+				if decodeErr != nil {
+					return columns, rows, decodeErr
+				}
+				if values != nil {
+					rows = append(rows, values)
+				}
+			}
+		}
+		if isEOF || res == nil {
+			return columns, rows, nil
+		}
+	}
+}
+
+// decodeTypedRow renders a row's values in their text encoding, nil for
+// SQL NULL; the MCP server parses them according to the column types.
+func decodeTypedRow(colz []sqldata.ISQLColumn, row sqldata.ISQLRow, ci *pgtype.ConnInfo) ([]interface{}, error) {
+	rawRow := row.GetRowDataNaive()
+	if len(rawRow) != len(colz) {
+		if len(rawRow) == 0 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("row length != column count (%d != %d)", len(rawRow), len(colz))
+	}
+	values := make([]interface{}, len(colz))
+	for i, col := range colz {
+		b, err := psqlwire.ExtractRowElement(col, rawRow[i], ci)
+		if err != nil {
+			return nil, err
+		}
+		if b != nil {
+			values[i] = string(b)
+		}
+	}
+	return values, nil
+}
+
+// RunQueryTyped runs a SELECT against the backing server, typing the
+// columns by their database type names.
+func (b *stackqlMCPReverseProxyService) RunQueryTyped(
+	ctx context.Context, input dto.QueryJSONInput,
+) (mcp_server.QueryResult, error) {
+	r, sqlErr := b.db.QueryContext(ctx, input.SQL)
+	if sqlErr != nil {
+		return nil, sqlErr
+	}
+	defer r.Close() //nolint:errcheck // read only
+	columnTypes, err := r.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	columns := make([]mcp_server.ColumnInfo, 0, len(columnTypes))
+	for _, ct := range columnTypes {
+		nullable, ok := ct.Nullable()
+		columns = append(columns, mcp_server.NewColumnInfo(
+			ct.Name(), columnTypeForSQLType(ct.DatabaseTypeName()), nullable || !ok,
+		))
+	}
+	rows := [][]interface{}{}
+	for r.Next() {
+		if input.RowLimit > 0 && len(rows) >= input.RowLimit {
+			break
+		}
+		scanArgs := make([]interface{}, len(columns))
+		for i := range scanArgs {
+			scanArgs[i] = new(sql.NullString)
+		}
+		if scanErr := r.Scan(scanArgs...); scanErr != nil {
+			return nil, scanErr
+		}
+		values := make([]interface{}, len(columns))
+		for i, arg := range scanArgs {
+			if ns := arg.(*sql.NullString); ns.Valid { //nolint:errcheck,forcetypeassert // scanned above
+				values[i] = ns.String
+			}
+		}
+		rows = append(rows, values)
+	}
+	if rowsErr := r.Err(); rowsErr != nil {
+		return nil, rowsErr
+	}
+	return mcp_server.NewQueryResult(columns, rows, 0, 0), nil
+}
@@ -0,0 +1,29 @@
+package mcpbackend //nolint:testpackage // exercise unexported type mapping
+
+import (
+	"testing"
+
+	"github.com/lib/pq/oid"
+	"github.com/stackql/stackql/pkg/mcp_server"
+)
+
+func TestColumnTypeForSQLType_OIDNames(t *testing.T) {
+	cases := map[oid.Oid]string{
+		oid.T_int4:        mcp_server.ColumnTypeInt64,
+		oid.T_int8:        mcp_server.ColumnTypeInt64,
+		oid.T_float8:      mcp_server.ColumnTypeFloat64,
+		oid.T_numeric:     mcp_server.ColumnTypeFloat64,
+		oid.T_bool:        mcp_server.ColumnTypeBool,
+		oid.T_timestamptz: mcp_server.ColumnTypeTimestamp,
+		oid.T_date:        mcp_server.ColumnTypeDate,
+		oid.T_jsonb:       mcp_server.ColumnTypeJSON,
+		oid.T_bytea:       mcp_server.ColumnTypeBytes,
+		oid.T_text:        mcp_server.ColumnTypeString,
+		oid.T_uuid:        mcp_server.ColumnTypeString,
+	}
+	for o, want := range cases {
+		if got := columnTypeForSQLType(oid.TypeName[o]); got != want {
+			t.Errorf("%s: expected %s, got %s", oid.TypeName[o], want, got)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package metacommand
+
+import (
+	"sort"
+	"strings"
+)
+
+// Catalogue lists the names of the provider hierarchy.
+type Catalogue interface {
+	Providers() []string
+	Services(provider string) []string
+	Resources(provider, service string) []string
+}
+
+// Completer completes shell lines, as the readline AutoCompleter does:
+// it returns the suffixes which complete the word before pos, and the
+// length of the part of the word they complete.
+type Completer interface {
+	Do(line []rune, pos int) ([][]rune, int)
+}
+
+type completer struct {
+	catalogue Catalogue
+}
+
+// NewCompleter completes meta command names, and the dotted names of
+// providers, services and resources where a statement or meta command
+// expects one: after FROM, INTO, DESCRIBE and the like, and as the
+// argument of \d and \dt.
+func NewCompleter(catalogue Catalogue) Completer {
+	return &completer{catalogue: catalogue}
+}
+
+// hierarchyKeywords precede a name of the provider hierarchy.
+//
+//nolint:gochecknoglobals // immutable lookup
+var hierarchyKeywords = map[string]struct{}{
+	"from":     {},
+	"join":     {},
+	"into":     {},
+	"update":   {},
+	"describe": {},
+	"extended": {},
+	"in":       {},
+	"exec":     {},
+	Describe:   {},
+	Tables:     {},
+}
+
+func (c *completer) Do(line []rune, pos int) ([][]rune, int) {
+	text := string(line[:pos])
+	fields := strings.Fields(text)
+	isNewWord := text == "" || strings.ContainsAny(text[len(text)-1:], " \t")
+	word := ""
+	if !isNewWord && len(fields) > 0 {
+		word = fields[len(fields)-1]
+		fields = fields[:len(fields)-1]
+	}
+	if len(fields) == 0 {
+		if strings.HasPrefix(word, `\`) {
+			return suffixes(word, Names, " ")
+		}
+		return nil, 0
+	}
+	if _, isHierarchy := hierarchyKeywords[strings.ToLower(fields[len(fields)-1])]; !isHierarchy {
+		return nil, 0
+	}
+	parts := strings.Split(word, ".")
+	last := parts[len(parts)-1]
+	switch len(parts) {
+	case 1:
+		return suffixes(last, c.catalogue.Providers(), "")
+	case 2: //nolint:mnd // provider.service
+		return suffixes(last, c.catalogue.Services(parts[0]), "")
+	case 3: //nolint:mnd // provider.service.resource
+		return suffixes(last, c.catalogue.Resources(parts[0], parts[1]), " ")
+	default:
+		return nil, 0
+	}
+}
+
+// suffixes returns the candidates beginning with prefix, less the prefix
+// and followed by terminator.
+func suffixes(prefix string, candidates []string, terminator string) ([][]rune, int) {
+	sorted := append([]string{}, candidates...)
+	sort.Strings(sorted)
+	var rv [][]rune
+	for _, candidate := range sorted {
+		if strings.HasPrefix(candidate, prefix) {
+			rv = append(rv, []rune(candidate[len(prefix):]+terminator))
+		}
+	}
+	return rv, len([]rune(prefix))
+}
@@ -0,0 +1,183 @@
+// Package metacommand interprets the backslash meta commands of the
+// interactive shell, after psql's: listing the provider hierarchy and
+// stored relations, redirecting output, timing, expanded display, running
+// scripts and setting template variables.
+package metacommand
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	Describe          = `\d`
+	Tables            = `\dt`
+	Views             = `\dv`
+	MaterializedViews = `\dm`
+	Output            = `\o`
+	Timing            = `\timing`
+	Expanded          = `\x`
+	Include           = `\i`
+	Set               = `\set`
+	Unset             = `\unset`
+	Help              = `\?`
+	Quit              = `\q`
+)
+
+// Names are the meta commands, in the order of the help text.
+//
+//nolint:gochecknoglobals // fixed list
+var Names = []string{
+	Describe, Tables, Views, MaterializedViews, Output, Timing, Expanded, Include, Set, Unset, Help, Quit,
+}
+
+//nolint:lll // help text
+const HelpText = `Meta commands:
+  \d [provider[.service[.resource]]]  list providers, the services of a provider or the resources of a service; describe a resource
+  \dt provider.service[.pattern]      list the resources of a service
+  \dv [pattern]                       list views
+  \dm [pattern]                       list materialized views
+  \o [file]                           send query output to file, or back to stdout
+  \timing [on|off]                    toggle the timing of statements
+  \x [on|off]                         toggle expanded display
+  \i file                             run the statements and meta commands of file
+  \set [name [value]]                 set a template variable, read as {{ .name }}; list them without arguments
+  \unset name                         unset a template variable
+  \?                                  show this message
+  \q                                  leave the shell
+Patterns accept * as a wildcard.
+`
+
+// Command is a parsed meta command.
+type Command struct {
+	Name string
+	Args []string
+}
+
+// Arg returns the i-th argument, empty where absent.
+func (c Command) Arg(i int) string {
+	if i < len(c.Args) {
+		return c.Args[i]
+	}
+	return ""
+}
+
+// Parse reads a meta command from line, reporting false where line is not
+// one.  Arguments are separated by whitespace, and may be single quoted.
+func Parse(line string) (Command, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, `\`) {
+		return Command{}, false
+	}
+	name, rest, _ := strings.Cut(line, " ")
+	rv := Command{Name: name}
+	rest = strings.TrimSpace(rest)
+	for rest != "" {
+		var arg string
+		if strings.HasPrefix(rest, "'") {
+			end := strings.Index(rest[1:], "'")
+			if end < 0 {
+				arg, rest = rest[1:], ""
+			} else {
+				arg, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			arg, rest, _ = strings.Cut(rest, " ")
+		}
+		rv.Args = append(rv.Args, arg)
+		rest = strings.TrimSpace(rest)
+	}
+	return rv, true
+}
+
+// ParseToggle reads the optional on|off argument of a toggling command,
+// returning the new state.
+func ParseToggle(c Command, current bool) (bool, error) {
+	switch strings.ToLower(c.Arg(0)) {
+	case "":
+		return !current, nil
+	case "on", "true", "1":
+		return true, nil
+	case "off", "false", "0":
+		return false, nil
+	default:
+		return current, fmt.Errorf(`%s: unrecognised value "%s"; expected on or off`, c.Name, c.Arg(0))
+	}
+}
+
+//nolint:gochecknoglobals // immutable pattern
+var patternPartRegexp = regexp.MustCompile(`^[A-Za-z0-9_$*-]+$`)
+
+// ListingQuery returns the statement which lists what c asks for.
+func ListingQuery(c Command) (string, error) {
+	var parts []string
+	if pattern := c.Arg(0); pattern != "" {
+		parts = strings.Split(pattern, ".")
+		for _, part := range parts {
+			if !patternPartRegexp.MatchString(part) {
+				return "", fmt.Errorf("%s: invalid pattern '%s'", c.Name, pattern)
+			}
+		}
+	}
+	switch c.Name {
+	case Describe:
+		return describeQuery(parts)
+	case Tables:
+		if len(parts) < 2 || len(parts) > 3 { //nolint:mnd // provider.service[.pattern]
+			return "", fmt.Errorf(`%s: expected provider.service[.pattern]`, c.Name)
+		}
+		return showQuery("SHOW RESOURCES IN "+parts[0]+"."+parts[1], parts[2:]), nil
+	case Views, MaterializedViews:
+		kind := "v"
+		if c.Name == MaterializedViews {
+			kind = "m"
+		}
+		if len(parts) > 1 {
+			return "", fmt.Errorf("%s: expected a name pattern", c.Name)
+		}
+		where := fmt.Sprintf("relkind = '%s'", kind)
+		if len(parts) == 1 {
+			where += fmt.Sprintf(" AND relname LIKE '%s'", likePattern(parts[0]))
+		}
+		return fmt.Sprintf("SELECT relname AS name FROM pg_catalog.pg_class WHERE %s ORDER BY relname;", where), nil
+	default:
+		return "", fmt.Errorf("%s is not a listing command", c.Name)
+	}
+}
+
+// describeQuery walks down the provider hierarchy: a last part with a
+// wildcard filters the level it names, else the level below is listed.
+func describeQuery(parts []string) (string, error) {
+	last := len(parts) - 1
+	isPattern := last >= 0 && strings.Contains(parts[last], "*")
+	switch {
+	case len(parts) == 0:
+		return "SHOW PROVIDERS;", nil
+	case len(parts) == 1 && isPattern:
+		return showQuery("SHOW PROVIDERS", parts), nil
+	case len(parts) == 1:
+		return fmt.Sprintf("SHOW SERVICES IN %s;", parts[0]), nil
+	case len(parts) == 2 && isPattern: //nolint:mnd // provider.service
+		return showQuery("SHOW SERVICES IN "+parts[0], parts[1:]), nil
+	case len(parts) == 2: //nolint:mnd // provider.service
+		return fmt.Sprintf("SHOW RESOURCES IN %s.%s;", parts[0], parts[1]), nil
+	case len(parts) == 3 && isPattern: //nolint:mnd // provider.service.resource
+		return showQuery("SHOW RESOURCES IN "+parts[0]+"."+parts[1], parts[2:]), nil
+	case len(parts) == 3: //nolint:mnd // provider.service.resource
+		return fmt.Sprintf("DESCRIBE %s;", strings.Join(parts, ".")), nil
+	default:
+		return "", fmt.Errorf(`%s: expected provider[.service[.resource]]`, Describe)
+	}
+}
+
+func showQuery(show string, pattern []string) string {
+	if len(pattern) == 0 {
+		return show + ";"
+	}
+	return fmt.Sprintf("%s LIKE '%s';", show, likePattern(pattern[0]))
+}
+
+func likePattern(pattern string) string {
+	return strings.ReplaceAll(pattern, "*", "%")
+}
@@ -0,0 +1,118 @@
+package metacommand_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stackql/stackql/internal/stackql/metacommand"
+)
+
+func TestParse(t *testing.T) {
+	if _, isMeta := metacommand.Parse("select 1;"); isMeta {
+		t.Fatalf("expected a statement not to parse as a meta command")
+	}
+	c, isMeta := metacommand.Parse(`  \set region 'us east 1'  `)
+	if !isMeta || c.Name != metacommand.Set || len(c.Args) != 2 || c.Arg(1) != "us east 1" || c.Arg(2) != "" {
+		t.Fatalf("unexpected command %+v", c)
+	}
+	for arg, expected := range map[string]bool{"": false, "on": true, "OFF": false} {
+		state, err := metacommand.ParseToggle(metacommand.Command{Name: metacommand.Timing, Args: []string{arg}}, true)
+		if err != nil || state != expected {
+			t.Errorf("%q: expected %v, got %v %v", arg, expected, state, err)
+		}
+	}
+	if _, err := metacommand.ParseToggle(metacommand.Command{Name: metacommand.Expanded, Args: []string{"maybe"}}, false); err == nil {
+		t.Errorf("expected an unrecognised toggle to be refused")
+	}
+}
+
+func TestListingQuery(t *testing.T) {
+	for line, expected := range map[string]string{
+		`\d`:                          "SHOW PROVIDERS;",
+		`\d goo*`:                     "SHOW PROVIDERS LIKE 'goo%';",
+		`\d google`:                   "SHOW SERVICES IN google;",
+		`\d google.comp*`:             "SHOW SERVICES IN google LIKE 'comp%';",
+		`\d google.compute`:           "SHOW RESOURCES IN google.compute;",
+		`\d google.compute.inst*`:     "SHOW RESOURCES IN google.compute LIKE 'inst%';",
+		`\d google.compute.instances`: "DESCRIBE google.compute.instances;",
+		`\dt google.compute`:          "SHOW RESOURCES IN google.compute;",
+		`\dt google.compute.*disk*`:   "SHOW RESOURCES IN google.compute LIKE '%disk%';",
+		`\dv`:                         "SELECT relname AS name FROM pg_catalog.pg_class WHERE relkind = 'v' ORDER BY relname;",
+		`\dm mv_*`:                    "SELECT relname AS name FROM pg_catalog.pg_class WHERE relkind = 'm' AND relname LIKE 'mv_%' ORDER BY relname;",
+	} {
+		c, _ := metacommand.Parse(line)
+		query, err := metacommand.ListingQuery(c)
+		if err != nil || query != expected {
+			t.Errorf("%s: expected %s, got %s %v", line, expected, query, err)
+		}
+	}
+	for _, line := range []string{`\dt`, `\dt google`, `\d a.b.c.d`, `\d google';drop`, `\dv a.b`, `\o`} {
+		c, _ := metacommand.Parse(line)
+		if query, err := metacommand.ListingQuery(c); err == nil {
+			t.Errorf("%s: expected an error, got %s", line, query)
+		}
+	}
+}
+
+func TestVars(t *testing.T) {
+	v := metacommand.NewVars()
+	if err := v.Set("region", "us-east-1"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := v.Set("1bad", "x"); err == nil {
+		t.Errorf("expected a variable not usable in templates to be refused")
+	}
+	_ = v.Set("project", "demo")
+	v.Unset("project")
+	if got := strings.Join(v.VarList([]string{"region=eu-west-1"}), ","); got != "region=eu-west-1,region=us-east-1" {
+		t.Errorf("unexpected var list %s", got)
+	}
+	if values := v.Values(); len(values) != 1 || values["region"] != "us-east-1" {
+		t.Errorf("unexpected values %v", values)
+	}
+}
+
+type testCatalogue struct{}
+
+func (testCatalogue) Providers() []string { return []string{"google", "github", "aws"} }
+
+func (testCatalogue) Services(provider string) []string {
+	if provider == "google" {
+		return []string{"compute", "container"}
+	}
+	return nil
+}
+
+func (testCatalogue) Resources(provider, service string) []string {
+	if provider == "google" && service == "compute" {
+		return []string{"instances", "disks"}
+	}
+	return nil
+}
+
+func TestCompleter(t *testing.T) {
+	c := metacommand.NewCompleter(testCatalogue{})
+	complete := func(line string) string {
+		candidates, _ := c.Do([]rune(line), len([]rune(line)))
+		var rv []string
+		for _, candidate := range candidates {
+			rv = append(rv, string(candidate))
+		}
+		return strings.Join(rv, ",")
+	}
+	for line, expected := range map[string]string{
+		`\ti`:                                    "ming ",
+		`\d g`:                                   "ithub,oogle",
+		"select * from google.co":                "mpute,ntainer",
+		"SELECT name FROM google.compute.in":     "stances ",
+		"describe extended google.compute.":      "disks ,instances ",
+		"SHOW SERVICES IN a":                     "ws",
+		"select * from google.compute.disks whe": "",
+		"select na":                              "",
+		"":                                       "",
+	} {
+		if got := complete(line); got != expected {
+			t.Errorf("%q: expected %q, got %q", line, expected, got)
+		}
+	}
+}
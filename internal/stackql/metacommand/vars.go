@@ -0,0 +1,71 @@
+package metacommand
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+//nolint:gochecknoglobals // immutable pattern
+var varNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Vars are the template variables of a shell session.
+type Vars interface {
+	Set(name, value string) error
+	Unset(name string)
+	Names() []string
+	Get(name string) string
+	Values() map[string]string
+	// VarList appends the variables to base, a --var list, so that they
+	// take precedence over it.
+	VarList(base []string) []string
+}
+
+type vars struct {
+	values map[string]string
+}
+
+func NewVars() Vars {
+	return &vars{values: make(map[string]string)}
+}
+
+func (v *vars) Set(name, value string) error {
+	if !varNameRegexp.MatchString(name) {
+		return fmt.Errorf(`%s: invalid variable name "%s"`, Set, name)
+	}
+	v.values[name] = value
+	return nil
+}
+
+func (v *vars) Unset(name string) {
+	delete(v.values, name)
+}
+
+func (v *vars) Names() []string {
+	rv := make([]string, 0, len(v.values))
+	for k := range v.values {
+		rv = append(rv, k)
+	}
+	sort.Strings(rv)
+	return rv
+}
+
+func (v *vars) Get(name string) string {
+	return v.values[name]
+}
+
+func (v *vars) Values() map[string]string {
+	rv := make(map[string]string, len(v.values))
+	for k, val := range v.values {
+		rv[k] = val
+	}
+	return rv
+}
+
+func (v *vars) VarList(base []string) []string {
+	rv := append([]string{}, base...)
+	for _, k := range v.Names() {
+		rv = append(rv, k+"="+v.values[k])
+	}
+	return rv
+}
@@ -0,0 +1,378 @@
+package output
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/jackc/pgtype"
+	"github.com/stackql/any-sdk/pkg/logging"
+	"github.com/stackql/psql-wire/pkg/sqldata"
+)
+
+const (
+	ParquetStr string = "parquet"
+	ArrowStr   string = "arrow"
+
+	// defaultColumnarBatchSize is the number of rows
+	// accumulated before a record batch (and, for parquet,
+	// a row group) is flushed to the underlying writer.
+	defaultColumnarBatchSize int = 65536
+)
+
+// recordSink is the format specific tail of a columnar writer.
+// It receives fully built record batches and owns the
+// encoding of those batches onto the output stream.
+type recordSink interface {
+	writeRecord(arrow.Record) error
+	close() error
+}
+
+type recordSinkOpener func(*arrow.Schema) (recordSink, error)
+
+// AbstractColumnarWriter streams an ISQLResultStream into
+// typed arrow record batches, flushing every batchSize rows
+// so that memory use is bounded irrespective of result size.
+type AbstractColumnarWriter struct {
+	AbstractTabularWriter
+	writer    io.Writer
+	errWriter io.Writer
+	batchSize int
+}
+
+type ParquetWriter struct {
+	AbstractColumnarWriter
+}
+
+type ArrowWriter struct {
+	AbstractColumnarWriter
+}
+
+func newAbstractColumnarWriter(
+	writer io.Writer,
+	errWriter io.Writer,
+	ci *pgtype.ConnInfo,
+) AbstractColumnarWriter {
+	return AbstractColumnarWriter{
+		AbstractTabularWriter: AbstractTabularWriter{
+			ci: ci,
+		},
+		writer:    writer,
+		errWriter: errWriter,
+		batchSize: defaultColumnarBatchSize,
+	}
+}
+
+type parquetRecordSink struct {
+	fileWriter *pqarrow.FileWriter
+}
+
+func (s *parquetRecordSink) writeRecord(rec arrow.Record) error {
+	// Each call to Write() starts a fresh row group.
+	return s.fileWriter.Write(rec)
+}
+
+func (s *parquetRecordSink) close() error {
+	return s.fileWriter.Close()
+}
+
+type arrowRecordSink struct {
+	ipcWriter *ipc.Writer
+}
+
+func (s *arrowRecordSink) writeRecord(rec arrow.Record) error {
+	return s.ipcWriter.Write(rec)
+}
+
+func (s *arrowRecordSink) close() error {
+	return s.ipcWriter.Close()
+}
+
+func (pw *ParquetWriter) openSink(schema *arrow.Schema) (recordSink, error) {
+	props := parquet.NewWriterProperties(
+		parquet.WithCompression(compress.Codecs.Snappy),
+		parquet.WithMaxRowGroupLength(int64(pw.batchSize)),
+	)
+	fw, err := pqarrow.NewFileWriter(schema, pw.writer, props, pqarrow.DefaultWriterProps())
+	if err != nil {
+		return nil, err
+	}
+	return &parquetRecordSink{fileWriter: fw}, nil
+}
+
+func (aw *ArrowWriter) openSink(schema *arrow.Schema) (recordSink, error) {
+	// The IPC streaming format is used, rather than the
+	// random access file format, so that stdout and pipes
+	// are valid destinations.
+	return &arrowRecordSink{
+		ipcWriter: ipc.NewWriter(
+			aw.writer,
+			ipc.WithSchema(schema),
+			ipc.WithAllocator(memory.DefaultAllocator),
+		),
+	}, nil
+}
+
+func (pw *ParquetWriter) Write(res sqldata.ISQLResultStream) error {
+	return pw.writeStream(res, pw.openSink)
+}
+
+func (aw *ArrowWriter) Write(res sqldata.ISQLResultStream) error {
+	return aw.writeStream(res, aw.openSink)
+}
+
+// WriteError always routes to the error stream, because
+// binary columnar formats cannot sensibly carry an error record.
+func (cw *AbstractColumnarWriter) WriteError(err error, _ string) error {
+	return writeStderrError(cw.errWriter, err)
+}
+
+//nolint:gocognit // acceptable
+func (cw *AbstractColumnarWriter) writeStream(
+	res sqldata.ISQLResultStream,
+	opener recordSinkOpener,
+) error {
+	var batch *columnarBatch
+	var sink recordSink
+	for {
+		r, err := res.Read()
+		logging.GetLogger().Debugln(fmt.Sprintf("result from stream: %v", r))
+		isEOF := errors.Is(err, io.EOF)
+		if err != nil && !isEOF {
+			return err
+		}
+		if r != nil { //nolint:nestif // acceptable
+			if batch == nil {
+				batch = newColumnarBatch(r.GetColumns(), cw.ci)
+				defer batch.release()
+				sink, err = opener(batch.schema)
+				if err != nil {
+					return err
+				}
+			}
+			for _, row := range r.GetRows() {
+				if appendErr := batch.appendRow(row); appendErr != nil {
+					return appendErr
+				}
+				if batch.len() >= cw.batchSize {
+					if flushErr := batch.flush(sink); flushErr != nil {
+						return flushErr
+					}
+				}
+			}
+		}
+		if isEOF {
+			break
+		}
+	}
+	if batch == nil {
+		// No result at all; emit a valid, empty payload.
+		emptySink, err := opener(arrow.NewSchema(nil, nil))
+		if err != nil {
+			return err
+		}
+		return emptySink.close()
+	}
+	if err := batch.flush(sink); err != nil {
+		return err
+	}
+	return sink.close()
+}
+
+type columnarBatch struct {
+	colz    []sqldata.ISQLColumn
+	ci      *pgtype.ConnInfo
+	schema  *arrow.Schema
+	builder *array.RecordBuilder
+}
+
+func newColumnarBatch(colz []sqldata.ISQLColumn, ci *pgtype.ConnInfo) *columnarBatch {
+	fields := make([]arrow.Field, len(colz))
+	for i, col := range colz {
+		fields[i] = arrow.Field{
+			Name:     col.GetName(),
+			Type:     arrowTypeForOID(col.GetObjectID()),
+			Nullable: true,
+		}
+	}
+	schema := arrow.NewSchema(fields, nil)
+	return &columnarBatch{
+		colz:    colz,
+		ci:      ci,
+		schema:  schema,
+		builder: array.NewRecordBuilder(memory.DefaultAllocator, schema),
+	}
+}
+
+func (cb *columnarBatch) len() int {
+	if len(cb.builder.Fields()) == 0 {
+		return 0
+	}
+	return cb.builder.Field(0).Len()
+}
+
+func (cb *columnarBatch) appendRow(row sqldata.ISQLRow) error {
+	decoded, err := decodeRow(cb.colz, row, cb.ci)
+	if err != nil {
+		return err
+	}
+	if decoded == nil {
+		return nil
+	}
+	for i, b := range decoded {
+		if appendErr := appendColumnarValue(cb.builder.Field(i), b); appendErr != nil {
+			return fmt.Errorf("column '%s': %w", cb.colz[i].GetName(), appendErr)
+		}
+	}
+	return nil
+}
+
+func (cb *columnarBatch) flush(sink recordSink) error {
+	if cb.len() == 0 {
+		return nil
+	}
+	rec := cb.builder.NewRecord()
+	defer rec.Release()
+	return sink.writeRecord(rec)
+}
+
+func (cb *columnarBatch) release() {
+	cb.builder.Release()
+}
+
+func arrowTypeForOID(colOID uint32) arrow.DataType {
+	switch colOID {
+	case pgtype.BoolOID:
+		return arrow.FixedWidthTypes.Boolean
+	case pgtype.Int2OID:
+		return arrow.PrimitiveTypes.Int16
+	case pgtype.Int4OID:
+		return arrow.PrimitiveTypes.Int32
+	case pgtype.Int8OID:
+		return arrow.PrimitiveTypes.Int64
+	case pgtype.Float4OID:
+		return arrow.PrimitiveTypes.Float32
+	case pgtype.Float8OID, pgtype.NumericOID:
+		return arrow.PrimitiveTypes.Float64
+	case pgtype.DateOID:
+		return arrow.FixedWidthTypes.Date32
+	case pgtype.TimestampOID:
+		return arrow.FixedWidthTypes.Timestamp_us
+	case pgtype.TimestamptzOID:
+		return &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"}
+	case pgtype.ByteaOID:
+		return arrow.BinaryTypes.Binary
+	default:
+		// text, varchar, json, jsonb and anything exotic
+		// are carried verbatim as their text encoding.
+		return arrow.BinaryTypes.String
+	}
+}
+
+var timestampLayouts = []string{ //nolint:gochecknoglobals // immutable lookup
+	"2006-01-02 15:04:05.999999999Z07:00:00",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z07",
+	"2006-01-02 15:04:05.999999999",
+	time.RFC3339Nano,
+}
+
+func parseTimestampText(s string) (time.Time, error) {
+	for _, layout := range timestampLayouts {
+		t, err := time.Parse(layout, s)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse '%s' as timestamp", s)
+}
+
+//nolint:gocyclo,cyclop // type switch is clearer flat
+func appendColumnarValue(b array.Builder, raw []byte) error {
+	if raw == nil {
+		b.AppendNull()
+		return nil
+	}
+	s := string(raw)
+	switch bldr := b.(type) {
+	case *array.StringBuilder:
+		bldr.Append(s)
+		return nil
+	case *array.BinaryBuilder:
+		decoded, err := hex.DecodeString(strings.TrimPrefix(s, `\x`))
+		if err != nil {
+			return err
+		}
+		bldr.Append(decoded)
+		return nil
+	}
+	// Numeric shim text may legitimately be the JSON null literal.
+	if s == "null" || s == "" {
+		b.AppendNull()
+		return nil
+	}
+	switch bldr := b.(type) {
+	case *array.BooleanBuilder:
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		bldr.Append(v)
+	case *array.Int16Builder:
+		v, err := strconv.ParseInt(s, 10, 16)
+		if err != nil {
+			return err
+		}
+		bldr.Append(int16(v))
+	case *array.Int32Builder:
+		v, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return err
+		}
+		bldr.Append(int32(v))
+	case *array.Int64Builder:
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		bldr.Append(v)
+	case *array.Float32Builder:
+		v, err := strconv.ParseFloat(s, 32)
+		if err != nil {
+			return err
+		}
+		bldr.Append(float32(v))
+	case *array.Float64Builder:
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		bldr.Append(v)
+	case *array.Date32Builder:
+		t, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return err
+		}
+		bldr.Append(arrow.Date32FromTime(t))
+	case *array.TimestampBuilder:
+		t, err := parseTimestampText(s)
+		if err != nil {
+			return err
+		}
+		bldr.Append(arrow.Timestamp(t.UnixMicro()))
+	default:
+		return fmt.Errorf("unsupported columnar builder type %T", b)
+	}
+	return nil
+}
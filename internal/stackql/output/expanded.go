@@ -0,0 +1,86 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jackc/pgtype"
+	"github.com/stackql/any-sdk/pkg/logging"
+	"github.com/stackql/psql-wire/pkg/sqldata"
+)
+
+// ExpandedStr is the expanded display of psql's \x: one record per block,
+// one line per column.
+const ExpandedStr string = "expanded"
+
+type ExpandedWriter struct {
+	writer    io.Writer
+	errWriter io.Writer
+	ci        *pgtype.ConnInfo
+	records   int
+}
+
+func NewExpandedWriter(writer io.Writer, errWriter io.Writer) IOutputWriter {
+	return &ExpandedWriter{
+		writer:    writer,
+		errWriter: errWriter,
+		ci:        pgtype.NewConnInfo(),
+	}
+}
+
+func (ew *ExpandedWriter) Write(res sqldata.ISQLResultStream) error {
+	for {
+		r, err := res.Read()
+		logging.GetLogger().Debugln(fmt.Sprintf("result from stream: %v", r))
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		if r != nil {
+			if writeErr := ew.writeResult(r); writeErr != nil {
+				return writeErr
+			}
+		}
+		if err != nil {
+			if ew.records == 0 {
+				fmt.Fprintln(ew.writer, "(0 rows)")
+			}
+			return nil
+		}
+	}
+}
+
+func (ew *ExpandedWriter) writeResult(r sqldata.ISQLResult) error {
+	var header []string
+	keyWidth := 0
+	for _, col := range r.GetColumns() {
+		header = append(header, col.GetName())
+		keyWidth = max(keyWidth, len(col.GetName()))
+	}
+	rowsArr, err := tabulateResults(r, ew.ci)
+	if err != nil {
+		return err
+	}
+	for _, rs := range rowsArr {
+		ew.records++
+		valueWidth := 0
+		for _, v := range rs {
+			valueWidth = max(valueWidth, len(v))
+		}
+		title := fmt.Sprintf("-[ RECORD %d ]", ew.records)
+		fmt.Fprintln(ew.writer, title+strings.Repeat("-", max(0, keyWidth+3+valueWidth-len(title))))
+		for i, v := range rs {
+			fmt.Fprintf(ew.writer, "%-*s | %s\n", keyWidth, header[i], v)
+		}
+	}
+	return nil
+}
+
+func (ew *ExpandedWriter) WriteError(err error, errorPresentation string) error {
+	if errorPresentation == stderrPressentationStr {
+		return writeStderrError(ew.errWriter, err)
+	}
+	_, writeErr := fmt.Fprintf(ew.writer, "%s | %s\n", errorKey, err.Error())
+	return writeErr
+}
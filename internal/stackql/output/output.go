@@ -103,6 +103,18 @@ func GetOutputWriter(
 			errWriter,
 		}
 		return &prettyWriter, nil
+	case ExpandedStr:
+		return NewExpandedWriter(writer, errWriter), nil
+	case ParquetStr:
+		parquetWriter := ParquetWriter{
+			newAbstractColumnarWriter(writer, errWriter, ci),
+		}
+		return &parquetWriter, nil
+	case ArrowStr:
+		arrowWriter := ArrowWriter{
+			newAbstractColumnarWriter(writer, errWriter, ci),
+		}
+		return &arrowWriter, nil
 	}
 	return nil, fmt.Errorf(
 		"unable to create output writer for output format = '%s'",
@@ -2,11 +2,19 @@ package output //nolint:testpackage // do not care
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io"
 	"strings"
 	"testing"
 
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/jackc/pgtype"
 	"github.com/stackql/any-sdk/pkg/dto"
 	"github.com/stackql/psql-wire/pkg/sqldata"
 	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
@@ -131,3 +139,158 @@ func TestJSONLWriter_Write_PropagatesStreamError(t *testing.T) {
 		t.Fatalf("expected %v, got %v", wantErr, err)
 	}
 }
+
+func getTypedResultStream() sqldata.ISQLResultStream {
+	table := sqldata.NewSQLTable(0, "t")
+	colz := []sqldata.ISQLColumn{
+		sqldata.NewSQLColumn(table, "name", 0, pgtype.TextOID, 1024, 0, "TextFormat"),
+		sqldata.NewSQLColumn(table, "size_gb", 0, pgtype.Int8OID, 1024, 0, "TextFormat"),
+		sqldata.NewSQLColumn(table, "is_boot", 0, pgtype.BoolOID, 1024, 0, "TextFormat"),
+	}
+	rows := []sqldata.ISQLRow{
+		sqldata.NewSQLRow([]interface{}{"disk-a", int64(10), true}),
+		sqldata.NewSQLRow([]interface{}{"disk-b", nil, false}),
+		sqldata.NewSQLRow([]interface{}{"disk-c", int64(30), nil}),
+	}
+	return sqldata.NewSimpleSQLResultStream(sqldata.NewSQLResult(colz, 0, 0, rows))
+}
+
+func assertTypedSchema(t *testing.T, schema *arrow.Schema) {
+	t.Helper()
+	expected := []arrow.DataType{
+		arrow.BinaryTypes.String,
+		arrow.PrimitiveTypes.Int64,
+		arrow.FixedWidthTypes.Boolean,
+	}
+	if len(schema.Fields()) != len(expected) {
+		t.Fatalf("expected %d fields, got %d", len(expected), len(schema.Fields()))
+	}
+	for i, f := range schema.Fields() {
+		if !arrow.TypeEqual(f.Type, expected[i]) {
+			t.Fatalf("field '%s': expected type %s, got %s", f.Name, expected[i], f.Type)
+		}
+	}
+}
+
+func TestArrowWriter_Write_TypedColumns(t *testing.T) {
+	var b bytes.Buffer
+	w := &ArrowWriter{newAbstractColumnarWriter(&b, io.Discard, pgtype.NewConnInfo())}
+	w.batchSize = 2
+
+	if err := w.Write(getTypedResultStream()); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	rdr, err := ipc.NewReader(&b, ipc.WithAllocator(memory.DefaultAllocator))
+	if err != nil {
+		t.Fatalf("ipc.NewReader() error = %v", err)
+	}
+	defer rdr.Release()
+	assertTypedSchema(t, rdr.Schema())
+
+	var batchCount, rowCount int
+	var sizes []int64
+	for rdr.Next() {
+		rec := rdr.Record()
+		batchCount++
+		rowCount += int(rec.NumRows())
+		col, ok := rec.Column(1).(*array.Int64)
+		if !ok {
+			t.Fatalf("expected *array.Int64, got %T", rec.Column(1))
+		}
+		for i := 0; i < col.Len(); i++ {
+			if col.IsNull(i) {
+				sizes = append(sizes, -1)
+				continue
+			}
+			sizes = append(sizes, col.Value(i))
+		}
+	}
+	if batchCount != 2 {
+		t.Fatalf("expected 2 record batches, got %d", batchCount)
+	}
+	if rowCount != 3 {
+		t.Fatalf("expected 3 rows, got %d", rowCount)
+	}
+	if sizes[0] != 10 || sizes[1] != -1 || sizes[2] != 30 {
+		t.Fatalf("unexpected size values %v", sizes)
+	}
+}
+
+func TestParquetWriter_Write_TypedColumns(t *testing.T) {
+	var b bytes.Buffer
+	w := &ParquetWriter{newAbstractColumnarWriter(&b, io.Discard, pgtype.NewConnInfo())}
+	w.batchSize = 2
+
+	if err := w.Write(getTypedResultStream()); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	pqRdr, err := file.NewParquetReader(bytes.NewReader(b.Bytes()))
+	if err != nil {
+		t.Fatalf("file.NewParquetReader() error = %v", err)
+	}
+	defer pqRdr.Close()
+	if pqRdr.NumRowGroups() != 2 {
+		t.Fatalf("expected 2 row groups, got %d", pqRdr.NumRowGroups())
+	}
+	fr, err := pqarrow.NewFileReader(pqRdr, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	if err != nil {
+		t.Fatalf("pqarrow.NewFileReader() error = %v", err)
+	}
+	tbl, err := fr.ReadTable(context.Background())
+	if err != nil {
+		t.Fatalf("ReadTable() error = %v", err)
+	}
+	defer tbl.Release()
+	assertTypedSchema(t, tbl.Schema())
+	if tbl.NumRows() != 3 {
+		t.Fatalf("expected 3 rows, got %d", tbl.NumRows())
+	}
+}
+
+func TestGetOutputWriter_Columnar(t *testing.T) {
+	for _, format := range []string{"parquet", "arrow"} {
+		ctx := internaldto.OutputContext{RuntimeContext: dto.RuntimeCtx{OutputFormat: format}}
+		w, err := GetOutputWriter(&bytes.Buffer{}, &bytes.Buffer{}, ctx)
+		if err != nil {
+			t.Fatalf("GetOutputWriter(%s) error = %v", format, err)
+		}
+		var errOut bytes.Buffer
+		switch tw := w.(type) {
+		case *ParquetWriter:
+			tw.errWriter = &errOut
+		case *ArrowWriter:
+			tw.errWriter = &errOut
+		default:
+			t.Fatalf("unexpected writer type %T for format %s", w, format)
+		}
+		if err = w.WriteError(errors.New("boom"), "record"); err != nil {
+			t.Fatalf("WriteError() error = %v", err)
+		}
+		if !strings.Contains(errOut.String(), "boom") {
+			t.Fatalf("expected error on error stream, got %q", errOut.String())
+		}
+	}
+}
+
+func TestExpandedWriter_Write(t *testing.T) {
+	ctx := internaldto.OutputContext{RuntimeContext: dto.RuntimeCtx{OutputFormat: ExpandedStr}}
+	var b bytes.Buffer
+	w, err := GetOutputWriter(&b, io.Discard, ctx)
+	if err != nil {
+		t.Fatalf("GetOutputWriter() error = %v", err)
+	}
+	if err = w.Write(getTypedResultStream()); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	out := b.String()
+	for _, expected := range []string{"-[ RECORD 1 ]", "name    | disk-a\n", "-[ RECORD 3 ]", "size_gb | 30\n"} {
+		if !strings.Contains(out, expected) {
+			t.Fatalf("expected %q in expanded output:\n%s", expected, out)
+		}
+	}
+	if strings.Contains(out, "-[ RECORD 4 ]") {
+		t.Fatalf("expected three records:\n%s", out)
+	}
+}
@@ -1,5 +1,6 @@
 // Package paramdecoder decodes parameter values from their wire format
-// (text or binary) into string representations suitable for SQL substitution.
+// (text or binary) into string representations suitable for SQL substitution,
+// or into typed values suitable for binding.
 package paramdecoder
 
 import (
@@ -16,6 +17,10 @@ import (
 // and OIDs, returning string representations for each.
 type Decoder interface {
 	DecodeParams(paramOIDs []uint32, paramFormats []int16, paramValues [][]byte) ([]string, error)
+	// DecodeTypedParams returns the Go value of each parameter: nil for
+	// NULL, bool, int64 or float64 where its OID is so typed, and string
+	// otherwise.
+	DecodeTypedParams(paramOIDs []uint32, paramFormats []int16, paramValues [][]byte) ([]any, error)
 }
 
 // NewDecoder creates a new parameter decoder.
@@ -48,6 +53,60 @@ func (d *standardDecoder) DecodeParams(
 	return result, nil
 }
 
+func (d *standardDecoder) DecodeTypedParams(
+	paramOIDs []uint32, paramFormats []int16, paramValues [][]byte,
+) ([]any, error) {
+	result := make([]any, len(paramValues))
+	for i, val := range paramValues {
+		if val == nil {
+			continue
+		}
+		paramOID := oid.Oid(0)
+		if i < len(paramOIDs) {
+			paramOID = oid.Oid(paramOIDs[i])
+		}
+		decoded, err := decodeParam(paramOID, resolveFormat(paramFormats, i), val)
+		if err != nil {
+			return nil, fmt.Errorf("parameter $%d: %w", i+1, err)
+		}
+		typed, err := typeParam(paramOID, decoded)
+		if err != nil {
+			return nil, fmt.Errorf("parameter $%d: %w", i+1, err)
+		}
+		result[i] = typed
+	}
+	return result, nil
+}
+
+// typeParam converts the string representation of a parameter to the Go
+// type of its OID.  Numerics stay strings, lest they lose precision.
+//
+//nolint:exhaustive // only the natively typed OIDs convert
+func typeParam(paramOID oid.Oid, decoded string) (any, error) {
+	switch paramOID {
+	case oid.T_bool:
+		v, err := strconv.ParseBool(decoded)
+		if err != nil {
+			return nil, fmt.Errorf("bool: %w", err)
+		}
+		return v, nil
+	case oid.T_int2, oid.T_int4, oid.T_int8:
+		v, err := strconv.ParseInt(decoded, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("integer: %w", err)
+		}
+		return v, nil
+	case oid.T_float4, oid.T_float8:
+		v, err := strconv.ParseFloat(decoded, 64)
+		if err != nil {
+			return nil, fmt.Errorf("float: %w", err)
+		}
+		return v, nil
+	default:
+		return decoded, nil
+	}
+}
+
 // resolveFormat returns the format code for parameter at index i.
 // Per postgres protocol: empty = all text, length 1 = applies to all,
 // otherwise per-parameter.
@@ -136,3 +136,26 @@ func TestDecodeUnknownOIDBinaryFallsBackToText(t *testing.T) {
 		t.Errorf("got %q, want raw-bytes", results[0])
 	}
 }
+
+func TestDecodeTypedParams(t *testing.T) {
+	d := paramdecoder.NewDecoder()
+	binaryInt8 := make([]byte, 8)
+	binary.BigEndian.PutUint64(binaryInt8, uint64(7))
+	results, err := d.DecodeTypedParams(
+		[]uint32{uint32(oid.T_int4), uint32(oid.T_int8), uint32(oid.T_bool), uint32(oid.T_float8), 0, uint32(oid.T_text)},
+		[]int16{0, 1, 0, 0, 0, 0},
+		[][]byte{[]byte("-12"), binaryInt8, []byte("t"), []byte("2.5"), []byte("us-east1"), nil},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []any{int64(-12), int64(7), true, 2.5, "us-east1", nil}
+	for i := range expected {
+		if results[i] != expected[i] {
+			t.Errorf("parameter %d: got %#v, want %#v", i+1, results[i], expected[i])
+		}
+	}
+	if _, err := d.DecodeTypedParams([]uint32{uint32(oid.T_int4)}, nil, [][]byte{[]byte("x")}); err == nil {
+		t.Errorf("expected a malformed integer to be reported")
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 
 	"github.com/stackql/stackql-parser/go/vt/sqlparser"
+
+	"github.com/stackql/stackql/pkg/textutil"
 )
 
 //nolint:unparam,revive // The unused cmd is retained as a future proofing measure
@@ -28,6 +30,10 @@ func NewParser() (Parser, error) {
 type basicParser struct{}
 
 func (p *basicParser) ParseQuery(cmd string) (sqlparser.Statement, error) {
-	statement, err := sqlparser.Parse(cmd)
+	normalised, err := textutil.NormaliseExplain(cmd)
+	if err != nil {
+		return nil, specialiseParserError(err, cmd)
+	}
+	statement, err := sqlparser.Parse(normalised)
 	return statement, specialiseParserError(err, cmd)
 }
@@ -66,3 +66,38 @@ func TestParseQuery(t *testing.T) {
 		assert.Nil(t, statement, "Expected no statement for invalid SQL query")
 	})
 }
+
+func TestParseQueryExplainOptions(t *testing.T) {
+	parser, err := NewParser()
+	assert.NoError(t, err, "Expected no error for NewParser")
+
+	t.Run("Parenthesised format", func(t *testing.T) {
+		statement, err := parser.ParseQuery("EXPLAIN (FORMAT JSON) SELECT a FROM t;")
+		assert.NoError(t, err, "Expected no error for parenthesised explain format")
+		explain, ok := statement.(*sqlparser.Explain)
+		assert.True(t, ok, "Expected explain statement")
+		assert.Equal(t, sqlparser.JSONStr, explain.Type)
+	})
+
+	t.Run("Parenthesised analyze", func(t *testing.T) {
+		statement, err := parser.ParseQuery("explain (analyze) select a from t;")
+		assert.NoError(t, err, "Expected no error for parenthesised explain analyze")
+		explain, ok := statement.(*sqlparser.Explain)
+		assert.True(t, ok, "Expected explain statement")
+		assert.Equal(t, sqlparser.AnalyzeStr, explain.Type)
+	})
+
+	t.Run("Native format syntax untouched", func(t *testing.T) {
+		statement, err := parser.ParseQuery("EXPLAIN FORMAT = JSON SELECT a FROM t;")
+		assert.NoError(t, err, "Expected no error for native explain format")
+		explain, ok := statement.(*sqlparser.Explain)
+		assert.True(t, ok, "Expected explain statement")
+		assert.Equal(t, sqlparser.JSONStr, explain.Type)
+	})
+
+	t.Run("Unsupported options", func(t *testing.T) {
+		statement, err := parser.ParseQuery("EXPLAIN (VERBOSE, COSTS) SELECT a FROM t;")
+		assert.Error(t, err, "Expected an error for unsupported explain options")
+		assert.Nil(t, statement)
+	})
+}
@@ -0,0 +1,560 @@
+package pgauth_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5" //nolint:gosec // the postgres md5 method is md5
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/stackql/stackql/internal/stackql/pgauth"
+	"github.com/stackql/stackql/internal/stackql/querycancel"
+)
+
+// testClient drives the client side of the postgres startup phase.
+type testClient struct {
+	t    *testing.T
+	conn net.Conn
+}
+
+func (c *testClient) startup(user string) {
+	body := binary.BigEndian.AppendUint32(nil, 196608)
+	for _, kv := range []string{"user", user, "database", "stackql"} {
+		body = append(append(body, kv...), 0)
+	}
+	body = append(body, 0)
+	msg := binary.BigEndian.AppendUint32(nil, uint32(4+len(body)))
+	c.write(append(msg, body...))
+}
+
+func (c *testClient) write(b []byte) {
+	if _, err := c.conn.Write(b); err != nil {
+		c.t.Fatalf("write: %v", err)
+	}
+}
+
+func (c *testClient) send(msgType byte, body []byte) {
+	msg := []byte{msgType}
+	msg = binary.BigEndian.AppendUint32(msg, uint32(4+len(body)))
+	c.write(append(msg, body...))
+}
+
+// receive reads one backend message.
+func (c *testClient) receive() (byte, []byte) {
+	var header [5]byte
+	if _, err := io.ReadFull(c.conn, header[:]); err != nil {
+		c.t.Fatalf("read: %v", err)
+	}
+	body := make([]byte, binary.BigEndian.Uint32(header[1:])-4)
+	if _, err := io.ReadFull(c.conn, body); err != nil {
+		c.t.Fatalf("read: %v", err)
+	}
+	return header[0], body
+}
+
+// receiveAuth reads an authentication request, returning its code.
+func (c *testClient) receiveAuth() (uint32, []byte) {
+	msgType, body := c.receive()
+	if msgType != 'R' {
+		c.t.Fatalf("expected authentication request, received %q: %q", msgType, body)
+	}
+	return binary.BigEndian.Uint32(body[:4]), body[4:]
+}
+
+func hmacSum(key, msg []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(msg)
+	return h.Sum(nil)
+}
+
+// scram runs the client side of SCRAM-SHA-256, returning the server's
+// final message, or the error response.
+func (c *testClient) scram(password string) (string, bool) {
+	code, data := c.receiveAuth()
+	if code != 10 || !bytes.HasPrefix(data, []byte("SCRAM-SHA-256\x00")) {
+		c.t.Fatalf("expected SASL, received %d %q", code, data)
+	}
+	clientFirstBare := "n=,r=clientnonce123"
+	clientFirst := "n,," + clientFirstBare
+	initial := append([]byte("SCRAM-SHA-256\x00"), binary.BigEndian.AppendUint32(nil, uint32(len(clientFirst)))...)
+	c.send('p', append(initial, clientFirst...))
+	code, data = c.receiveAuth()
+	if code != 11 {
+		c.t.Fatalf("expected SASLContinue, received %d", code)
+	}
+	serverFirst := string(data)
+	attrs := map[string]string{}
+	for _, a := range strings.Split(serverFirst, ",") {
+		attrs[a[:1]] = a[2:]
+	}
+	if !strings.HasPrefix(attrs["r"], "clientnonce123") {
+		c.t.Fatalf("server nonce does not extend client nonce: %q", attrs["r"])
+	}
+	salt, _ := base64.StdEncoding.DecodeString(attrs["s"])
+	iterations, _ := strconv.Atoi(attrs["i"])
+	salted, err := pbkdf2.Key(sha256.New, password, salt, iterations, sha256.Size)
+	if err != nil {
+		c.t.Fatalf("pbkdf2: %v", err)
+	}
+	clientKey := hmacSum(salted, []byte("Client Key"))
+	storedKey := sha256.Sum256(clientKey)
+	withoutProof := "c=biws,r=" + attrs["r"]
+	authMessage := []byte(clientFirstBare + "," + serverFirst + "," + withoutProof)
+	signature := hmacSum(storedKey[:], authMessage)
+	proof := make([]byte, len(clientKey))
+	for i := range proof {
+		proof[i] = clientKey[i] ^ signature[i]
+	}
+	c.send('p', []byte(withoutProof+",p="+base64.StdEncoding.EncodeToString(proof)))
+	msgType, body := c.receive()
+	if msgType == 'E' {
+		return string(body), false
+	}
+	if code := binary.BigEndian.Uint32(body[:4]); msgType != 'R' || code != 12 {
+		c.t.Fatalf("expected SASLFinal, received %q %d", msgType, code)
+	}
+	serverKey := hmacSum(salted, []byte("Server Key"))
+	expected := "v=" + base64.StdEncoding.EncodeToString(hmacSum(serverKey, authMessage))
+	if string(body[4:]) != expected {
+		c.t.Fatalf("server signature mismatch: %q != %q", body[4:], expected)
+	}
+	return string(body[4:]), true
+}
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s)) //nolint:gosec // the postgres md5 method is md5
+	return hex.EncodeToString(sum[:])
+}
+
+// md5 answers an md5 challenge.  Success is silent: AuthenticationOk is
+// left to the wire server.
+func (c *testClient) md5(user, password string) {
+	code, salt := c.receiveAuth()
+	if code != 5 || len(salt) != 4 {
+		c.t.Fatalf("expected md5 request, received %d %q", code, salt)
+	}
+	response := "md5" + md5Hex(md5Hex(password+user)+string(salt))
+	c.send('p', append([]byte(response), 0))
+}
+
+func mustUsers(t *testing.T, method string) pgauth.Users {
+	t.Helper()
+	verifier, err := pgauth.NewSCRAMVerifier("s3cret", []byte("0123456789abcdef"), pgauth.DefaultSCRAMIterations)
+	if err != nil {
+		t.Fatalf("verifier: %v", err)
+	}
+	doc := fmt.Sprintf(`
+method: %s
+users:
+  - name: analyst
+    password: %s
+  - name: ops
+    password: %s
+    mode: full_access
+    auth:
+      google:
+        type: service_account
+        credentialsfilepath: /secrets/ops.json
+`, method, verifier, pgauth.NewMD5Verifier("ops", "0ps"))
+	if method == pgauth.MethodSCRAMSHA256 {
+		doc = strings.Replace(doc, pgauth.NewMD5Verifier("ops", "0ps"), verifier, 1)
+	}
+	users, err := pgauth.ParseUsers([]byte(doc))
+	if err != nil {
+		t.Fatalf("ParseUsers: %v", err)
+	}
+	return users
+}
+
+type authResult struct {
+	conn net.Conn
+	user pgauth.User
+	err  error
+}
+
+func startAuth(t *testing.T, users pgauth.Users) (*testClient, chan authResult) {
+	t.Helper()
+	server, client := net.Pipe()
+	t.Cleanup(func() { server.Close(); client.Close() })
+	_ = client.SetDeadline(time.Now().Add(10 * time.Second))
+	done := make(chan authResult, 1)
+	go func() {
+		conn, user, _, err := pgauth.Authenticate(server, nil, users)
+		done <- authResult{conn, user, err}
+	}()
+	return &testClient{t: t, conn: client}, done
+}
+
+func TestAuthenticate_SCRAM(t *testing.T) {
+	users := mustUsers(t, pgauth.MethodSCRAMSHA256)
+	c, done := startAuth(t, users)
+	c.startup("analyst")
+	if _, ok := c.scram("s3cret"); !ok {
+		t.Fatalf("expected SCRAM success")
+	}
+	res := <-done
+	if res.err != nil {
+		t.Fatalf("Authenticate: %v", res.err)
+	}
+	if res.user.GetName() != "analyst" || res.user.GetMode() != pgauth.ModeReadOnly {
+		t.Fatalf("unexpected user %q mode %q", res.user.GetName(), res.user.GetMode())
+	}
+	// The startup message is replayed for the wire server.
+	go func() { _, _ = c.conn.Write([]byte("next")) }()
+	replayed := make([]byte, 8)
+	if _, err := io.ReadFull(res.conn, replayed); err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if code := binary.BigEndian.Uint32(replayed[4:]); code != 196608 {
+		t.Fatalf("expected replayed startup message, received code %d", code)
+	}
+}
+
+func TestAuthenticate_SCRAMWrongPassword(t *testing.T) {
+	c, done := startAuth(t, mustUsers(t, pgauth.MethodSCRAMSHA256))
+	c.startup("analyst")
+	msg, ok := c.scram("guess")
+	if ok {
+		t.Fatalf("expected SCRAM failure")
+	}
+	if !strings.Contains(msg, "28P01") || !strings.Contains(msg, `password authentication failed for user "analyst"`) {
+		t.Fatalf("unexpected error response %q", msg)
+	}
+	if res := <-done; res.err == nil {
+		t.Fatalf("expected an error")
+	}
+}
+
+func TestAuthenticate_UnknownUserIsIndistinguishable(t *testing.T) {
+	c, done := startAuth(t, mustUsers(t, pgauth.MethodSCRAMSHA256))
+	c.startup("mallory")
+	msg, ok := c.scram("s3cret")
+	if ok {
+		t.Fatalf("expected SCRAM failure")
+	}
+	if !strings.Contains(msg, `password authentication failed for user "mallory"`) {
+		t.Fatalf("unexpected error response %q", msg)
+	}
+	if res := <-done; res.err == nil {
+		t.Fatalf("expected an error")
+	}
+}
+
+func TestAuthenticate_MD5(t *testing.T) {
+	users := mustUsers(t, pgauth.MethodMD5)
+	c, done := startAuth(t, users)
+	c.startup("ops")
+	c.md5("ops", "0ps")
+	res := <-done
+	if res.err != nil {
+		t.Fatalf("Authenticate: %v", res.err)
+	}
+	if res.user.GetMode() != pgauth.ModeFullAccess {
+		t.Fatalf("expected full_access, received %q", res.user.GetMode())
+	}
+	if !strings.Contains(res.user.GetRawAuth(), "credentialsfilepath: /secrets/ops.json") {
+		t.Fatalf("unexpected raw auth %q", res.user.GetRawAuth())
+	}
+
+	c, done = startAuth(t, users)
+	c.startup("ops")
+	c.md5("ops", "wrong")
+	if msgType, body := c.receive(); msgType != 'E' || !strings.Contains(string(body), "28P01") {
+		t.Fatalf("expected md5 failure, received %q %q", msgType, body)
+	}
+	if res := <-done; res.err == nil {
+		t.Fatalf("expected an error")
+	}
+}
+
+func TestParseUsers_Rejects(t *testing.T) {
+	for name, doc := range map[string]string{
+		"method":          "method: password\nusers: []",
+		"plaintext":       "users:\n  - name: a\n    password: hunter2",
+		"md5 under scram": "users:\n  - name: a\n    password: " + pgauth.NewMD5Verifier("a", "b"),
+		"mode":            "method: md5\nusers:\n  - name: a\n    mode: admin\n    password: " + pgauth.NewMD5Verifier("a", "b"),
+		"duplicate": "method: md5\nusers:\n  - {name: a, password: " + pgauth.NewMD5Verifier("a", "b") +
+			"}\n  - {name: a, password: " + pgauth.NewMD5Verifier("a", "b") + "}",
+	} {
+		if _, err := pgauth.ParseUsers([]byte(doc)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestCheckMode(t *testing.T) {
+	allowed := []string{
+		"SELECT name FROM google.compute.instances WHERE project = 'p'",
+		"SET search_path = public",
+		"CREATE VIEW v AS SELECT 1",
+		"BEGIN",
+		"/* dashboard */ SELECT 1",
+		"-- refresh\nSELECT 1",
+		"EXPLAIN DELETE FROM google.storage.buckets WHERE bucket = 'b'",
+		"EXPLAIN ANALYZE SELECT 1",
+	}
+	denied := []string{
+		"INSERT INTO google.storage.buckets (project, data__name) SELECT 'p', 'b'",
+		"DELETE FROM google.storage.buckets WHERE project = 'p' AND bucket = 'b'",
+		"EXEC google.compute.instances.stop @project='p', @zone='z', @instance='i'",
+		"WITH x AS (SELECT 1) DELETE FROM google.storage.buckets WHERE bucket = 'b'",
+		"/* x */ DELETE FROM google.storage.buckets WHERE project = 'p' AND bucket = 'b'",
+		"-- c\nDELETE FROM google.storage.buckets WHERE project = 'p' AND bucket = 'b'",
+		"/* a */ -- b\n/* c */ EXEC google.compute.instances.stop @project='p', @zone='z', @instance='i'",
+		"EXPLAIN ANALYZE DELETE FROM google.storage.buckets WHERE bucket = 'b'",
+		"REGISTRY PULL google",
+		"FROBNICATE google.storage.buckets",
+	}
+	if err := pgauth.CheckMode(pgauth.ModeReadOnly, allowed); err != nil {
+		t.Fatalf("expected read_only to allow %v: %v", allowed, err)
+	}
+	for _, stmt := range denied {
+		if err := pgauth.CheckMode(pgauth.ModeReadOnly, []string{allowed[0], stmt}); err == nil {
+			t.Errorf("expected read_only to deny %q", stmt)
+		}
+		if err := pgauth.CheckMode(pgauth.ModeFullAccess, []string{stmt}); err != nil {
+			t.Errorf("expected full_access to allow %q: %v", stmt, err)
+		}
+	}
+}
+
+// fakeWireServer stands in for the wire server: it consumes the replayed
+// startup message, announces the user and turns to ready.  A query is run
+// until its context, bound to the session's cancel key, is cancelled.
+func fakeWireServer(started chan<- string) pgauth.ServeFunc {
+	return func(user pgauth.User, ul net.Listener) error {
+		name := "trusted"
+		if user != nil {
+			name = user.GetName()
+		}
+		started <- name
+		for {
+			conn, acceptErr := ul.Accept()
+			if acceptErr != nil {
+				return acceptErr
+			}
+			go func() {
+				defer conn.Close()
+				raw := make([]byte, 4)
+				_, _ = io.ReadFull(conn, raw)
+				_, _ = io.CopyN(io.Discard, conn, int64(binary.BigEndian.Uint32(raw))-4)
+				writeTestMessage(conn, 'S', []byte(name+"\x00"))
+				writeTestMessage(conn, 'Z', []byte{'I'})
+				header := make([]byte, 5)
+				if _, err := io.ReadFull(conn, header); err != nil {
+					return
+				}
+				body := make([]byte, binary.BigEndian.Uint32(header[1:])-4)
+				_, _ = io.ReadFull(conn, body)
+				_, processID, tagged := querycancel.Untag(strings.TrimRight(string(body), "\x00"))
+				if !tagged {
+					writeTestMessage(conn, 'E', []byte("untagged query\x00"))
+					return
+				}
+				ctx, cancel := querycancel.DefaultRegistry().Bind(context.Background(), processID)
+				defer cancel()
+				select {
+				case <-ctx.Done():
+					writeTestMessage(conn, 'E', []byte(context.Cause(ctx).Error()+"\x00"))
+				case <-time.After(10 * time.Second):
+					writeTestMessage(conn, 'C', []byte("SELECT 1\x00"))
+				}
+			}()
+		}
+	}
+}
+
+func writeTestMessage(w io.Writer, msgType byte, body []byte) {
+	msg := binary.BigEndian.AppendUint32([]byte{msgType}, uint32(4+len(body)))
+	_, _ = w.Write(append(msg, body...))
+}
+
+// releasingWireServer stands in for a wire server whose sessions end only
+// as the driver learns of it: it registers a release hook for the session
+// of the first query and never closes the connection itself.
+func releasingWireServer(released chan<- uint32) pgauth.ServeFunc {
+	return func(_ pgauth.User, ul net.Listener) error {
+		for {
+			conn, acceptErr := ul.Accept()
+			if acceptErr != nil {
+				return acceptErr
+			}
+			go func() {
+				raw := make([]byte, 4)
+				_, _ = io.ReadFull(conn, raw)
+				_, _ = io.CopyN(io.Discard, conn, int64(binary.BigEndian.Uint32(raw))-4)
+				writeTestMessage(conn, 'Z', []byte{'I'})
+				header := make([]byte, 5)
+				if _, err := io.ReadFull(conn, header); err != nil {
+					return
+				}
+				body := make([]byte, binary.BigEndian.Uint32(header[1:])-4)
+				_, _ = io.ReadFull(conn, body)
+				_, processID, _ := querycancel.Untag(strings.TrimRight(string(body), "\x00"))
+				querycancel.DefaultRegistry().OnRelease(processID, func() { released <- processID })
+				writeTestMessage(conn, 'C', []byte("BEGIN\x00"))
+				writeTestMessage(conn, 'Z', []byte{'T'})
+				_, _ = io.ReadAll(conn)
+			}()
+		}
+	}
+}
+
+func startRouter(t *testing.T, users pgauth.Users) (string, chan string) {
+	t.Helper()
+	started := make(chan string, 4)
+	return startRouterServing(t, users, fakeWireServer(started)), started
+}
+
+func startRouterServing(t *testing.T, users pgauth.Users, serve pgauth.ServeFunc) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	router := pgauth.NewRouter(users, nil, serve, logger)
+	go func() { _ = router.Serve(l) }()
+	t.Cleanup(func() { l.Close() })
+	return l.Addr().String()
+}
+
+// readSession reads the session's opening messages, returning the name
+// announced and the cancel key.
+func (c *testClient) readSession() (string, []byte) {
+	msgType, body := c.receive()
+	if msgType != 'S' {
+		c.t.Fatalf("expected the announcement, received %q", msgType)
+	}
+	keyType, key := c.receive()
+	if keyType != 'K' || len(key) != 8 {
+		c.t.Fatalf("expected BackendKeyData ahead of ReadyForQuery, received %q %v", keyType, key)
+	}
+	if readyType, _ := c.receive(); readyType != 'Z' {
+		c.t.Fatalf("expected ReadyForQuery, received %q", readyType)
+	}
+	return strings.TrimRight(string(body), "\x00"), key
+}
+
+func TestRouter_RoutesByUser(t *testing.T) {
+	addr, started := startRouter(t, mustUsers(t, pgauth.MethodSCRAMSHA256))
+	for _, name := range []string{"analyst", "ops", "analyst"} {
+		conn, dialErr := net.Dial("tcp", addr)
+		if dialErr != nil {
+			t.Fatalf("dial: %v", dialErr)
+		}
+		_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+		c := &testClient{t: t, conn: conn}
+		c.startup(name)
+		if _, ok := c.scram("s3cret"); !ok {
+			t.Fatalf("expected SCRAM success for %s", name)
+		}
+		if announced, _ := c.readSession(); announced != name {
+			t.Fatalf("connection of %s routed to %q", name, announced)
+		}
+		conn.Close()
+	}
+	close(started)
+	var servers []string
+	for name := range started {
+		servers = append(servers, name)
+	}
+	if len(servers) != 2 {
+		t.Fatalf("expected one server per user, started %v", servers)
+	}
+}
+
+func TestRouter_CancelRequest(t *testing.T) {
+	addr, _ := startRouter(t, nil)
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+	c := &testClient{t: t, conn: conn}
+	c.startup("anyone")
+	announced, key := c.readSession()
+	if announced != "trusted" {
+		t.Fatalf("expected the trusted server, received %q", announced)
+	}
+	c.send('Q', []byte("SELECT * FROM aws.ec2.instances\x00"))
+
+	cancel := func(secret []byte) {
+		cc, dialErr := net.Dial("tcp", addr)
+		if dialErr != nil {
+			t.Fatalf("dial: %v", dialErr)
+		}
+		defer cc.Close()
+		packet := binary.BigEndian.AppendUint32(nil, 16)
+		packet = binary.BigEndian.AppendUint32(packet, 80877102)
+		packet = append(append(packet, key[:4]...), secret...)
+		if _, writeErr := cc.Write(packet); writeErr != nil {
+			t.Fatalf("write: %v", writeErr)
+		}
+		// The server closes without reply.
+		_, _ = io.ReadAll(cc)
+	}
+	wrongSecret := append([]byte{}, key[4:]...)
+	wrongSecret[0] ^= 0xff
+	cancel(wrongSecret)
+	_ = conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	if _, readErr := conn.Read(make([]byte, 1)); readErr == nil {
+		t.Fatalf("expected a wrong secret to leave the query running")
+	}
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+	cancel(key[4:])
+	msgType, body := c.receive()
+	if msgType != 'E' || !strings.Contains(string(body), querycancel.ErrCanceled.Error()) {
+		t.Fatalf("expected the query to be cancelled, received %q %q", msgType, body)
+	}
+}
+
+func TestRouter_DisconnectReleasesSession(t *testing.T) {
+	released := make(chan uint32, 1)
+	addr := startRouterServing(t, nil, releasingWireServer(released))
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+	c := &testClient{t: t, conn: conn}
+	c.startup("anyone")
+	keyType, key := c.receive()
+	if keyType != 'K' {
+		t.Fatalf("expected BackendKeyData, received %q", keyType)
+	}
+	if readyType, _ := c.receive(); readyType != 'Z' {
+		t.Fatalf("expected ReadyForQuery, received %q", readyType)
+	}
+	c.send('Q', []byte("BEGIN\x00"))
+	if completeType, _ := c.receive(); completeType != 'C' {
+		t.Fatalf("expected CommandComplete, received %q", completeType)
+	}
+	if readyType, status := c.receive(); readyType != 'Z' || status[0] != 'T' {
+		t.Fatalf("expected ReadyForQuery in a transaction, received %q %q", readyType, status)
+	}
+	conn.Close()
+	select {
+	case processID := <-released:
+		if processID != binary.BigEndian.Uint32(key[:4]) {
+			t.Fatalf("expected the session of the disconnected client released, released %d", processID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the session released as its client disconnects")
+	}
+}
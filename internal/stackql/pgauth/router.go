@@ -0,0 +1,172 @@
+package pgauth
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/stackql/stackql/internal/stackql/querycancel"
+)
+
+// authTimeout bounds the startup and authentication phases.
+const authTimeout = 30 * time.Second
+
+// ServeFunc serves the connections of one user, as a wire server does.
+// The user is nil where clients are trusted.
+type ServeFunc func(user User, l net.Listener) error
+
+// Router fronts the wire server.  It authenticates incoming connections,
+// issues each a cancel key, acts on cancel requests and hands each
+// connection to the listener of its user.  The server of a user starts on
+// its first connection.
+type Router interface {
+	Serve(l net.Listener) error
+}
+
+type standardRouter struct {
+	users    Users
+	tlsCfg   *tls.Config
+	serve    ServeFunc
+	logger   *logrus.Logger
+	registry querycancel.Registry
+
+	mu        sync.Mutex
+	listeners map[string]*userListener
+	errCh     chan error
+}
+
+// NewRouter returns a router over users, trusting every client where
+// users is nil.  TLS, where tlsCfg is set, is terminated by the router.
+func NewRouter(users Users, tlsCfg *tls.Config, serve ServeFunc, logger *logrus.Logger) Router {
+	return &standardRouter{
+		users:     users,
+		tlsCfg:    tlsCfg,
+		serve:     serve,
+		logger:    logger,
+		registry:  querycancel.DefaultRegistry(),
+		listeners: make(map[string]*userListener),
+		errCh:     make(chan error, 1),
+	}
+}
+
+// Serve accepts connections on l until it, or the server of a user, fails.
+func (r *standardRouter) Serve(l net.Listener) error {
+	defer r.closeListeners()
+	acceptErrCh := make(chan error, 1)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				acceptErrCh <- err
+				return
+			}
+			go r.route(conn, l.Addr())
+		}
+	}()
+	select {
+	case err := <-acceptErrCh:
+		return err
+	case err := <-r.errCh:
+		l.Close()
+		return err
+	}
+}
+
+func (r *standardRouter) route(conn net.Conn, addr net.Addr) {
+	_ = conn.SetDeadline(time.Now().Add(authTimeout))
+	authed, user, cancelPacket, err := Authenticate(conn, r.tlsCfg, r.users)
+	if errors.Is(err, ErrCancelRequest) {
+		// As postgres, reply nothing either way.
+		handleCancelRequest(cancelPacket, r.registry)
+		conn.Close()
+		return
+	}
+	if err != nil {
+		r.logger.Infof("postgres connection from %s refused: %s", conn.RemoteAddr(), err)
+		conn.Close()
+		return
+	}
+	_ = authed.SetDeadline(time.Time{})
+	key, err := r.registry.Issue()
+	if err != nil {
+		authed.Close()
+		return
+	}
+	session := newSessionConn(authed, key, r.registry)
+	if !r.listenerFor(user, addr).deliver(session) {
+		session.Close()
+	}
+}
+
+// listenerFor returns the listener of a user, starting its server.
+func (r *standardRouter) listenerFor(user User, addr net.Addr) *userListener {
+	var name string
+	if user != nil {
+		name = user.GetName()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if ul, ok := r.listeners[name]; ok {
+		return ul
+	}
+	ul := newUserListener(addr)
+	r.listeners[name] = ul
+	go func() {
+		if err := r.serve(user, ul); err != nil && !errors.Is(err, net.ErrClosed) {
+			select {
+			case r.errCh <- err:
+			default:
+			}
+		}
+	}()
+	return ul
+}
+
+func (r *standardRouter) closeListeners() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, ul := range r.listeners {
+		ul.Close()
+	}
+}
+
+// userListener is a net.Listener fed with the connections of one user.
+type userListener struct {
+	addr      net.Addr
+	conns     chan net.Conn
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newUserListener(addr net.Addr) *userListener {
+	return &userListener{addr: addr, conns: make(chan net.Conn), done: make(chan struct{})}
+}
+
+func (l *userListener) deliver(conn net.Conn) bool {
+	select {
+	case l.conns <- conn:
+		return true
+	case <-l.done:
+		return false
+	}
+}
+
+func (l *userListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *userListener) Close() error {
+	l.closeOnce.Do(func() { close(l.done) })
+	return nil
+}
+
+func (l *userListener) Addr() net.Addr { return l.addr }
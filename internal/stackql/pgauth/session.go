@@ -0,0 +1,173 @@
+package pgauth
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/stackql/stackql/internal/stackql/querycancel"
+)
+
+const (
+	msgQuery           byte = 'Q'
+	msgParse           byte = 'P'
+	msgBackendKeyData  byte = 'K'
+	msgReadyForQuery   byte = 'Z'
+	backendKeyDataSize      = 8
+)
+
+// sessionConn carries a session between client and wire server.  It
+// announces the session's cancel key in BackendKeyData, ahead of the
+// first ReadyForQuery, and tags the session's queries with its process ID
+// so that the driver can bind them to the key.
+type sessionConn struct {
+	net.Conn
+	key      querycancel.Key
+	registry querycancel.Registry
+
+	// in yields the replayed startup message, then client messages.
+	in      io.Reader
+	started bool
+	pending []byte
+
+	writeMu  sync.Mutex
+	outBuf   []byte
+	announce bool
+
+	closeOnce sync.Once
+}
+
+// newSessionConn wraps conn, as returned by Authenticate.
+func newSessionConn(conn net.Conn, key querycancel.Key, registry querycancel.Registry) *sessionConn {
+	return &sessionConn{
+		Conn:     conn,
+		key:      key,
+		registry: registry,
+		in:       conn,
+		announce: true,
+	}
+}
+
+// Read yields the startup message, then the client's messages whole,
+// with the SQL of Query and Parse messages tagged.
+func (c *sessionConn) Read(p []byte) (int, error) {
+	if len(c.pending) == 0 {
+		msg, err := c.readClientMessage()
+		if err != nil {
+			// The client is gone, or has broken the protocol,
+			// so the session is over even before the close.
+			c.release()
+			return 0, err
+		}
+		c.pending = msg
+	}
+	n := copy(p, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+func (c *sessionConn) readClientMessage() ([]byte, error) {
+	if !c.started {
+		c.started = true
+		return readStartup(c.in)
+	}
+	var header [5]byte
+	if _, err := io.ReadFull(c.in, header[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(header[1:])
+	if n < 4 { //nolint:mnd // length includes itself
+		return nil, io.ErrUnexpectedEOF
+	}
+	body := make([]byte, n-4)
+	if _, err := io.ReadFull(c.in, body); err != nil {
+		return nil, err
+	}
+	switch header[0] {
+	case msgQuery:
+		query, rest, _ := bytes.Cut(body, []byte{0})
+		body = append(append([]byte(querycancel.Tag(string(query), c.key.ProcessID)), 0), rest...)
+	case msgParse:
+		name, rest, _ := bytes.Cut(body, []byte{0})
+		query, tail, _ := bytes.Cut(rest, []byte{0})
+		tagged := append(append([]byte{}, name...), 0)
+		tagged = append(append(tagged, querycancel.Tag(string(query), c.key.ProcessID)...), 0)
+		body = append(tagged, tail...)
+	}
+	msg := make([]byte, 5, 5+len(body))
+	msg[0] = header[0]
+	binary.BigEndian.PutUint32(msg[1:], uint32(4+len(body))) //nolint:gosec // bounded by the original
+	return append(msg, body...), nil
+}
+
+// Write passes the wire server's messages on, rewriting or inserting
+// BackendKeyData until the first ReadyForQuery.
+func (c *sessionConn) Write(p []byte) (int, error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if !c.announce {
+		return c.Conn.Write(p)
+	}
+	c.outBuf = append(c.outBuf, p...)
+	var out []byte
+	for c.announce && len(c.outBuf) >= 5 {
+		n := int(binary.BigEndian.Uint32(c.outBuf[1:5]))
+		if len(c.outBuf) < 1+n {
+			break
+		}
+		msg := c.outBuf[:1+n]
+		switch msg[0] {
+		case msgBackendKeyData:
+			msg = c.backendKeyData()
+			c.announce = false
+		case msgReadyForQuery:
+			out = append(out, c.backendKeyData()...)
+			c.announce = false
+		}
+		out = append(out, msg...)
+		c.outBuf = c.outBuf[1+n:]
+	}
+	if !c.announce {
+		out = append(out, c.outBuf...)
+		c.outBuf = nil
+	}
+	if len(out) > 0 {
+		if _, err := c.Conn.Write(out); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (c *sessionConn) backendKeyData() []byte {
+	body := binary.BigEndian.AppendUint32(nil, c.key.ProcessID)
+	body = binary.BigEndian.AppendUint32(body, c.key.Secret)
+	msg := []byte{msgBackendKeyData}
+	msg = binary.BigEndian.AppendUint32(msg, 4+backendKeyDataSize)
+	return append(msg, body...)
+}
+
+func (c *sessionConn) Close() error {
+	c.release()
+	return c.Conn.Close()
+}
+
+// release releases the session's cancel key, running the hooks with
+// which the driver ends the session.
+func (c *sessionConn) release() {
+	c.closeOnce.Do(func() { c.registry.Release(c.key.ProcessID) })
+}
+
+// handleCancelRequest acts on a cancel request packet, which carries the
+// key after its code.
+func handleCancelRequest(raw []byte, registry querycancel.Registry) bool {
+	if len(raw) < 16 { //nolint:mnd // length, code, process ID, secret
+		return false
+	}
+	return registry.Cancel(querycancel.Key{
+		ProcessID: binary.BigEndian.Uint32(raw[8:12]),
+		Secret:    binary.BigEndian.Uint32(raw[12:16]),
+	})
+}
@@ -0,0 +1,223 @@
+// Package pgauth authenticates postgres wire protocol clients of
+// `stackql srv` against a users file, with SCRAM-SHA-256 or md5, and
+// routes each authenticated connection to the server of its user.
+package pgauth
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/stackql/stackql-parser/go/vt/sqlparser"
+	"gopkg.in/yaml.v2"
+
+	"github.com/stackql/stackql/internal/stackql/parser"
+	"github.com/stackql/stackql/pkg/mcp_server/policy"
+)
+
+// Authentication methods.
+const (
+	MethodSCRAMSHA256 = "scram-sha-256"
+	MethodMD5         = "md5"
+)
+
+// User modes, named as the MCP server modes.  A read only user may not
+// run statements which mutate provider resources.
+const (
+	ModeReadOnly   = policy.ModeReadOnly
+	ModeFullAccess = policy.ModeFullAccess
+)
+
+// Users is the parsed users file.
+type Users interface {
+	// GetMethod returns the authentication method.
+	GetMethod() string
+	// Lookup returns the named user.
+	Lookup(name string) (User, bool)
+}
+
+// User is one database user.
+type User interface {
+	GetName() string
+	GetMode() string
+	// GetRawAuth returns the user's auth contexts, in the shape of the
+	// --auth flag, or the empty string to use those of the process.
+	GetRawAuth() string
+	getVerifier() verifier
+}
+
+type usersFile struct {
+	Method string      `yaml:"method"`
+	Users  []userEntry `yaml:"users"`
+}
+
+type userEntry struct {
+	Name     string                 `yaml:"name"`
+	Password string                 `yaml:"password"`
+	Mode     string                 `yaml:"mode"`
+	Auth     map[string]interface{} `yaml:"auth"`
+}
+
+type standardUsers struct {
+	method string
+	users  map[string]*standardUser
+}
+
+type standardUser struct {
+	name     string
+	mode     string
+	rawAuth  string
+	verifier verifier
+}
+
+func (u *standardUsers) GetMethod() string { return u.method }
+
+func (u *standardUsers) Lookup(name string) (User, bool) {
+	user, ok := u.users[name]
+	return user, ok
+}
+
+func (u *standardUser) GetName() string       { return u.name }
+func (u *standardUser) GetMode() string       { return u.mode }
+func (u *standardUser) GetRawAuth() string    { return u.rawAuth }
+func (u *standardUser) getVerifier() verifier { return u.verifier }
+
+// LoadUsers reads and parses the users file at path.
+func LoadUsers(path string) (Users, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("users file: %w", err)
+	}
+	users, err := ParseUsers(raw)
+	if err != nil {
+		return nil, fmt.Errorf("users file %q: %w", path, err)
+	}
+	return users, nil
+}
+
+// ParseUsers parses a users file.  Passwords are held as the verifiers
+// postgres itself stores: "SCRAM-SHA-256$<iterations>:<salt>$<StoredKey>:<ServerKey>"
+// or "md5" followed by the hex md5 of the password and user name.
+//
+//nolint:gocognit // flat validation
+func ParseUsers(data []byte) (Users, error) {
+	var f usersFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	method := strings.ToLower(f.Method)
+	switch method {
+	case "":
+		method = MethodSCRAMSHA256
+	case MethodSCRAMSHA256, MethodMD5:
+	default:
+		return nil, fmt.Errorf("invalid method %q (legal: %s, %s)", f.Method, MethodSCRAMSHA256, MethodMD5)
+	}
+	rv := &standardUsers{method: method, users: make(map[string]*standardUser, len(f.Users))}
+	for _, e := range f.Users {
+		if e.Name == "" {
+			return nil, fmt.Errorf("a user has no name")
+		}
+		if _, dup := rv.users[e.Name]; dup {
+			return nil, fmt.Errorf("user %q is declared twice", e.Name)
+		}
+		v, err := parseVerifier(e.Password)
+		if err != nil {
+			return nil, fmt.Errorf("user %q: %w", e.Name, err)
+		}
+		if method == MethodSCRAMSHA256 && v.method() != MethodSCRAMSHA256 {
+			return nil, fmt.Errorf("user %q: method %s needs a SCRAM-SHA-256 verifier", e.Name, method)
+		}
+		mode := e.Mode
+		switch mode {
+		case "":
+			mode = ModeReadOnly
+		case ModeReadOnly, ModeFullAccess:
+		default:
+			return nil, fmt.Errorf("user %q: invalid mode %q (legal: %s, %s)", e.Name, e.Mode, ModeReadOnly, ModeFullAccess)
+		}
+		var rawAuth string
+		if len(e.Auth) > 0 {
+			b, marshalErr := yaml.Marshal(e.Auth)
+			if marshalErr != nil {
+				return nil, fmt.Errorf("user %q: auth: %w", e.Name, marshalErr)
+			}
+			rawAuth = string(b)
+		}
+		rv.users[e.Name] = &standardUser{name: e.Name, mode: mode, rawAuth: rawAuth, verifier: v}
+	}
+	return rv, nil
+}
+
+// CheckMode returns an error where mode forbids one of the statements.
+// Read only users may run only statements known not to reach mutating
+// provider methods: queries, SHOW, DESCRIBE, EXPLAIN short of EXPLAIN
+// ANALYZE of a mutation, and local statements, such as SET, BEGIN or
+// CREATE VIEW, which BI tools and psql issue routinely.  Each statement
+// is classified from its parse, so that comments do not hide its verb;
+// a statement which fails to parse, or reaches EXEC anywhere within it,
+// is denied.
+func CheckMode(mode string, statements []string) error {
+	if mode != ModeReadOnly {
+		return nil
+	}
+	p, err := parser.NewParser()
+	if err != nil {
+		return err
+	}
+	for _, raw := range statements {
+		if strings.TrimSpace(sqlparser.StripLeadingComments(raw)) == "" {
+			continue
+		}
+		stmt, parseErr := p.ParseQuery(raw)
+		if parseErr != nil || !isReadOnlyStatement(stmt) {
+			return fmt.Errorf("permission denied: user is in '%s' mode", ModeReadOnly)
+		}
+	}
+	return nil
+}
+
+// isReadOnlyStatement reports whether stmt is positively known not to
+// mutate provider resources.
+func isReadOnlyStatement(stmt sqlparser.Statement) bool {
+	switch node := stmt.(type) {
+	case *sqlparser.Explain:
+		if strings.EqualFold(node.Type, sqlparser.AnalyzeStr) {
+			return isReadOnlyStatement(node.Statement)
+		}
+		return true
+	case sqlparser.SelectStatement,
+		*sqlparser.Show,
+		*sqlparser.DescribeTable,
+		*sqlparser.DescribeMethod,
+		*sqlparser.OtherRead,
+		*sqlparser.Set,
+		*sqlparser.SetTransaction,
+		*sqlparser.Use,
+		*sqlparser.Begin,
+		*sqlparser.Commit,
+		*sqlparser.Rollback,
+		*sqlparser.SRollback,
+		*sqlparser.Savepoint,
+		*sqlparser.Release,
+		*sqlparser.DDL,
+		*sqlparser.RefreshMaterializedView,
+		*sqlparser.Sleep:
+		return !reachesExec(stmt)
+	default:
+		return false
+	}
+}
+
+// reachesExec reports whether an EXEC lies anywhere within node.
+func reachesExec(node sqlparser.SQLNode) bool {
+	isFound := false
+	_ = sqlparser.Walk(func(n sqlparser.SQLNode) (bool, error) {
+		if _, isExec := n.(*sqlparser.Exec); isExec {
+			isFound = true
+			return false, nil
+		}
+		return true, nil
+	}, node)
+	return isFound
+}
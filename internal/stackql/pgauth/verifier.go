@@ -0,0 +1,130 @@
+package pgauth
+
+import (
+	"crypto/hmac"
+	"crypto/md5" //nolint:gosec // the postgres md5 method is md5
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	scramPrefix = "SCRAM-SHA-256"
+	md5Prefix   = "md5"
+
+	// DefaultSCRAMIterations is the postgres default scram_iterations.
+	DefaultSCRAMIterations = 4096
+	scramSaltLen           = 16
+)
+
+// verifier is a stored password.
+type verifier interface {
+	method() string
+}
+
+type scramVerifier struct {
+	iterations int
+	salt       []byte
+	storedKey  []byte
+	serverKey  []byte
+}
+
+func (v *scramVerifier) method() string { return MethodSCRAMSHA256 }
+
+// md5Verifier holds the hex md5 of password and user name.
+type md5Verifier struct {
+	hash string
+}
+
+func (v *md5Verifier) method() string { return MethodMD5 }
+
+func parseVerifier(s string) (verifier, error) {
+	switch {
+	case strings.HasPrefix(s, scramPrefix+"$"):
+		return parseSCRAMVerifier(s)
+	case strings.HasPrefix(s, md5Prefix) && len(s) == len(md5Prefix)+2*md5.Size:
+		if _, err := hex.DecodeString(s[len(md5Prefix):]); err != nil {
+			return nil, fmt.Errorf("invalid md5 password hash")
+		}
+		return &md5Verifier{hash: strings.ToLower(s[len(md5Prefix):])}, nil
+	case s == "":
+		return nil, fmt.Errorf("no password")
+	default:
+		return nil, fmt.Errorf("password must be a SCRAM-SHA-256 or md5 hash, as stored by postgres")
+	}
+}
+
+func parseSCRAMVerifier(s string) (verifier, error) {
+	parts := strings.Split(s, "$")
+	if len(parts) != 3 { //nolint:mnd // method$iterations:salt$keys
+		return nil, fmt.Errorf("invalid SCRAM-SHA-256 verifier")
+	}
+	iterSalt := strings.SplitN(parts[1], ":", 2) //nolint:mnd // pair
+	keys := strings.SplitN(parts[2], ":", 2)     //nolint:mnd // pair
+	if len(iterSalt) != 2 || len(keys) != 2 {    //nolint:mnd // pair
+		return nil, fmt.Errorf("invalid SCRAM-SHA-256 verifier")
+	}
+	iterations, err := strconv.Atoi(iterSalt[0])
+	if err != nil || iterations < 1 {
+		return nil, fmt.Errorf("invalid SCRAM-SHA-256 iteration count")
+	}
+	v := &scramVerifier{iterations: iterations}
+	for _, f := range []struct {
+		dst *[]byte
+		src string
+	}{{&v.salt, iterSalt[1]}, {&v.storedKey, keys[0]}, {&v.serverKey, keys[1]}} {
+		b, decodeErr := base64.StdEncoding.DecodeString(f.src)
+		if decodeErr != nil {
+			return nil, fmt.Errorf("invalid SCRAM-SHA-256 verifier: %w", decodeErr)
+		}
+		*f.dst = b
+	}
+	if len(v.storedKey) != sha256.Size || len(v.serverKey) != sha256.Size {
+		return nil, fmt.Errorf("invalid SCRAM-SHA-256 key length")
+	}
+	return v, nil
+}
+
+// NewSCRAMVerifier returns the SCRAM-SHA-256 verifier postgres would store
+// for password, for writing into a users file.
+func NewSCRAMVerifier(password string, salt []byte, iterations int) (string, error) {
+	saltedPassword, err := pbkdf2.Key(sha256.New, password, salt, iterations, sha256.Size)
+	if err != nil {
+		return "", err
+	}
+	clientKey := hmacSHA256(saltedPassword, []byte("Client Key"))
+	storedKey := sha256.Sum256(clientKey)
+	serverKey := hmacSHA256(saltedPassword, []byte("Server Key"))
+	return fmt.Sprintf("%s$%d:%s$%s:%s",
+		scramPrefix,
+		iterations,
+		base64.StdEncoding.EncodeToString(salt),
+		base64.StdEncoding.EncodeToString(storedKey[:]),
+		base64.StdEncoding.EncodeToString(serverKey),
+	), nil
+}
+
+// NewMD5Verifier returns the md5 hash postgres would store for password.
+func NewMD5Verifier(user, password string) string {
+	sum := md5.Sum([]byte(password + user)) //nolint:gosec // the postgres md5 method is md5
+	return md5Prefix + hex.EncodeToString(sum[:])
+}
+
+// checkMD5Response checks the client's response to an md5 challenge:
+// "md5" followed by the hex md5 of the stored hash and the salt.
+func checkMD5Response(v *md5Verifier, salt []byte, response string) bool {
+	sum := md5.Sum(append([]byte(v.hash), salt...)) //nolint:gosec // the postgres md5 method is md5
+	expected := md5Prefix + hex.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(response)) == 1
+}
+
+func hmacSHA256(key, msg []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(msg)
+	return h.Sum(nil)
+}
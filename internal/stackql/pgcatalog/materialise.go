@@ -0,0 +1,101 @@
+package pgcatalog
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// insertBatchSize bounds the rows of one INSERT.
+const insertBatchSize = 200
+
+// Statements returns the statements which create and populate the backing
+// tables of the catalog for tables.  They are portable across SQL
+// backends.
+func Statements(tables []Table) []string {
+	s := newSnapshot(tables)
+	var rv []string
+	for _, rel := range relations {
+		name := quoteIdent(rel.backingName())
+		colDefs := make([]string, 0, len(rel.columns))
+		colNames := make([]string, 0, len(rel.columns))
+		for _, col := range rel.columns {
+			colDefs = append(colDefs, col.name+" "+col.sqlType)
+			colNames = append(colNames, col.name)
+		}
+		rv = append(rv,
+			fmt.Sprintf("DROP TABLE IF EXISTS %s", name),
+			fmt.Sprintf("CREATE TABLE %s (%s)", name, strings.Join(colDefs, ", ")),
+		)
+		if rel.rows == nil {
+			continue
+		}
+		rows := rel.rows(s)
+		for start := 0; start < len(rows); start += insertBatchSize {
+			end := min(start+insertBatchSize, len(rows))
+			values := make([]string, 0, end-start)
+			for _, row := range rows[start:end] {
+				values = append(values, "("+literals(row)+")")
+			}
+			rv = append(rv, fmt.Sprintf(
+				"INSERT INTO %s (%s) VALUES %s", name, strings.Join(colNames, ", "), strings.Join(values, ", ")))
+		}
+	}
+	return rv
+}
+
+func literals(row []any) string {
+	rv := make([]string, len(row))
+	for i, v := range row {
+		switch v := v.(type) {
+		case nil:
+			rv[i] = "NULL"
+		case bool:
+			rv[i] = strings.ToUpper(strconv.FormatBool(v))
+		case int64:
+			rv[i] = strconv.FormatInt(v, 10)
+		case string:
+			rv[i] = "'" + strings.ReplaceAll(v, "'", "''") + "'"
+		default:
+			rv[i] = "'" + strings.ReplaceAll(fmt.Sprint(v), "'", "''") + "'"
+		}
+	}
+	return strings.Join(rv, ", ")
+}
+
+// Materialiser keeps the backing tables in step with the tables of the
+// catalog.
+type Materialiser interface {
+	// Materialise populates the backing tables for tables, running each
+	// statement with exec, unless they already hold those tables.
+	Materialise(tables []Table, exec func(string) error) error
+}
+
+// NewMaterialiser returns a materialiser for one SQL backend.
+func NewMaterialiser() Materialiser {
+	return &standardMaterialiser{}
+}
+
+type standardMaterialiser struct {
+	mu     sync.Mutex
+	digest [sha256.Size]byte
+}
+
+func (m *standardMaterialiser) Materialise(tables []Table, exec func(string) error) error {
+	statements := Statements(tables)
+	digest := sha256.Sum256([]byte(strings.Join(statements, ";\n")))
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if digest == m.digest {
+		return nil
+	}
+	for _, stmt := range statements {
+		if err := exec(stmt); err != nil {
+			return fmt.Errorf("could not materialise pg_catalog: %w", err)
+		}
+	}
+	m.digest = digest
+	return nil
+}
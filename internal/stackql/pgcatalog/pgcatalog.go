@@ -0,0 +1,167 @@
+// Package pgcatalog emulates enough of pg_catalog and information_schema
+// for the schema browsers of BI tools.  The catalog relations are
+// synthesised from a list of tables, kept in backing tables of the SQL
+// backend and queried there, introspection queries being rewritten to
+// address the backing tables in place of the catalog relations.
+package pgcatalog
+
+import (
+	"sort"
+	"strings"
+)
+
+// Kind is the relkind of a table.
+type Kind string
+
+const (
+	KindTable            Kind = "r"
+	KindView             Kind = "v"
+	KindMaterializedView Kind = "m"
+)
+
+const (
+	// DatabaseName is the catalog name reported to clients.
+	DatabaseName = "stackql"
+	// PublicSchema holds user space views and tables.
+	PublicSchema = "public"
+
+	pgCatalogSchema         = "pg_catalog"
+	informationSchemaSchema = "information_schema"
+	backingPrefix           = "__iql__."
+)
+
+// Column is a column of a table, its type a postgres type name.
+type Column struct {
+	Name string
+	Type string
+}
+
+// Table is a table, as the catalog presents it.
+type Table struct {
+	Schema  string
+	Name    string
+	Kind    Kind
+	Columns []Column
+	// Definition is the query of a view.
+	Definition string
+}
+
+// ColumnInferrer infers the result columns of a query without running
+// it, as the columns of views are not stored with them.
+type ColumnInferrer interface {
+	InferColumns(query string) []Column
+}
+
+// wellKnownNamespaces are present whatever the tables, with postgres'
+// own OIDs.
+//
+//nolint:gochecknoglobals // immutable lookup
+var wellKnownNamespaces = map[string]int64{
+	pgCatalogSchema:         11,
+	PublicSchema:            2200,
+	informationSchemaSchema: 13000,
+}
+
+// firstUserOID is the first OID postgres allots to user objects.
+const firstUserOID = 16384
+
+// pgType is a row of pg_type.
+type pgType struct {
+	oid      int64
+	name     string
+	length   int64
+	byVal    bool
+	category string
+	array    int64
+}
+
+// pgTypes are the types columns are given, with postgres' own OIDs.
+//
+//nolint:gochecknoglobals,mnd // immutable lookup
+var pgTypes = []pgType{
+	{16, "bool", 1, true, "B", 1000},
+	{17, "bytea", -1, false, "U", 1001},
+	{18, "char", 1, true, "S", 1002},
+	{19, "name", 64, false, "S", 1003},
+	{20, "int8", 8, true, "N", 1016},
+	{21, "int2", 2, true, "N", 1005},
+	{23, "int4", 4, true, "N", 1007},
+	{25, "text", -1, false, "S", 1009},
+	{26, "oid", 4, true, "N", 1028},
+	{114, "json", -1, false, "U", 199},
+	{700, "float4", 4, true, "N", 1021},
+	{701, "float8", 8, true, "N", 1022},
+	{1043, "varchar", -1, false, "S", 1015},
+	{1082, "date", 4, true, "D", 1182},
+	{1114, "timestamp", 8, true, "D", 1115},
+	{1184, "timestamptz", 8, true, "D", 1185},
+	{1700, "numeric", -1, false, "N", 1231},
+	{3802, "jsonb", -1, false, "U", 3807},
+}
+
+// typeAliases map the SQL spellings of types to their pg_type names.
+//
+//nolint:gochecknoglobals // immutable lookup
+var typeAliases = map[string]string{
+	"boolean":                     "bool",
+	"bigint":                      "int8",
+	"smallint":                    "int2",
+	"integer":                     "int4",
+	"int":                         "int4",
+	"real":                        "float4",
+	"double":                      "float8",
+	"double precision":            "float8",
+	"character varying":           "varchar",
+	"timestamp without time zone": "timestamp",
+	"timestamp with time zone":    "timestamptz",
+	"decimal":                     "numeric",
+}
+
+// sqlTypeNames are the information_schema data_type of each pg_type name.
+//
+//nolint:gochecknoglobals // immutable lookup
+var sqlTypeNames = map[string]string{
+	"bool":        "boolean",
+	"int8":        "bigint",
+	"int2":        "smallint",
+	"int4":        "integer",
+	"float4":      "real",
+	"float8":      "double precision",
+	"varchar":     "character varying",
+	"timestamp":   "timestamp without time zone",
+	"timestamptz": "timestamp with time zone",
+	"char":        "\"char\"",
+}
+
+// lookupType returns the pg_type of a type name, text where unknown.
+func lookupType(name string) pgType {
+	n := strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := typeAliases[n]; ok {
+		n = alias
+	}
+	for _, t := range pgTypes {
+		if t.name == n {
+			return t
+		}
+	}
+	return lookupType("text")
+}
+
+func sqlTypeName(t pgType) string {
+	if name, ok := sqlTypeNames[t.name]; ok {
+		return name
+	}
+	return t.name
+}
+
+// sortTables orders tables by schema and name, so that OIDs are stable.
+func sortTables(tables []Table) []Table {
+	rv := append([]Table(nil), tables...)
+	sort.SliceStable(rv, func(i, j int) bool {
+		if rv[i].Schema != rv[j].Schema {
+			return rv[i].Schema < rv[j].Schema
+		}
+		return rv[i].Name < rv[j].Name
+	})
+	return rv
+}
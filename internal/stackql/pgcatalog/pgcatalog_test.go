@@ -0,0 +1,87 @@
+package pgcatalog_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stackql/stackql/internal/stackql/pgcatalog"
+)
+
+func TestRewrite(t *testing.T) {
+	for query, expected := range map[string]string{
+		"SELECT relname FROM pg_catalog.pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace": `SELECT relname FROM "__iql__.pg_catalog.pg_class" c JOIN "__iql__.pg_catalog.pg_namespace" n ON n.oid = c.relnamespace`,      //nolint:lll // expectation
+		`select * from "pg_catalog"."pg_attribute" where pg_attribute.attnum > 0`:                 `select * from "__iql__.pg_catalog.pg_attribute" where "__iql__.pg_catalog.pg_attribute".attnum > 0`,                          //nolint:lll // expectation
+		"SELECT column_name FROM information_schema.columns WHERE table_name = 'pg_class'":        `SELECT column_name FROM "__iql__.information_schema.columns" WHERE table_name = 'pg_class'`,                                  //nolint:lll // expectation
+		"SELECT format_type(a.atttypid, a.atttypmod) FROM pg_attribute a":                         `SELECT (SELECT typname FROM "__iql__.pg_catalog.pg_type" WHERE oid = (a.atttypid)) FROM "__iql__.pg_catalog.pg_attribute" a`, //nolint:lll // expectation
+	} {
+		rewritten, ok := pgcatalog.Rewrite(query)
+		if !ok || rewritten != expected {
+			t.Errorf("%s:\nexpected %s\nreceived %s", query, expected, rewritten)
+		}
+	}
+	for _, query := range []string{
+		"SELECT * FROM google.compute.instances WHERE project = 'pg_class'",
+		"SELECT columns FROM tables",
+		"SELECT my_pg_class FROM t -- pg_class",
+	} {
+		if rewritten, ok := pgcatalog.Rewrite(query); ok || rewritten != query {
+			t.Errorf("%s: expected no rewrite, received %s", query, rewritten)
+		}
+	}
+}
+
+func TestStatements(t *testing.T) {
+	statements := pgcatalog.Statements([]pgcatalog.Table{
+		{
+			Schema:  "google",
+			Name:    "compute.instances",
+			Kind:    pgcatalog.KindTable,
+			Columns: []pgcatalog.Column{{Name: "name", Type: "text"}, {Name: "id", Type: "bigint"}},
+		},
+		{
+			Schema:     pgcatalog.PublicSchema,
+			Name:       "my_view",
+			Kind:       pgcatalog.KindView,
+			Columns:    []pgcatalog.Column{{Name: "it's", Type: "boolean"}},
+			Definition: "SELECT 'x'",
+		},
+	})
+	joined := strings.Join(statements, "\n")
+	for _, expected := range []string{
+		`CREATE TABLE "__iql__.pg_catalog.pg_class" (`,
+		`(16384, 'google', 10, NULL)`,
+		`(16385, 'compute.instances', 16384, `,
+		`(16386, 'my_view', 2200, `,
+		`(16385, 'id', 20, 8, 2, `,
+		`(16386, 'it''s', 16, 1, 1, `,
+		`('stackql', 'google', 'compute.instances', 'id', 2, NULL, 'YES', 'bigint', `,
+		`('stackql', 'public', 'my_view', 'SELECT ''x''')`,
+		`CREATE TABLE "__iql__.pg_catalog.pg_index" (`,
+	} {
+		if !strings.Contains(joined, expected) {
+			t.Errorf("expected the statements to contain %s", expected)
+		}
+	}
+}
+
+func TestMaterialiser(t *testing.T) {
+	m := pgcatalog.NewMaterialiser()
+	tables := []pgcatalog.Table{{Schema: "okta", Name: "user.users", Kind: pgcatalog.KindTable}}
+	var executed int
+	exec := func(string) error { executed++; return nil }
+	if err := m.Materialise(tables, exec); err != nil || executed == 0 {
+		t.Fatalf("expected statements to run, received %d %v", executed, err)
+	}
+	first := executed
+	if err := m.Materialise(tables, exec); err != nil || executed != first {
+		t.Fatalf("expected an unchanged catalog not to be rematerialised")
+	}
+	failing := func(string) error { return errors.New("boom") }
+	if err := m.Materialise(nil, failing); err == nil {
+		t.Fatalf("expected the failure to be reported")
+	}
+	if err := m.Materialise(nil, exec); err != nil || executed == first {
+		t.Fatalf("expected a failed materialisation to be retried")
+	}
+}
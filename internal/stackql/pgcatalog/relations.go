@@ -0,0 +1,276 @@
+package pgcatalog
+
+import (
+	"strings"
+)
+
+const (
+	colInt  = "bigint"
+	colText = "text"
+	colBool = "boolean"
+	// ownerOID is the OID of the bootstrap superuser, owner of everything.
+	ownerOID int64 = 10
+)
+
+type relationColumn struct {
+	name    string
+	sqlType string
+}
+
+// relation is an emulated catalog relation.
+type relation struct {
+	schema  string
+	name    string
+	columns []relationColumn
+	rows    func(s *snapshot) [][]any
+}
+
+func (r relation) backingName() string {
+	return backingPrefix + r.schema + "." + r.name
+}
+
+func cols(spec string) []relationColumn {
+	var rv []relationColumn
+	for _, field := range strings.Split(spec, ",") {
+		name, sqlType, _ := strings.Cut(strings.TrimSpace(field), " ")
+		rv = append(rv, relationColumn{name: name, sqlType: sqlType})
+	}
+	return rv
+}
+
+// namespace is a row of pg_namespace.
+type namespace struct {
+	oid  int64
+	name string
+}
+
+// class is a row of pg_class.
+type class struct {
+	oid       int64
+	namespace namespace
+	table     Table
+}
+
+// snapshot numbers the namespaces and classes of a list of tables.
+type snapshot struct {
+	namespaces []namespace
+	classes    []class
+}
+
+func newSnapshot(tables []Table) *snapshot {
+	s := &snapshot{}
+	byName := make(map[string]namespace)
+	for _, name := range []string{pgCatalogSchema, PublicSchema, informationSchemaSchema} {
+		ns := namespace{oid: wellKnownNamespaces[name], name: name}
+		byName[name] = ns
+		s.namespaces = append(s.namespaces, ns)
+	}
+	next := int64(firstUserOID)
+	sorted := sortTables(tables)
+	for _, t := range sorted {
+		if _, ok := byName[t.Schema]; ok {
+			continue
+		}
+		ns := namespace{oid: next, name: t.Schema}
+		next++
+		byName[t.Schema] = ns
+		s.namespaces = append(s.namespaces, ns)
+	}
+	for _, t := range sorted {
+		s.classes = append(s.classes, class{oid: next, namespace: byName[t.Schema], table: t})
+		next++
+	}
+	return s
+}
+
+//nolint:gochecknoglobals // immutable lookup
+var relations = []relation{
+	{
+		schema:  pgCatalogSchema,
+		name:    "pg_namespace",
+		columns: cols("oid bigint, nspname text, nspowner bigint, nspacl text"),
+		rows: func(s *snapshot) [][]any {
+			var rv [][]any
+			for _, ns := range s.namespaces {
+				rv = append(rv, []any{ns.oid, ns.name, ownerOID, nil})
+			}
+			return rv
+		},
+	},
+	{
+		schema: pgCatalogSchema,
+		name:   "pg_class",
+		columns: cols("oid bigint, relname text, relnamespace bigint, reltype bigint, reloftype bigint, " +
+			"relowner bigint, relam bigint, relfilenode bigint, reltablespace bigint, relpages bigint, " +
+			"reltuples bigint, relhasindex boolean, relisshared boolean, relpersistence text, relkind text, " +
+			"relnatts bigint, relchecks bigint, relhasrules boolean, relhastriggers boolean, " +
+			"relhassubclass boolean, relrowsecurity boolean, relforcerowsecurity boolean, " +
+			"relispopulated boolean, relreplident text, relispartition boolean, relacl text, " +
+			"reloptions text, relpartbound text"),
+		rows: func(s *snapshot) [][]any {
+			var rv [][]any
+			for _, c := range s.classes {
+				rv = append(rv, []any{
+					c.oid, c.table.Name, c.namespace.oid, int64(0), int64(0),
+					ownerOID, int64(0), int64(0), int64(0), int64(0),
+					int64(-1), false, false, "p", string(c.table.Kind),
+					int64(len(c.table.Columns)), int64(0), false, false,
+					false, false, false,
+					true, "d", false, nil,
+					nil, nil,
+				})
+			}
+			return rv
+		},
+	},
+	{
+		schema: pgCatalogSchema,
+		name:   "pg_attribute",
+		columns: cols("attrelid bigint, attname text, atttypid bigint, attlen bigint, attnum bigint, " +
+			"attndims bigint, atttypmod bigint, attbyval boolean, attnotnull boolean, atthasdef boolean, " +
+			"attidentity text, attgenerated text, attisdropped boolean, attislocal boolean, " +
+			"attinhcount bigint, attcollation bigint, attacl text, attoptions text"),
+		rows: func(s *snapshot) [][]any {
+			var rv [][]any
+			for _, c := range s.classes {
+				for i, col := range c.table.Columns {
+					t := lookupType(col.Type)
+					rv = append(rv, []any{
+						c.oid, col.Name, t.oid, t.length, int64(i + 1),
+						int64(0), int64(-1), t.byVal, false, false,
+						"", "", false, true,
+						int64(0), int64(0), nil, nil,
+					})
+				}
+			}
+			return rv
+		},
+	},
+	{
+		schema: pgCatalogSchema,
+		name:   "pg_type",
+		columns: cols("oid bigint, typname text, typnamespace bigint, typowner bigint, typlen bigint, " +
+			"typbyval boolean, typtype text, typcategory text, typisdefined boolean, typdelim text, " +
+			"typrelid bigint, typelem bigint, typarray bigint, typbasetype bigint, typtypmod bigint, " +
+			"typnotnull boolean, typndims bigint, typcollation bigint"),
+		rows: func(_ *snapshot) [][]any {
+			var rv [][]any
+			for _, t := range pgTypes {
+				rv = append(rv, []any{
+					t.oid, t.name, wellKnownNamespaces[pgCatalogSchema], ownerOID, t.length,
+					t.byVal, "b", t.category, true, ",",
+					int64(0), int64(0), t.array, int64(0), int64(-1),
+					false, int64(0), int64(0),
+				})
+			}
+			return rv
+		},
+	},
+	{
+		schema:  pgCatalogSchema,
+		name:    "pg_database",
+		columns: cols("oid bigint, datname text, datdba bigint, encoding bigint, datallowconn boolean, datacl text"),
+		rows: func(_ *snapshot) [][]any {
+			return [][]any{{int64(firstUserOID - 1), DatabaseName, ownerOID, int64(6), true, nil}} //nolint:mnd // UTF8
+		},
+	},
+	{
+		schema:  pgCatalogSchema,
+		name:    "pg_description",
+		columns: cols("objoid bigint, classoid bigint, objsubid bigint, description text"),
+	},
+	{
+		schema:  pgCatalogSchema,
+		name:    "pg_index",
+		columns: cols("indexrelid bigint, indrelid bigint, indnatts bigint, indisunique boolean, indisprimary boolean, indkey text"), //nolint:lll // column list
+	},
+	{
+		schema:  pgCatalogSchema,
+		name:    "pg_constraint",
+		columns: cols("oid bigint, conname text, connamespace bigint, contype text, conrelid bigint, confrelid bigint, conkey text, confkey text"), //nolint:lll // column list
+	},
+	{
+		schema:  pgCatalogSchema,
+		name:    "pg_inherits",
+		columns: cols("inhrelid bigint, inhparent bigint, inhseqno bigint"),
+	},
+	{
+		schema:  pgCatalogSchema,
+		name:    "pg_attrdef",
+		columns: cols("oid bigint, adrelid bigint, adnum bigint, adbin text"),
+	},
+	{
+		schema:  informationSchemaSchema,
+		name:    "schemata",
+		columns: cols("catalog_name text, schema_name text, schema_owner text"),
+		rows: func(s *snapshot) [][]any {
+			var rv [][]any
+			for _, ns := range s.namespaces {
+				rv = append(rv, []any{DatabaseName, ns.name, DatabaseName})
+			}
+			return rv
+		},
+	},
+	{
+		schema:  informationSchemaSchema,
+		name:    "tables",
+		columns: cols("table_catalog text, table_schema text, table_name text, table_type text, is_insertable_into text"),
+		rows: func(s *snapshot) [][]any {
+			var rv [][]any
+			for _, c := range s.classes {
+				var tableType string
+				switch c.table.Kind {
+				case KindTable:
+					tableType = "BASE TABLE"
+				case KindView:
+					tableType = "VIEW"
+				default:
+					// As postgres, which omits materialized views here.
+					continue
+				}
+				rv = append(rv, []any{DatabaseName, c.namespace.name, c.table.Name, tableType, "NO"})
+			}
+			return rv
+		},
+	},
+	{
+		schema: informationSchemaSchema,
+		name:   "columns",
+		columns: cols("table_catalog text, table_schema text, table_name text, column_name text, " +
+			"ordinal_position bigint, column_default text, is_nullable text, data_type text, udt_catalog text, " +
+			"udt_schema text, udt_name text, character_maximum_length bigint, numeric_precision bigint, " +
+			"numeric_scale bigint, is_updatable text"),
+		rows: func(s *snapshot) [][]any {
+			var rv [][]any
+			for _, c := range s.classes {
+				if c.table.Kind == KindMaterializedView {
+					continue
+				}
+				for i, col := range c.table.Columns {
+					t := lookupType(col.Type)
+					rv = append(rv, []any{
+						DatabaseName, c.namespace.name, c.table.Name, col.Name,
+						int64(i + 1), nil, "YES", sqlTypeName(t), DatabaseName,
+						pgCatalogSchema, t.name, nil, nil,
+						nil, "NO",
+					})
+				}
+			}
+			return rv
+		},
+	},
+	{
+		schema:  informationSchemaSchema,
+		name:    "views",
+		columns: cols("table_catalog text, table_schema text, table_name text, view_definition text"),
+		rows: func(s *snapshot) [][]any {
+			var rv [][]any
+			for _, c := range s.classes {
+				if c.table.Kind == KindView {
+					rv = append(rv, []any{DatabaseName, c.namespace.name, c.table.Name, c.table.Definition})
+				}
+			}
+			return rv
+		},
+	},
+}
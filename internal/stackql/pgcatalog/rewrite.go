@@ -0,0 +1,270 @@
+package pgcatalog
+
+import (
+	"strings"
+)
+
+// Rewrite rewrites the references of query to emulated catalog relations,
+// qualified or, for pg_catalog, not, to address their backing tables.
+// Calls of format_type are rewritten to look the type up in the emulated
+// pg_type, so that they work whatever the SQL backend.  Rewrite reports
+// false where query references no emulated relation.
+func Rewrite(query string) (string, bool) {
+	r := &rewriter{src: query}
+	rv := r.rewrite()
+	return rv, r.isRewritten
+}
+
+type rewriter struct {
+	src         string
+	pos         int
+	out         strings.Builder
+	isRewritten bool
+}
+
+// namePart is one part of a possibly qualified name.
+type namePart struct {
+	raw      string
+	value    string
+	isQuoted bool
+}
+
+func (r *rewriter) rewrite() string {
+	for r.pos < len(r.src) {
+		c := r.src[r.pos]
+		switch {
+		case c == '\'':
+			r.copyQuoted('\'')
+		case c == '-' && strings.HasPrefix(r.src[r.pos:], "--"):
+			end := strings.IndexByte(r.src[r.pos:], '\n')
+			if end < 0 {
+				end = len(r.src) - r.pos
+			}
+			r.copyN(end)
+		case c == '/' && strings.HasPrefix(r.src[r.pos:], "/*"):
+			end := strings.Index(r.src[r.pos+2:], "*/")
+			if end < 0 {
+				r.copyN(len(r.src) - r.pos)
+			} else {
+				r.copyN(end + 4) //nolint:mnd // both delimiters
+			}
+		case c == '"' || isIdentStart(c):
+			if r.pos > 0 && (isIdentChar(r.src[r.pos-1]) || r.src[r.pos-1] == '.') {
+				r.copyN(1)
+				continue
+			}
+			r.name()
+		default:
+			r.copyN(1)
+		}
+	}
+	return r.out.String()
+}
+
+func (r *rewriter) copyN(n int) {
+	r.out.WriteString(r.src[r.pos : r.pos+n])
+	r.pos += n
+}
+
+// copyQuoted copies a literal or quoted identifier through.
+func (r *rewriter) copyQuoted(quote byte) {
+	start := r.pos
+	r.pos++
+	for r.pos < len(r.src) {
+		if r.src[r.pos] == quote {
+			if r.pos+1 < len(r.src) && r.src[r.pos+1] == quote {
+				r.pos += 2
+				continue
+			}
+			r.pos++
+			break
+		}
+		r.pos++
+	}
+	r.out.WriteString(r.src[start:r.pos])
+}
+
+// name reads a qualified name, rewriting it where it addresses an emulated
+// relation.
+func (r *rewriter) name() {
+	start := r.pos
+	var parts []namePart
+	for {
+		part, ok := r.part()
+		if !ok {
+			break
+		}
+		parts = append(parts, part)
+		mark := r.pos
+		r.skipSpace()
+		if r.pos < len(r.src) && r.src[r.pos] == '.' {
+			r.pos++
+			r.skipSpace()
+			continue
+		}
+		r.pos = mark
+		break
+	}
+	if len(parts) == 0 {
+		r.pos = start
+		r.copyN(1)
+		return
+	}
+	if rel, consumed, ok := matchRelation(parts); ok {
+		r.isRewritten = true
+		r.out.WriteString(quoteIdent(rel.backingName()))
+		for _, p := range parts[consumed:] {
+			r.out.WriteString(".")
+			r.out.WriteString(p.raw)
+		}
+		return
+	}
+	if isFormatType(parts) && r.formatType() {
+		return
+	}
+	r.out.WriteString(r.src[start:r.pos])
+}
+
+func (r *rewriter) part() (namePart, bool) {
+	if r.pos >= len(r.src) {
+		return namePart{}, false
+	}
+	start := r.pos
+	if r.src[r.pos] == '"' {
+		var b strings.Builder
+		for i := r.pos + 1; i < len(r.src); i++ {
+			if r.src[i] != '"' {
+				b.WriteByte(r.src[i])
+				continue
+			}
+			if i+1 < len(r.src) && r.src[i+1] == '"' {
+				b.WriteByte('"')
+				i++
+				continue
+			}
+			r.pos = i + 1
+			return namePart{raw: r.src[start:r.pos], value: b.String(), isQuoted: true}, true
+		}
+		return namePart{}, false
+	}
+	if !isIdentStart(r.src[r.pos]) {
+		return namePart{}, false
+	}
+	for r.pos < len(r.src) && isIdentChar(r.src[r.pos]) {
+		r.pos++
+	}
+	raw := r.src[start:r.pos]
+	return namePart{raw: raw, value: strings.ToLower(raw)}, true
+}
+
+func (r *rewriter) skipSpace() {
+	for r.pos < len(r.src) && strings.IndexByte(" \t\r\n", r.src[r.pos]) >= 0 {
+		r.pos++
+	}
+}
+
+// formatType rewrites the arguments of a format_type call, positioned
+// after its name, to a lookup of the emulated pg_type.
+func (r *rewriter) formatType() bool {
+	mark := r.pos
+	r.skipSpace()
+	if r.pos >= len(r.src) || r.src[r.pos] != '(' {
+		r.pos = mark
+		return false
+	}
+	args, end, ok := splitArgs(r.src, r.pos)
+	if !ok || len(args) != 2 { //nolint:mnd // type OID and modifier
+		r.pos = mark
+		return false
+	}
+	typeOID, _ := Rewrite(args[0])
+	r.pos = end
+	r.isRewritten = true
+	r.out.WriteString("(SELECT typname FROM ")
+	r.out.WriteString(quoteIdent(relationByName(pgCatalogSchema, "pg_type").backingName()))
+	r.out.WriteString(" WHERE oid = (")
+	r.out.WriteString(strings.TrimSpace(typeOID))
+	r.out.WriteString("))")
+	return true
+}
+
+// splitArgs splits the top level arguments of the parenthesised list at
+// open, returning the position after it.
+func splitArgs(src string, open int) ([]string, int, bool) {
+	var args []string
+	depth := 0
+	argStart := open + 1
+	for i := open; i < len(src); i++ {
+		switch src[i] {
+		case '\'', '"':
+			quote := src[i]
+			for i++; i < len(src) && src[i] != quote; i++ {
+			}
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return append(args, src[argStart:i]), i + 1, true
+			}
+		case ',':
+			if depth == 1 {
+				args = append(args, src[argStart:i])
+				argStart = i + 1
+			}
+		}
+	}
+	return nil, 0, false
+}
+
+// matchRelation matches the leading parts of a name with an emulated
+// relation, returning how many parts name it.  pg_catalog relations match
+// unqualified too, pg_catalog being on every search path.
+func matchRelation(parts []namePart) (relation, int, bool) {
+	if len(parts) >= 2 { //nolint:mnd // schema and relation
+		if rel, ok := relationByNameOK(parts[0].value, parts[1].value); ok {
+			return rel, 2, true //nolint:mnd // schema and relation
+		}
+	}
+	if rel, ok := relationByNameOK(pgCatalogSchema, parts[0].value); ok {
+		return rel, 1, true
+	}
+	return relation{}, 0, false
+}
+
+func isFormatType(parts []namePart) bool {
+	switch len(parts) {
+	case 1:
+		return parts[0].value == "format_type"
+	case 2: //nolint:mnd // schema and function
+		return parts[0].value == pgCatalogSchema && parts[1].value == "format_type"
+	default:
+		return false
+	}
+}
+
+func relationByNameOK(schema, name string) (relation, bool) {
+	for _, rel := range relations {
+		if rel.schema == schema && rel.name == name {
+			return rel, true
+		}
+	}
+	return relation{}, false
+}
+
+func relationByName(schema, name string) relation {
+	rel, _ := relationByNameOK(schema, name)
+	return rel
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9') || c == '$'
+}
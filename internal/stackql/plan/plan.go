@@ -1,6 +1,7 @@
 package plan
 
 import (
+	"sync"
 	"time"
 
 	"github.com/stackql/stackql/internal/stackql/acid/binlog"
@@ -11,7 +12,8 @@ import (
 )
 
 var (
-	_ Plan = &standardPlan{}
+	_ Plan      = &standardPlan{}
+	_ BoundPlan = &standardBoundPlan{}
 )
 
 type Plan interface {
@@ -184,3 +186,29 @@ func (p *standardPlan) GetColumnMetadata() []typing.ColumnMetadata {
 func (p *standardPlan) SetColumnMetadata(columns []typing.ColumnMetadata) {
 	p.columnMetadata = columns
 }
+
+// BoundPlan is a plan of the plan template cache, held by one query,
+// with the literal values of that query bound into it, until released.
+type BoundPlan interface {
+	Plan
+	// Release returns the plan to the cache, once executed or abandoned.
+	Release()
+}
+
+// NewBoundPlan returns plan, returned to its cache by release.
+func NewBoundPlan(plan Plan, release func()) BoundPlan {
+	return &standardBoundPlan{
+		Plan:    plan,
+		release: release,
+	}
+}
+
+type standardBoundPlan struct {
+	Plan
+	once    sync.Once
+	release func()
+}
+
+func (p *standardBoundPlan) Release() {
+	p.once.Do(p.release)
+}
@@ -0,0 +1,45 @@
+package planbuilder
+
+import (
+	"strings"
+
+	"github.com/lib/pq/oid"
+	"github.com/stackql/stackql/internal/stackql/handler"
+	"github.com/stackql/stackql/internal/stackql/pgcatalog"
+)
+
+var (
+	_ pgcatalog.ColumnInferrer = &catalogColumnInferrer{}
+)
+
+// catalogColumnInferrer infers the columns of views for the emulated
+// pg_catalog by planning a query of each, without running it.
+type catalogColumnInferrer struct {
+	handlerCtx handler.HandlerContext
+}
+
+func newCatalogColumnInferrer(handlerCtx handler.HandlerContext) pgcatalog.ColumnInferrer {
+	return &catalogColumnInferrer{
+		handlerCtx: handlerCtx,
+	}
+}
+
+func (ci *catalogColumnInferrer) InferColumns(query string) []pgcatalog.Column {
+	clonedCtx := ci.handlerCtx.Clone()
+	clonedCtx.SetQuery(query)
+	clonedCtx.SetRawQuery(query)
+	qPlan, err := NewPlanBuilder(nil).BuildPlanFromContext(clonedCtx)
+	if err != nil || qPlan == nil {
+		return nil
+	}
+	defer releasePlan(qPlan)
+	colMeta := qPlan.GetColumnMetadata()
+	rv := make([]pgcatalog.Column, 0, len(colMeta))
+	for _, col := range colMeta {
+		rv = append(rv, pgcatalog.Column{
+			Name: col.GetIdentifier(),
+			Type: strings.ToLower(oid.TypeName[col.GetColumnOID()]),
+		})
+	}
+	return rv
+}
@@ -7,12 +7,14 @@ import (
 	"github.com/stackql/stackql-parser/go/vt/sqlparser"
 	"github.com/stackql/stackql/internal/stackql/acid/txn_context"
 	"github.com/stackql/stackql/internal/stackql/astanalysis/earlyanalysis"
+	"github.com/stackql/stackql/internal/stackql/bindparam"
 	"github.com/stackql/stackql/internal/stackql/handler"
 	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
 	"github.com/stackql/stackql/internal/stackql/intrinsic"
 	"github.com/stackql/stackql/internal/stackql/parser"
 	"github.com/stackql/stackql/internal/stackql/parserutil"
 	"github.com/stackql/stackql/internal/stackql/plan"
+	"github.com/stackql/stackql/internal/stackql/plancache"
 	"github.com/stackql/stackql/internal/stackql/primitive"
 	"github.com/stackql/stackql/internal/stackql/primitivegenerator"
 )
@@ -50,8 +52,28 @@ func (pb *standardPlanBuilder) BuildPlanFromContext(handlerCtx handler.HandlerCo
 	if err != nil {
 		return nil, err
 	}
+	// A prepared statement is planned once, at its first execution: later
+	// executions rebind their values into the slots of its plan template.
+	params := handlerCtx.GetBindParams()
+	var shape plancache.Shape
+	isShaped := false
+	if len(params) > 0 && isPlanCacheEnabled() {
+		var shapeErr error
+		shape, shapeErr = bindparam.Shape(handlerCtx.GetQuery(), params)
+		isShaped = shapeErr == nil
+		if isShaped {
+			pl, isHit, hitErr := lookupPlanTemplate(handlerCtx, shape)
+			if hitErr != nil {
+				return nil, hitErr
+			}
+			if isHit {
+				logging.GetLogger().Infoln("retrieving prepared statement plan template from cache")
+				return pl, nil
+			}
+		}
+	}
 	planKey := handlerCtx.GetQuery()
-	if qp, ok := handlerCtx.GetLRUCache().Get(planKey); ok && isPlanCacheEnabled() {
+	if qp, ok := handlerCtx.GetLRUCache().Get(planKey); ok && isPlanCacheEnabled() && len(params) == 0 {
 		logging.GetLogger().Infoln("retrieving query plan from cache")
 		pl, plOk := qp.(plan.Plan)
 		if plOk {
@@ -77,6 +99,27 @@ func (pb *standardPlanBuilder) BuildPlanFromContext(handlerCtx handler.HandlerCo
 	if err != nil {
 		return createErroneousPlan(handlerCtx, qPlan, rowSort, err)
 	}
+	if len(params) > 0 {
+		statement, shape.Slots, err = bindparam.Bind(statement, params)
+		if err != nil {
+			return createErroneousPlan(handlerCtx, qPlan, rowSort, err)
+		}
+	} else {
+		// Statements differing only in the literals of their predicates
+		// share a plan template, into which those literals are rebound.
+		shape, isShaped = plancache.Normalise(statement)
+		isShaped = isShaped && isPlanCacheEnabled()
+	}
+	if isShaped && len(params) == 0 {
+		pl, isHit, hitErr := lookupPlanTemplate(handlerCtx, shape)
+		if hitErr != nil {
+			return nil, hitErr
+		}
+		if isHit {
+			logging.GetLogger().Infoln("retrieving query plan template from cache")
+			return pl, nil
+		}
+	}
 	//nolint:gocritic // acceptable
 	switch stmt := statement.(type) {
 	case *sqlparser.RefreshMaterializedView:
@@ -129,7 +172,8 @@ func (pb *standardPlanBuilder) BuildPlanFromContext(handlerCtx handler.HandlerCo
 	}
 
 	primitiveGenerator := primitivegenerator.NewRootPrimitiveGenerator(
-		statement, handlerCtx, pGBuilder.getPlanGraphHolder())
+		statement, handlerCtx, pGBuilder.getPlanGraphHolder(),
+	).WithCatalogColumnInferrer(newCatalogColumnInferrer(handlerCtx))
 
 	pGBuilder.setRootPrimitiveGenerator(primitiveGenerator)
 
@@ -218,7 +262,12 @@ func (pb *standardPlanBuilder) BuildPlanFromContext(handlerCtx handler.HandlerCo
 		if err != nil {
 			return createErroneousPlan(handlerCtx, qPlan, rowSort, err)
 		}
-		if qPlan.IsCacheable() {
+		if qPlan.IsCacheable() && isShaped {
+			annotations, _ := earlyPassScreenerAnalyzer.GetPlanBuilderInput().GetAnnotations()
+			where := pGBuilder.getRootPrimitiveGenerator().GetPrimitiveComposer().GetWhere()
+			return storePlanTemplate(handlerCtx, shape, qPlan, annotations, where), nil
+		}
+		if qPlan.IsCacheable() && len(params) == 0 {
 			handlerCtx.GetLRUCache().Set(planKey, qPlan)
 		}
 	}
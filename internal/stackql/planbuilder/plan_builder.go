@@ -941,6 +941,7 @@ func (pgb *standardPlanGraphBuilder) handlePurge(pbi planbuilderinput.PlanBuilde
 			}
 			// This happens in all cases, provided the ourge is successful.
 			handlerCtx.GetLRUCache().Clear()
+			handlerCtx.GetPlanTemplateCache().Clear()
 			purgeMsg := fmt.Sprintf("PURGE of type '%s' successfully completed", targetStr)
 			return util.PrepareResultSet(
 				internaldto.NewPrepareResultSetPlusRawDTO(
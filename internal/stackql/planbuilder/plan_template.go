@@ -0,0 +1,89 @@
+package planbuilder
+
+import (
+	"github.com/stackql/stackql-parser/go/vt/sqlparser"
+	"github.com/stackql/stackql/internal/stackql/handler"
+	"github.com/stackql/stackql/internal/stackql/parserutil"
+	"github.com/stackql/stackql/internal/stackql/plan"
+	"github.com/stackql/stackql/internal/stackql/plancache"
+	"github.com/stackql/stackql/internal/stackql/taxonomy"
+)
+
+// lookupPlanTemplate returns the cached plan of a statement of shape,
+// with the values of the statement rebound into it.  The plan is held
+// for the statement until released, so that no other statement rebinds
+// it meanwhile.
+func lookupPlanTemplate(handlerCtx handler.HandlerContext, shape plancache.Shape) (plan.Plan, bool, error) {
+	tmpl, hit := handlerCtx.GetPlanTemplateCache().Lookup(shape)
+	if !hit {
+		return nil, false, nil
+	}
+	pl, isPlan := tmpl.Get().(plan.Plan)
+	if !isPlan {
+		tmpl.Release()
+		return nil, false, nil
+	}
+	txnID, err := handlerCtx.GetTxnCounterMgr().GetNextTxnID()
+	if err != nil {
+		tmpl.Release()
+		return nil, false, err
+	}
+	tmpl.Bind(shape.Values)
+	pl.SetTxnID(txnID)
+	return plan.NewBoundPlan(pl, tmpl.Release), true, nil
+}
+
+// storePlanTemplate caches qPlan as the template of shape, held for the
+// statement which built it until released.
+func storePlanTemplate(
+	handlerCtx handler.HandlerContext,
+	shape plancache.Shape,
+	qPlan plan.Plan,
+	annotations taxonomy.AnnotationCtxMap,
+	where *sqlparser.Where,
+) plan.Plan {
+	tmpl := plancache.NewTemplate(shape, qPlan, rebindableSlots(annotations, where))
+	handlerCtx.GetPlanTemplateCache().Store(shape, tmpl)
+	return plan.NewBoundPlan(qPlan, tmpl.Release)
+}
+
+// releasePlan returns a plan held from the template cache, as when it is
+// built only for its metadata.
+func releasePlan(pl plan.Plan) {
+	if boundPlan, isBound := pl.(plan.BoundPlan); isBound {
+		boundPlan.Release()
+	}
+}
+
+// rebindableSlots reports the literals which the plan reads only as it
+// executes: the provider request parameters.  Literals of predicates left
+// in the WHERE clause are rendered into SQL as the plan is built, so
+// cannot be rebound.
+func rebindableSlots(
+	annotations taxonomy.AnnotationCtxMap,
+	where *sqlparser.Where,
+) func(*sqlparser.SQLVal) bool {
+	live := make(map[*sqlparser.SQLVal]struct{})
+	for _, ac := range annotations {
+		for _, v := range ac.GetParameters() {
+			if param, isParam := v.(parserutil.ParameterMetadata); isParam {
+				v = param.GetVal()
+			}
+			if literal, isLiteral := v.(*sqlparser.SQLVal); isLiteral {
+				live[literal] = struct{}{}
+			}
+		}
+	}
+	if where != nil {
+		_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+			if literal, isLiteral := node.(*sqlparser.SQLVal); isLiteral {
+				delete(live, literal)
+			}
+			return true, nil
+		}, where)
+	}
+	return func(slot *sqlparser.SQLVal) bool {
+		_, isLive := live[slot]
+		return isLive
+	}
+}
@@ -0,0 +1,137 @@
+package plancache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// maxVariants bounds the templates of one shape, which differ in the
+// values of their fixed slots.
+const maxVariants = 4
+
+// Stats are the counters of a cache.
+type Stats struct {
+	Capacity  int
+	Entries   int
+	Templates int
+	Lookups   uint64
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+}
+
+// HitRatio returns the share of lookups which hit, or zero before any.
+func (s Stats) HitRatio() float64 {
+	if s.Lookups == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Lookups)
+}
+
+// Cache holds the templates of the most recently planned shapes.
+type Cache interface {
+	// Lookup returns a template of shape into which its values can be
+	// rebound, held for the caller, who must release it.
+	Lookup(shape Shape) (Template, bool)
+	// Store adds template as a variant of shape, evicting the oldest
+	// variant beyond a few and the least recently used shape beyond
+	// capacity.
+	Store(shape Shape, template Template)
+	// Clear drops all templates, keeping the counters.
+	Clear()
+	Stats() Stats
+}
+
+// NewCache returns a cache of up to capacity shapes; a cache without
+// capacity holds nothing.
+func NewCache(capacity int) Cache {
+	return &standardCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+type entry struct {
+	key       string
+	templates []Template
+}
+
+type standardCache struct {
+	mu        sync.Mutex
+	capacity  int
+	entries   map[string]*list.Element
+	order     *list.List
+	templates int
+	lookups   uint64
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+func (c *standardCache) Lookup(shape Shape) (Template, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lookups++
+	if elem, ok := c.entries[shape.Key]; ok {
+		for _, t := range elem.Value.(*entry).templates { //nolint:errcheck,forcetypeassert // only entries are stored
+			if t.Matches(shape.Values) && t.Acquire() {
+				c.order.MoveToFront(elem)
+				c.hits++
+				return t, true
+			}
+		}
+	}
+	c.misses++
+	return nil, false
+}
+
+func (c *standardCache) Store(shape Shape, template Template) {
+	if c.capacity <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[shape.Key]; ok {
+		e := elem.Value.(*entry) //nolint:errcheck,forcetypeassert // only entries are stored
+		e.templates = append(e.templates, template)
+		c.templates++
+		if len(e.templates) > maxVariants {
+			e.templates = e.templates[1:]
+			c.templates--
+		}
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[shape.Key] = c.order.PushFront(&entry{key: shape.Key, templates: []Template{template}})
+	c.templates++
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		e := c.order.Remove(oldest).(*entry) //nolint:errcheck,forcetypeassert // only entries are stored
+		delete(c.entries, e.key)
+		c.templates -= len(e.templates)
+		c.evictions++
+	}
+}
+
+func (c *standardCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+	c.templates = 0
+}
+
+func (c *standardCache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Stats{
+		Capacity:  c.capacity,
+		Entries:   c.order.Len(),
+		Templates: c.templates,
+		Lookups:   c.lookups,
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+	}
+}
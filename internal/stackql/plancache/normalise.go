@@ -0,0 +1,126 @@
+// Package plancache caches query plans as templates keyed by normalised
+// statements, whose literal operands are lifted into parameter slots, so
+// that statements differing only in those literals share one plan.  The
+// values of a statement are rebound into its template at execution.
+package plancache
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"github.com/stackql/stackql-parser/go/vt/sqlparser"
+)
+
+// Value is the literal bound to one slot.
+type Value struct {
+	Type sqlparser.ValType
+	Val  []byte
+}
+
+// Equals reports whether v and other are the same literal.
+func (v Value) Equals(other Value) bool {
+	return v.Type == other.Type && bytes.Equal(v.Val, other.Val)
+}
+
+// Shape is a statement normalised into parameter slots.
+type Shape struct {
+	// Key renders the statement with each slot in place of its literal.
+	Key string
+	// Slots are the literal nodes of the statement, in order.
+	Slots []*sqlparser.SQLVal
+	// Values are the literals of the slots, in order.
+	Values []Value
+}
+
+// Normalise lifts the literal operands of the WHERE comparisons of stmt
+// into slots.  Only selects from a single table, free of subqueries, are
+// normalised: the literals of any other statement may be consumed where
+// a slot cannot reach them.
+func Normalise(stmt sqlparser.Statement) (Shape, bool) {
+	sel, isSelect := stmt.(*sqlparser.Select)
+	if !isSelect || sel.Where == nil || len(sel.From) != 1 {
+		return Shape{}, false
+	}
+	aliased, isAliased := sel.From[0].(*sqlparser.AliasedTableExpr)
+	if !isAliased {
+		return Shape{}, false
+	}
+	if _, isTable := aliased.Expr.(sqlparser.TableName); !isTable || hasSubquery(sel) {
+		return Shape{}, false
+	}
+	var slots []*sqlparser.SQLVal
+	collectSlots(sel.Where.Expr, &slots)
+	if len(slots) == 0 {
+		return Shape{}, false
+	}
+	rv := Shape{
+		Slots:  slots,
+		Values: make([]Value, len(slots)),
+	}
+	for i, slot := range slots {
+		rv.Values[i] = Value{Type: slot.Type, Val: slot.Val}
+		slot.Type = sqlparser.ValArg
+		slot.Val = []byte(fmt.Sprintf(":%s%d", slotTag(rv.Values[i].Type), i+1))
+	}
+	rv.Key = sqlparser.String(sel)
+	for i, slot := range slots {
+		slot.Type = rv.Values[i].Type
+		slot.Val = rv.Values[i].Val
+	}
+	return rv, true
+}
+
+// collectSlots appends the literal right hand operands of the column
+// comparisons of expr to slots.
+func collectSlots(expr sqlparser.Expr, slots *[]*sqlparser.SQLVal) {
+	switch expr := expr.(type) {
+	case *sqlparser.AndExpr:
+		collectSlots(expr.Left, slots)
+		collectSlots(expr.Right, slots)
+	case *sqlparser.OrExpr:
+		collectSlots(expr.Left, slots)
+		collectSlots(expr.Right, slots)
+	case *sqlparser.NotExpr:
+		collectSlots(expr.Expr, slots)
+	case *sqlparser.ComparisonExpr:
+		if _, isCol := expr.Left.(*sqlparser.ColName); !isCol {
+			return
+		}
+		literal, isLiteral := expr.Right.(*sqlparser.SQLVal)
+		if !isLiteral {
+			return
+		}
+		switch literal.Type { //nolint:exhaustive // other literals stay in the key
+		case sqlparser.StrVal, sqlparser.IntVal, sqlparser.FloatVal:
+			*slots = append(*slots, literal)
+		}
+	}
+}
+
+// slotTag types the slots of a key, so that 1 and '1' key apart.
+func slotTag(t sqlparser.ValType) string {
+	switch t { //nolint:exhaustive // only slotted types
+	case sqlparser.StrVal:
+		return "str"
+	case sqlparser.IntVal:
+		return "int"
+	case sqlparser.FloatVal:
+		return "float"
+	default:
+		return "v" + strconv.Itoa(int(t))
+	}
+}
+
+func hasSubquery(node sqlparser.SQLNode) bool {
+	var found bool
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch node.(type) {
+		case *sqlparser.Subquery, *sqlparser.ExistsExpr:
+			found = true
+			return false, nil
+		}
+		return !found, nil
+	}, node)
+	return found
+}
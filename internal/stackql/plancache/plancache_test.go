@@ -0,0 +1,160 @@
+package plancache_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stackql/stackql-parser/go/vt/sqlparser"
+	"github.com/stackql/stackql/internal/stackql/parser"
+	"github.com/stackql/stackql/internal/stackql/plancache"
+)
+
+func normalise(t *testing.T, query string) (plancache.Shape, bool) {
+	t.Helper()
+	p, err := parser.NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt, err := p.ParseQuery(query)
+	if err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	shape, ok := plancache.Normalise(stmt)
+	if ok && sqlparser.String(stmt) == shape.Key {
+		t.Fatalf("%s: expected the statement to be restored after keying", query)
+	}
+	return shape, ok
+}
+
+func TestNormalise(t *testing.T) {
+	a, ok := normalise(t, "select name from google.compute.instances where project = 'a' and zone = 'b' and id > 3")
+	if !ok || len(a.Slots) != 3 {
+		t.Fatalf("expected three slots, received %v", a)
+	}
+	b, _ := normalise(t, "select name from google.compute.instances where project = 'x' and zone = 'y' and id > 7")
+	if a.Key != b.Key {
+		t.Errorf("expected literals to key together:\n%s\n%s", a.Key, b.Key)
+	}
+	if string(b.Values[0].Val) != "x" || b.Values[2].Type != sqlparser.IntVal {
+		t.Errorf("unexpected values %v", b.Values)
+	}
+	c, _ := normalise(t, "select name from google.compute.instances where project = 'x' and zone = 'y' and id > '7'")
+	if a.Key == c.Key {
+		t.Errorf("expected differently typed literals to key apart")
+	}
+	for _, query := range []string{
+		"select name from google.compute.instances",
+		"select 1 from t where 2 = 3",
+		"select a from t1 join t2 on t1.x = t2.x where t1.y = 'z'",
+		"select a from t where b = 'c' and d in (select d from u)",
+		"select a from t where b = 'c' union select a from u where b = 'd'",
+	} {
+		if _, isShaped := normalise(t, query); isShaped {
+			t.Errorf("%s: expected no normalisation", query)
+		}
+	}
+}
+
+func TestCache(t *testing.T) {
+	cache := plancache.NewCache(1)
+	a, _ := normalise(t, "select name from t where project = 'a' and name = 'n'")
+	if _, hit := cache.Lookup(a); hit {
+		t.Fatalf("expected an empty cache to miss")
+	}
+	// The name predicate is consumed as the plan is built, so it is fixed.
+	fixedSlot := a.Slots[1]
+	built := plancache.NewTemplate(a, "plan", func(slot *sqlparser.SQLVal) bool { return slot != fixedSlot })
+	cache.Store(a, built)
+	built.Release()
+
+	b, _ := normalise(t, "select name from t where project = 'b' and name = 'n'")
+	tmpl, hit := cache.Lookup(b)
+	if !hit || tmpl.Get() != "plan" {
+		t.Fatalf("expected a hit")
+	}
+	tmpl.Bind(b.Values)
+	if string(a.Slots[0].Val) != "b" || string(a.Slots[1].Val) != "n" {
+		t.Errorf("expected the rebindable slot to be rebound, received %s %s", a.Slots[0].Val, a.Slots[1].Val)
+	}
+	tmpl.Release()
+
+	c, _ := normalise(t, "select name from t where project = 'b' and name = 'm'")
+	if _, hit = cache.Lookup(c); hit {
+		t.Fatalf("expected a fixed slot of another value to miss")
+	}
+	d, _ := normalise(t, "select id from t where project = 'b'")
+	cache.Store(d, plancache.NewTemplate(d, "other", func(*sqlparser.SQLVal) bool { return true }))
+
+	stats := cache.Stats()
+	if stats.Lookups != 3 || stats.Hits != 1 || stats.Misses != 2 || stats.Evictions != 1 || stats.Entries != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if ratio := stats.HitRatio(); ratio < 0.33 || ratio > 0.34 {
+		t.Errorf("unexpected hit ratio %f", ratio)
+	}
+	cache.Clear()
+	if _, hit = cache.Lookup(d); hit || cache.Stats().Templates != 0 {
+		t.Errorf("expected a cleared cache to miss")
+	}
+}
+
+func TestTemplateIsHeldByOneQuery(t *testing.T) {
+	cache := plancache.NewCache(1)
+	a, _ := normalise(t, "select name from t where project = 'a'")
+	built := plancache.NewTemplate(a, "plan", func(*sqlparser.SQLVal) bool { return true })
+	cache.Store(a, built)
+	b, _ := normalise(t, "select name from t where project = 'b'")
+	if _, hit := cache.Lookup(b); hit {
+		t.Fatalf("expected a template held by the query which built it to miss")
+	}
+	built.Release()
+	tmpl, hit := cache.Lookup(b)
+	if !hit {
+		t.Fatalf("expected a released template to hit")
+	}
+	if _, hit = cache.Lookup(b); hit {
+		t.Fatalf("expected a template held by another query to miss")
+	}
+	tmpl.Release()
+	if _, hit = cache.Lookup(b); !hit {
+		t.Fatalf("expected the template to hit once released again")
+	}
+}
+
+// TestTemplateConcurrentQueries runs queries of one shape at once, each
+// reading its slot as a provider request would: none may see the value
+// of another.
+func TestTemplateConcurrentQueries(t *testing.T) {
+	cache := plancache.NewCache(4)
+	var wg sync.WaitGroup
+	errs := make(chan error, 64)
+	for i := 0; i < 64; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			project := fmt.Sprintf("p%d", i)
+			shape, _ := normalise(t, "select name from t where project = '"+project+"'")
+			tmpl, hit := cache.Lookup(shape)
+			if hit {
+				tmpl.Bind(shape.Values)
+			} else {
+				// Planned afresh: the plan reads the slots of its own shape.
+				tmpl = plancache.NewTemplate(shape, shape.Slots, func(*sqlparser.SQLVal) bool { return true })
+				cache.Store(shape, tmpl)
+			}
+			defer tmpl.Release()
+			for n := 0; n < 100; n++ {
+				if got := string(tmpl.Get().([]*sqlparser.SQLVal)[0].Val); got != project {
+					errs <- fmt.Errorf("query for %s read %s", project, got)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,90 @@
+package plancache
+
+import (
+	"sync/atomic"
+
+	"github.com/stackql/stackql-parser/go/vt/sqlparser"
+)
+
+// Template is a plan shared by the statements of one shape.  A template
+// is held by one query at a time, which rebinds its values into the slots
+// of the plan; a query finding every template of its shape held plans
+// afresh, so that concurrent queries never share slots.
+type Template interface {
+	// Get returns the plan of the template.
+	Get() interface{}
+	// Matches reports whether values can be rebound into the template: its
+	// fixed slots, whose literals the plan consumed as it was built, must
+	// hold the same values.
+	Matches(values []Value) bool
+	// Acquire takes the template for one query, reporting false where
+	// another query holds it.
+	Acquire() bool
+	// Bind rebinds values into the slots of the template, which the caller
+	// must hold.
+	Bind(values []Value)
+	// Release returns the template for other queries to acquire.
+	Release()
+}
+
+// NewTemplate returns the template of plan, built for shape, held by the
+// query which built it.  Slots for which isRebindable is false are fixed
+// to their values in shape.
+func NewTemplate(shape Shape, plan interface{}, isRebindable func(*sqlparser.SQLVal) bool) Template {
+	rv := &standardTemplate{
+		plan:   plan,
+		slots:  shape.Slots,
+		values: shape.Values,
+		fixed:  make([]bool, len(shape.Slots)),
+	}
+	rv.isHeld.Store(true)
+	for i, slot := range shape.Slots {
+		rv.fixed[i] = slot == nil || !isRebindable(slot)
+	}
+	return rv
+}
+
+type standardTemplate struct {
+	isHeld atomic.Bool
+	plan   interface{}
+	slots  []*sqlparser.SQLVal
+	values []Value
+	fixed  []bool
+}
+
+func (t *standardTemplate) Get() interface{} {
+	return t.plan
+}
+
+func (t *standardTemplate) Matches(values []Value) bool {
+	if len(values) != len(t.values) {
+		return false
+	}
+	for i, v := range values {
+		if v.Type != t.values[i].Type {
+			return false
+		}
+		if t.fixed[i] && !v.Equals(t.values[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *standardTemplate) Acquire() bool {
+	return t.isHeld.CompareAndSwap(false, true)
+}
+
+func (t *standardTemplate) Bind(values []Value) {
+	for i, slot := range t.slots {
+		if t.fixed[i] || i >= len(values) {
+			continue
+		}
+		slot.Type = values[i].Type
+		slot.Val = values[i].Val
+	}
+}
+
+func (t *standardTemplate) Release() {
+	t.isHeld.Store(false)
+}
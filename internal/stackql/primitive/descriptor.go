@@ -0,0 +1,101 @@
+package primitive
+
+const (
+	KindSingleAcquire    string = "single_acquire"
+	KindDependentAcquire string = "dependent_acquire"
+	KindSQLSourceAcquire string = "sql_data_source_acquire"
+	KindLocalSelect      string = "local_select"
+	KindNativeSelect     string = "native_select"
+	KindUnion            string = "union"
+	KindPassThrough      string = "pass_through"
+	KindNop              string = "nop"
+	KindUnknown          string = "primitive"
+)
+
+var (
+	_ Descriptor = &standardDescriptor{}
+)
+
+// Descriptor is the plan level description of a primitive.
+// It exists purely for introspection, for example by EXPLAIN,
+// and has no bearing upon execution.
+type Descriptor interface {
+	GetKind() string
+	GetProvider() string
+	GetService() string
+	GetResource() string
+	GetMethod() string
+	// Human readable summary of the upstream push-down
+	// intent, empty where nothing is pushed.
+	GetPushdown() string
+	// Index of the data flow weakly connected component
+	// to which the primitive belongs, if any.
+	GetComponent() (int, bool)
+	WithHierarchy(provider, service, resource, method string) Descriptor
+	WithPushdown(pushdown string) Descriptor
+	WithComponent(component int) Descriptor
+}
+
+type standardDescriptor struct {
+	kind         string
+	provider     string
+	service      string
+	resource     string
+	method       string
+	pushdown     string
+	component    int
+	hasComponent bool
+}
+
+func NewDescriptor(kind string) Descriptor {
+	return &standardDescriptor{
+		kind: kind,
+	}
+}
+
+func (d *standardDescriptor) GetKind() string {
+	return d.kind
+}
+
+func (d *standardDescriptor) GetProvider() string {
+	return d.provider
+}
+
+func (d *standardDescriptor) GetService() string {
+	return d.service
+}
+
+func (d *standardDescriptor) GetResource() string {
+	return d.resource
+}
+
+func (d *standardDescriptor) GetMethod() string {
+	return d.method
+}
+
+func (d *standardDescriptor) GetPushdown() string {
+	return d.pushdown
+}
+
+func (d *standardDescriptor) GetComponent() (int, bool) {
+	return d.component, d.hasComponent
+}
+
+func (d *standardDescriptor) WithHierarchy(provider, service, resource, method string) Descriptor {
+	d.provider = provider
+	d.service = service
+	d.resource = resource
+	d.method = method
+	return d
+}
+
+func (d *standardDescriptor) WithPushdown(pushdown string) Descriptor {
+	d.pushdown = pushdown
+	return d
+}
+
+func (d *standardDescriptor) WithComponent(component int) Descriptor {
+	d.component = component
+	d.hasComponent = true
+	return d
+}
@@ -19,6 +19,7 @@ type GenericPrimitive struct {
 	undoLog       binlog.LogEntry
 	redoLog       binlog.LogEntry
 	debugName     string
+	descriptor    Descriptor
 }
 
 func NewGenericPrimitive(
@@ -42,6 +43,15 @@ func (pr *GenericPrimitive) WithDebugName(name string) IPrimitive {
 	return pr
 }
 
+func (pr *GenericPrimitive) GetDescriptor() (Descriptor, bool) {
+	return pr.descriptor, pr.descriptor != nil
+}
+
+func (pr *GenericPrimitive) WithDescriptor(descriptor Descriptor) IPrimitive {
+	pr.descriptor = descriptor
+	return pr
+}
+
 func (pr *GenericPrimitive) SetUndoLog(log binlog.LogEntry) {
 	pr.undoLog = log
 }
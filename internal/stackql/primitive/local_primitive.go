@@ -15,6 +15,7 @@ type LocalPrimitive struct {
 	undoLog    binlog.LogEntry
 	redoLog    binlog.LogEntry
 	debugName  string
+	descriptor Descriptor
 }
 
 func NewLocalPrimitive(executor func(pc IPrimitiveCtx) internaldto.ExecutorOutput) IPrimitive {
@@ -79,6 +80,15 @@ func (pr *LocalPrimitive) WithDebugName(name string) IPrimitive {
 	return pr
 }
 
+func (pr *LocalPrimitive) GetDescriptor() (Descriptor, bool) {
+	return pr.descriptor, pr.descriptor != nil
+}
+
+func (pr *LocalPrimitive) WithDescriptor(descriptor Descriptor) IPrimitive {
+	pr.descriptor = descriptor
+	return pr
+}
+
 func (pr *LocalPrimitive) Execute(pc IPrimitiveCtx) internaldto.ExecutorOutput {
 	if pr.Executor != nil {
 		logging.GetLogger().Infof("running local primitive")
@@ -17,6 +17,7 @@ type MetaDataPrimitive struct {
 	undoLog    binlog.LogEntry
 	redoLog    binlog.LogEntry
 	debugName  string
+	descriptor Descriptor
 }
 
 func (pr *MetaDataPrimitive) SetTxnID(_ int) {
@@ -80,6 +81,15 @@ func (pr *MetaDataPrimitive) WithDebugName(name string) IPrimitive {
 	return pr
 }
 
+func (pr *MetaDataPrimitive) GetDescriptor() (Descriptor, bool) {
+	return pr.descriptor, pr.descriptor != nil
+}
+
+func (pr *MetaDataPrimitive) WithDescriptor(descriptor Descriptor) IPrimitive {
+	pr.descriptor = descriptor
+	return pr
+}
+
 func NewMetaDataPrimitive(
 	provider provider.IProvider,
 	executor func(pc IPrimitiveCtx) internaldto.ExecutorOutput,
@@ -16,6 +16,7 @@ type PassThroughPrimitive struct {
 	undoLog                binlog.LogEntry
 	redoLog                binlog.LogEntry
 	debugName              string
+	descriptor             Descriptor
 }
 
 func NewPassThroughPrimitive(
@@ -80,6 +81,15 @@ func (pr *PassThroughPrimitive) WithDebugName(name string) IPrimitive {
 	return pr
 }
 
+func (pr *PassThroughPrimitive) GetDescriptor() (Descriptor, bool) {
+	return pr.descriptor, pr.descriptor != nil
+}
+
+func (pr *PassThroughPrimitive) WithDescriptor(descriptor Descriptor) IPrimitive {
+	pr.descriptor = descriptor
+	return pr
+}
+
 func (pr *PassThroughPrimitive) collectGarbage() {
 	// placeholder
 }
@@ -14,6 +14,14 @@ type IPrimitiveCtx interface {
 	GetErrWriter() io.Writer
 }
 
+// AnalysablePrimitiveCtx is implemented by contexts which gather
+// per node runtime statistics; graphs hand each node the context
+// returned by ForNode().
+type AnalysablePrimitiveCtx interface {
+	IPrimitiveCtx
+	ForNode(id int64) internaldto.BasicPrimitiveContext
+}
+
 type IPrimitive interface {
 	Optimise() error
 
@@ -42,4 +50,9 @@ type IPrimitive interface {
 	GetInputFromAlias(string) (internaldto.ExecutorOutput, bool)
 
 	WithDebugName(string) IPrimitive
+
+	// Get the plan level description, if any.
+	GetDescriptor() (Descriptor, bool)
+
+	WithDescriptor(Descriptor) IPrimitive
 }
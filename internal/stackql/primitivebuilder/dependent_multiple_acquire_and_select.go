@@ -48,6 +48,8 @@ func (ss *DependentMultipleAcquireAndSelect) Build() error {
 			ss.sqlSystem,
 			ss.graph.GetTxnControlCounterSlice(),
 			false,
+		).WithDescriptor(
+			primitive.NewDescriptor(primitive.KindPassThrough),
 		),
 	)
 	err := ss.selectBuilder.Build()
@@ -0,0 +1,40 @@
+package primitivebuilder
+
+import (
+	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/builder_input"
+	"github.com/stackql/stackql/internal/stackql/primitive"
+	"github.com/stackql/stackql/internal/stackql/pushdown"
+	"github.com/stackql/stackql/internal/stackql/tablemetadata"
+)
+
+// describeAcquire derives the plan level descriptor for
+// an acquire primitive from the table metadata, as resolved
+// by method selection and push-down analysis, and from the
+// data flow facts recorded upon the builder input.
+func describeAcquire(
+	kind string,
+	tableMeta tablemetadata.ExtendedTableMetadata,
+	bldrInput builder_input.BuilderInput,
+) primitive.Descriptor {
+	if bldrInput != nil && bldrInput.IsDataflowDependent() && kind == primitive.KindSingleAcquire {
+		kind = primitive.KindDependentAcquire
+	}
+	rv := primitive.NewDescriptor(kind)
+	if tableMeta == nil {
+		return rv
+	}
+	providerStr, _ := tableMeta.GetProviderStr()
+	serviceStr, _ := tableMeta.GetServiceStr()
+	resourceStr, _ := tableMeta.GetResourceStr()
+	methodStr, _ := tableMeta.GetMethodStr()
+	rv = rv.WithHierarchy(providerStr, serviceStr, resourceStr, methodStr)
+	if intent, hasIntent := tableMeta.GetPushdownIntent(); hasIntent {
+		rv = rv.WithPushdown(pushdown.DescribeIntent(intent))
+	}
+	if bldrInput != nil {
+		if component, hasComponent := bldrInput.GetDataflowComponent(); hasComponent {
+			rv = rv.WithComponent(component)
+		}
+	}
+	return rv
+}
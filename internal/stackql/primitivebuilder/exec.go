@@ -5,6 +5,7 @@ import (
 	"github.com/stackql/stackql-parser/go/vt/sqlparser"
 	"github.com/stackql/stackql/internal/stackql/asynccompose"
 	"github.com/stackql/stackql/internal/stackql/drm"
+	"github.com/stackql/stackql/internal/stackql/execstats"
 	"github.com/stackql/stackql/internal/stackql/execution"
 	"github.com/stackql/stackql/internal/stackql/handler"
 	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/builder_input"
@@ -122,6 +123,7 @@ func (ss *Exec) Build() error {
 		}
 		polyHandler := execution.NewStandardPolyHandler(
 			handlerCtx,
+			execstats.FromContext(pc),
 		)
 		tableName, tableNameErr := tbl.GetTableName()
 		if tableNameErr != nil {
@@ -1,9 +1,17 @@
 package primitivebuilder
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/stackql/any-sdk/public/sqlengine"
+	"github.com/stackql/stackql-parser/go/vt/sqlparser"
+	"github.com/stackql/stackql/internal/stackql/execstats"
 	"github.com/stackql/stackql/internal/stackql/handler"
 	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
 	"github.com/stackql/stackql/internal/stackql/primitive"
@@ -13,10 +21,33 @@ import (
 
 var (
 	defaultConcludeExplainMessages []string = []string{"OK"} //nolint:revive,gochecknoglobals // prefer declarative
+	explainColumns                 []string = []string{      //nolint:revive,gochecknoglobals // prefer declarative
+		"id",
+		"kind",
+		"provider",
+		"service",
+		"resource",
+		"method",
+		"pushdown",
+		"depends_on",
+		"component",
+	}
+	explainAnalyzeColumns []string = append( //nolint:revive,gochecknoglobals // prefer declarative
+		append([]string{}, explainColumns...),
+		"elapsed_ms",
+		"http_requests",
+		"pages",
+		"bytes_received",
+		"rows",
+		"retries",
+	)
+	explainJSONColumns []string = []string{"plan"} //nolint:revive,gochecknoglobals // prefer declarative
 )
 
 type ExplainBuilder struct {
 	graph          primitivegraph.PrimitiveGraphHolder
+	explainedGraph primitivegraph.BasePrimitiveGraph
+	format         string
 	handlerCtx     handler.HandlerContext
 	root           primitivegraph.PrimitiveNode
 	sqlEngine      sqlengine.SQLEngine
@@ -24,8 +55,17 @@ type ExplainBuilder struct {
 	instructionErr error
 }
 
+// NewExplainBuilder returns a builder for a primitive that,
+// rather than executing the explained statement, describes
+// the primitive graph planned for it: one row per node in
+// the default format, or a single JSON document when
+// the format is JSON.  For EXPLAIN ANALYZE the explained
+// graph is executed and each row also carries the runtime
+// statistics gathered for its node.
 func NewExplainBuilder(
 	graph primitivegraph.PrimitiveGraphHolder,
+	explainedGraph primitivegraph.BasePrimitiveGraph,
+	format string,
 	txnControlCounters internaldto.TxnControlCounters, //nolint:revive // future proofing
 	handlerCtx handler.HandlerContext,
 	sqlEngine sqlengine.SQLEngine,
@@ -38,6 +78,8 @@ func NewExplainBuilder(
 	messages = append(messages, defaultConcludeExplainMessages...)
 	return &ExplainBuilder{
 		graph:          graph,
+		explainedGraph: explainedGraph,
+		format:         strings.ToLower(format),
 		handlerCtx:     handlerCtx,
 		sqlEngine:      sqlEngine,
 		messages:       messages,
@@ -45,27 +87,189 @@ func NewExplainBuilder(
 	}
 }
 
+func explainRowKey(idx int) string {
+	return fmt.Sprintf("%08d", idx)
+}
+
+func formatExplainDependencies(dependsOn []int64) string {
+	strs := make([]string, len(dependsOn))
+	for i, d := range dependsOn {
+		strs[i] = strconv.FormatInt(d, 10)
+	}
+	return strings.Join(strs, ",")
+}
+
+func (nb *ExplainBuilder) textRows(
+	descriptions []primitivegraph.NodeDescription,
+) map[string]map[string]any {
+	rowMap := make(map[string]map[string]any, len(descriptions))
+	for i, d := range descriptions {
+		var component any
+		if d.Component != nil {
+			component = *d.Component
+		}
+		rowMap[explainRowKey(i)] = map[string]any{
+			"id":         d.ID,
+			"kind":       d.Kind,
+			"provider":   d.Provider,
+			"service":    d.Service,
+			"resource":   d.Resource,
+			"method":     d.Method,
+			"pushdown":   d.Pushdown,
+			"depends_on": formatExplainDependencies(d.DependsOn),
+			"component":  component,
+		}
+	}
+	return rowMap
+}
+
+func (nb *ExplainBuilder) analyzeRows(
+	descriptions []primitivegraph.NodeDescription,
+	collector execstats.Collector,
+) map[string]map[string]any {
+	rowMap := nb.textRows(descriptions)
+	for i, d := range descriptions {
+		row := rowMap[explainRowKey(i)]
+		snapshot, _ := collector.GetNode(d.ID)
+		var rows, bytesReceived any
+		if snapshot.RowsRecorded {
+			rows = snapshot.Rows
+		}
+		if snapshot.BytesRecorded {
+			bytesReceived = snapshot.BytesReceived
+		}
+		row["elapsed_ms"] = float64(snapshot.Elapsed) / float64(time.Millisecond)
+		row["http_requests"] = snapshot.HTTPRequests
+		row["pages"] = snapshot.Pages
+		row["bytes_received"] = bytesReceived
+		row["rows"] = rows
+		row["retries"] = snapshot.Retries
+	}
+	return rowMap
+}
+
+// analyze executes the explained graph, gathering statistics
+// per node.  The rows of the final result are drained and
+// attributed to the terminal node, unless it reported its own.
+func (nb *ExplainBuilder) analyze(
+	pc primitive.IPrimitiveCtx,
+	descriptions []primitivegraph.NodeDescription,
+) (execstats.Collector, error) {
+	collector := execstats.NewCollector()
+	if len(descriptions) == 0 {
+		return collector, nil
+	}
+	var baseCtx internaldto.BasicPrimitiveContext = pc
+	if baseCtx == nil {
+		baseCtx = internaldto.NewBasicPrimitiveContext(
+			nil,
+			nb.handlerCtx.GetOutfile(),
+			nb.handlerCtx.GetOutErrFile(),
+		)
+	}
+	if err := nb.explainedGraph.Optimise(); err != nil {
+		return nil, err
+	}
+	output := nb.explainedGraph.Execute(
+		internaldto.NewAnalyzePrimitiveContext(baseCtx, collector),
+	)
+	if output == nil {
+		return collector, nil
+	}
+	if err := output.GetError(); err != nil {
+		return nil, err
+	}
+	terminalID := descriptions[len(descriptions)-1].ID
+	terminalSnapshot, _ := collector.GetNode(terminalID)
+	if terminalSnapshot.RowsRecorded {
+		return collector, nil
+	}
+	rowCount, err := countResultRows(output)
+	if err != nil {
+		return nil, err
+	}
+	collector.ForNode(terminalID).RecordRows(rowCount)
+	return collector, nil
+}
+
+func countResultRows(output internaldto.ExecutorOutput) (int64, error) {
+	stream := output.GetSQLResult()
+	if stream == nil {
+		return 0, nil
+	}
+	var rowCount int64
+	for {
+		res, err := stream.Read()
+		if res != nil {
+			rowCount += int64(len(res.GetRows()))
+		}
+		if errors.Is(err, io.EOF) {
+			return rowCount, nil
+		}
+		if err != nil {
+			return rowCount, err
+		}
+	}
+}
+
+func (nb *ExplainBuilder) jsonRows(
+	descriptions []primitivegraph.NodeDescription,
+) (map[string]map[string]any, error) {
+	if descriptions == nil {
+		descriptions = []primitivegraph.NodeDescription{}
+	}
+	planBytes, err := json.Marshal(
+		map[string]any{
+			"query": nb.handlerCtx.GetQuery(),
+			"nodes": descriptions,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]map[string]any{
+		explainRowKey(0): {"plan": string(planBytes)},
+	}, nil
+}
+
 func (nb *ExplainBuilder) Build() error {
 	pr := primitive.NewLocalPrimitive(
-		//nolint:revive // no big deal
 		func(pc primitive.IPrimitiveCtx) internaldto.ExecutorOutput {
 			if nb.instructionErr != nil {
 				return internaldto.NewErroneousExecutorOutput(nb.instructionErr)
 			}
-			oneLinerOutput := time.Now().Format("2006-01-02T15:04:05-07:00 MST")
-			resultMap := map[string]any{
-				"query":     nb.handlerCtx.GetQuery(),
-				"timestamp": oneLinerOutput,
-				"result":    "OK",
-				"valid":     true,
+			descriptions, err := primitivegraph.DescribeGraph(nb.explainedGraph)
+			if err != nil {
+				return internaldto.NewErroneousExecutorOutput(err)
+			}
+			columns := explainColumns
+			var rowMap map[string]map[string]any
+			switch nb.format {
+			case sqlparser.JSONStr:
+				columns = explainJSONColumns
+				rowMap, err = nb.jsonRows(descriptions)
+				if err != nil {
+					return internaldto.NewErroneousExecutorOutput(err)
+				}
+			case sqlparser.AnalyzeStr:
+				columns = explainAnalyzeColumns
+				collector, analyzeErr := nb.analyze(pc, descriptions)
+				if analyzeErr != nil {
+					return internaldto.NewErroneousExecutorOutput(analyzeErr)
+				}
+				rowMap = nb.analyzeRows(descriptions, collector)
+			case "", sqlparser.TraditionalStr, sqlparser.TreeStr, sqlparser.VitessStr:
+				rowMap = nb.textRows(descriptions)
+			default:
+				return internaldto.NewErroneousExecutorOutput(
+					fmt.Errorf("unsupported explain format '%s'", nb.format))
 			}
-			columns := []string{"query", "result", "timestamp", "valid"}
 			return util.PrepareResultSet(
 				internaldto.NewPrepareResultSetPlusRawDTO(
 					nil,
-					map[string]map[string]any{"0": resultMap},
+					rowMap,
 					columns,
-					nil,
+					util.DefaultRowSort,
 					nil,
 					internaldto.NewBackendMessages(nb.messages), nil,
 					nb.handlerCtx.GetTypingConfig()),
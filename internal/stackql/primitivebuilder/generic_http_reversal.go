@@ -8,6 +8,7 @@ import (
 	"github.com/stackql/stackql/internal/stackql/acid/binlog"
 	"github.com/stackql/stackql/internal/stackql/asynccompose"
 	"github.com/stackql/stackql/internal/stackql/drm"
+	"github.com/stackql/stackql/internal/stackql/execstats"
 	"github.com/stackql/stackql/internal/stackql/execution"
 	"github.com/stackql/stackql/internal/stackql/handler"
 	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/builder_input"
@@ -146,6 +147,7 @@ func (gh *genericHTTPReversal) Build() error {
 				isSkipResponse := responseAnalysisErr != nil
 				polyHandler := execution.NewStandardPolyHandler(
 					handlerCtx,
+					execstats.FromContext(pc),
 				)
 				nullaryEx := func() internaldto.ExecutorOutput {
 					pp := execution.NewProcessorPayload(
@@ -12,6 +12,7 @@ import (
 	"github.com/stackql/stackql/internal/stackql/acid/binlog"
 	"github.com/stackql/stackql/internal/stackql/asynccompose"
 	"github.com/stackql/stackql/internal/stackql/drm"
+	"github.com/stackql/stackql/internal/stackql/execstats"
 	"github.com/stackql/stackql/internal/stackql/execution"
 	"github.com/stackql/stackql/internal/stackql/handler"
 	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/builder_input"
@@ -42,6 +43,7 @@ type genericHTTPStreamInput struct {
 	reversalStream    formulation.HttpPreparatorStream
 	reversalBuilder   Builder
 	rollbackType      constants.RollbackType
+	isCompensating    bool
 	insertCtx         drm.PreparedStatementCtx
 }
 
@@ -87,13 +89,17 @@ func newGenericHTTPStreamInput(
 		parserNode:        parserNode,
 		reversalStream:    formulation.NewHttpPreparatorStream(),
 		rollbackType:      handlerCtx.GetRollbackType(),
+		isCompensating:    handlerCtx.IsCompensatingRollback(),
 		insertCtx:         insertCtx,
 		isMutation:        isMutation,
 	}, nil
 }
 
+// isReverseRequired is false under compensating rollback,
+// which tolerates mutations without an inverse and reports
+// them as uncompensated upon rollback.
 func (gh *genericHTTPStreamInput) isReverseRequired() bool {
-	return gh.rollbackType != constants.NopRollback
+	return gh.rollbackType != constants.NopRollback && !gh.isCompensating
 }
 
 func (gh *genericHTTPStreamInput) GetRoot() primitivegraph.PrimitiveNode {
@@ -295,6 +301,7 @@ func (gh *genericHTTPStreamInput) Build() error {
 				isSkipResponse := responseAnalysisErr != nil || responseSchema == nil
 				polyHandler := execution.NewStandardPolyHandler(
 					handlerCtx,
+					execstats.FromContext(pc),
 				)
 				nullaryEx := func() internaldto.ExecutorOutput {
 					pp := execution.NewProcessorPayload(
@@ -105,7 +105,7 @@ func (ss *GraphQLSingleSelectAcquire) Build() error {
 		ss.graph.AddTxnControlCounters(currentTcc)
 
 		for _, reqCtx := range httpArmoury.GetRequestParams() {
-			req := reqCtx.GetRequest()
+			req := reqCtx.GetRequest().WithContext(ss.handlerCtx.GetContext())
 			// Emit the GraphQL wire request + raw response when --http.log.enabled is set
 			// (alpha08 ContextWithHTTPLogger), mirroring the REST acquire path.
 			if ss.handlerCtx.GetRuntimeContext().HTTPLogEnabled {
@@ -210,6 +210,8 @@ func (ss *GraphQLSingleSelectAcquire) Build() error {
 		prep,
 		ss.txnCtrlCtr,
 		primitive_context.NewPrimitiveContext(),
+	).WithDescriptor(
+		describeAcquire(primitive.KindSingleAcquire, ss.tableMeta, ss.bldrInput),
 	)
 	graph := ss.graph
 	insertNode := graph.CreatePrimitiveNode(insertPrim)
@@ -113,7 +113,11 @@ func (mv *monoValentBuilder) Build() error {
 		prep,
 		mv.txnCtrlCtr,
 		primitiveCtx,
-	).WithDebugName(fmt.Sprintf("insert_%s_%s", tableName, mv.tableMeta.GetAlias()))
+	).WithDebugName(
+		fmt.Sprintf("insert_%s_%s", tableName, mv.tableMeta.GetAlias()),
+	).WithDescriptor(
+		describeAcquire(primitive.KindSingleAcquire, mv.tableMeta, mv.bldrInput),
+	)
 	graphHolder := mv.graphHolder
 	insertNode := graphHolder.CreatePrimitiveNode(insertPrim)
 	mv.root = insertNode
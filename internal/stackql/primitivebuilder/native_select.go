@@ -86,7 +86,11 @@ func (ss *NativeSelect) Build() error {
 		)
 	}
 	graph := ss.graph
-	selectNode := graph.CreatePrimitiveNode(primitive.NewLocalPrimitive(selectEx))
+	selectNode := graph.CreatePrimitiveNode(
+		primitive.NewLocalPrimitive(selectEx).WithDescriptor(
+			primitive.NewDescriptor(primitive.KindNativeSelect),
+		),
+	)
 	ss.root = selectNode
 
 	return nil
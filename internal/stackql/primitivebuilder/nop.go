@@ -54,6 +54,8 @@ func (nb *NopBuilder) Build() error {
 					nb.handlerCtx.GetTypingConfig()),
 			)
 		},
+	).WithDescriptor(
+		primitive.NewDescriptor(primitive.KindNop),
 	)
 	nb.root = nb.graph.CreatePrimitiveNode(pr)
 	return nil
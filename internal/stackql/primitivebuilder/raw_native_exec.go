@@ -52,7 +52,8 @@ func (ss *RawNativeExec) Build() error {
 		// select phase
 		logging.GetLogger().Infoln(fmt.Sprintf("running native query: '''%s''' ", ss.nativeQuery))
 
-		row, err := ss.handlerCtx.GetSQLEngine().Exec(ss.nativeQuery)
+		query, args := nativeQueryArgs(ss.handlerCtx, ss.nativeQuery)
+		row, err := ss.handlerCtx.GetSQLEngine().Exec(query, args...)
 
 		if row != nil {
 			rowsAffected, countErr := row.RowsAffected()
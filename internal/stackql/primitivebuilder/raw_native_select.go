@@ -3,7 +3,9 @@ package primitivebuilder
 import (
 	"fmt"
 
+	"github.com/stackql/any-sdk/pkg/constants"
 	"github.com/stackql/any-sdk/pkg/logging"
+	"github.com/stackql/stackql/internal/stackql/bindparam"
 	"github.com/stackql/stackql/internal/stackql/data_staging/input_data_staging"
 	"github.com/stackql/stackql/internal/stackql/handler"
 	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
@@ -47,7 +49,8 @@ func (ss *RawNativeSelect) Build() error {
 		// select phase
 		logging.GetLogger().Infoln(fmt.Sprintf("running native query: '''%s''' ", ss.nativeQuery))
 
-		rows, err := ss.handlerCtx.GetSQLEngine().Query(ss.nativeQuery)
+		query, args := nativeQueryArgs(ss.handlerCtx, ss.nativeQuery)
+		rows, err := ss.handlerCtx.GetSQLEngine().Query(query, args...)
 
 		if err != nil {
 			return internaldto.NewErroneousExecutorOutput(err)
@@ -65,8 +68,28 @@ func (ss *RawNativeSelect) Build() error {
 	}
 
 	graph := ss.graph
-	selectNode := graph.CreatePrimitiveNode(primitive.NewLocalPrimitive(selectEx))
+	selectNode := graph.CreatePrimitiveNode(
+		primitive.NewLocalPrimitive(selectEx).WithDescriptor(
+			primitive.NewDescriptor(primitive.KindNativeSelect),
+		),
+	)
 	ss.root = selectNode
 
 	return nil
 }
+
+// nativeQueryArgs returns query in the placeholder syntax of the SQL
+// backend, with the parameters bound to it as arguments.  Queries without
+// bound parameters are returned unchanged.
+func nativeQueryArgs(handlerCtx handler.HandlerContext, query string) (string, []any) {
+	params := handlerCtx.GetBindParams()
+	n := bindparam.Count(query)
+	if len(params) == 0 || n == 0 {
+		return query, nil
+	}
+	style := bindparam.StyleNumberedQuestion
+	if handlerCtx.GetSQLSystem().GetName() == constants.SQLDialectPostgres {
+		style = bindparam.StyleDollar
+	}
+	return bindparam.Native(query, style), params[:min(n, len(params))]
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/stackql/stackql/internal/stackql/handler"
 	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
 	"github.com/stackql/stackql/internal/stackql/metadatavisitors"
+	"github.com/stackql/stackql/internal/stackql/plancache"
 	"github.com/stackql/stackql/internal/stackql/primitive"
 	"github.com/stackql/stackql/internal/stackql/provider"
 	"github.com/stackql/stackql/internal/stackql/tablemetadata"
@@ -96,7 +97,7 @@ func NewInsertableValsPrimitive(
 		}), nil
 }
 
-//nolint:funlen,gocognit // permissable
+//nolint:funlen,gocognit,gocyclo,cyclop // permissable
 func NewShowInstructionExecutor(
 	node *sqlparser.Show,
 	prov provider.IProvider,
@@ -251,6 +252,8 @@ func NewShowInstructionExecutor(
 		keys = convertProviderServicesToMap(services, extended)
 	case "VERSION":
 		columnOrder, keys = buildVersionShowOutput(extended)
+	case "PLANCACHE":
+		columnOrder, keys = buildPlanCacheShowOutput(handlerCtx.GetPlanTemplateCache().Stats())
 	}
 	return util.PrepareResultSet(internaldto.NewPrepareResultSetDTO(nil, keys, columnOrder, nil, err, nil,
 		handlerCtx.GetTypingConfig()))
@@ -340,6 +343,24 @@ func buildVersionShowOutput(extended bool) ([]string, map[string]map[string]inte
 		}
 }
 
+// buildPlanCacheShowOutput renders the SHOW PLANCACHE result, the counters
+// of the plan template cache.
+func buildPlanCacheShowOutput(stats plancache.Stats) ([]string, map[string]map[string]interface{}) {
+	return []string{"capacity", "entries", "templates", "lookups", "hits", "misses", "evictions", "hit_ratio"},
+		map[string]map[string]interface{}{
+			"1": {
+				"capacity":  stats.Capacity,
+				"entries":   stats.Entries,
+				"templates": stats.Templates,
+				"lookups":   stats.Lookups,
+				"hits":      stats.Hits,
+				"misses":    stats.Misses,
+				"evictions": stats.Evictions,
+				"hit_ratio": strconv.FormatFloat(stats.HitRatio(), 'f', 4, 64),
+			},
+		}
+}
+
 //nolint:errcheck // future proofing
 func filterResources(
 	resources map[string]formulation.Resource,
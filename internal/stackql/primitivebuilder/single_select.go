@@ -11,6 +11,7 @@ import (
 	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
 	"github.com/stackql/stackql/internal/stackql/primitive"
 	"github.com/stackql/stackql/internal/stackql/primitivegraph"
+	"github.com/stackql/stackql/internal/stackql/sqlmachinery"
 	"github.com/stackql/stackql/internal/stackql/tableinsertioncontainer"
 )
 
@@ -68,7 +69,7 @@ func (ss *SingleSelect) Build() error {
 		outputter := output_data_staging.NewNaiveOutputter(
 			output_data_staging.NewNaivePacketPreparator(
 				output_data_staging.NewNaiveSource(
-					ss.handlerCtx.GetSQLEngine(),
+					sqlmachinery.NewContextQuerier(ss.handlerCtx.GetContext(), ss.handlerCtx.GetSQLEngine()),
 					drm.NewPreparedStatementParameterized(ss.selectPreparedStatementCtx, nil, true),
 					ss.drmCfg,
 				),
@@ -85,7 +86,11 @@ func (ss *SingleSelect) Build() error {
 		return rv
 	}
 	graph := ss.graph
-	selectNode := graph.CreatePrimitiveNode(primitive.NewLocalPrimitive(selectEx))
+	selectNode := graph.CreatePrimitiveNode(
+		primitive.NewLocalPrimitive(selectEx).WithDescriptor(
+			primitive.NewDescriptor(primitive.KindLocalSelect),
+		),
+	)
 	ss.root = selectNode
 
 	return nil
@@ -139,6 +139,8 @@ func (ss *sqlDataSourceSingleSelectAcquire) Build() error {
 		prep,
 		ss.txnCtrlCtr,
 		primitiveCtx,
+	).WithDescriptor(
+		describeAcquire(primitive.KindSQLSourceAcquire, ss.tableMeta, nil),
 	)
 	graph := ss.graph
 	insertNode := graph.CreatePrimitiveNode(insertPrim)
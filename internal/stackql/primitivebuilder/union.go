@@ -8,6 +8,7 @@ import (
 	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
 	"github.com/stackql/stackql/internal/stackql/primitive"
 	"github.com/stackql/stackql/internal/stackql/primitivegraph"
+	"github.com/stackql/stackql/internal/stackql/sqlmachinery"
 )
 
 type Union struct {
@@ -25,7 +26,7 @@ func (un *Union) Build() error {
 		outputter := output_data_staging.NewNaiveOutputter(
 			output_data_staging.NewNaivePacketPreparator(
 				output_data_staging.NewNaiveSource(
-					un.handlerCtx.GetSQLEngine(),
+					sqlmachinery.NewContextQuerier(un.handlerCtx.GetContext(), un.handlerCtx.GetSQLEngine()),
 					us,
 					un.drmCfg,
 				),
@@ -39,7 +40,11 @@ func (un *Union) Build() error {
 		return outputter.OutputExecutorResult()
 	}
 	graph := un.graph
-	unionNode := graph.CreatePrimitiveNode(primitive.NewLocalPrimitive(unionEx))
+	unionNode := graph.CreatePrimitiveNode(
+		primitive.NewLocalPrimitive(unionEx).WithDescriptor(
+			primitive.NewDescriptor(primitive.KindUnion),
+		),
+	)
 	un.root = unionNode
 	un.tail = unionNode
 	return nil
@@ -0,0 +1,213 @@
+package primitivegenerator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/lib/pq/oid"
+	"github.com/stackql/any-sdk/pkg/logging"
+	"github.com/stackql/stackql/internal/stackql/handler"
+	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
+	"github.com/stackql/stackql/internal/stackql/pgcatalog"
+	"github.com/stackql/stackql/internal/stackql/provider"
+	"github.com/stackql/stackql/internal/stackql/sql_system"
+	"github.com/stackql/stackql/internal/stackql/typing"
+)
+
+//nolint:gochecknoglobals // one SQL backend per process
+var (
+	pgCatalogMaterialiser = pgcatalog.NewMaterialiser()
+	pgCatalogProviders    = &providerTableCache{tables: make(map[string][]pgcatalog.Table)}
+)
+
+// providerTableCache memoises the tables of each provider by name and
+// version, as walking a provider's documents is costly.
+type providerTableCache struct {
+	mu     sync.Mutex
+	tables map[string][]pgcatalog.Table
+}
+
+// rewritePGCatalogQuery rewrites an introspection query to address the
+// emulated pg_catalog and information_schema, bringing them up to date
+// first.  Other queries are returned unchanged.  The columns of views are
+// those inferred by inferrer, where it is not nil.
+func rewritePGCatalogQuery(
+	handlerCtx handler.HandlerContext, inferrer pgcatalog.ColumnInferrer, query string,
+) (string, error) {
+	rewritten, isCatalogQuery := pgcatalog.Rewrite(query)
+	if !isCatalogQuery {
+		return query, nil
+	}
+	tables := catalogProviderTables(handlerCtx)
+	tables = append(tables, catalogRelationTables(handlerCtx, inferrer)...)
+	sqlEngine := handlerCtx.GetSQLEngine()
+	err := pgCatalogMaterialiser.Materialise(tables, func(stmt string) error {
+		_, execErr := sqlEngine.Exec(stmt)
+		return execErr
+	})
+	if err != nil {
+		return "", err
+	}
+	return rewritten, nil
+}
+
+// catalogProviderTables presents each locally available provider as a
+// schema, whose tables are its resources, named service.resource, with
+// the columns of their select methods.
+func catalogProviderTables(handlerCtx handler.HandlerContext) []pgcatalog.Table {
+	providers, err := handlerCtx.GetSupportedProviders(false)
+	if err != nil {
+		logging.GetLogger().Infof("pg_catalog: cannot list providers: %v", err)
+		return nil
+	}
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var rv []pgcatalog.Table
+	for _, name := range names {
+		prov, provErr := handlerCtx.GetProvider(name)
+		if provErr != nil {
+			continue
+		}
+		rv = append(rv, pgCatalogProviders.get(handlerCtx, name, prov)...)
+	}
+	return rv
+}
+
+func (c *providerTableCache) get(
+	handlerCtx handler.HandlerContext, name string, prov provider.IProvider,
+) []pgcatalog.Table {
+	key := fmt.Sprintf("%s@%s", name, prov.GetVersion())
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if tables, ok := c.tables[key]; ok {
+		return tables
+	}
+	tables := walkProviderTables(handlerCtx, name, prov)
+	c.tables[key] = tables
+	return tables
+}
+
+func walkProviderTables(
+	handlerCtx handler.HandlerContext, name string, prov provider.IProvider,
+) []pgcatalog.Table {
+	rtCtx := handlerCtx.GetRuntimeContext()
+	services, err := prov.GetProviderServicesRedacted(rtCtx, false)
+	if err != nil {
+		logging.GetLogger().Infof("pg_catalog: cannot list services of provider '%s': %v", name, err)
+		return nil
+	}
+	typCfg := handlerCtx.GetTypingConfig()
+	var rv []pgcatalog.Table
+	for _, svc := range services {
+		if !rtCtx.UseNonPreferredAPIs && !svc.IsPreferred() {
+			continue
+		}
+		svcName := svc.GetName()
+		resources, rscErr := prov.GetResourcesMap(svcName, rtCtx)
+		if rscErr != nil {
+			continue
+		}
+		for rscName := range resources {
+			method, _, methodErr := prov.GetFirstMethodForAction(svcName, rscName, "select", rtCtx)
+			if methodErr != nil {
+				continue
+			}
+			schema, _, schemaErr := method.GetSelectSchemaAndObjectPath()
+			if schemaErr != nil || schema == nil {
+				continue
+			}
+			table := pgcatalog.Table{
+				Schema: name,
+				Name:   fmt.Sprintf("%s.%s", svcName, rscName),
+				Kind:   pgcatalog.KindTable,
+			}
+			if tabulation := schema.Tabulate(false, ""); tabulation != nil {
+				for _, col := range tabulation.GetColumns() {
+					colType := typCfg.GetRelationalType("")
+					if colSchema := col.GetSchema(); colSchema != nil {
+						colType = typCfg.GetRelationalType(colSchema.GetType())
+					}
+					table.Columns = append(table.Columns, pgcatalog.Column{Name: col.GetName(), Type: colType})
+				}
+			}
+			rv = append(rv, table)
+		}
+	}
+	return rv
+}
+
+// catalogRelationTables presents the stored views and user space tables
+// in the public schema.  The columns of tables and materialized views are
+// stored with them; those of views are inferred.
+func catalogRelationTables(handlerCtx handler.HandlerContext, inferrer pgcatalog.ColumnInferrer) []pgcatalog.Table {
+	sqlSystem := handlerCtx.GetSQLSystem()
+	relations, err := sqlSystem.ListRelations()
+	if err != nil {
+		logging.GetLogger().Infof("pg_catalog: cannot list stored relations: %v", err)
+		return nil
+	}
+	rv := make([]pgcatalog.Table, 0, len(relations))
+	for _, r := range relations {
+		table := pgcatalog.Table{
+			Schema: pgcatalog.PublicSchema,
+			Name:   r.GetName(),
+			Kind:   catalogKind(r),
+		}
+		switch {
+		case table.Kind != pgcatalog.KindView:
+			table.Columns = storedRelationColumns(sqlSystem, r)
+		case inferrer != nil:
+			table.Definition = r.GetRawQuery()
+			table.Columns = inferrer.InferColumns(fmt.Sprintf("SELECT * FROM %s", r.GetName()))
+		default:
+			table.Definition = r.GetRawQuery()
+		}
+		rv = append(rv, table)
+	}
+	return rv
+}
+
+func storedRelationColumns(sqlSystem sql_system.SQLSystem, r internaldto.RelationDTO) []pgcatalog.Column {
+	var stored internaldto.RelationDTO
+	var isStored bool
+	if r.IsTable() {
+		stored, isStored = sqlSystem.GetPhysicalTableByName(r.GetName())
+	} else {
+		stored, isStored = sqlSystem.GetMaterializedViewByName(r.GetName())
+	}
+	if !isStored {
+		return nil
+	}
+	return relationalColumnsToCatalog(stored.GetColumns())
+}
+
+func relationalColumnsToCatalog(cols []typing.RelationalColumn) []pgcatalog.Column {
+	rv := make([]pgcatalog.Column, 0, len(cols))
+	for _, col := range cols {
+		colOID := oid.T_text
+		if storedOID, ok := col.GetOID(); ok {
+			colOID = storedOID
+		}
+		rv = append(rv, pgcatalog.Column{
+			Name: col.GetIdentifier(),
+			Type: strings.ToLower(oid.TypeName[colOID]),
+		})
+	}
+	return rv
+}
+
+func catalogKind(r internaldto.RelationDTO) pgcatalog.Kind {
+	switch {
+	case r.IsTable():
+		return pgcatalog.KindTable
+	case r.IsMaterialized():
+		return pgcatalog.KindMaterializedView
+	default:
+		return pgcatalog.KindView
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/stackql/stackql/internal/stackql/handler"
 	"github.com/stackql/stackql/internal/stackql/iqlutil"
 	"github.com/stackql/stackql/internal/stackql/parserutil"
+	"github.com/stackql/stackql/internal/stackql/pgcatalog"
 	"github.com/stackql/stackql/internal/stackql/planbuilderinput"
 	"github.com/stackql/stackql/internal/stackql/primitivebuilder"
 	"github.com/stackql/stackql/internal/stackql/primitivecomposer"
@@ -59,6 +60,7 @@ type PrimitiveGenerator interface {
 	GetIndirectCreateTailBuilder() ([]primitivebuilder.Builder, bool)
 	SetIndirectCreateTailBuilder(builder []primitivebuilder.Builder)
 	WithAstIndirect(astindirect.Indirect) PrimitiveGenerator
+	WithCatalogColumnInferrer(pgcatalog.ColumnInferrer) PrimitiveGenerator
 }
 
 type standardPrimitiveGenerator struct {
@@ -70,6 +72,7 @@ type standardPrimitiveGenerator struct {
 	PrimitiveComposer         primitivecomposer.PrimitiveComposer
 	isElideRead               bool
 	indirectCreateTailBuilder []primitivebuilder.Builder
+	catalogColumnInferrer     pgcatalog.ColumnInferrer
 }
 
 func NewRootPrimitiveGenerator(
@@ -94,6 +97,15 @@ func (pb *standardPrimitiveGenerator) WithAstIndirect(astIndirect astindirect.In
 	return pb
 }
 
+// WithCatalogColumnInferrer sets the inferrer of the columns of views
+// presented by the emulated pg_catalog.  The planner supplies it, as
+// inferring columns is planning.
+func (pb *standardPrimitiveGenerator) WithCatalogColumnInferrer(
+	inferrer pgcatalog.ColumnInferrer) PrimitiveGenerator {
+	pb.catalogColumnInferrer = inferrer
+	return pb
+}
+
 func (pb *standardPrimitiveGenerator) GetIndirectCreateTailBuilder() ([]primitivebuilder.Builder, bool) {
 	return pb.indirectCreateTailBuilder, pb.indirectCreateTailBuilder != nil
 }
@@ -143,7 +155,7 @@ func (pb *standardPrimitiveGenerator) CreateIndirectPrimitiveGenerator(
 	handlerCtx handler.HandlerContext) PrimitiveGenerator {
 	rv := NewRootPrimitiveGenerator(
 		ast, handlerCtx, pb.PrimitiveComposer.GetGraphHolder(),
-	).WithDataFlowDependentPrimitiveGenerator(pb)
+	).WithDataFlowDependentPrimitiveGenerator(pb).WithCatalogColumnInferrer(pb.catalogColumnInferrer)
 	pb.indirects = append(pb.indirects, rv)
 	pb.PrimitiveComposer.GetGraphHolder().SetContainsIndirect(true)
 	pb.PrimitiveComposer.AddIndirect(rv.GetPrimitiveComposer())
@@ -160,7 +172,8 @@ func (pb *standardPrimitiveGenerator) AddChildPrimitiveGenerator(
 		tables = make(taxonomy.TblMap)
 	}
 	retVal := &standardPrimitiveGenerator{
-		Parent: pb,
+		Parent:                pb,
+		catalogColumnInferrer: pb.catalogColumnInferrer,
 		PrimitiveComposer: primitivecomposer.NewPrimitiveComposer(
 			pb.PrimitiveComposer,
 			ast,
@@ -274,6 +287,8 @@ func (pb *standardPrimitiveGenerator) inferProviderForShow(
 		// no provider, might create some dummy object dunno
 	case "VERSION":
 		// no provider needed
+	case "PLANCACHE":
+		// no provider needed
 	case "RESOURCES":
 		prov, err := handlerCtx.GetProvider(node.OnTable.Qualifier.GetRawVal())
 		if err != nil {
@@ -693,10 +693,23 @@ func (pb *standardPrimitiveGenerator) AnalyzeExplain(
 	instructionErr error,
 ) error {
 	handlerCtx := pbi.GetHandlerCtx()
+	var format string
+	if explain, isExplain := pbi.GetExplain(); isExplain {
+		format = explain.Type
+		// EXPLAIN ANALYZE executes the explained statement,
+		// which is only acceptable absent side effects.
+		if _, isSelect := explain.Statement.(sqlparser.SelectStatement); !isSelect && format == sqlparser.AnalyzeStr {
+			instructionErr = fmt.Errorf("EXPLAIN ANALYZE is only supported for SELECT statements")
+		}
+	}
+	// The explained plan must be captured before the graph is blanked.
+	explainedGraph := pb.PrimitiveComposer.GetGraphHolder().GetPrimitiveGraph()
 	_ = pb.PrimitiveComposer.GetGraphHolder().Blank()
 	pb.PrimitiveComposer.SetBuilder(
 		primitivebuilder.NewExplainBuilder(
 			pb.PrimitiveComposer.GetGraphHolder(),
+			explainedGraph,
+			format,
 			pb.PrimitiveComposer.GetTxnCtrlCtrs(),
 			handlerCtx,
 			handlerCtx.GetSQLEngine(),
@@ -919,9 +932,13 @@ func (pb *standardPrimitiveGenerator) AnalyzePGInternal(
 	// pass through
 	if backendQueryType, ok := handlerCtx.GetDBMSInternalRouter().CanRoute(pbi.GetStatement()); ok {
 		if backendQueryType == constants.BackendQuery {
+			nativeQuery, rewriteErr := rewritePGCatalogQuery(handlerCtx, pb.catalogColumnInferrer, pbi.GetRawQuery())
+			if rewriteErr != nil {
+				return rewriteErr
+			}
 			bldr := primitivebuilder.NewRawNativeSelect(
 				pb.PrimitiveComposer.GetGraphHolder(), handlerCtx, pbi.GetTxnCtrlCtrs(),
-				pbi.GetRawQuery())
+				nativeQuery)
 			pb.PrimitiveComposer.SetBuilder(bldr)
 			return nil
 		}
@@ -1651,6 +1668,8 @@ func (pb *standardPrimitiveGenerator) analyzeShow(
 		// TODO
 	case "VERSION":
 		// no further analysis required
+	case "PLANCACHE":
+		// no further analysis required
 	case "RESOURCES":
 		prov, err := handlerCtx.GetProvider(node.OnTable.Qualifier.GetRawVal())
 		if err != nil {
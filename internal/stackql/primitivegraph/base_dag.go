@@ -3,8 +3,10 @@ package primitivegraph
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/stackql/stackql/internal/stackql/acid/binlog"
+	"github.com/stackql/stackql/internal/stackql/execstats"
 	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
 	"github.com/stackql/stackql/internal/stackql/primitive"
 
@@ -70,6 +72,10 @@ func (pg *standardBasePrimitiveGraph) Nodes() graph.Nodes {
 	return pg.g.Nodes()
 }
 
+func (pg *standardBasePrimitiveGraph) To(id int64) graph.Nodes {
+	return pg.g.To(id)
+}
+
 func (pg *standardBasePrimitiveGraph) SetRedoLog(binlog.LogEntry) {
 }
 
@@ -214,9 +220,15 @@ func (pg *standardBasePrimitiveGraph) Execute(ctx primitive.IPrimitiveCtx) inter
 			}
 			nodeIdx := currentNodeIdx
 			idxMap[nodeID] = nodeIdx
+			var nodeCtx primitive.IPrimitiveCtx = ctx
+			if analysableCtx, isAnalysable := ctx.(primitive.AnalysablePrimitiveCtx); isAnalysable {
+				nodeCtx = analysableCtx.ForNode(nodeID)
+			}
 			pg.errGroup.Go(
 				func() error {
-					funOutput := node.GetOperation().Execute(ctx)
+					start := time.Now()
+					funOutput := node.GetOperation().Execute(nodeCtx)
+					execstats.FromContext(nodeCtx).RecordElapsed(time.Since(start))
 					thisChan := outChan[nodeIdx]
 					thisChan <- funOutput
 					close(thisChan)
@@ -280,6 +292,14 @@ func (pg *standardBasePrimitiveGraph) WithDebugName(_ string) primitive.IPrimiti
 	return pg
 }
 
+func (pg *standardBasePrimitiveGraph) GetDescriptor() (primitive.Descriptor, bool) {
+	return nil, false
+}
+
+func (pg *standardBasePrimitiveGraph) WithDescriptor(_ primitive.Descriptor) primitive.IPrimitive {
+	return pg
+}
+
 func newBasePrimitiveGraph(concurrencyLimit int) BasePrimitiveGraph {
 	eg, egCtx := errgroup.WithContext(context.Background())
 	eg.SetLimit(concurrencyLimit)
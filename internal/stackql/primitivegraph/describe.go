@@ -0,0 +1,67 @@
+package primitivegraph
+
+import (
+	"sort"
+
+	"github.com/stackql/stackql/internal/stackql/primitive"
+)
+
+// NodeDescription is the introspection (EXPLAIN) view
+// of a single node in a primitive graph.
+type NodeDescription struct {
+	ID        int64   `json:"id"`
+	Kind      string  `json:"kind"`
+	Provider  string  `json:"provider,omitempty"`
+	Service   string  `json:"service,omitempty"`
+	Resource  string  `json:"resource,omitempty"`
+	Method    string  `json:"method,omitempty"`
+	Pushdown  string  `json:"pushdown,omitempty"`
+	DependsOn []int64 `json:"depends_on"`
+	Component *int    `json:"component,omitempty"`
+}
+
+// DescribeGraph walks the graph in topological order and
+// returns one description per primitive node.  Dependencies
+// are the IDs of nodes which must complete before the node
+// in question may begin.
+func DescribeGraph(pg BasePrimitiveGraph) ([]NodeDescription, error) {
+	if pg == nil {
+		return nil, nil
+	}
+	sorted, err := pg.Sort()
+	if err != nil {
+		return nil, err
+	}
+	var rv []NodeDescription
+	for _, n := range sorted {
+		node, isPrimitiveNode := n.(PrimitiveNode)
+		if !isPrimitiveNode {
+			continue
+		}
+		description := NodeDescription{
+			ID:        node.ID(),
+			Kind:      primitive.KindUnknown,
+			DependsOn: []int64{},
+		}
+		if descriptor, hasDescriptor := node.GetOperation().GetDescriptor(); hasDescriptor {
+			description.Kind = descriptor.GetKind()
+			description.Provider = descriptor.GetProvider()
+			description.Service = descriptor.GetService()
+			description.Resource = descriptor.GetResource()
+			description.Method = descriptor.GetMethod()
+			description.Pushdown = descriptor.GetPushdown()
+			if component, hasComponent := descriptor.GetComponent(); hasComponent {
+				description.Component = &component
+			}
+		}
+		incidentNodes := pg.To(node.ID())
+		for incidentNodes.Next() {
+			description.DependsOn = append(description.DependsOn, incidentNodes.Node().ID())
+		}
+		sort.Slice(description.DependsOn, func(i, j int) bool {
+			return description.DependsOn[i] < description.DependsOn[j]
+		})
+		rv = append(rv, description)
+	}
+	return rv, nil
+}
@@ -29,6 +29,7 @@ type BasePrimitiveGraph interface {
 	NewNode() graph.Node
 	AddNode(graph.Node)
 	Nodes() graph.Nodes
+	To(id int64) graph.Nodes
 }
 
 type PrimitiveGraph interface {
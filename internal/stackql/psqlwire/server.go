@@ -6,6 +6,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"net"
 
 	"github.com/sirupsen/logrus"
 	"github.com/stackql/any-sdk/pkg/dto"
@@ -13,6 +14,7 @@ import (
 	"gopkg.in/yaml.v2"
 
 	"github.com/stackql/psql-wire/pkg/sqlbackend"
+	"github.com/stackql/stackql/internal/stackql/pgauth"
 
 	wire "github.com/stackql/psql-wire"
 )
@@ -21,88 +23,121 @@ type IWireServer interface {
 	Serve() error
 }
 
-type SimpleWireServer struct {
-	logger *logrus.Logger
-	server *wire.Server
-	rtCtx  dto.RuntimeCtx
-	tlsCfg dto.PgTLSCfg
-}
-
-//nolint:gocognit,nestif,nolintlint
+// MakeWireServer returns a server which trusts every client, serving
+// sessions with the backends of sbe.  Connections are fronted, as those of
+// MakeUserWireServer, so that cancel requests reach running queries.
 func MakeWireServer(sbe sqlbackend.SQLBackendFactory, cfg dto.RuntimeCtx) (IWireServer, error) {
-	logger := logging.GetLogger()
+	return makeRoutedWireServer(
+		cfg,
+		nil,
+		func(pgauth.User) (sqlbackend.SQLBackendFactory, error) { return sbe, nil },
+	)
+}
 
-	var tlsCfg dto.PgTLSCfg
-	var server *wire.Server
+// MakeUserWireServer returns a server which authenticates clients against
+// users, serving each user's sessions with the backends of factoryFor.
+// TLS, where configured, is terminated ahead of authentication.
+func MakeUserWireServer(
+	cfg dto.RuntimeCtx,
+	users pgauth.Users,
+	factoryFor func(pgauth.User) (sqlbackend.SQLBackendFactory, error),
+) (IWireServer, error) {
+	return makeRoutedWireServer(cfg, users, factoryFor)
+}
 
-	var err error
+func makeRoutedWireServer(
+	cfg dto.RuntimeCtx,
+	users pgauth.Users,
+	factoryFor func(pgauth.User) (sqlbackend.SQLBackendFactory, error),
+) (IWireServer, error) {
+	logger := logging.GetLogger()
 	pgSrvMiscConfig := make(map[string]interface{})
 	if cfg.PGSrvRawSrvCfg != "" {
-		err = yaml.Unmarshal([]byte(cfg.PGSrvRawSrvCfg), &pgSrvMiscConfig)
-		if err != nil {
+		if err := yaml.Unmarshal([]byte(cfg.PGSrvRawSrvCfg), &pgSrvMiscConfig); err != nil {
 			return nil, err
 		}
 	}
-	if cfg.PGSrvRawTLSCfg != "" {
-		err = json.Unmarshal([]byte(cfg.PGSrvRawTLSCfg), &tlsCfg)
-		if err != nil {
-			return nil, err
-		}
-		var cert tls.Certificate
-		cert, err = tlsCfg.GetKeyPair()
-		if err != nil {
-			return nil, err
-		}
-		certs := []tls.Certificate{cert}
-		server, err = wire.NewServer(
-			wire.SQLBackendFactory(sbe),
-			wire.Certificates(certs),
-			wire.Logger(logging.GetLogger()),
-		)
-		var cp *x509.CertPool
-		if len(tlsCfg.ClientCAs) > 0 {
-			cp = x509.NewCertPool()
-			for _, pemStr := range tlsCfg.ClientCAs {
-				var b []byte
-				b, err = base64.StdEncoding.DecodeString(pemStr)
-				if err != nil {
-					return nil, fmt.Errorf("failed to decode Client CA PEM: %w, with string '%s'", err, pemStr)
-				}
-				ok := cp.AppendCertsFromPEM(b)
-				if !ok {
-					logger.Error("failed loading Client CA")
-				}
-			}
-			server.ClientCAs = cp
-			// The strongest assertion a server can provide, per https://smallstep.com/hello-mtls/doc/server/go
-			server.ClientAuth = tls.RequireAndVerifyClientCert
-		}
-		if err != nil {
-			return nil, err
+	tlsConfig, err := makeTLSConfig(cfg.PGSrvRawTLSCfg)
+	if err != nil {
+		return nil, err
+	}
+	serve := func(user pgauth.User, l net.Listener) error {
+		sbe, factoryErr := factoryFor(user)
+		if factoryErr != nil {
+			return factoryErr
 		}
-	} else {
-		server, err = wire.NewServer(
+		server, serverErr := wire.NewServer(
 			wire.SQLBackendFactory(sbe),
 			wire.SundryConfig(pgSrvMiscConfig),
 			wire.IsCaptureDebug(cfg.PGSrvIsDebugNoticesEnabled),
+			wire.Logger(logger),
 		)
-		if err != nil {
-			return nil, err
+		if serverErr != nil {
+			return serverErr
 		}
+		return server.Serve(l)
 	}
-	return &SimpleWireServer{
-		logger: logger,
-		rtCtx:  cfg,
-		server: server,
-		tlsCfg: tlsCfg,
+	return &routedWireServer{
+		logger:          logger,
+		rtCtx:           cfg,
+		router:          pgauth.NewRouter(users, tlsConfig, serve, logger),
+		isAuthenticated: users != nil,
 	}, nil
 }
 
-func (sws *SimpleWireServer) Serve() error {
-	sws.logger.Info(
-		fmt.Sprintf("PostgreSQL server is up and running at [%s:%d]",
-			sws.rtCtx.PGSrvAddress,
-			sws.rtCtx.PGSrvPort),
-	)
-	return sws.server.ListenAndServe(fmt.Sprintf("%s:%d", sws.rtCtx.PGSrvAddress, sws.rtCtx.PGSrvPort))
+// makeTLSConfig builds the TLS config of the server, nil if none is set.
+func makeTLSConfig(rawTLSCfg string) (*tls.Config, error) {
+	if rawTLSCfg == "" {
+		return nil, nil //nolint:nilnil // no TLS
+	}
+	var tlsCfg dto.PgTLSCfg
+	if err := json.Unmarshal([]byte(rawTLSCfg), &tlsCfg); err != nil {
+		return nil, err
+	}
+	cert, err := tlsCfg.GetKeyPair()
+	if err != nil {
+		return nil, err
+	}
+	rv := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if len(tlsCfg.ClientCAs) > 0 {
+		cp := x509.NewCertPool()
+		for _, pemStr := range tlsCfg.ClientCAs {
+			b, decodeErr := base64.StdEncoding.DecodeString(pemStr)
+			if decodeErr != nil {
+				return nil, fmt.Errorf("failed to decode Client CA PEM: %w, with string '%s'", decodeErr, pemStr)
+			}
+			if !cp.AppendCertsFromPEM(b) {
+				logging.GetLogger().Error("failed loading Client CA")
+			}
+		}
+		rv.ClientCAs = cp
+		rv.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return rv, nil
+}
+
+type routedWireServer struct {
+	logger          *logrus.Logger
+	rtCtx           dto.RuntimeCtx
+	router          pgauth.Router
+	isAuthenticated bool
+}
+
+func (rws *routedWireServer) Serve() error {
+	addr := fmt.Sprintf("%s:%d", rws.rtCtx.PGSrvAddress, rws.rtCtx.PGSrvPort)
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	if rws.isAuthenticated {
+		rws.logger.Info(
+			fmt.Sprintf("PostgreSQL server is up and running at [%s], with password authentication", addr),
+		)
+	} else {
+		rws.logger.Info(fmt.Sprintf("PostgreSQL server is up and running at [%s]", addr))
+	}
+	return rws.router.Serve(l)
 }
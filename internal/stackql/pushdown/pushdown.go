@@ -9,6 +9,7 @@
 package pushdown
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -260,3 +261,43 @@ func RedactHeaderValue(key string, val string, allowedInnocuous map[string]bool)
 	}
 	return string(runes)
 }
+
+// DescribeIntent renders a PushdownIntent as a compact, stable, single line summary
+// suitable for plan introspection (EXPLAIN). A nil intent renders as the empty string.
+func DescribeIntent(intent formulation.PushdownIntent) string {
+	if intent == nil {
+		return ""
+	}
+	var parts []string
+	if intent.IsCount() {
+		parts = append(parts, "count")
+	}
+	if proj := intent.GetProjection(); len(proj) > 0 {
+		parts = append(parts, "select("+strings.Join(proj, ", ")+")")
+	}
+	if preds := intent.GetPredicates(); len(preds) > 0 {
+		predStrs := make([]string, len(preds))
+		for i, p := range preds {
+			predStrs[i] = fmt.Sprintf("%s %s %v", p.GetColumn(), p.GetOperator(), p.GetValue())
+		}
+		parts = append(parts, "where("+strings.Join(predStrs, " and ")+")")
+	}
+	if orderBy := intent.GetOrderBy(); len(orderBy) > 0 {
+		orderStrs := make([]string, len(orderBy))
+		for i, o := range orderBy {
+			direction := "asc"
+			if o.IsDescending() {
+				direction = "desc"
+			}
+			orderStrs[i] = o.GetColumn() + " " + direction
+		}
+		parts = append(parts, "order_by("+strings.Join(orderStrs, ", ")+")")
+	}
+	if limit, isSet := intent.GetLimit(); isSet {
+		parts = append(parts, "limit("+strconv.Itoa(limit)+")")
+	}
+	if offset, isSet := intent.GetOffset(); isSet {
+		parts = append(parts, "offset("+strconv.Itoa(offset)+")")
+	}
+	return strings.Join(parts, " ")
+}
@@ -206,3 +206,20 @@ func TestBuildPushdownIntent_CountSuppressesLimitAndProjection(t *testing.T) {
 		t.Errorf("expected top/skip/select/orderby suppressed for COUNT")
 	}
 }
+
+func TestDescribeIntent(t *testing.T) {
+	node := mustSelect(t,
+		"select a from t where x = 'v' and y > 5 order by z desc limit 10 offset 2")
+	intent, ok := buildPushdownIntent(node)
+	if !ok {
+		t.Fatalf("expected pushable intent")
+	}
+	got := DescribeIntent(intent)
+	want := "select(a, x, y, z) where(x = v and y > 5) order_by(z desc) limit(10) offset(2)"
+	if got != want {
+		t.Errorf("DescribeIntent() = %q, want %q", got, want)
+	}
+	if DescribeIntent(nil) != "" {
+		t.Errorf("expected empty description for nil intent")
+	}
+}
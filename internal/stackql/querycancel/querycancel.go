@@ -0,0 +1,172 @@
+// Package querycancel cancels the queries of postgres wire protocol
+// sessions.  The wire server front end issues each connection a cancel
+// key, as carried by BackendKeyData, and tags the queries of the
+// connection with its process ID; the driver binds the context of each
+// tagged query to the key, so that a CancelRequest bearing the key
+// cancels whatever the session is running.  The key is released as the
+// connection ends, which is how the driver learns that its session is
+// over.
+package querycancel
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Causes of cancellation, as reported to clients.
+var (
+	ErrCanceled         = errors.New("canceling statement due to user request")
+	ErrStatementTimeout = errors.New("canceling statement due to statement timeout")
+)
+
+// Key is a cancel key.
+type Key struct {
+	ProcessID uint32
+	Secret    uint32
+}
+
+// Registry maps cancel keys onto the running queries of their sessions.
+type Registry interface {
+	// Issue returns a new key.
+	Issue() (Key, error)
+	// Release forgets a key, once its connection closes, and runs the
+	// session's release hooks.
+	Release(processID uint32)
+	// OnRelease registers a hook run as the session's key is released,
+	// reporting false if the key is not live.
+	OnRelease(processID uint32, hook func()) bool
+	// Bind returns a context cancelled by a cancel request for the
+	// session, until the returned function is called.
+	Bind(ctx context.Context, processID uint32) (context.Context, context.CancelFunc)
+	// Cancel cancels the running query of the session, where the secret
+	// matches.
+	Cancel(key Key) bool
+}
+
+type session struct {
+	secret    uint32
+	cancel    context.CancelCauseFunc
+	onRelease []func()
+}
+
+type standardRegistry struct {
+	mu       sync.Mutex
+	sessions map[uint32]*session
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() Registry {
+	return &standardRegistry{sessions: make(map[uint32]*session)}
+}
+
+//nolint:gochecknoglobals // shared by the front end and the driver
+var defaultRegistry = NewRegistry()
+
+// DefaultRegistry returns the process wide registry.
+func DefaultRegistry() Registry { return defaultRegistry }
+
+func (r *standardRegistry) Issue() (Key, error) {
+	var b [8]byte
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for {
+		if _, err := rand.Read(b[:]); err != nil {
+			return Key{}, err
+		}
+		k := Key{ProcessID: binary.BigEndian.Uint32(b[:4]) >> 1, Secret: binary.BigEndian.Uint32(b[4:])}
+		if _, taken := r.sessions[k.ProcessID]; k.ProcessID == 0 || taken {
+			continue
+		}
+		r.sessions[k.ProcessID] = &session{secret: k.Secret}
+		return k, nil
+	}
+}
+
+func (r *standardRegistry) Release(processID uint32) {
+	r.mu.Lock()
+	s, ok := r.sessions[processID]
+	delete(r.sessions, processID)
+	r.mu.Unlock()
+	if !ok {
+		return
+	}
+	for _, hook := range s.onRelease {
+		hook()
+	}
+}
+
+func (r *standardRegistry) OnRelease(processID uint32, hook func()) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.sessions[processID]
+	if !ok {
+		return false
+	}
+	s.onRelease = append(s.onRelease, hook)
+	return true
+}
+
+func (r *standardRegistry) Bind(ctx context.Context, processID uint32) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	r.mu.Lock()
+	s, ok := r.sessions[processID]
+	if ok {
+		s.cancel = cancel
+	}
+	r.mu.Unlock()
+	return ctx, func() {
+		r.mu.Lock()
+		if ok && s.cancel != nil {
+			s.cancel = nil
+		}
+		r.mu.Unlock()
+		cancel(context.Canceled)
+	}
+}
+
+func (r *standardRegistry) Cancel(key Key) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.sessions[key.ProcessID]
+	if !ok || s.secret != key.Secret || s.cancel == nil {
+		return false
+	}
+	s.cancel(ErrCanceled)
+	return true
+}
+
+const (
+	tagPrefix = "/*stackql:session="
+	tagSuffix = "*/ "
+)
+
+// Tag prefixes a query with the session of a process ID.  Blank queries
+// are left alone, so that they still draw EmptyQueryResponse.
+func Tag(query string, processID uint32) string {
+	if strings.TrimSpace(query) == "" {
+		return query
+	}
+	return tagPrefix + strconv.FormatUint(uint64(processID), 10) + tagSuffix + query
+}
+
+// Untag strips the tag of Tag, returning the process ID it carried.
+func Untag(query string) (string, uint32, bool) {
+	rest, found := strings.CutPrefix(query, tagPrefix)
+	if !found {
+		return query, 0, false
+	}
+	idStr, stripped, found := strings.Cut(rest, tagSuffix)
+	if !found {
+		return query, 0, false
+	}
+	processID, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		return query, 0, false
+	}
+	return stripped, uint32(processID), true
+}
@@ -0,0 +1,107 @@
+package querycancel_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stackql/stackql/internal/stackql/querycancel"
+)
+
+func TestTagRoundTrip(t *testing.T) {
+	tagged := querycancel.Tag("SELECT 1; SELECT 2", 4242)
+	query, processID, ok := querycancel.Untag(tagged)
+	if !ok || processID != 4242 || query != "SELECT 1; SELECT 2" {
+		t.Fatalf("unexpected untag of %q: %q %d %v", tagged, query, processID, ok)
+	}
+	if blank := querycancel.Tag("  ", 1); blank != "  " {
+		t.Fatalf("expected a blank query to stay blank, received %q", blank)
+	}
+	if _, _, ok = querycancel.Untag("/* a comment */ SELECT 1"); ok {
+		t.Fatalf("expected an ordinary comment not to untag")
+	}
+}
+
+func TestRegistry_Cancel(t *testing.T) {
+	r := querycancel.NewRegistry()
+	key, err := r.Issue()
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if r.Cancel(key) {
+		t.Fatalf("expected nothing to cancel while idle")
+	}
+	ctx, release := r.Bind(context.Background(), key.ProcessID)
+	if r.Cancel(querycancel.Key{ProcessID: key.ProcessID, Secret: key.Secret + 1}) {
+		t.Fatalf("expected a wrong secret to be refused")
+	}
+	if !r.Cancel(key) {
+		t.Fatalf("expected the running query to be cancelled")
+	}
+	<-ctx.Done()
+	if !errors.Is(context.Cause(ctx), querycancel.ErrCanceled) {
+		t.Fatalf("unexpected cause %v", context.Cause(ctx))
+	}
+	release()
+	if r.Cancel(key) {
+		t.Fatalf("expected nothing to cancel once released")
+	}
+	r.Release(key.ProcessID)
+	ctx, release = r.Bind(context.Background(), key.ProcessID)
+	defer release()
+	if r.Cancel(key) || ctx.Err() != nil {
+		t.Fatalf("expected a released key to cancel nothing")
+	}
+}
+
+func TestRegistry_OnRelease(t *testing.T) {
+	r := querycancel.NewRegistry()
+	key, err := r.Issue()
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	var released int
+	if !r.OnRelease(key.ProcessID, func() { released++ }) {
+		t.Fatalf("expected a hook upon a live key")
+	}
+	r.Release(key.ProcessID)
+	r.Release(key.ProcessID)
+	if released != 1 {
+		t.Fatalf("expected the hook run once, ran %d times", released)
+	}
+	if r.OnRelease(key.ProcessID, func() {}) {
+		t.Fatalf("expected no hook upon a released key")
+	}
+}
+
+func TestStatementTimeoutSetting(t *testing.T) {
+	for stmt, expected := range map[string]time.Duration{
+		"SET statement_timeout = 5000":           5 * time.Second,
+		"set statement_timeout to '5s'":          5 * time.Second,
+		"SET SESSION statement_timeout = '2min'": 2 * time.Minute,
+		"SET LOCAL statement_timeout TO 250":     250 * time.Millisecond,
+		`SET "statement_timeout" = '1.5 h'`:      90 * time.Minute,
+		"SET statement_timeout TO DEFAULT":       0,
+		"RESET statement_timeout;":               0,
+		"SET statement_timeout = 0":              0,
+	} {
+		d, ok, err := querycancel.StatementTimeoutSetting(stmt)
+		if err != nil || !ok || d != expected {
+			t.Errorf("%s: expected %v, received %v %v %v", stmt, expected, d, ok, err)
+		}
+	}
+	for _, stmt := range []string{
+		"SET search_path = public",
+		"SELECT statement_timeout FROM t",
+		"RESET ALL",
+		`SET "$.auth.google" = '{}'`,
+	} {
+		if _, ok, _ := querycancel.StatementTimeoutSetting(stmt); ok {
+			t.Errorf("%s: expected no statement_timeout setting", stmt)
+		}
+	}
+	if _, ok, err := querycancel.StatementTimeoutSetting("SET statement_timeout = '5 fortnights'"); !ok || err == nil {
+		t.Errorf("expected an invalid unit to be an error")
+	}
+}
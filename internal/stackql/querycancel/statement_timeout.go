@@ -0,0 +1,87 @@
+package querycancel
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const statementTimeoutParam = "statement_timeout"
+
+// timeoutUnits are the units postgres accepts for time valued settings.
+//
+//nolint:gochecknoglobals // immutable lookup
+var timeoutUnits = map[string]time.Duration{
+	"us":  time.Microsecond,
+	"ms":  time.Millisecond,
+	"s":   time.Second,
+	"min": time.Minute,
+	"h":   time.Hour,
+	"d":   24 * time.Hour, //nolint:mnd // a day
+}
+
+// ParseStatementTimeout parses a statement_timeout value as postgres does:
+// a bare number is milliseconds, else the number carries a unit among us,
+// ms, s, min, h and d.  Zero disables the timeout.
+func ParseStatementTimeout(value string) (time.Duration, error) {
+	v := strings.TrimSpace(strings.Trim(strings.TrimSpace(value), `'"`))
+	if strings.EqualFold(v, "default") {
+		return 0, nil
+	}
+	i := strings.IndexFunc(v, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	numStr, unitStr := v, "ms"
+	if i >= 0 {
+		numStr, unitStr = v[:i], strings.ToLower(strings.TrimSpace(v[i:]))
+	}
+	unit, ok := timeoutUnits[unitStr]
+	if !ok {
+		return 0, fmt.Errorf(`invalid value for parameter "%s": "%s"`, statementTimeoutParam, value)
+	}
+	n, err := strconv.ParseFloat(numStr, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf(`invalid value for parameter "%s": "%s"`, statementTimeoutParam, value)
+	}
+	return time.Duration(n * float64(unit)), nil
+}
+
+// StatementTimeoutSetting recognises a statement which sets the session's
+// statement_timeout: SET [SESSION] statement_timeout {=|TO} value, or
+// RESET statement_timeout.  SET LOCAL is treated as SET, there being no
+// transaction scope to revert it at.
+func StatementTimeoutSetting(stmt string) (time.Duration, bool, error) {
+	fields := strings.Fields(strings.TrimSuffix(strings.TrimSpace(stmt), ";"))
+	if len(fields) < 2 { //nolint:mnd // keyword and parameter
+		return 0, false, nil
+	}
+	switch strings.ToUpper(fields[0]) {
+	case "RESET":
+		if len(fields) == 2 && strings.EqualFold(fields[1], statementTimeoutParam) {
+			return 0, true, nil
+		}
+		return 0, false, nil
+	case "SET":
+	default:
+		return 0, false, nil
+	}
+	rest := fields[1:]
+	if scope := strings.ToUpper(rest[0]); scope == "SESSION" || scope == "LOCAL" {
+		rest = rest[1:]
+	}
+	if len(rest) == 0 {
+		return 0, false, nil
+	}
+	name, value, hasEquals := strings.Cut(strings.Join(rest, " "), "=")
+	if !hasEquals {
+		parts := strings.Fields(name)
+		if len(parts) < 3 || !strings.EqualFold(parts[1], "TO") { //nolint:mnd // name TO value
+			return 0, false, nil
+		}
+		name, value = parts[0], strings.Join(parts[2:], " ")
+	}
+	if !strings.EqualFold(strings.Trim(strings.TrimSpace(name), `"`), statementTimeoutParam) {
+		return 0, false, nil
+	}
+	d, err := ParseStatementTimeout(value)
+	return d, true, err
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/stackql/psql-wire/pkg/sqldata"
 	"github.com/stackql/stackql-parser/go/vt/sqlparser"
 	"github.com/stackql/stackql/internal/stackql/handler"
+	"github.com/stackql/stackql/internal/stackql/plan"
 	"github.com/stackql/stackql/internal/stackql/planbuilder"
 	"github.com/stackql/stackql/internal/stackql/sql_system"
 	"github.com/stackql/stackql/internal/stackql/typing"
@@ -97,6 +98,10 @@ func (si *standardInferrer) inferFromPlan(query string) []sqldata.ISQLColumn {
 	if err != nil || qPlan == nil {
 		return nil
 	}
+	if boundPlan, isBound := qPlan.(plan.BoundPlan); isBound {
+		// Held from the template cache, though never to be executed.
+		defer boundPlan.Release()
+	}
 	colMeta := qPlan.GetColumnMetadata()
 	if len(colMeta) == 0 {
 		return nil
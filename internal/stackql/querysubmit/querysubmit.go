@@ -96,6 +96,9 @@ func (qs *basicQuerySubmitter) SubmitQuery() internaldto.ExecutorOutput {
 		qs.handlerCtx.GetOutfile(),
 		qs.handlerCtx.GetOutErrFile(),
 	)
+	if boundPlan, isBound := qs.queryPlan.(plan.BoundPlan); isBound {
+		defer boundPlan.Release()
+	}
 	return qs.queryPlan.GetInstructions().GetPrimitiveGraph().Execute(pl)
 }
 
@@ -0,0 +1,120 @@
+package sql_system //nolint:revive,stylecheck // package name is meaningful and readable
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/stackql/any-sdk/pkg/db/sqlcontrol"
+	"github.com/stackql/any-sdk/pkg/dto"
+	"github.com/stackql/any-sdk/public/sqlengine"
+	"github.com/stackql/stackql-parser/go/vt/sqlparser"
+	"github.com/stackql/stackql/internal/stackql/astfuncrewrite"
+	"github.com/stackql/stackql/internal/stackql/datasource/sql_datasource"
+	"github.com/stackql/stackql/internal/stackql/duckdbengine"
+	"github.com/stackql/stackql/internal/stackql/typing"
+)
+
+var (
+	_ embeddedDialect = (*duckDBDialect)(nil)
+)
+
+func newDuckDBSystem(
+	sqlEngine sqlengine.SQLEngine,
+	analyticsNamespaceLikeString string,
+	controlAttributes sqlcontrol.ControlAttributes,
+	formatter sqlparser.NodeFormatter,
+	sqlCfg dto.SQLBackendCfg, //nolint:unparam,revive // future proof
+	authCfg map[string]*dto.AuthCtx,
+	sqlDataSources map[string]sql_datasource.SQLDataSource,
+	typCfg typing.Config,
+	exportNamepsace string,
+) (SQLSystem, error) {
+	return newEmbeddedSQLSystem(
+		&duckDBDialect{},
+		sqlEngine,
+		analyticsNamespaceLikeString,
+		controlAttributes,
+		formatter,
+		authCfg,
+		sqlDataSources,
+		typCfg,
+		exportNamepsace,
+	)
+}
+
+type duckDBDialect struct{}
+
+func (d *duckDBDialect) getName() string {
+	return typing.SQLDialectDuckDB
+}
+
+func (d *duckDBDialect) getSetupDDL() string {
+	return duckdbengine.GetSetupDDL()
+}
+
+func (d *duckDBDialect) getASTFuncRewriter() astfuncrewrite.ASTFuncRewriter {
+	return astfuncrewrite.GetDuckDBASTFuncRewriter()
+}
+
+// getUserTables lists the tables of the database
+// in use, shorn of those of DuckDB itself.
+func (d *duckDBDialect) getUserTables() string {
+	return `(
+		SELECT
+			table_name AS name
+		FROM
+			duckdb_tables()
+		WHERE
+			database_name = current_database()
+			AND
+			schema_name = current_schema()
+			AND
+			NOT internal
+		)`
+}
+
+// getDropTableExpr drops the table `name`
+// along with any sequence of its surrogate key.
+func (d *duckDBDialect) getDropTableExpr() string {
+	return `'DROP TABLE IF EXISTS "' || name || '" ; DROP SEQUENCE IF EXISTS "iql_' || name || '_id_seq" ; '`
+}
+
+// getSurrogateKeyDDL creates a sequence per table to
+// supply the surrogate key, as DuckDB has no AUTOINCREMENT.
+func (d *duckDBDialect) getSurrogateKeyDDL(tableName string) ([]string, string) {
+	seqName := fmt.Sprintf("iql_%s_id_seq", tableName)
+	return []string{
+			fmt.Sprintf(`create sequence if not exists "%s" `, seqName),
+		},
+		fmt.Sprintf(`"iql_%s_id" BIGINT PRIMARY KEY DEFAULT nextval('"%s"')`, tableName, seqName)
+}
+
+// getLatestUpdateColumnType is a naive `TIMESTAMP`, populated
+// in UTC from the epoch, as DuckDB converts `TIMESTAMP WITH
+// TIME ZONE` only with the ICU extension, which may not be present.
+func (d *duckDBDialect) getLatestUpdateColumnType() string {
+	return "TIMESTAMP NOT NULL DEFAULT make_timestamp(epoch_us(now()))"
+}
+
+// getOldestUpdateQuery takes the counters of the oldest row
+// per `arg_min()`, as DuckDB has no bare columns in aggregates.
+func (d *duckDBDialect) getOldestUpdateQuery(
+	tableName string,
+	updateColName string,
+	requestEncodingColName string,
+	requestEncoding string,
+	counterColNames []string,
+) string {
+	counters := make([]string, 0, len(counterColNames))
+	for _, colName := range counterColNames {
+		counters = append(counters, fmt.Sprintf("arg_min(%s, %s)", colName, updateColName))
+	}
+	return fmt.Sprintf(
+		"SELECT strftime(min(%s), '%%Y-%%m-%%dT%%H:%%M:%%S') as oldest_update, %s FROM \"%s\" WHERE %s = '%s';",
+		updateColName,
+		strings.Join(counters, ", "),
+		tableName,
+		requestEncodingColName,
+		requestEncoding,
+	)
+}
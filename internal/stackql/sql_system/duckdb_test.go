@@ -0,0 +1,268 @@
+package sql_system_test //nolint:stylecheck,revive // package name is meaningful and readable
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stackql/any-sdk/pkg/db/sqlcontrol"
+	"github.com/stackql/any-sdk/pkg/dto"
+	"github.com/stackql/stackql-parser/go/vt/sqlparser"
+	"github.com/stackql/stackql/internal/stackql/duckdbengine"
+	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
+	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/relationaldto"
+	"github.com/stackql/stackql/internal/stackql/sql_system"
+	"github.com/stackql/stackql/internal/stackql/typing"
+)
+
+func newDuckDBSystem(t *testing.T) (sql_system.SQLSystem, duckdbengine.Engine) {
+	t.Helper()
+	engine, err := duckdbengine.NewEngine("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() {
+		db, _ := engine.GetDB()
+		db.Close()
+	})
+	typCfg, err := typing.NewTypingConfig(typing.SQLDialectDuckDB)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	system, err := sql_system.NewSQLSystem(
+		engine,
+		"stackql_analytics_%",
+		sqlcontrol.GetControlAttributes("standard"),
+		dto.SQLBackendCfg{SQLSystem: typing.SQLDialectDuckDB},
+		nil,
+		nil,
+		typCfg,
+		"",
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return system, engine
+}
+
+func countRows(t *testing.T, engine duckdbengine.Engine, query string) int {
+	t.Helper()
+	var ct int
+	if err := engine.QueryRow(query).Scan(&ct); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return ct
+}
+
+func TestDuckDBViews(t *testing.T) {
+	system, _ := newDuckDBSystem(t)
+	if err := system.CreateView("v", "select 1 as x", false, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := system.CreateView("v", "select 2 as x", false, nil); err == nil {
+		t.Fatalf("expected an error re-creating a view without replacement")
+	}
+	if err := system.CreateView("v", "select 2 as x", true, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	view, ok := system.GetViewByName("v")
+	if !ok || view.GetRawQuery() != "select 2 as x" {
+		t.Fatalf("expected the view replaced, got %v", view)
+	}
+	if err := system.CreateView("pv", "select 3 as x", false, []string{"region"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok = system.GetViewByNameAndParameters("pv", nil); ok {
+		t.Fatalf("expected no match absent required parameters")
+	}
+	if _, ok = system.GetViewByNameAndParameters("pv", map[string]any{"region": "eu"}); !ok {
+		t.Fatalf("expected a match upon required parameters")
+	}
+	relations, err := system.ListRelations()
+	if err != nil || len(relations) != 2 {
+		t.Fatalf("expected two relations, got %d: %v", len(relations), err)
+	}
+	if err = system.DropView("v"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok = system.GetViewByName("v"); ok {
+		t.Fatalf("expected the view dropped")
+	}
+}
+
+func TestDuckDBMaterializedViews(t *testing.T) {
+	system, engine := newDuckDBSystem(t)
+	if _, err := engine.Exec(
+		`CREATE TABLE "src" (name TEXT, size INTEGER) ; INSERT INTO "src" VALUES ('a', 1), ('b', 2), ('c', 3)`,
+	); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	colz := []typing.RelationalColumn{
+		typing.NewRelationalColumn("name", "TEXT"),
+		typing.NewRelationalColumn("size", "INTEGER"),
+	}
+	selectQuery := `SELECT name, size FROM "src" WHERE size > ?`
+	if err := system.CreateMaterializedView(
+		"mv", colz, "create materialized view mv as select name, size from src", false, selectQuery, 1,
+	); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mv, ok := system.GetMaterializedViewByName("mv")
+	if !ok || !mv.IsMaterialized() || len(mv.GetColumns()) != len(colz) {
+		t.Fatalf("expected a materialized view of %d columns, got %v", len(colz), mv)
+	}
+	if ct := countRows(t, engine, `SELECT count(*) FROM "mv"`); ct != 2 {
+		t.Fatalf("expected 2 rows, got %d", ct)
+	}
+	rows, err := system.QueryMaterializedView(`"name"`, "mv", `"size" = 3`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var name string
+	if !rows.Next() || rows.Scan(&name) != nil || name != "c" {
+		t.Fatalf("expected row 'c', got %q", name)
+	}
+	rows.Close()
+	if _, err = engine.Exec(`INSERT INTO "src" VALUES ('d', 4)`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = system.RefreshMaterializedView("mv", colz, selectQuery, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ct := countRows(t, engine, `SELECT count(*) FROM "mv"`); ct != 3 {
+		t.Fatalf("expected 3 rows after refresh, got %d", ct)
+	}
+	if err = system.DropMaterializedView("mv"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok = system.GetMaterializedViewByName("mv"); ok {
+		t.Fatalf("expected the materialized view dropped")
+	}
+	if ct := countRows(t, engine, `SELECT count(*) FROM duckdb_tables() WHERE table_name = 'mv'`); ct != 0 {
+		t.Fatalf("expected the materialized view table dropped")
+	}
+}
+
+//nolint:funlen // sequential scenario
+func TestDuckDBGarbageCollection(t *testing.T) {
+	system, engine := newDuckDBSystem(t)
+	hIDs := internaldto.NewHeirarchyIdentifiers("google", "compute", "instances", "list")
+	var tableName string
+	for _, discoveryID := range []int{1, 2} {
+		table, err := system.GetTable(hIDs, discoveryID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		tableName = table.GetName()
+		relationalTable := relationaldto.NewRelationalTable(hIDs, discoveryID, tableName, table.GetNameStump())
+		relationalTable.PushBackColumn(typing.NewRelationalColumn("name", "TEXT"))
+		ddl, err := system.GenerateDDL(relationalTable, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err = engine.ExecInTxn(ddl); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if discoveryID < 2 {
+			continue
+		}
+		dml, err := system.GenerateInsertDML(relationalTable, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for i, name := range []string{"a", "b"} {
+			if _, err = engine.Exec(dml, 1, 1, i+1, 1, "enc", name); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+	}
+	current, err := system.GetCurrentTable(hIDs)
+	if err != nil || current.GetDiscoveryID() != 2 || current.GetName() != tableName {
+		t.Fatalf("expected current table '%s', got '%s': %v", tableName, current.GetName(), err)
+	}
+	if !system.IsTablePresent(tableName, "enc", "") {
+		t.Fatalf("expected rows of the request encoding")
+	}
+	oldest, tcc := system.TableOldestUpdateUTC(tableName, "enc", "iql_last_modified", "iql_insert_encoded")
+	if tcc == nil || tcc.GetGenID() != 1 || time.Since(oldest) < 0 || time.Since(oldest) > time.Minute {
+		t.Fatalf("expected a recent oldest update in UTC, got %v", oldest)
+	}
+	if err = system.GCAdd(tableName, nil, internaldto.NewTxnControlCountersFromVals(1, 1, 1, 1)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err = engine.Exec(
+		`UPDATE "` + tableName + `" SET iql_max_txn_id = CASE WHEN name = 'a' THEN 1 ELSE 10 END`,
+	); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = system.GCCollectObsoleted(5); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ct := countRows(t, engine, `SELECT count(*) FROM "`+tableName+`"`); ct != 1 {
+		t.Fatalf("expected the obsoleted row collected, %d rows remain", ct)
+	}
+	if err = system.GCCollectAll(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ct := countRows(t, engine, `SELECT count(*) FROM "`+tableName+`"`); ct != 0 {
+		t.Fatalf("expected all rows collected, %d rows remain", ct)
+	}
+	if err = system.PurgeAll(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ct := countRows(
+		t, engine, `SELECT count(*) FROM duckdb_tables() WHERE table_name LIKE 'google.%'`,
+	); ct != 0 {
+		t.Fatalf("expected the data tables dropped, %d remain", ct)
+	}
+	if ct := countRows(
+		t, engine, `SELECT count(*) FROM duckdb_sequences() WHERE sequence_name LIKE 'iql_google.%'`,
+	); ct != 0 {
+		t.Fatalf("expected the surrogate key sequences dropped, %d remain", ct)
+	}
+	if ct := countRows(t, engine, `SELECT count(*) FROM "__iql__.control.gc.rings"`); ct != 1 {
+		t.Fatalf("expected the control tables untouched")
+	}
+}
+
+func TestDuckDBJSONExtractYieldsText(t *testing.T) {
+	system, engine := newDuckDBSystem(t)
+	if _, err := engine.Exec(
+		`CREATE TABLE "docs" (doc TEXT) ; INSERT INTO "docs" VALUES ('{"n": 2, "s": "x", "b": true, "o": {"c": 1}}')`,
+	); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for path, expected := range map[string]string{
+		"$.n": "2",
+		"$.s": "x",
+		"$.b": "true",
+		"$.o": `{"c":1}`,
+	} {
+		stmt, err := sqlparser.Parse("select json_extract(doc, '" + path + "') from docs")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		aliased, _ := stmt.(*sqlparser.Select).SelectExprs[0].(*sqlparser.AliasedExpr)
+		funcExpr, _ := aliased.Expr.(*sqlparser.FuncExpr)
+		if _, err = system.GetASTFuncRewriter().RewriteFunc(funcExpr); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var val any
+		if err = engine.QueryRow(sqlparser.String(stmt)).Scan(&val); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if text, isText := val.(string); !isText || text != expected {
+			t.Fatalf("expected text %q at %s, got %T %v", expected, path, val, val)
+		}
+	}
+	// Numbers compare as text absent a cast.
+	if ct := countRows(
+		t, engine, `SELECT count(*) FROM (SELECT json_extract_string('{"a": 10}', '$.a') AS a) WHERE a > '9'`,
+	); ct != 0 {
+		t.Fatalf("expected a text comparison")
+	}
+	if ct := countRows(
+		t, engine, `SELECT count(*) FROM (SELECT json_extract_string('{"a": 10}', '$.a') AS a) WHERE CAST(a AS INTEGER) > 9`,
+	); ct != 1 {
+		t.Fatalf("expected a numeric comparison upon a cast")
+	}
+}
@@ -3,6 +3,7 @@ package sql_system //nolint:revive,stylecheck // package name is meaningful and
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"reflect"
 	"regexp"
@@ -18,6 +19,7 @@ import (
 	"github.com/stackql/any-sdk/public/sqlengine"
 	"github.com/stackql/stackql-parser/go/vt/sqlparser"
 	"github.com/stackql/stackql/internal/stackql/astfuncrewrite"
+	"github.com/stackql/stackql/internal/stackql/datasource/sql_datasource"
 	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
 	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/relationaldto"
 	"github.com/stackql/stackql/internal/stackql/typing"
@@ -32,6 +34,7 @@ func newPostgresSystem(
 	formatter sqlparser.NodeFormatter,
 	sqlCfg dto.SQLBackendCfg,
 	authCfg map[string]*dto.AuthCtx,
+	sqlDataSources map[string]sql_datasource.SQLDataSource,
 	typCfg typing.Config,
 	exportNamepsace string,
 ) (SQLSystem, error) {
@@ -54,6 +57,7 @@ func newPostgresSystem(
 		tableSchema:                  tableSchemaName,
 		tableCatalog:                 catalogName,
 		authCfg:                      authCfg,
+		sqlDataSources:               sqlDataSources,
 		exportNamespace:              exportNamepsace,
 	}
 	viewSchemataEnabled, err := rv.inferViewSchemataEnabled(sqlCfg.Schemata)
@@ -95,6 +99,7 @@ type postgresSystem struct {
 	intelViewSchema              string
 	tableCatalog                 string
 	authCfg                      map[string]*dto.AuthCtx
+	sqlDataSources               map[string]sql_datasource.SQLDataSource
 	exportNamespace              string
 }
 
@@ -277,10 +282,7 @@ func (eng *postgresSystem) obtainRelationalColumnsFromExternalSQLtable(
 		rv = append(rv, relationalColumn)
 	}
 	if !hasRow {
-		return nil, fmt.Errorf(
-			"cannot generate relational table from external table = '%s': not present in external metadata",
-			tableName,
-		)
+		return obtainRelationalColumnsFromSQLDataSource(eng.sqlDataSources, hierarchyIDs)
 	}
 	return rv, nil
 }
@@ -344,6 +346,9 @@ func (eng *postgresSystem) obtainRelationalColumnFromExternalSQLtable(
 	var columnName, columnType string
 	var oID, colWidth, colPrecision int
 	err := row.Scan(&columnName, &columnType, &oID, &colWidth, &colPrecision)
+	if errors.Is(err, sql.ErrNoRows) {
+		return obtainRelationalColumnFromSQLDataSource(eng.sqlDataSources, hierarchyIDs, colName)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -531,6 +536,10 @@ func (eng *postgresSystem) GetViewByName(viewName string) (internaldto.RelationD
 	return rv, ok
 }
 
+func (eng *postgresSystem) ListRelations() ([]internaldto.RelationDTO, error) {
+	return listStoredRelations(eng.sqlEngine, eng.exportNamespace)
+}
+
 func (eng *postgresSystem) GetViewByNameAndParameters(
 	viewName string, params map[string]any) (internaldto.RelationDTO, bool) {
 	rv, err := eng.selectMatchingView(viewName, params)
@@ -14,6 +14,7 @@ import (
 	"github.com/stackql/any-sdk/public/sqlengine"
 	"github.com/stackql/stackql-parser/go/vt/sqlparser"
 	"github.com/stackql/stackql/internal/stackql/astfuncrewrite"
+	"github.com/stackql/stackql/internal/stackql/datasource/sql_datasource"
 	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
 	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/relationaldto"
 	"github.com/stackql/stackql/internal/stackql/typing"
@@ -72,6 +73,9 @@ type SQLSystem interface {
 	DropView(viewName string) error
 	GetViewByName(viewName string) (internaldto.RelationDTO, bool)
 	GetViewByNameAndParameters(viewName string, params map[string]any) (internaldto.RelationDTO, bool)
+	// ListRelations returns the live views, materialized views and user
+	// space tables, by unqualified name and without column metadata.
+	ListRelations() ([]internaldto.RelationDTO, error)
 
 	// Materialized Views
 	CreateMaterializedView(
@@ -131,6 +135,9 @@ func getNodeFormatter(name string) sqlparser.NodeFormatter {
 	if name == constants.SQLDialectSQLite3 {
 		return astformat.SQLiteSelectExprsFormatter
 	}
+	if name == typing.SQLDialectDuckDB {
+		return astformat.DuckDBSelectExprsFormatter
+	}
 	return astformat.DefaultSelectExprsFormatter
 }
 
@@ -140,6 +147,7 @@ func NewSQLSystem(
 	controlAttributes sqlcontrol.ControlAttributes,
 	sqlCfg dto.SQLBackendCfg,
 	authCfg map[string]*dto.AuthCtx,
+	sqlDataSources map[string]sql_datasource.SQLDataSource,
 	typCfg typing.Config,
 	exportNamepsace string,
 ) (SQLSystem, error) {
@@ -155,6 +163,19 @@ func NewSQLSystem(
 			formatter,
 			sqlCfg,
 			authCfg,
+			sqlDataSources,
+			typCfg,
+			exportNamepsace,
+		)
+	case typing.SQLDialectDuckDB:
+		return newDuckDBSystem(
+			sqlEngine,
+			analyticsNamespaceLikeString,
+			controlAttributes,
+			formatter,
+			sqlCfg,
+			authCfg,
+			sqlDataSources,
 			typCfg,
 			exportNamepsace,
 		)
@@ -166,6 +187,7 @@ func NewSQLSystem(
 			formatter,
 			sqlCfg,
 			authCfg,
+			sqlDataSources,
 			typCfg,
 			exportNamepsace,
 		)
@@ -173,3 +195,115 @@ func NewSQLSystem(
 		return nil, fmt.Errorf("cannot initialise sql system: cannot accomodate sql dialect '%s'", name)
 	}
 }
+
+// obtainRelationalColumnsFromSQLDataSource types the columns of an
+// external table absent from external metadata by describing it
+// through the data source of the same name as its provider.
+func obtainRelationalColumnsFromSQLDataSource(
+	sqlDataSources map[string]sql_datasource.SQLDataSource,
+	hierarchyIDs internaldto.HeirarchyIdentifiers,
+) ([]typing.RelationalColumn, error) {
+	tableName := hierarchyIDs.GetResourceStr()
+	dataSource, hasDataSource := sqlDataSources[hierarchyIDs.GetProviderStr()]
+	if !hasDataSource {
+		return nil, fmt.Errorf(
+			"cannot generate relational table from external table = '%s': not present in external metadata",
+			tableName,
+		)
+	}
+	tableMetadata, err := dataSource.GetTableMetadata(hierarchyIDs.GetServiceStr(), tableName)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"cannot generate relational table from external table = '%s': %w",
+			tableName,
+			err,
+		)
+	}
+	return tableMetadata.GetColumns(), nil
+}
+
+func obtainRelationalColumnFromSQLDataSource(
+	sqlDataSources map[string]sql_datasource.SQLDataSource,
+	hierarchyIDs internaldto.HeirarchyIdentifiers,
+	colName string,
+) (typing.RelationalColumn, error) {
+	columns, err := obtainRelationalColumnsFromSQLDataSource(sqlDataSources, hierarchyIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, col := range columns {
+		if col.GetName() == colName {
+			return col, nil
+		}
+	}
+	return nil, fmt.Errorf(
+		"column '%s' not present in external table = '%s'",
+		colName,
+		hierarchyIDs.GetResourceStr(),
+	)
+}
+
+// listStoredRelations reads the live relations from the control tables,
+// whose DDL is common to every SQL system.  Materialized views and tables
+// are stored under the export namespace, which is stripped.
+func listStoredRelations(
+	sqlEngine sqlengine.SQLEngine,
+	exportNamespace string,
+) ([]internaldto.RelationDTO, error) {
+	type relationQuery struct {
+		query       string
+		constructor func(name, ddl string) internaldto.RelationDTO
+	}
+	queries := []relationQuery{
+		{
+			query:       `SELECT view_name, view_ddl FROM "__iql__.views" WHERE deleted_dttm IS NULL ORDER BY view_name`,
+			constructor: internaldto.NewViewDTO,
+		},
+		{
+			query: `SELECT view_name, view_ddl FROM "__iql__.materialized_views" WHERE deleted_dttm IS NULL ORDER BY view_name`, //nolint:lll // single query
+			constructor: func(name, ddl string) internaldto.RelationDTO {
+				return internaldto.NewMaterializedViewDTO(name, ddl, exportNamespace)
+			},
+		},
+		{
+			query: `SELECT table_name, table_ddl FROM "__iql__.tables" WHERE deleted_dttm IS NULL ORDER BY table_name`,
+			constructor: func(name, ddl string) internaldto.RelationDTO {
+				return internaldto.NewPhysicalTableDTO(name, ddl, exportNamespace)
+			},
+		},
+	}
+	var rv []internaldto.RelationDTO
+	for _, q := range queries {
+		relations, err := scanStoredRelations(sqlEngine, q.query, exportNamespace, q.constructor)
+		if err != nil {
+			return nil, err
+		}
+		rv = append(rv, relations...)
+	}
+	return rv, nil
+}
+
+func scanStoredRelations(
+	sqlEngine sqlengine.SQLEngine,
+	query string,
+	exportNamespace string,
+	constructor func(name, ddl string) internaldto.RelationDTO,
+) ([]internaldto.RelationDTO, error) {
+	rows, err := sqlEngine.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var rv []internaldto.RelationDTO
+	for rows.Next() {
+		var name, ddl string
+		if scanErr := rows.Scan(&name, &ddl); scanErr != nil {
+			return nil, scanErr
+		}
+		if exportNamespace != "" {
+			name = strings.TrimPrefix(name, exportNamespace+".")
+		}
+		rv = append(rv, constructor(name, ddl))
+	}
+	return rv, rows.Err()
+}
@@ -1,6 +1,9 @@
 package sqlmachinery
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+)
 
 type Querier interface {
 	Query(string, ...interface{}) (*sql.Rows, error)
@@ -9,3 +12,30 @@ type Querier interface {
 type ExecQuerier interface {
 	Exec(string, ...interface{}) (sql.Result, error)
 }
+
+// ContextQuerier is implemented by queriers which can abandon a query
+// when its context is done.
+type ContextQuerier interface {
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+}
+
+// NewContextQuerier binds querier to ctx.  Where querier cannot abandon a
+// query in flight, the query is refused once ctx is done.
+func NewContextQuerier(ctx context.Context, querier Querier) Querier {
+	return &contextQuerier{ctx: ctx, querier: querier}
+}
+
+type contextQuerier struct {
+	ctx     context.Context //nolint:containedctx // bound for the life of one query
+	querier Querier
+}
+
+func (cq *contextQuerier) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	if err := context.Cause(cq.ctx); err != nil {
+		return nil, err
+	}
+	if ctxQuerier, ok := cq.querier.(ContextQuerier); ok {
+		return ctxQuerier.QueryContext(cq.ctx, query, args...)
+	}
+	return cq.querier.Query(query, args...)
+}
@@ -15,6 +15,12 @@ var (
 	_ Config = &genericTypingConfig{}
 )
 
+const (
+	// SQLDialectDuckDB is not (yet) a member of the
+	// upstream dialect constants, hence its home here.
+	SQLDialectDuckDB = "duckdb"
+)
+
 func getPostgresTypeMappings() map[string]ORMCoupling {
 	return map[string]ORMCoupling{
 		"array":   NewORMCoupling("text", reflect.Slice),
@@ -39,12 +45,27 @@ func getSQLiteTypeMappings() map[string]ORMCoupling {
 	}
 }
 
+func getDuckDBTypeMappings() map[string]ORMCoupling {
+	return map[string]ORMCoupling{
+		"array":   NewORMCoupling("text", reflect.Slice),
+		"boolean": NewORMCoupling("boolean", reflect.Bool),
+		"int":     NewORMCoupling("bigint", reflect.Int64),
+		"integer": NewORMCoupling("bigint", reflect.Int64),
+		"object":  NewORMCoupling("text", reflect.Map),
+		"string":  NewORMCoupling("text", reflect.String),
+		"number":  NewORMCoupling("double", reflect.Float64),
+		"numeric": NewORMCoupling("double", reflect.Float64),
+	}
+}
+
 func getTypeMappings(sqlDialect string) (map[string]ORMCoupling, error) {
 	switch sqlDialect {
 	case constants.SQLDialectPostgres:
 		return getPostgresTypeMappings(), nil
 	case constants.SQLDialectSQLite3:
 		return getSQLiteTypeMappings(), nil
+	case SQLDialectDuckDB:
+		return getDuckDBTypeMappings(), nil
 	default:
 		return nil, fmt.Errorf("cannot support type mappings for sqlDialect = '%s'", sqlDialect)
 	}
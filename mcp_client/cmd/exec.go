@@ -30,6 +30,8 @@ var (
 
 const (
 	listToolsAction     = "list_tools"
+	listPromptsAction   = "list_prompts"
+	listResourcesAction = "list_resources"
 	listProvidersAction = "list_providers"
 )
 
@@ -56,12 +58,22 @@ var execCmd = &cobra.Command{
 		if setupErr != nil {
 			panic(fmt.Sprintf("error setting up mcp client: %v", setupErr))
 		}
+		defer client.Close() //nolint:errcheck // best effort
 		var outputString string
 		switch actionName {
-		case listToolsAction:
-			rv, rvErr := client.InspectTools()
+		case listToolsAction, listPromptsAction, listResourcesAction:
+			var rv []map[string]any
+			var rvErr error
+			switch actionName {
+			case listPromptsAction:
+				rv, rvErr = client.ListPrompts()
+			case listResourcesAction:
+				rv, rvErr = client.ListResources()
+			default:
+				rv, rvErr = client.InspectTools()
+			}
 			if rvErr != nil {
-				panic(fmt.Sprintf("error inspecting tools: %v", rvErr))
+				panic(fmt.Sprintf("error running %s: %v", actionName, rvErr))
 			}
 			output, outPutErr := json.MarshalIndent(rv, "", "  ")
 			if outPutErr != nil {
@@ -81,6 +81,7 @@ func init() {
 
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
 	rootCmd.AddCommand(execCmd)
+	rootCmd.AddCommand(shellCmd)
 	execCmd.PersistentFlags().StringVar(&actionName, "exec.action", "list_tools", "MCP server action name")
 	execCmd.PersistentFlags().StringVar(&actionArgs, "exec.args", "{}", "MCP server action arguments as JSON string")
 }
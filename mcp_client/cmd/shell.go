@@ -0,0 +1,112 @@
+/*
+Copyright © 2025 stackql info@stackql.io
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/chzyer/readline"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/stackql/stackql/pkg/mcp_server"
+	"github.com/stackql/stackql/pkg/mcp_server/clientshell"
+)
+
+const shellPrompt = "mcp>> "
+
+// shellCmd represents the shell command.
+//
+//nolint:gochecknoglobals // cobra pattern
+var shellCmd = &cobra.Command{
+	Use:   "shell",
+	Short: "Interactive mcp client shell",
+	Long: `Interactive shell against an MCP server.  Tools are called by name
+with key=value arguments, which are checked against the tool's input schema
+and tab completed.  Approval prompts from the server are answered inline.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		clientCfgMap := make(map[string]any)
+		jsonErr := json.Unmarshal([]byte(clientCfgJSON), &clientCfgMap)
+		if jsonErr != nil {
+			panic(fmt.Sprintf("error unmarshaling client cfg json: %v", jsonErr))
+		}
+		logger := logrus.New()
+		logger.SetLevel(logrus.WarnLevel)
+
+		// The client calls the prompter while a tool call is outstanding,
+		// when the shell is not itself reading.
+		var l *readline.Instance
+		readLine := func(prompt string) (string, error) {
+			l.SetPrompt(prompt)
+			defer l.SetPrompt(shellPrompt)
+			return l.Readline()
+		}
+		client, setupErr := mcp_server.NewMCPClientWithElicitation(
+			clientType,
+			url,
+			clientCfgMap,
+			logger,
+			clientshell.NewElicitationPrompter(readLine, os.Stderr),
+		)
+		if setupErr != nil {
+			panic(fmt.Sprintf("error setting up mcp client: %v", setupErr))
+		}
+		defer client.Close() //nolint:errcheck // best effort
+
+		session, sessionErr := clientshell.NewSession(client, os.Stdout)
+		if sessionErr != nil {
+			panic(fmt.Sprintf("error listing tools: %v", sessionErr))
+		}
+		var err error
+		l, err = readline.NewEx(&readline.Config{
+			Prompt:            shellPrompt,
+			InterruptPrompt:   "^C",
+			EOFPrompt:         "exit",
+			AutoComplete:      clientshell.NewCompleter(session.Catalogue(), clientshell.Commands),
+			HistorySearchFold: true,
+		})
+		if err != nil {
+			panic(err)
+		}
+		defer l.Close()
+
+		fmt.Fprintf(os.Stderr, "%d tools available; type help for usage\n", len(session.Catalogue().Names()))
+		for {
+			var rawLine string
+			rawLine, err = l.Readline()
+			if errors.Is(err, readline.ErrInterrupt) {
+				if len(rawLine) == 0 {
+					break
+				}
+				continue
+			} else if errors.Is(err, io.EOF) {
+				break
+			}
+			exit, execErr := session.Execute(strings.TrimSpace(rawLine))
+			if execErr != nil {
+				fmt.Fprintln(os.Stderr, execErr.Error())
+			}
+			if exit {
+				break
+			}
+		}
+	},
+}
@@ -0,0 +1,48 @@
+package astformat
+
+import (
+	"strings"
+
+	"github.com/stackql/any-sdk/pkg/constants"
+	"github.com/stackql/stackql-parser/go/vt/sqlparser"
+)
+
+//nolint:revive // Explicit type declaration removes any ambiguity
+var (
+	_ sqlparser.NodeFormatter = DuckDBSelectExprsFormatter
+)
+
+// DuckDBSelectExprsFormatter follows SQLite in the treatment
+// of identifiers; DuckDB's lone departure of consequence
+// is that GROUP_CONCAT accepts no SEPARATOR clause.
+func DuckDBSelectExprsFormatter(buf *sqlparser.TrackedBuffer, node sqlparser.SQLNode) {
+	switch node := node.(type) {
+	case sqlparser.ColIdent:
+		formatColIdentCaseInsensitive(node, buf)
+		return
+	case *sqlparser.GroupConcatExpr:
+		sb := sqlparser.NewTrackedBuffer(DuckDBSelectExprsFormatter)
+		sb.AstPrintf(
+			node,
+			"%s(%v, %v)",
+			constants.SQLFuncGroupConcatPostgres,
+			node.Exprs[0],
+			sqlparser.NewStrVal([]byte(groupConcatSeparator(node))),
+		)
+		buf.WriteString(sb.String())
+		return
+
+	default:
+		node.Format(buf)
+		return
+	}
+}
+
+// groupConcatSeparator recovers the separator literal, which
+// the parser retains in rendered form, eg ` separator ';'`.
+func groupConcatSeparator(node *sqlparser.GroupConcatExpr) string {
+	if node.Separator == "" {
+		return ","
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(node.Separator, " separator '"), "'")
+}
@@ -95,3 +95,35 @@ func TestString(t *testing.T) {
 		})
 	}
 }
+
+func TestDuckDBSelectExprsFormatter(t *testing.T) {
+	p, err := parser.NewParser()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{
+			"DuckDBSelectExprsFormatter: group_concat default separator",
+			"select group_concat(name) from a",
+			"select string_agg(name, ',') from \"a\"",
+		},
+		{
+			"DuckDBSelectExprsFormatter: group_concat explicit separator",
+			"select group_concat(name separator ';') from a",
+			"select string_agg(name, ';') from \"a\"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, parseErr := p.ParseQuery(tt.query)
+			if parseErr != nil {
+				t.Fatalf("unexpected error: %v", parseErr)
+			}
+			assert.Equal(t, tt.expected, String(node, DuckDBSelectExprsFormatter))
+		})
+	}
+}
@@ -45,4 +45,16 @@ type Event struct {
 	Args       map[string]any `json:"args,omitempty"`
 	DurationMs int64          `json:"duration_ms"`
 	Error      string         `json:"error,omitempty"`
+	// PolicyRule names the policy rule which produced Decision, or
+	// "default" for the rule set default; empty where the server mode
+	// alone decided.
+	PolicyRule   string `json:"policy_rule,omitempty"`
+	PolicyReason string `json:"policy_reason,omitempty"`
+	// RewrittenSQL is the statement actually run, where the policy
+	// rewrote SQL, eg to cap its LIMIT.
+	RewrittenSQL string `json:"rewritten_sql,omitempty"`
+	// Principal is the authenticated caller's subject, and AuthMethod
+	// how it was established; both are empty for anonymous callers.
+	Principal  string `json:"principal,omitempty"`
+	AuthMethod string `json:"auth_method,omitempty"`
 }
@@ -0,0 +1,324 @@
+// Package auth authenticates callers of the MCP HTTP transport and carries
+// the resulting principal to the tool gate.  Three methods are supported:
+// static bearer tokens, mTLS client certificates and JWTs validated against
+// a JWKS file.  Any subset may be configured; where none is, the transport
+// is unauthenticated, as before.
+//
+// The SDK's streamable HTTP handler forwards only the request headers (and
+// bearer token info) to tool handlers, so the principal travels in the
+// PrincipalHeader header.  The middleware strips any inbound copy before
+// setting its own, so that a client cannot forge it.
+package auth
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Authentication methods, as recorded in Principal.Method.
+const (
+	MethodBearer = "bearer"
+	MethodMTLS   = "mtls"
+	MethodJWT    = "jwt"
+)
+
+// PrincipalHeader carries the JSON encoded Principal from the
+// middleware to the gate.  It is never accepted from a client.
+const PrincipalHeader = "X-Stackql-Mcp-Principal"
+
+// ErrUnauthenticated is returned where a request presents no
+// acceptable credential.
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// Config configures HTTP transport authentication.
+//
+//	auth:
+//	  bearer:
+//	    tokens:
+//	      - subject: ci-bot
+//	        token_env: STACKQL_MCP_CI_TOKEN
+//	        mode: read_only
+//	  mtls:
+//	    client_ca_file: /etc/stackql/client-ca.pem
+//	  jwt:
+//	    jwks_file: /etc/stackql/jwks.json
+//	    issuer: https://idp.example.com/
+//	    audience: stackql-mcp
+//	    mode_claim: stackql_mode
+//	  modes:
+//	    alice@example.com: full_access
+type Config struct {
+	Bearer BearerConfig `json:"bearer,omitempty" yaml:"bearer,omitempty"`
+	MTLS   MTLSConfig   `json:"mtls,omitempty" yaml:"mtls,omitempty"`
+	JWT    JWTConfig    `json:"jwt,omitempty" yaml:"jwt,omitempty"`
+	// Modes maps principal subjects to server modes, overriding the
+	// server mode for those callers.  A mode attached to the credential
+	// itself (a token's mode, a JWT's mode claim) takes precedence.
+	Modes map[string]string `json:"modes,omitempty" yaml:"modes,omitempty"`
+}
+
+// BearerConfig lists the static bearer tokens accepted.
+type BearerConfig struct {
+	Tokens []BearerToken `json:"tokens,omitempty" yaml:"tokens,omitempty"`
+}
+
+// BearerToken is one static token.  Exactly one of Token and TokenEnv is
+// set; TokenEnv, naming an environment variable, keeps the secret out of
+// the config file.
+type BearerToken struct {
+	Subject  string `json:"subject" yaml:"subject"`
+	Token    string `json:"token,omitempty" yaml:"token,omitempty"`
+	TokenEnv string `json:"token_env,omitempty" yaml:"token_env,omitempty"`
+	// Mode, where set, is the server mode for this token's holder.
+	Mode string `json:"mode,omitempty" yaml:"mode,omitempty"`
+}
+
+// MTLSConfig enables client certificate authentication.  The principal's
+// subject is the certificate's common name, or its first DNS or email SAN
+// where the common name is empty.
+type MTLSConfig struct {
+	// ClientCAFile is a PEM bundle of the CAs trusted to issue client
+	// certificates.  Empty disables mTLS.
+	ClientCAFile string `json:"client_ca_file,omitempty" yaml:"client_ca_file,omitempty"`
+}
+
+// JWTConfig enables validation of bearer JWTs against a JWKS file.
+// Tokens must be signed by a key in the set and carry an expiry.
+type JWTConfig struct {
+	// JWKSFile is the path of a JSON Web Key Set.  Empty disables JWT.
+	JWKSFile string `json:"jwks_file,omitempty" yaml:"jwks_file,omitempty"`
+	// Issuer, where set, must match the iss claim.
+	Issuer string `json:"issuer,omitempty" yaml:"issuer,omitempty"`
+	// Audience, where set, must be among the aud claim.
+	Audience string `json:"audience,omitempty" yaml:"audience,omitempty"`
+	// SubjectClaim names the claim taken as the subject; default "sub".
+	SubjectClaim string `json:"subject_claim,omitempty" yaml:"subject_claim,omitempty"`
+	// ModeClaim, where set, names a string claim holding the server mode.
+	ModeClaim string `json:"mode_claim,omitempty" yaml:"mode_claim,omitempty"`
+}
+
+// IsEnabled reports whether any authentication method is configured.
+func (c Config) IsEnabled() bool {
+	return len(c.Bearer.Tokens) > 0 || c.MTLS.ClientCAFile != "" || c.JWT.JWKSFile != ""
+}
+
+// Principal is an authenticated caller.
+type Principal struct {
+	Subject string `json:"subject"`
+	Method  string `json:"method"`
+	// Mode is the server mode for this caller; empty means the server's.
+	Mode string `json:"mode,omitempty"`
+}
+
+// Authenticator establishes the principal behind an HTTP request.
+type Authenticator interface {
+	// Authenticate returns the principal, or an error wrapping
+	// ErrUnauthenticated where no credential is acceptable.
+	Authenticate(r *http.Request) (Principal, error)
+	// TLSConfig returns the server TLS settings mTLS requires,
+	// or nil where mTLS is not configured.
+	TLSConfig() *tls.Config
+}
+
+type bearerEntry struct {
+	token []byte
+	BearerToken
+}
+
+type authenticator struct {
+	bearer    []bearerEntry
+	clientCAs *x509.CertPool
+	// mtlsOnly means that a client certificate is
+	// the only acceptable credential, so is required.
+	mtlsOnly bool
+	jwt      *jwtValidator
+	modes    map[string]string
+	// isLegalMode vets modes carried by JWTs.
+	isLegalMode func(string) bool
+}
+
+// NewAuthenticator validates cfg and loads the material it references.
+// isLegalMode vets configured modes without this package importing policy.
+func NewAuthenticator(cfg Config, isLegalMode func(string) bool) (Authenticator, error) {
+	rv := &authenticator{modes: cfg.Modes, isLegalMode: isLegalMode}
+	for subject, mode := range cfg.Modes {
+		if !isLegalMode(mode) {
+			return nil, fmt.Errorf("auth modes: invalid mode %q for %q", mode, subject)
+		}
+	}
+	for i, t := range cfg.Bearer.Tokens {
+		if t.Subject == "" {
+			return nil, fmt.Errorf("auth bearer token %d has no subject", i)
+		}
+		if t.Mode != "" && !isLegalMode(t.Mode) {
+			return nil, fmt.Errorf("auth bearer token %q: invalid mode %q", t.Subject, t.Mode)
+		}
+		token := t.Token
+		switch {
+		case token != "" && t.TokenEnv != "":
+			return nil, fmt.Errorf("auth bearer token %q: set only one of token and token_env", t.Subject)
+		case t.TokenEnv != "":
+			token = os.Getenv(t.TokenEnv)
+			if token == "" {
+				return nil, fmt.Errorf("auth bearer token %q: environment variable %s is empty", t.Subject, t.TokenEnv)
+			}
+		case token == "":
+			return nil, fmt.Errorf("auth bearer token %q: token or token_env is required", t.Subject)
+		}
+		rv.bearer = append(rv.bearer, bearerEntry{token: []byte(token), BearerToken: t})
+	}
+	if cfg.MTLS.ClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.MTLS.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("auth mtls client_ca_file: %w", err)
+		}
+		rv.clientCAs = x509.NewCertPool()
+		if !rv.clientCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("auth mtls client_ca_file %q: no certificates found", cfg.MTLS.ClientCAFile)
+		}
+		rv.mtlsOnly = len(rv.bearer) == 0 && cfg.JWT.JWKSFile == ""
+	}
+	if cfg.JWT.JWKSFile != "" {
+		v, err := newJWTValidator(cfg.JWT)
+		if err != nil {
+			return nil, err
+		}
+		rv.jwt = v
+	}
+	return rv, nil
+}
+
+func (a *authenticator) TLSConfig() *tls.Config {
+	if a.clientCAs == nil {
+		return nil
+	}
+	clientAuth := tls.VerifyClientCertIfGiven
+	if a.mtlsOnly {
+		clientAuth = tls.RequireAndVerifyClientCert
+	}
+	return &tls.Config{
+		ClientCAs:  a.clientCAs,
+		ClientAuth: clientAuth,
+		MinVersion: tls.VersionTLS12,
+	}
+}
+
+// Authenticate tries a verified client certificate, then a bearer
+// token: first as a static token and then as a JWT.
+func (a *authenticator) Authenticate(r *http.Request) (Principal, error) {
+	if p, ok := a.authenticateCertificate(r); ok {
+		return a.withMode(p), nil
+	}
+	token, hasToken := bearerToken(r)
+	if !hasToken {
+		return Principal{}, fmt.Errorf("%w: no credential presented", ErrUnauthenticated)
+	}
+	for _, entry := range a.bearer {
+		if subtle.ConstantTimeCompare(entry.token, []byte(token)) == 1 {
+			return a.withMode(Principal{Subject: entry.Subject, Method: MethodBearer, Mode: entry.Mode}), nil
+		}
+	}
+	if a.jwt != nil && strings.Count(token, ".") == 2 { //nolint:mnd // compact JWS
+		p, err := a.jwt.validate(token)
+		if err != nil {
+			return Principal{}, fmt.Errorf("%w: %w", ErrUnauthenticated, err)
+		}
+		if p.Mode != "" && !a.isLegalMode(p.Mode) {
+			return Principal{}, fmt.Errorf("%w: token carries invalid mode %q", ErrUnauthenticated, p.Mode)
+		}
+		return a.withMode(p), nil
+	}
+	return Principal{}, fmt.Errorf("%w: bearer token not recognised", ErrUnauthenticated)
+}
+
+func (a *authenticator) authenticateCertificate(r *http.Request) (Principal, bool) {
+	if a.clientCAs == nil || r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return Principal{}, false
+	}
+	subject := certificateSubject(r.TLS.VerifiedChains[0][0])
+	if subject == "" {
+		return Principal{}, false
+	}
+	return Principal{Subject: subject, Method: MethodMTLS}, true
+}
+
+func certificateSubject(cert *x509.Certificate) string {
+	switch {
+	case cert.Subject.CommonName != "":
+		return cert.Subject.CommonName
+	case len(cert.DNSNames) > 0:
+		return cert.DNSNames[0]
+	case len(cert.EmailAddresses) > 0:
+		return cert.EmailAddresses[0]
+	default:
+		return ""
+	}
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	fields := strings.Fields(r.Header.Get("Authorization"))
+	if len(fields) != 2 || !strings.EqualFold(fields[0], "bearer") { //nolint:mnd // scheme and token
+		return "", false
+	}
+	return fields[1], true
+}
+
+// withMode applies the subject's configured mode
+// where the credential did not carry one.
+func (a *authenticator) withMode(p Principal) Principal {
+	if p.Mode == "" {
+		p.Mode = a.modes[p.Subject]
+	}
+	return p
+}
+
+// Middleware authenticates every request, rejecting with 401 those which
+// fail, and hands the principal on in PrincipalHeader.  A nil
+// authenticator admits every request anonymously; the header is stripped
+// regardless.
+func Middleware(a Authenticator, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Header.Del(PrincipalHeader)
+		if a == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		p, err := a.Authenticate(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="stackql-mcp"`)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		encoded, err := json.Marshal(p)
+		if err != nil {
+			http.Error(w, "failed to encode principal", http.StatusInternalServerError)
+			return
+		}
+		r.Header.Set(PrincipalHeader, string(encoded))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// PrincipalFromHeader decodes the principal set by Middleware.  The
+// boolean is false for an anonymous request.
+func PrincipalFromHeader(h http.Header) (Principal, bool) {
+	if h == nil {
+		return Principal{}, false
+	}
+	encoded := h.Get(PrincipalHeader)
+	if encoded == "" {
+		return Principal{}, false
+	}
+	var p Principal
+	if err := json.Unmarshal([]byte(encoded), &p); err != nil || p.Subject == "" {
+		return Principal{}, false
+	}
+	return p, true
+}
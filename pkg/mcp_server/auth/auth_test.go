@@ -0,0 +1,282 @@
+package auth_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/stackql/stackql/pkg/mcp_server/auth"
+)
+
+func isLegalMode(mode string) bool {
+	switch mode {
+	case "read_only", "safe", "delete_safe", "full_access":
+		return true
+	default:
+		return false
+	}
+}
+
+func bearerRequest(token string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	return r
+}
+
+func mustAuthenticator(t *testing.T, cfg auth.Config) auth.Authenticator {
+	t.Helper()
+	a, err := auth.NewAuthenticator(cfg, isLegalMode)
+	if err != nil {
+		t.Fatalf("NewAuthenticator: %v", err)
+	}
+	return a
+}
+
+func TestBearerTokens(t *testing.T) {
+	t.Setenv("TEST_MCP_TOKEN", "env-secret")
+	a := mustAuthenticator(t, auth.Config{
+		Bearer: auth.BearerConfig{Tokens: []auth.BearerToken{
+			{Subject: "ci-bot", Token: "literal-secret", Mode: "read_only"},
+			{Subject: "alice", TokenEnv: "TEST_MCP_TOKEN"},
+		}},
+		Modes: map[string]string{"alice": "full_access"},
+	})
+	p, err := a.Authenticate(bearerRequest("literal-secret"))
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if p != (auth.Principal{Subject: "ci-bot", Method: auth.MethodBearer, Mode: "read_only"}) {
+		t.Errorf("unexpected principal %+v", p)
+	}
+	p, err = a.Authenticate(bearerRequest("env-secret"))
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if p.Subject != "alice" || p.Mode != "full_access" {
+		t.Errorf("expected alice with her configured mode, got %+v", p)
+	}
+	for _, token := range []string{"", "wrong"} {
+		if _, err := a.Authenticate(bearerRequest(token)); !errors.Is(err, auth.ErrUnauthenticated) {
+			t.Errorf("token %q: expected ErrUnauthenticated, got %v", token, err)
+		}
+	}
+}
+
+func TestNewAuthenticatorRejectsIllegalConfig(t *testing.T) {
+	for _, cfg := range []auth.Config{
+		{Bearer: auth.BearerConfig{Tokens: []auth.BearerToken{{Token: "x"}}}},
+		{Bearer: auth.BearerConfig{Tokens: []auth.BearerToken{{Subject: "s"}}}},
+		{Bearer: auth.BearerConfig{Tokens: []auth.BearerToken{{Subject: "s", Token: "x", TokenEnv: "Y"}}}},
+		{Bearer: auth.BearerConfig{Tokens: []auth.BearerToken{{Subject: "s", TokenEnv: "TEST_MCP_UNSET_TOKEN"}}}},
+		{Bearer: auth.BearerConfig{Tokens: []auth.BearerToken{{Subject: "s", Token: "x", Mode: "root"}}}},
+		{Modes: map[string]string{"s": "root"}},
+		{MTLS: auth.MTLSConfig{ClientCAFile: filepath.Join(t.TempDir(), "missing.pem")}},
+		{JWT: auth.JWTConfig{JWKSFile: filepath.Join(t.TempDir(), "missing.json")}},
+	} {
+		if _, err := auth.NewAuthenticator(cfg, isLegalMode); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+}
+
+func writeJWKS(t *testing.T, kid string, key *rsa.PublicKey) string {
+	t.Helper()
+	enc := base64.RawURLEncoding
+	jwks := map[string]any{"keys": []map[string]any{{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"n":   enc.EncodeToString(key.N.Bytes()),
+		"e":   enc.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}}
+	data, err := json.Marshal(jwks)
+	if err != nil {
+		t.Fatalf("marshal jwks: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("write jwks: %v", err)
+	}
+	return path
+}
+
+func signJWT(t *testing.T, kid string, key *rsa.PrivateKey, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return signed
+}
+
+func TestJWT(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	a := mustAuthenticator(t, auth.Config{JWT: auth.JWTConfig{
+		JWKSFile:  writeJWKS(t, "k1", &key.PublicKey),
+		Issuer:    "https://idp.example.com/",
+		Audience:  "stackql-mcp",
+		ModeClaim: "stackql_mode",
+	}})
+	valid := jwt.MapClaims{
+		"iss":          "https://idp.example.com/",
+		"aud":          "stackql-mcp",
+		"sub":          "alice@example.com",
+		"exp":          time.Now().Add(time.Hour).Unix(),
+		"stackql_mode": "delete_safe",
+	}
+	p, err := a.Authenticate(bearerRequest(signJWT(t, "k1", key, valid)))
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if p != (auth.Principal{Subject: "alice@example.com", Method: auth.MethodJWT, Mode: "delete_safe"}) {
+		t.Errorf("unexpected principal %+v", p)
+	}
+
+	with := func(k string, v any) jwt.MapClaims {
+		rv := jwt.MapClaims{}
+		for ck, cv := range valid {
+			rv[ck] = cv
+		}
+		if v == nil {
+			delete(rv, k)
+		} else {
+			rv[k] = v
+		}
+		return rv
+	}
+	cases := map[string]string{
+		"expired":      signJWT(t, "k1", key, with("exp", time.Now().Add(-time.Hour).Unix())),
+		"no expiry":    signJWT(t, "k1", key, with("exp", nil)),
+		"wrong issuer": signJWT(t, "k1", key, with("iss", "https://evil.example.com/")),
+		"wrong aud":    signJWT(t, "k1", key, with("aud", "someone-else")),
+		"no subject":   signJWT(t, "k1", key, with("sub", nil)),
+		"bad mode":     signJWT(t, "k1", key, with("stackql_mode", "root")),
+		"unknown kid":  signJWT(t, "k2", key, valid),
+		"wrong key":    signJWT(t, "k1", otherKey, valid),
+	}
+	for name, token := range cases {
+		if _, err := a.Authenticate(bearerRequest(token)); !errors.Is(err, auth.ErrUnauthenticated) {
+			t.Errorf("%s: expected ErrUnauthenticated, got %v", name, err)
+		}
+	}
+}
+
+func writeCA(t *testing.T) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create ca: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("write ca: %v", err)
+	}
+	return path
+}
+
+func TestMTLS(t *testing.T) {
+	caPath := writeCA(t)
+	a := mustAuthenticator(t, auth.Config{
+		MTLS:  auth.MTLSConfig{ClientCAFile: caPath},
+		Modes: map[string]string{"build-agent": "read_only"},
+	})
+	if got := a.TLSConfig(); got == nil || got.ClientAuth != tls.RequireAndVerifyClientCert {
+		t.Errorf("mTLS alone should require client certificates, got %+v", got)
+	}
+	r := bearerRequest("")
+	r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{
+		{Subject: pkix.Name{CommonName: "build-agent"}},
+	}}}
+	p, err := a.Authenticate(r)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if p != (auth.Principal{Subject: "build-agent", Method: auth.MethodMTLS, Mode: "read_only"}) {
+		t.Errorf("unexpected principal %+v", p)
+	}
+	if _, err := a.Authenticate(bearerRequest("")); !errors.Is(err, auth.ErrUnauthenticated) {
+		t.Errorf("expected ErrUnauthenticated without a certificate, got %v", err)
+	}
+
+	mixed := mustAuthenticator(t, auth.Config{
+		MTLS:   auth.MTLSConfig{ClientCAFile: caPath},
+		Bearer: auth.BearerConfig{Tokens: []auth.BearerToken{{Subject: "s", Token: "x"}}},
+	})
+	if got := mixed.TLSConfig(); got == nil || got.ClientAuth != tls.VerifyClientCertIfGiven {
+		t.Errorf("mTLS alongside tokens should make certificates optional, got %+v", got)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	a := mustAuthenticator(t, auth.Config{
+		Bearer: auth.BearerConfig{Tokens: []auth.BearerToken{{Subject: "ci-bot", Token: "secret"}}},
+	})
+	var seen http.Header
+	next := http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) { seen = r.Header.Clone() })
+
+	forged := `{"subject":"admin","method":"bearer","mode":"full_access"}`
+	r := bearerRequest("secret")
+	r.Header.Set(auth.PrincipalHeader, forged)
+	w := httptest.NewRecorder()
+	auth.Middleware(a, next).ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+	p, ok := auth.PrincipalFromHeader(seen)
+	if !ok || p.Subject != "ci-bot" {
+		t.Errorf("expected the authenticated principal, got %+v", p)
+	}
+
+	seen = nil
+	w = httptest.NewRecorder()
+	auth.Middleware(a, next).ServeHTTP(w, bearerRequest("wrong"))
+	if w.Code != http.StatusUnauthorized || seen != nil {
+		t.Errorf("expected 401 without reaching the handler, got %d", w.Code)
+	}
+
+	r = bearerRequest("")
+	r.Header.Set(auth.PrincipalHeader, forged)
+	auth.Middleware(nil, next).ServeHTTP(httptest.NewRecorder(), r)
+	if _, ok := auth.PrincipalFromHeader(seen); ok {
+		t.Error("an unauthenticated transport must not pass a client-supplied principal")
+	}
+}
@@ -0,0 +1,210 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const defaultSubjectClaim = "sub"
+
+// jwtSigningMethods are the asymmetric algorithms accepted; symmetric
+// algorithms are excluded, a JWKS being public.
+//
+//nolint:gochecknoglobals // immutable lookup
+var jwtSigningMethods = []string{
+	"RS256", "RS384", "RS512", "PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512", "EdDSA",
+}
+
+// jsonWebKey is the subset of RFC 7517 needed for verification.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+type jwtValidator struct {
+	cfg  JWTConfig
+	keys map[string]crypto.PublicKey
+	// soleKey verifies tokens without a kid where
+	// the set holds exactly one signing key.
+	soleKey crypto.PublicKey
+}
+
+func newJWTValidator(cfg JWTConfig) (*jwtValidator, error) {
+	data, err := os.ReadFile(cfg.JWKSFile)
+	if err != nil {
+		return nil, fmt.Errorf("auth jwt jwks_file: %w", err)
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return nil, fmt.Errorf("auth jwt jwks_file %q: %w", cfg.JWKSFile, err)
+	}
+	if cfg.SubjectClaim == "" {
+		cfg.SubjectClaim = defaultSubjectClaim
+	}
+	rv := &jwtValidator{cfg: cfg, keys: keys}
+	if len(keys) == 1 {
+		for _, k := range keys {
+			rv.soleKey = k
+		}
+	}
+	return rv, nil
+}
+
+// parseJWKS returns the signature keys of a JWKS by kid.  Keys of
+// unsupported types, or marked for encryption, are skipped.
+func parseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set jsonWebKeySet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+	rv := make(map[string]crypto.PublicKey)
+	for i, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("key %d (kid %q): %w", i, k.Kid, err)
+		}
+		if key == nil {
+			continue
+		}
+		if _, isDuplicate := rv[k.Kid]; isDuplicate {
+			return nil, fmt.Errorf("duplicate kid %q", k.Kid)
+		}
+		rv[k.Kid] = key
+	}
+	if len(rv) == 0 {
+		return nil, fmt.Errorf("no usable signing keys")
+	}
+	return rv, nil
+}
+
+func decodeKeyParam(name, value string) ([]byte, error) {
+	if value == "" {
+		return nil, fmt.Errorf("missing %q", name)
+	}
+	rv, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("parameter %q: %w", name, err)
+	}
+	return rv, nil
+}
+
+// publicKey decodes the key, returning nil for an unsupported key type.
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeKeyParam("n", k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeKeyParam("e", k.E)
+		if err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("RSA exponent out of range")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported EC curve %q", k.Crv)
+		}
+		x, err := decodeKeyParam("x", k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeKeyParam("y", k.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(key.X, key.Y) { //nolint:staticcheck // no non-deprecated check for big.Int coordinates
+			return nil, fmt.Errorf("EC point is not on curve %s", k.Crv)
+		}
+		return key, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported OKP curve %q", k.Crv)
+		}
+		x, err := decodeKeyParam("x", k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("Ed25519 key has %d bytes", len(x))
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, nil //nolint:nilnil // unsupported types are skipped
+	}
+}
+
+func (v *jwtValidator) keyFor(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" && v.soleKey != nil {
+		return v.soleKey, nil
+	}
+	key, ok := v.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
+
+func (v *jwtValidator) validate(token string) (Principal, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(jwtSigningMethods),
+		jwt.WithExpirationRequired(),
+	}
+	if v.cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(v.cfg.Issuer))
+	}
+	if v.cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(v.cfg.Audience))
+	}
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(token, claims, v.keyFor, opts...); err != nil {
+		return Principal{}, err
+	}
+	subject, _ := claims[v.cfg.SubjectClaim].(string)
+	if subject == "" {
+		return Principal{}, fmt.Errorf("token has no %q claim", v.cfg.SubjectClaim)
+	}
+	rv := Principal{Subject: subject, Method: MethodJWT}
+	if v.cfg.ModeClaim != "" {
+		rv.Mode, _ = claims[v.cfg.ModeClaim].(string)
+	}
+	return rv, nil
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql/driver"
 
+	"github.com/stackql/stackql-parser/go/vt/sqlparser"
+
 	"github.com/stackql/stackql/pkg/mcp_server/dto"
 )
 
@@ -62,6 +64,41 @@ type Backend interface {
 	ReloadCredentials(ctx context.Context, input dto.CredentialsReloadInput) (dto.CredentialsReloadDTO, error)
 }
 
+// PolicyTableExtractor may be implemented by a Backend to supply the policy
+// engine with table extraction consistent with the backend's own planner.
+// Absent it, policy.ExtractTables is used.
+type PolicyTableExtractor interface {
+	ExtractPolicyTables(stmt sqlparser.Statement) (sqlparser.TableExprs, error)
+}
+
+// RelationLister may be implemented by a Backend to publish the views,
+// materialized views and user space tables of its SQL system as MCP
+// resources.  The list is polled, so it should be cheap.
+type RelationLister interface {
+	ListRelations(ctx context.Context) ([]dto.StoredRelationDTO, error)
+}
+
+// TypedQueryRunner may be implemented by a Backend to return SELECT
+// results with their column types.  run_select_query then carries typed
+// values, and a JSON Schema describing them, in structuredContent.  Absent
+// it, RunQueryJSON is used and rows are returned as the backend shaped them.
+type TypedQueryRunner interface {
+	RunQueryTyped(ctx context.Context, input dto.QueryJSONInput) (QueryResult, error)
+}
+
+// Column types reported by ColumnInfo.GetType.  Values of a column are
+// coerced to its type where they arrive in their text encoding.
+const (
+	ColumnTypeString    = "string"
+	ColumnTypeInt64     = "int64"
+	ColumnTypeFloat64   = "float64"
+	ColumnTypeBool      = "bool"
+	ColumnTypeTimestamp = "timestamp"
+	ColumnTypeDate      = "date"
+	ColumnTypeJSON      = "json"
+	ColumnTypeBytes     = "bytes"
+)
+
 // QueryResult represents the result of a query execution.
 type QueryResult interface {
 	// GetColumns returns metadata about each column in the result set.
@@ -82,7 +119,8 @@ type ColumnInfo interface {
 	// GetName returns the column name as returned by the query.
 	GetName() string
 
-	// GetType returns the data type of the column (e.g., "string", "int64", "float64").
+	// GetType returns the data type of the column, one of the ColumnType
+	// constants (e.g., "string", "int64", "float64").
 	GetType() string
 
 	// IsNullable indicates whether the column can contain null values.
@@ -27,14 +27,35 @@ const (
 type MCPClient interface {
 	InspectTools() ([]map[string]any, error)
 	CallToolText(toolName string, args map[string]any) (string, error)
+	ListPrompts() ([]map[string]any, error)
+	ListResources() ([]map[string]any, error)
+	// Close ends any session held open by the client.
+	Close() error
 }
 
+// ElicitationHandler answers the elicitation requests of a server, such as
+// the approval prompts of the policy gate.
+type ElicitationHandler func(context.Context, *mcp.ElicitRequest) (*mcp.ElicitResult, error)
+
 func NewMCPClient(clientType string, baseURL string, clientCfgMap map[string]any, logger *logrus.Logger) (MCPClient, error) {
+	return NewMCPClientWithElicitation(clientType, baseURL, clientCfgMap, logger, nil)
+}
+
+// NewMCPClientWithElicitation returns a client which advertises
+// elicitation and answers it with elicit, in place of any response
+// scripted in the client config.
+func NewMCPClientWithElicitation(
+	clientType string,
+	baseURL string,
+	clientCfgMap map[string]any,
+	logger *logrus.Logger,
+	elicit ElicitationHandler,
+) (MCPClient, error) {
 	switch clientType {
 	case MCPClientTypeHTTP:
-		return newHTTPMCPClient(baseURL, clientCfgMap, logger)
+		return newHTTPMCPClient(baseURL, clientCfgMap, logger, elicit)
 	case MCPClientTypeSTDIO:
-		return newStdioMCPClient(logger)
+		return newStdioMCPClient(clientCfgMap, logger, elicit)
 	default:
 		return nil, fmt.Errorf("unknown client type: %s", clientType)
 	}
@@ -248,7 +269,12 @@ func getHTTPClient(logger *logrus.Logger, clientCfgMap map[string]any) (*http.Cl
 	return http.DefaultClient, nil
 }
 
-func newHTTPMCPClient(baseURL string, clientCfgMap map[string]any, logger *logrus.Logger) (MCPClient, error) {
+func newHTTPMCPClient(
+	baseURL string,
+	clientCfgMap map[string]any,
+	logger *logrus.Logger,
+	elicit ElicitationHandler,
+) (MCPClient, error) {
 	if logger == nil {
 		logger = logrus.New()
 		logger.SetLevel(logrus.InfoLevel)
@@ -262,6 +288,7 @@ func newHTTPMCPClient(baseURL string, clientCfgMap map[string]any, logger *logru
 		httpClient: httpClient,
 		logger:     logger,
 		clientCfg:  clientCfgMap,
+		elicit:     elicit,
 	}, nil
 }
 
@@ -270,6 +297,7 @@ type httpMCPClient struct {
 	httpClient *http.Client
 	logger     *logrus.Logger
 	clientCfg  map[string]any
+	elicit     ElicitationHandler
 }
 
 func (c *httpMCPClient) connect() (*mcp.ClientSession, error) {
@@ -279,11 +307,16 @@ func (c *httpMCPClient) connect() (*mcp.ClientSession, error) {
 	// Create the URL for the server.
 	c.logger.Infof("Connecting to MCP server at %s", url)
 
+	clientOpts, optsErr := newClientOptions(c.clientCfg, c.logger, c.elicit)
+	if optsErr != nil {
+		return nil, optsErr
+	}
+
 	// Create an MCP client.
 	client := mcp.NewClient(&mcp.Implementation{
 		Name:    "stackql-client",
 		Version: "1.0.0",
-	}, nil)
+	}, clientOpts)
 
 	// Connect to the server.
 	return client.Connect(ctx, &mcp.StreamableClientTransport{Endpoint: url}, nil)
@@ -312,11 +345,7 @@ func (c *httpMCPClient) InspectTools() ([]map[string]any, error) {
 	var rv []map[string]any
 	for _, tool := range toolsResult.Tools {
 		c.logger.Infof("  - %s: %s\n", tool.Name, tool.Description)
-		toolInfo := map[string]any{
-			"name":        tool.Name,
-			"description": tool.Description,
-		}
-		rv = append(rv, toolInfo)
+		rv = append(rv, toolInfo(tool))
 	}
 
 	c.logger.Infof("Client completed successfully")
@@ -407,26 +436,122 @@ func extractText(toolCall *mcp.CallToolResult) string {
 	return sb.String()
 }
 
-type stdioMCPClient struct {
-	logger *logrus.Logger
+func (c *httpMCPClient) ListPrompts() ([]map[string]any, error) {
+	session, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+	return listPrompts(context.Background(), session)
 }
 
-func newStdioMCPClient(logger *logrus.Logger) (MCPClient, error) {
-	if logger == nil {
-		logger = logrus.New()
-		logger.SetLevel(logrus.InfoLevel)
+func (c *httpMCPClient) ListResources() ([]map[string]any, error) {
+	session, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+	return listResources(context.Background(), session)
+}
+
+// Close is a no-op: the HTTP client connects afresh for each call.
+func (c *httpMCPClient) Close() error {
+	return nil
+}
+
+// toolInfo presents a tool, with its input schema as a JSON object.
+func toolInfo(tool *mcp.Tool) map[string]any {
+	rv := map[string]any{
+		"name":        tool.Name,
+		"description": tool.Description,
 	}
-	return &stdioMCPClient{
-		logger: logger,
+	if raw, err := json.Marshal(tool.InputSchema); err == nil {
+		var inputSchema map[string]any
+		if json.Unmarshal(raw, &inputSchema) == nil && inputSchema != nil {
+			rv["input_schema"] = inputSchema
+		}
+	}
+	return rv
+}
+
+// newClientOptions returns the options of an MCP client from its config.
+// The client advertises elicitation only where elicit is given or the
+// config nominates a response, `"elicitation": {"action": "accept",
+// "content": {...}}`, which then answers every elicitation request from
+// the server.
+func newClientOptions(
+	clientCfgMap map[string]any,
+	logger *logrus.Logger,
+	elicit ElicitationHandler,
+) (*mcp.ClientOptions, error) {
+	if elicit != nil {
+		return &mcp.ClientOptions{ElicitationHandler: elicit}, nil
+	}
+	rawElicitation, hasElicitation := clientCfgMap["elicitation"]
+	if !hasElicitation {
+		return nil, nil //nolint:nilnil // no options is a valid configuration
+	}
+	elicitationCfg, isMap := rawElicitation.(map[string]any)
+	if !isMap {
+		return nil, fmt.Errorf("elicitation must be an object")
+	}
+	action, isString := elicitationCfg["action"].(string)
+	if !isString {
+		return nil, fmt.Errorf("elicitation.action must be a string")
+	}
+	switch action {
+	case "accept", "decline", "cancel":
+	default:
+		return nil, fmt.Errorf("elicitation.action must be one of accept, decline or cancel, not %q", action)
+	}
+	var content map[string]any
+	if rawContent, hasContent := elicitationCfg["content"]; hasContent {
+		content, isMap = rawContent.(map[string]any)
+		if !isMap {
+			return nil, fmt.Errorf("elicitation.content must be an object")
+		}
+	}
+	return &mcp.ClientOptions{
+		ElicitationHandler: func(_ context.Context, req *mcp.ElicitRequest) (*mcp.ElicitResult, error) {
+			logger.Infof("Answering elicitation %q with %s", req.Params.Message, action)
+			return &mcp.ElicitResult{Action: action, Content: content}, nil
+		},
 	}, nil
 }
 
-func (c *stdioMCPClient) InspectTools() ([]map[string]any, error) {
-	c.logger.Infof("stdio MCP client not implemented yet")
-	return nil, nil
+func listPrompts(ctx context.Context, session *mcp.ClientSession) ([]map[string]any, error) {
+	promptsResult, err := session.ListPrompts(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prompts: %w", err)
+	}
+	rv := make([]map[string]any, 0, len(promptsResult.Prompts))
+	for _, prompt := range promptsResult.Prompts {
+		var arguments []string
+		for _, arg := range prompt.Arguments {
+			arguments = append(arguments, arg.Name)
+		}
+		rv = append(rv, map[string]any{
+			"name":        prompt.Name,
+			"description": prompt.Description,
+			"arguments":   arguments,
+		})
+	}
+	return rv, nil
 }
 
-func (c *stdioMCPClient) CallToolText(toolName string, args map[string]any) (string, error) {
-	c.logger.Infof("stdio MCP client not implemented yet")
-	return "", nil
+func listResources(ctx context.Context, session *mcp.ClientSession) ([]map[string]any, error) {
+	resourcesResult, err := session.ListResources(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resources: %w", err)
+	}
+	rv := make([]map[string]any, 0, len(resourcesResult.Resources))
+	for _, resource := range resourcesResult.Resources {
+		rv = append(rv, map[string]any{
+			"uri":         resource.URI,
+			"name":        resource.Name,
+			"description": resource.Description,
+			"mime_type":   resource.MIMEType,
+		})
+	}
+	return rv, nil
 }
@@ -0,0 +1,228 @@
+package clientshell
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParseCall splits a shell line into its command, the first word, and the
+// remainder.
+func ParseCall(line string) (string, string) {
+	line = strings.TrimSpace(line)
+	idx := strings.IndexAny(line, " \t")
+	if idx < 0 {
+		return line, ""
+	}
+	return line[:idx], strings.TrimSpace(line[idx+1:])
+}
+
+// Args reads the arguments of a call of the tool, either a JSON object or
+// a list of key=value pairs, and validates them against the tool schema.
+// Values are quoted as in a shell and typed by the schema, so that
+//
+//	run_query sql='select 1' row_limit=10
+//
+// passes row_limit as an integer.
+func (t Tool) Args(text string) (map[string]any, error) {
+	text = strings.TrimSpace(text)
+	var rv map[string]any
+	if strings.HasPrefix(text, "{") {
+		decoder := json.NewDecoder(strings.NewReader(text))
+		decoder.UseNumber()
+		if err := decoder.Decode(&rv); err != nil {
+			return nil, fmt.Errorf("invalid JSON arguments: %w", err)
+		}
+		rv = normaliseNumbers(rv)
+	} else {
+		fields, err := splitFields(text)
+		if err != nil {
+			return nil, err
+		}
+		rv = make(map[string]any, len(fields))
+		for _, field := range fields {
+			k, v, hasValue := strings.Cut(field, "=")
+			if !hasValue || k == "" {
+				return nil, fmt.Errorf("expected key=value, got %q", field)
+			}
+			if _, isDuplicate := rv[k]; isDuplicate {
+				return nil, fmt.Errorf("argument %s given twice", k)
+			}
+			coerced, coerceErr := coerce(t.Properties[k].Type, v)
+			if coerceErr != nil {
+				return nil, fmt.Errorf("argument %s: %w", k, coerceErr)
+			}
+			rv[k] = coerced
+		}
+	}
+	if err := t.Validate(rv); err != nil {
+		return nil, err
+	}
+	return rv, nil
+}
+
+// Validate checks args against the top level of the tool schema.
+func (t Tool) Validate(args map[string]any) error {
+	var problems []string
+	for _, k := range t.Required {
+		if _, isPresent := args[k]; !isPresent {
+			problems = append(problems, "missing required argument "+k)
+		}
+	}
+	keys := make([]string, 0, len(args))
+	for k := range args {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		p, isDeclared := t.Properties[k]
+		if !isDeclared {
+			if t.Closed {
+				problems = append(problems, "unknown argument "+k)
+			}
+			continue
+		}
+		if !hasType(p.Type, args[k]) {
+			problems = append(problems, fmt.Sprintf("argument %s must be of type %s", k, p.Type))
+			continue
+		}
+		if len(p.Enum) > 0 && !inEnum(p.Enum, args[k]) {
+			problems = append(problems, fmt.Sprintf("argument %s must be one of %v", k, p.Enum))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid arguments for %s: %s", t.Name, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// coerce types the text of a value by its schema type.  Values of
+// undeclared type are read as JSON where they parse, else as strings.
+func coerce(typ string, v string) (any, error) {
+	switch typ {
+	case "string":
+		return v, nil
+	case "integer":
+		return strconv.ParseInt(v, 10, 64)
+	case "number":
+		return strconv.ParseFloat(v, 64)
+	case "boolean":
+		return strconv.ParseBool(v)
+	case "object", "array":
+		var rv any
+		if err := json.Unmarshal([]byte(v), &rv); err != nil {
+			return nil, fmt.Errorf("expected JSON %s: %w", typ, err)
+		}
+		return rv, nil
+	default:
+		var rv any
+		if json.Unmarshal([]byte(v), &rv) == nil {
+			return rv, nil
+		}
+		return v, nil
+	}
+}
+
+func normaliseNumbers(m map[string]any) map[string]any {
+	for k, v := range m {
+		n, isNumber := v.(json.Number)
+		if !isNumber {
+			continue
+		}
+		if i, err := n.Int64(); err == nil {
+			m[k] = i
+		} else if f, fErr := n.Float64(); fErr == nil {
+			m[k] = f
+		}
+	}
+	return m
+}
+
+func hasType(typ string, v any) bool {
+	switch typ {
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "integer":
+		switch v := v.(type) {
+		case int, int64:
+			return true
+		case float64:
+			return v == math.Trunc(v)
+		}
+		return false
+	case "number":
+		switch v.(type) {
+		case int, int64, float64:
+			return true
+		}
+		return false
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "object":
+		_, ok := v.(map[string]any)
+		return ok
+	case "array":
+		_, ok := v.([]any)
+		return ok
+	default:
+		return true
+	}
+}
+
+func inEnum(enum []any, v any) bool {
+	for _, e := range enum {
+		if fmt.Sprint(e) == fmt.Sprint(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitFields splits text into words on whitespace, honouring single and
+// double quotes and backslash escapes outside single quotes.
+func splitFields(text string) ([]string, error) {
+	var rv []string
+	var sb strings.Builder
+	var quote rune
+	inWord, escaped := false, false
+	for _, r := range text {
+		switch {
+		case escaped:
+			sb.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				sb.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				rv = append(rv, sb.String())
+				sb.Reset()
+				inWord = false
+			}
+		default:
+			sb.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote in %q", text)
+	}
+	if inWord {
+		rv = append(rv, sb.String())
+	}
+	return rv, nil
+}
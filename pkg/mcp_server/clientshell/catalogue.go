@@ -0,0 +1,187 @@
+// Package clientshell implements the interactive shell of the MCP client:
+// a catalogue of the server's tools built from their input schemas, line
+// parsing and validation of tool arguments against those schemas, tab
+// completion, and interactive answers to the server's elicitation prompts.
+package clientshell
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Property is one argument of a tool, as declared by its input schema.
+type Property struct {
+	Type        string
+	Description string
+	Enum        []any
+}
+
+// Tool is a tool of the server together with its argument schema.
+type Tool struct {
+	Name        string
+	Description string
+	Properties  map[string]Property
+	Required    []string
+	// Closed reports that the schema forbids arguments it does not declare.
+	Closed bool
+}
+
+// Keys returns the argument names of the tool, required arguments first.
+func (t Tool) Keys() []string {
+	rv := make([]string, 0, len(t.Properties))
+	for k := range t.Properties {
+		rv = append(rv, k)
+	}
+	sort.Slice(rv, func(i, j int) bool {
+		ri, rj := t.isRequired(rv[i]), t.isRequired(rv[j])
+		if ri != rj {
+			return ri
+		}
+		return rv[i] < rv[j]
+	})
+	return rv
+}
+
+func (t Tool) isRequired(key string) bool {
+	for _, k := range t.Required {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Usage describes the tool and its arguments.
+func (t Tool) Usage() string {
+	var sb strings.Builder
+	sb.WriteString(t.Name)
+	if t.Description != "" {
+		sb.WriteString(": " + t.Description)
+	}
+	sb.WriteString("\n")
+	for _, k := range t.Keys() {
+		p := t.Properties[k]
+		qualifiers := []string{}
+		if p.Type != "" {
+			qualifiers = append(qualifiers, p.Type)
+		}
+		if t.isRequired(k) {
+			qualifiers = append(qualifiers, "required")
+		}
+		sb.WriteString("  " + k)
+		if len(qualifiers) > 0 {
+			sb.WriteString(" (" + strings.Join(qualifiers, ", ") + ")")
+		}
+		if len(p.Enum) > 0 {
+			sb.WriteString(" one of " + fmt.Sprint(p.Enum))
+		}
+		if p.Description != "" {
+			sb.WriteString(": " + p.Description)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// Catalogue is the set of tools published by a server.
+type Catalogue interface {
+	Names() []string
+	Tool(name string) (Tool, bool)
+}
+
+type catalogue struct {
+	tools map[string]Tool
+}
+
+// NewCatalogue builds a catalogue from the tool listing of an MCP client,
+// whose entries carry name, description and input_schema.
+func NewCatalogue(tools []map[string]any) Catalogue {
+	rv := &catalogue{tools: make(map[string]Tool, len(tools))}
+	for _, info := range tools {
+		name, _ := info["name"].(string)
+		if name == "" {
+			continue
+		}
+		description, _ := info["description"].(string)
+		tool := newToolFromSchema(schemaMap(info["input_schema"]))
+		tool.Name = name
+		tool.Description = description
+		rv.tools[name] = tool
+	}
+	return rv
+}
+
+func (c *catalogue) Names() []string {
+	rv := make([]string, 0, len(c.tools))
+	for k := range c.tools {
+		rv = append(rv, k)
+	}
+	sort.Strings(rv)
+	return rv
+}
+
+func (c *catalogue) Tool(name string) (Tool, bool) {
+	t, ok := c.tools[name]
+	return t, ok
+}
+
+// schemaMap returns a JSON schema as a map, whatever its Go representation.
+func schemaMap(schema any) map[string]any {
+	if m, isMap := schema.(map[string]any); isMap {
+		return m
+	}
+	raw, err := json.Marshal(schema)
+	if err != nil {
+		return nil
+	}
+	var rv map[string]any
+	if json.Unmarshal(raw, &rv) != nil {
+		return nil
+	}
+	return rv
+}
+
+// newToolFromSchema reads the top level properties of an object schema;
+// nested schemas are left for the server to validate.
+func newToolFromSchema(schema map[string]any) Tool {
+	rv := Tool{Properties: make(map[string]Property)}
+	properties, _ := schema["properties"].(map[string]any)
+	for k, v := range properties {
+		prop, _ := v.(map[string]any)
+		description, _ := prop["description"].(string)
+		enum, _ := prop["enum"].([]any)
+		rv.Properties[k] = Property{
+			Type:        schemaType(prop["type"]),
+			Description: description,
+			Enum:        enum,
+		}
+	}
+	required, _ := schema["required"].([]any)
+	for _, r := range required {
+		if s, isString := r.(string); isString {
+			rv.Required = append(rv.Required, s)
+		}
+	}
+	if additional, isBool := schema["additionalProperties"].(bool); isBool && !additional {
+		rv.Closed = true
+	}
+	return rv
+}
+
+// schemaType returns the type of a property, the first non null type where
+// the schema lists several.
+func schemaType(t any) string {
+	switch t := t.(type) {
+	case string:
+		return t
+	case []any:
+		for _, item := range t {
+			if s, isString := item.(string); isString && s != "null" {
+				return s
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,211 @@
+package clientshell_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stackql/stackql/pkg/mcp_server/clientshell"
+)
+
+type fakeClient struct {
+	calledTool string
+	calledArgs map[string]any
+}
+
+func (f *fakeClient) InspectTools() ([]map[string]any, error) {
+	return []map[string]any{
+		{
+			"name":        "run_query",
+			"description": "Run a query.\nMore detail.",
+			"input_schema": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"sql":       map[string]any{"type": "string"},
+					"row_limit": map[string]any{"type": []any{"null", "integer"}},
+					"format":    map[string]any{"type": "string", "enum": []any{"json", "markdown"}},
+				},
+				"required":             []any{"sql"},
+				"additionalProperties": false,
+			},
+		},
+		{"name": "list_providers", "description": "List providers."},
+	}, nil
+}
+
+func (f *fakeClient) CallToolText(toolName string, args map[string]any) (string, error) {
+	f.calledTool, f.calledArgs = toolName, args
+	return `{"rows":[{"a":1}]}`, nil
+}
+
+func (f *fakeClient) ListPrompts() ([]map[string]any, error) {
+	return []map[string]any{{"name": "explore"}}, nil
+}
+
+func (f *fakeClient) ListResources() ([]map[string]any, error) { return nil, nil }
+
+func (f *fakeClient) Close() error { return nil }
+
+func newTestSession(t *testing.T) (clientshell.Session, *fakeClient, *bytes.Buffer) {
+	t.Helper()
+	client := &fakeClient{}
+	var out bytes.Buffer
+	s, err := clientshell.NewSession(client, &out)
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	return s, client, &out
+}
+
+func TestArgs(t *testing.T) {
+	s, _, _ := newTestSession(t)
+	tool, _ := s.Catalogue().Tool("run_query")
+
+	args, err := tool.Args(`sql='select 1 from "t"' row_limit=10 format=json`)
+	if err != nil {
+		t.Fatalf("Args: %v", err)
+	}
+	if args["sql"] != `select 1 from "t"` || args["row_limit"] != int64(10) || args["format"] != "json" {
+		t.Errorf("unexpected args %#v", args)
+	}
+	if args, err = tool.Args(`{"sql": "select 1", "row_limit": 5}`); err != nil || args["row_limit"] != int64(5) {
+		t.Errorf("unexpected JSON args %#v %v", args, err)
+	}
+	for text, expected := range map[string]string{
+		"row_limit=10":                   "missing required argument sql",
+		"sql=x row_limit=ten":            "row_limit",
+		"sql=x format=yaml":              "must be one of",
+		"sql=x colour=red":               "unknown argument colour",
+		`{"sql": 1}`:                     "sql must be of type string",
+		"sql='unterminated":              "unterminated quote",
+		"sql":                            "expected key=value",
+		"sql=x sql=y":                    "given twice",
+		`{"sql": "x", "row_limit": 1.5}`: "row_limit must be of type integer",
+	} {
+		if _, err := tool.Args(text); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("%s: expected an error containing %q, got %v", text, expected, err)
+		}
+	}
+}
+
+func TestCompleter(t *testing.T) {
+	s, _, _ := newTestSession(t)
+	c := clientshell.NewCompleter(s.Catalogue(), clientshell.Commands)
+	complete := func(line string) []string {
+		candidates, _ := c.Do([]rune(line), len([]rune(line)))
+		var rv []string
+		for _, candidate := range candidates {
+			rv = append(rv, string(candidate))
+		}
+		return rv
+	}
+	if got := complete("run"); len(got) != 1 || got[0] != "_query " {
+		t.Errorf("unexpected tool completion %q", got)
+	}
+	if got := complete("desc"); len(got) != 1 || got[0] != "ribe " {
+		t.Errorf("unexpected command completion %q", got)
+	}
+	if got := complete("describe li"); len(got) != 1 || got[0] != "st_providers" {
+		t.Errorf("unexpected describe completion %q", got)
+	}
+	if got := complete("run_query sql='select 1' "); strings.Join(got, ",") != "format=,row_limit=" {
+		t.Errorf("unexpected key completion %q", got)
+	}
+	if got := complete("run_query format=j"); len(got) != 1 || got[0] != "son " {
+		t.Errorf("unexpected enum completion %q", got)
+	}
+	if got := complete("unknown_tool "); len(got) != 0 {
+		t.Errorf("expected no completion, got %q", got)
+	}
+}
+
+func TestSessionExecute(t *testing.T) {
+	s, client, out := newTestSession(t)
+	if exit, err := s.Execute("run_query sql='select 1'"); exit || err != nil {
+		t.Fatalf("Execute: %v %v", exit, err)
+	}
+	if client.calledTool != "run_query" || client.calledArgs["sql"] != "select 1" {
+		t.Errorf("unexpected call %s %v", client.calledTool, client.calledArgs)
+	}
+	if !strings.Contains(out.String(), "\"rows\": [\n") {
+		t.Errorf("expected indented output, got %s", out.String())
+	}
+	out.Reset()
+	if _, err := s.Execute("tools"); err != nil || !strings.Contains(out.String(), "Run a query.\n") ||
+		strings.Contains(out.String(), "More detail") {
+		t.Errorf("unexpected tools listing %q %v", out.String(), err)
+	}
+	out.Reset()
+	if _, err := s.Execute("describe run_query"); err != nil || !strings.Contains(out.String(), "sql (string, required)") {
+		t.Errorf("unexpected description %q %v", out.String(), err)
+	}
+	if _, err := s.Execute("prompts"); err != nil || !strings.Contains(out.String(), "explore") {
+		t.Errorf("unexpected prompts %q %v", out.String(), err)
+	}
+	if _, err := s.Execute("run_query"); err == nil {
+		t.Errorf("expected a call without required arguments to be refused")
+	}
+	if _, err := s.Execute("nonesuch"); err == nil {
+		t.Errorf("expected an unknown command to be refused")
+	}
+	if exit, _ := s.Execute(`\q`); !exit {
+		t.Errorf("expected \\q to end the session")
+	}
+	if got := clientshell.Pretty("plain text"); got != "plain text" {
+		t.Errorf("expected text output to pass through, got %q", got)
+	}
+}
+
+func TestElicitationPrompter(t *testing.T) {
+	prompt := func(answers ...string) (*mcp.ElicitResult, string) {
+		var out bytes.Buffer
+		readLine := func(string) (string, error) {
+			if len(answers) == 0 {
+				return "", errors.New("EOF")
+			}
+			answer := answers[0]
+			answers = answers[1:]
+			return answer, nil
+		}
+		handler := clientshell.NewElicitationPrompter(readLine, &out)
+		rv, err := handler(context.Background(), &mcp.ElicitRequest{Params: &mcp.ElicitParams{
+			Message: "Approve delete from t?",
+			RequestedSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"reason": map[string]any{"type": "string"},
+					"ttl":    map[string]any{"type": "integer"},
+				},
+				"required": []any{"reason"},
+			},
+		}})
+		if err != nil {
+			t.Fatalf("handler: %v", err)
+		}
+		return rv, out.String()
+	}
+	if rv, out := prompt("y", "tidy up", "x", "30"); rv.Action != "accept" ||
+		rv.Content["reason"] != "tidy up" || rv.Content["ttl"] != int64(30) || !strings.Contains(out, "Approve delete") {
+		t.Errorf("unexpected acceptance %+v %s", rv, out)
+	}
+	if rv, _ := prompt("y", "tidy up", ""); rv.Action != "accept" || len(rv.Content) != 1 {
+		t.Errorf("expected an optional field to be skipped, got %+v", rv)
+	}
+	for _, tc := range []struct {
+		answers  []string
+		expected string
+	}{
+		{[]string{""}, "decline"},
+		{[]string{"n"}, "decline"},
+		{[]string{"c"}, "cancel"},
+		{[]string{"y"}, "cancel"},
+		{nil, "cancel"},
+	} {
+		if rv, _ := prompt(tc.answers...); rv.Action != tc.expected {
+			t.Errorf("%q: expected %s, got %s", tc.answers, tc.expected, rv.Action)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package clientshell
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Completer completes shell lines, as the readline AutoCompleter does:
+// it returns the suffixes which complete the word before pos, and the
+// length of that word.
+type Completer interface {
+	Do(line []rune, pos int) ([][]rune, int)
+}
+
+type completer struct {
+	catalogue Catalogue
+	commands  []string
+}
+
+// NewCompleter completes the first word of a line from the tool names and
+// the shell commands; the later words of a tool call from the arguments
+// not yet given, and from the enumerated values of an argument.
+func NewCompleter(catalogue Catalogue, commands []string) Completer {
+	return &completer{
+		catalogue: catalogue,
+		commands:  commands,
+	}
+}
+
+func (c *completer) Do(line []rune, pos int) ([][]rune, int) {
+	text := string(line[:pos])
+	word := text[strings.LastIndexAny(text, " \t")+1:]
+	command, rest := ParseCall(text)
+	if command == strings.TrimLeft(text, " \t") {
+		return suffixes(word, append(c.catalogue.Names(), c.commands...), " ")
+	}
+	if command == describeCommand {
+		return suffixes(word, c.catalogue.Names(), "")
+	}
+	tool, isTool := c.catalogue.Tool(command)
+	if !isTool {
+		return nil, 0
+	}
+	if key, value, hasValue := strings.Cut(word, "="); hasValue {
+		var values []string
+		for _, e := range tool.Properties[key].Enum {
+			values = append(values, fmt.Sprint(e))
+		}
+		return suffixes(value, values, " ")
+	}
+	given := make(map[string]struct{})
+	fields, _ := splitFields(rest)
+	for _, f := range fields {
+		if k, _, hasValue := strings.Cut(f, "="); hasValue {
+			given[k] = struct{}{}
+		}
+	}
+	var keys []string
+	for _, k := range tool.Keys() {
+		if _, isGiven := given[k]; !isGiven {
+			keys = append(keys, k)
+		}
+	}
+	return suffixes(word, keys, "=")
+}
+
+// suffixes returns the candidates beginning with prefix, less the prefix
+// and followed by terminator.
+func suffixes(prefix string, candidates []string, terminator string) ([][]rune, int) {
+	sort.Strings(candidates)
+	var rv [][]rune
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, prefix) {
+			rv = append(rv, []rune(candidate[len(prefix):]+terminator))
+		}
+	}
+	return rv, len([]rune(prefix))
+}
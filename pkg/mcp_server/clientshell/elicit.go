@@ -0,0 +1,63 @@
+package clientshell
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stackql/stackql/pkg/mcp_server"
+)
+
+// NewElicitationPrompter answers the elicitation requests of the server,
+// such as the approval prompts of the policy gate, by asking the operator.
+// The operator accepts, declines or cancels; on accepting, they are asked
+// for each field of the requested schema.  A failed read cancels.
+func NewElicitationPrompter(readLine func(prompt string) (string, error), out io.Writer) mcp_server.ElicitationHandler {
+	return func(_ context.Context, req *mcp.ElicitRequest) (*mcp.ElicitResult, error) {
+		params := req.Params
+		fmt.Fprintf(out, "\nThe server asks: %s\n", params.Message)
+		answer, err := readLine("approve? [y]es / [N]o / [c]ancel: ")
+		if err != nil {
+			return &mcp.ElicitResult{Action: "cancel"}, nil
+		}
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+		case "c", "cancel":
+			return &mcp.ElicitResult{Action: "cancel"}, nil
+		default:
+			return &mcp.ElicitResult{Action: "decline"}, nil
+		}
+		requested := newToolFromSchema(schemaMap(params.RequestedSchema))
+		requested.Name = "the elicitation"
+		content := make(map[string]any)
+		for _, k := range requested.Keys() {
+			for {
+				prompt := k
+				if p := requested.Properties[k]; p.Description != "" {
+					prompt += " (" + p.Description + ")"
+				}
+				text, readErr := readLine(prompt + ": ")
+				if readErr != nil {
+					return &mcp.ElicitResult{Action: "cancel"}, nil
+				}
+				if text == "" && !requested.isRequired(k) {
+					break
+				}
+				v, coerceErr := coerce(requested.Properties[k].Type, text)
+				if coerceErr != nil {
+					fmt.Fprintf(out, "%v\n", coerceErr)
+					continue
+				}
+				content[k] = v
+				break
+			}
+		}
+		if err := requested.Validate(content); err != nil {
+			fmt.Fprintf(out, "%v\n", err)
+			return &mcp.ElicitResult{Action: "cancel"}, nil
+		}
+		return &mcp.ElicitResult{Action: "accept", Content: content}, nil
+	}
+}
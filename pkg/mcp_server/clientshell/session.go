@@ -0,0 +1,155 @@
+package clientshell
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/stackql/stackql/pkg/mcp_server"
+)
+
+const (
+	helpCommand      = "help"
+	toolsCommand     = "tools"
+	describeCommand  = "describe"
+	promptsCommand   = "prompts"
+	resourcesCommand = "resources"
+)
+
+// Commands are the shell commands other than tool calls.
+//
+//nolint:gochecknoglobals // fixed list
+var Commands = []string{
+	helpCommand,
+	toolsCommand,
+	describeCommand,
+	promptsCommand,
+	resourcesCommand,
+	"exit",
+	"quit",
+	`\q`,
+}
+
+const usage = `Call a tool by name, with key=value arguments or one JSON object:
+
+  run_query sql='select 1' row_limit=10
+  run_query {"sql": "select 1"}
+
+Commands:
+  tools            list the tools of the server
+  describe <tool>  show the arguments of a tool
+  prompts          list the prompts of the server
+  resources        list the resources of the server
+  help             show this message
+  exit, quit, \q   leave the shell
+
+Tab completes tool names and argument keys.
+`
+
+// Session runs the lines of the shell against an MCP client.
+type Session interface {
+	Catalogue() Catalogue
+	// Execute runs one line, writing its output.  It reports whether the
+	// line ends the session.
+	Execute(line string) (bool, error)
+}
+
+type session struct {
+	client    mcp_server.MCPClient
+	catalogue Catalogue
+	out       io.Writer
+}
+
+// NewSession loads the tool catalogue of the server behind client.
+func NewSession(client mcp_server.MCPClient, out io.Writer) (Session, error) {
+	tools, err := client.InspectTools()
+	if err != nil {
+		return nil, err
+	}
+	return &session{
+		client:    client,
+		catalogue: NewCatalogue(tools),
+		out:       out,
+	}, nil
+}
+
+func (s *session) Catalogue() Catalogue {
+	return s.catalogue
+}
+
+//nolint:gocyclo,cyclop // dispatch on the command
+func (s *session) Execute(line string) (bool, error) {
+	command, rest := ParseCall(line)
+	switch command {
+	case "":
+		return false, nil
+	case "exit", "quit", `\q`:
+		return true, nil
+	case helpCommand:
+		fmt.Fprint(s.out, usage)
+		return false, nil
+	case toolsCommand:
+		for _, name := range s.catalogue.Names() {
+			tool, _ := s.catalogue.Tool(name)
+			fmt.Fprintf(s.out, "%-32s %s\n", name, firstLine(tool.Description))
+		}
+		return false, nil
+	case describeCommand:
+		tool, isTool := s.catalogue.Tool(rest)
+		if !isTool {
+			return false, fmt.Errorf("unknown tool %q", rest)
+		}
+		fmt.Fprint(s.out, tool.Usage())
+		return false, nil
+	case promptsCommand, resourcesCommand:
+		listing := s.client.ListPrompts
+		if command == resourcesCommand {
+			listing = s.client.ListResources
+		}
+		rv, err := listing()
+		if err != nil {
+			return false, err
+		}
+		raw, err := json.MarshalIndent(rv, "", "  ")
+		if err != nil {
+			return false, err
+		}
+		fmt.Fprintln(s.out, string(raw))
+		return false, nil
+	}
+	tool, isTool := s.catalogue.Tool(command)
+	if !isTool {
+		return false, fmt.Errorf("unknown tool or command %q; try help", command)
+	}
+	args, err := tool.Args(rest)
+	if err != nil {
+		return false, err
+	}
+	out, err := s.client.CallToolText(command, args)
+	if err != nil {
+		return false, err
+	}
+	fmt.Fprintln(s.out, Pretty(out))
+	return false, nil
+}
+
+// Pretty indents tool output which is JSON, and returns any other output
+// as it is.
+func Pretty(out string) string {
+	trimmed := strings.TrimSpace(out)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return out
+	}
+	var buf bytes.Buffer
+	if json.Indent(&buf, []byte(trimmed), "", "  ") != nil {
+		return out
+	}
+	return buf.String()
+}
+
+func firstLine(s string) string {
+	first, _, _ := strings.Cut(s, "\n")
+	return first
+}
@@ -3,11 +3,13 @@ package mcp_server //nolint:revive,stylecheck,mnd // fine for now
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 	"time"
 
 	"gopkg.in/yaml.v2"
 
 	"github.com/stackql/stackql/pkg/mcp_server/audit"
+	"github.com/stackql/stackql/pkg/mcp_server/auth"
 	"github.com/stackql/stackql/pkg/mcp_server/policy"
 	"github.com/stackql/stackql/pkg/mcp_server/render"
 	"github.com/stackql/stackql/pkg/sink"
@@ -41,17 +43,105 @@ type Config struct {
 	// QueryLibrary configures the query library tools (query_library_search,
 	// query_library_get).  Zero value means defaults + env var resolution.
 	QueryLibrary QueryLibraryConfig `json:"query_library,omitempty" yaml:"query_library,omitempty"`
+
+	// Pagination bounds the rows in one run_select_query response.
+	Pagination PaginationConfig `json:"pagination,omitempty" yaml:"pagination,omitempty"`
+
+	// StoredRelations configures publication of views, materialized views
+	// and user space tables as resources.
+	StoredRelations StoredRelationsConfig `json:"stored_relations,omitempty" yaml:"stored_relations,omitempty"`
+
+	// evaluator applies the loaded policy rules; nil means mode only.
+	evaluator policy.Evaluator
 }
 
 // QueryLibraryConfig configures retrieval of the published query library.
 // Explicit config wins over env vars, which win over defaults.  Env vars:
 // STACKQL_QUERY_LIBRARY_BASE_URL, STACKQL_QUERY_LIBRARY_OFFLINE,
-// STACKQL_QUERY_LIBRARY_TTL (seconds).
+// STACKQL_QUERY_LIBRARY_TTL (seconds), STACKQL_QUERY_LIBRARY_LOCAL_DIRS
+// (a path list).
 type QueryLibraryConfig struct {
 	BaseURL     string `json:"base_url,omitempty" yaml:"base_url,omitempty"`
 	FallbackURL string `json:"fallback_url,omitempty" yaml:"fallback_url,omitempty"`
 	Offline     bool   `json:"offline,omitempty" yaml:"offline,omitempty"`
 	TTLSeconds  int    `json:"ttl_seconds,omitempty" yaml:"ttl_seconds,omitempty"`
+	// LocalDirs mounts local libraries, in the published layout, ahead of
+	// the central library; earlier directories take precedence.
+	LocalDirs []string `json:"local_dirs,omitempty" yaml:"local_dirs,omitempty"`
+	// Ranker selects the search ranker: lexical (default) or bm25.
+	Ranker string `json:"ranker,omitempty" yaml:"ranker,omitempty"`
+}
+
+// Pagination defaults.
+const (
+	defaultPageSize   = 200
+	defaultStashTTL   = 10 * time.Minute
+	defaultMaxStashed = 32
+)
+
+// PaginationConfig configures paging of run_select_query results.  Rows
+// beyond the first page are stashed server side, scoped to the MCP session,
+// and served by fetch_more until StashTTL has elapsed since last access.
+// Zero values mean defaults.
+type PaginationConfig struct {
+	// PageSize is the default, and the maximum, rows per page; default 200.
+	PageSize int `json:"page_size,omitempty" yaml:"page_size,omitempty"`
+	// StashTTL is how long an untouched stashed result lives; default 10m.
+	StashTTL Duration `json:"stash_ttl,omitempty" yaml:"stash_ttl,omitempty"`
+	// MaxStashed caps the results stashed across all sessions, the least
+	// recently used being evicted; default 32.
+	MaxStashed int `json:"max_stashed,omitempty" yaml:"max_stashed,omitempty"`
+}
+
+// GetPageSize returns the rows per page for a call requesting
+// requested rows, zero meaning the default.
+func (p PaginationConfig) GetPageSize(requested int) int {
+	limit := p.PageSize
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	if requested <= 0 || requested > limit {
+		return limit
+	}
+	return requested
+}
+
+// GetStashTTL returns the effective stash TTL.
+func (p PaginationConfig) GetStashTTL() time.Duration {
+	if p.StashTTL <= 0 {
+		return defaultStashTTL
+	}
+	return time.Duration(p.StashTTL)
+}
+
+// GetMaxStashed returns the effective stash capacity.
+func (p PaginationConfig) GetMaxStashed() int {
+	if p.MaxStashed <= 0 {
+		return defaultMaxStashed
+	}
+	return p.MaxStashed
+}
+
+const defaultRelationPollInterval = 30 * time.Second
+
+// StoredRelationsConfig configures the stackql://views/{name} resources,
+// published where the backend implements RelationLister.  The relations are
+// re-read every PollInterval, clients being sent resources/list_changed
+// when one is created, dropped or redefined.  EnabledResources, where set,
+// applies to relation names.
+type StoredRelationsConfig struct {
+	// Disabled suppresses the relation resources.
+	Disabled bool `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+	// PollInterval is how often relations are re-read; default 30s.
+	PollInterval Duration `json:"poll_interval,omitempty" yaml:"poll_interval,omitempty"`
+}
+
+// GetPollInterval returns the effective poll interval.
+func (r StoredRelationsConfig) GetPollInterval() time.Duration {
+	if r.PollInterval <= 0 {
+		return defaultRelationPollInterval
+	}
+	return time.Duration(r.PollInterval)
 }
 
 // nameEnabled reports whether name is allowed by an allowlist where nil or
@@ -120,6 +210,15 @@ func (c *Config) GetMode() string {
 	return c.Server.Mode
 }
 
+// getPolicyEvaluator returns the evaluator installed by the server,
+// or a mode-only evaluator where there is none.
+func (c *Config) getPolicyEvaluator() policy.Evaluator {
+	if c == nil || c.evaluator == nil {
+		return policy.NewEvaluator(nil, nil)
+	}
+	return c.evaluator
+}
+
 // GetRender returns the server-level default render format for tool result
 // text content.  Empty string is mapped to the markdown default.
 func (c *Config) GetRender() string {
@@ -186,6 +285,15 @@ type ServerConfig struct {
 	// Audit configures the audit subsystem.  Audit is enabled by default
 	// (Disabled is false) and writes to a file sink.
 	Audit AuditConfig `json:"audit,omitempty" yaml:"audit,omitempty"`
+
+	// Policy configures the optional rule-based policy, which refines the
+	// decision of Mode per statement kind, resource and method.
+	Policy PolicyConfig `json:"policy,omitempty" yaml:"policy,omitempty"`
+
+	// Auth configures authentication of the HTTP transport.  Empty means
+	// unauthenticated.  Authenticated callers may be assigned their own
+	// Mode; see auth.Config.
+	Auth auth.Config `json:"auth,omitempty" yaml:"auth,omitempty"`
 }
 
 // serverConfigWire mirrors ServerConfig with the legacy `read_only` flag
@@ -205,6 +313,8 @@ type serverConfigWire struct {
 	Mode                  string         `json:"mode,omitempty" yaml:"mode,omitempty"`
 	Render                string         `json:"render,omitempty" yaml:"render,omitempty"`
 	Audit                 AuditConfig    `json:"audit,omitempty" yaml:"audit,omitempty"`
+	Policy                PolicyConfig   `json:"policy,omitempty" yaml:"policy,omitempty"`
+	Auth                  auth.Config    `json:"auth,omitempty" yaml:"auth,omitempty"`
 	// LegacyReadOnly preserves the PR1 `read_only: true` wire form.
 	LegacyReadOnly *bool `json:"read_only,omitempty" yaml:"read_only,omitempty"`
 }
@@ -224,6 +334,8 @@ func (s *ServerConfig) fromWire(w serverConfigWire) {
 	s.Mode = w.Mode
 	s.Render = w.Render
 	s.Audit = w.Audit
+	s.Policy = w.Policy
+	s.Auth = w.Auth
 	// Legacy: `read_only: true` with no `mode` -> Mode = "read_only".
 	// `mode` always wins.
 	if s.Mode == "" && w.LegacyReadOnly != nil && *w.LegacyReadOnly {
@@ -261,13 +373,46 @@ type AuditConfig struct {
 	// Legal values: "strict" (default), "strict_mutations", "best_effort".
 	FailureMode string `json:"failure_mode,omitempty" yaml:"failure_mode,omitempty"`
 
-	// Sink selects the destination kind.  Currently only "file" is
-	// implemented; other values are reserved.  Empty defaults to "file".
+	// Sink selects the destination kind: "file", "syslog" or "http".
+	// Empty defaults to "file".
 	Sink string `json:"sink,omitempty" yaml:"sink,omitempty"`
 
 	// File holds file-sink-specific options.  Only consulted when Sink is
 	// "file" (the default).
 	File sink.FileConfig `json:"file,omitempty" yaml:"file,omitempty"`
+
+	// Syslog holds syslog-sink-specific options.  Only consulted when Sink
+	// is "syslog".
+	Syslog sink.SyslogConfig `json:"syslog,omitempty" yaml:"syslog,omitempty"`
+
+	// HTTP holds HTTP-sink-specific options.  Only consulted when Sink is
+	// "http".
+	HTTP sink.HTTPConfig `json:"http,omitempty" yaml:"http,omitempty"`
+}
+
+// PolicyConfig locates the rule-based policy.  See policy.RuleSet for the
+// file format.
+type PolicyConfig struct {
+	// File is the path of a YAML policy file.  Empty means no rules: the
+	// server mode alone decides.
+	File string `json:"file,omitempty" yaml:"file,omitempty"`
+}
+
+// loadPolicyRules reads and validates the configured policy file,
+// returning nil when none is configured.
+func (c *Config) loadPolicyRules() (*policy.RuleSet, error) {
+	if c == nil || c.Server.Policy.File == "" {
+		return nil, nil //nolint:nilnil // absence of rules is not an error
+	}
+	data, err := os.ReadFile(c.Server.Policy.File)
+	if err != nil {
+		return nil, fmt.Errorf("policy file: %w", err)
+	}
+	rules, err := policy.LoadRuleSet(data)
+	if err != nil {
+		return nil, fmt.Errorf("policy file %q: %w", c.Server.Policy.File, err)
+	}
+	return rules, nil
 }
 
 // GetFailureMode returns the effective failure-mode string with the default
@@ -399,6 +544,17 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid server.audit.failure_mode %q (legal: strict, strict_mutations, best_effort)",
 			c.Server.Audit.FailureMode)
 	}
+	if !isLegalQueryLibraryRanker(c.QueryLibrary.Ranker) {
+		return fmt.Errorf("invalid query_library.ranker %q (legal: lexical, bm25)", c.QueryLibrary.Ranker)
+	}
+	if c.Server.Auth.IsEnabled() {
+		if c.GetServerTransport() != serverTransportHTTP {
+			return fmt.Errorf("server.auth requires the %s transport", serverTransportHTTP)
+		}
+		if c.Server.Auth.MTLS.ClientCAFile != "" && (c.Server.TLSCertFile == "" || c.Server.TLSKeyFile == "") {
+			return fmt.Errorf("server.auth.mtls requires server.tls_cert_file and server.tls_key_file")
+		}
+	}
 	return nil
 }
 
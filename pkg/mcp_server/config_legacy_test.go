@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stackql/stackql/pkg/mcp_server/audit"
+	"github.com/stackql/stackql/pkg/mcp_server/auth"
 	"github.com/stackql/stackql/pkg/mcp_server/policy"
 )
 
@@ -89,6 +90,22 @@ func TestValidate_RejectsUnknownAuditFailureMode(t *testing.T) {
 	}
 }
 
+func TestValidate_AuthRequiresHTTPTransport(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Server.Auth.Bearer.Tokens = []auth.BearerToken{{Subject: "s", Token: "x"}}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected validation error for auth over stdio")
+	}
+	cfg.Server.Transport = serverTransportHTTP
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("validate rejected bearer auth over http: %v", err)
+	}
+	cfg.Server.Auth.MTLS.ClientCAFile = "ca.pem"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected validation error for mtls without a server certificate")
+	}
+}
+
 func TestValidate_AcceptsAllLegalFailureModes(t *testing.T) {
 	for _, m := range []string{"", audit.FailureModeStrict, audit.FailureModeStrictMutations, audit.FailureModeBestEffort} {
 		cfg := DefaultConfig()
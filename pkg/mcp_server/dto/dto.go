@@ -42,8 +42,16 @@ type ServerInfoDTO struct {
 type QueryJSONInput struct {
 	SQL      string `json:"sql" yaml:"sql"`
 	RowLimit int    `json:"row_limit,omitempty" yaml:"row_limit,omitempty"`
-	Format   string `json:"format,omitempty" yaml:"format,omitempty" jsonschema:"text content render format: markdown (default) or json"`                          //nolint:lll // schema doc
-	Source   string `json:"source,omitempty" yaml:"source,omitempty" jsonschema:"query library id when the SQL originated from a library entry; attribution only"` //nolint:lll // schema doc
+	Format   string `json:"format,omitempty" yaml:"format,omitempty" jsonschema:"text content render format: markdown (default) or json"`                                      //nolint:lll // schema doc
+	Source   string `json:"source,omitempty" yaml:"source,omitempty" jsonschema:"query library id when the SQL originated from a library entry; attribution only"`             //nolint:lll // schema doc
+	PageSize int    `json:"page_size,omitempty" yaml:"page_size,omitempty" jsonschema:"rows per page for run_select_query; defaults to and is capped by the server page size"` //nolint:lll // schema doc
+}
+
+// FetchMoreInput is the input shape for fetch_more.
+type FetchMoreInput struct {
+	Cursor   string `json:"cursor" yaml:"cursor" jsonschema:"cursor returned by run_select_query or a previous fetch_more"`
+	PageSize int    `json:"page_size,omitempty" yaml:"page_size,omitempty" jsonschema:"rows per page; defaults to and is capped by the server page size"`
+	Format   string `json:"format,omitempty" yaml:"format,omitempty" jsonschema:"text content render format: markdown (default) or json"` //nolint:lll // schema doc
 }
 
 // QueryLibrarySearchInput is the input shape for query_library_search.
@@ -74,6 +82,21 @@ type QueryLibraryHitDTO struct {
 	Mutation       bool     `json:"mutation"`
 	RequiredParams []string `json:"required_params,omitempty"`
 	Score          float64  `json:"score"`
+	// Source is "local:<dir>" for an entry of a local library; empty for
+	// the central library, whose tier the search result carries.
+	Source string `json:"source,omitempty"`
+	// Explanation lists the contributions to Score, largest first.
+	Explanation []QueryLibraryMatchDTO `json:"explanation,omitempty"`
+}
+
+// QueryLibraryMatchDTO is one contribution to a hit's score: an intent
+// term (or, for the phrase field, a whole intent keyword) matching a field.
+type QueryLibraryMatchDTO struct {
+	Field string `json:"field"`
+	Term  string `json:"term"`
+	// Via is the related term, reached by expanding Term, that matched.
+	Via   string  `json:"via,omitempty"`
+	Score float64 `json:"score"`
 }
 
 // QueryLibrarySearchDTO is the result of query_library_search.
@@ -84,6 +107,7 @@ type QueryLibrarySearchDTO struct {
 	SourceTier string               `json:"source_tier,omitempty" jsonschema:"primary, fallback or snapshot"`
 	SourceURL  string               `json:"source_url,omitempty" jsonschema:"citation: URL or embedded path the catalogue was loaded from"`
 	Stale      bool                 `json:"stale,omitempty" jsonschema:"true when served from cache or snapshot after fetch failure"`
+	Ranker     string               `json:"ranker,omitempty" jsonschema:"the ranker that scored the hits: lexical or bm25"`
 }
 
 // QueryLibraryParamDTO describes one declared template parameter.
@@ -180,8 +204,36 @@ type CredentialsReloadDTO struct {
 }
 
 // QueryResultDTO is the typed structured payload returned alongside the rendered text.
+// Rows is one page of the result; Cursor, present while rows remain, resumes
+// it via fetch_more.
 type QueryResultDTO struct {
 	Rows []map[string]any `json:"rows"`
+	// Offset is the zero based position of the first row of the page.
+	Offset int `json:"offset,omitempty"`
+	// TotalRows is the size of the result, where known; a result cut short
+	// by row_limit has no known total.
+	TotalRows *int   `json:"total_rows,omitempty"`
+	Cursor    string `json:"cursor,omitempty"`
+	// Schema describes the rows where the backend reports column types.
+	Schema *RowSchemaDTO `json:"schema,omitempty"`
+}
+
+// RowSchemaDTO is the JSON Schema of one result row.
+type RowSchemaDTO struct {
+	Type       string                     `json:"type"`
+	Properties map[string]ColumnSchemaDTO `json:"properties"`
+	// ColumnOrder is the column order of the result set.
+	ColumnOrder []string `json:"x-column-order"`
+}
+
+// ColumnSchemaDTO is the JSON Schema of one result column.  Type is empty
+// for JSON columns, whose values may be of any JSON type.
+type ColumnSchemaDTO struct {
+	Type            []string `json:"type,omitempty"`
+	Format          string   `json:"format,omitempty"`
+	ContentEncoding string   `json:"contentEncoding,omitempty"`
+	// ColumnType is the backend's column type, eg int64 or json.
+	ColumnType string `json:"x-column-type"`
 }
 
 // ValidationResultDTO is the result of validate_select_query.
@@ -189,3 +241,19 @@ type ValidationResultDTO struct {
 	Valid  bool     `json:"valid"`
 	Errors []string `json:"errors,omitempty"`
 }
+
+// RelationColumnDTO is one column of a stored relation.
+type RelationColumnDTO struct {
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"`
+}
+
+// StoredRelationDTO describes a view, materialized view or user space table
+// defined in the SQL system.  Kind is one of view, materialized_view, table;
+// Columns is empty where the shape could not be inferred.
+type StoredRelationDTO struct {
+	Name    string              `json:"name"`
+	Kind    string              `json:"kind"`
+	DDL     string              `json:"ddl"`
+	Columns []RelationColumnDTO `json:"columns,omitempty"`
+}
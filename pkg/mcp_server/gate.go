@@ -2,6 +2,7 @@ package mcp_server //nolint:revive // fine for now
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -10,6 +11,7 @@ import (
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 
 	"github.com/stackql/stackql/pkg/mcp_server/audit"
+	"github.com/stackql/stackql/pkg/mcp_server/auth"
 	"github.com/stackql/stackql/pkg/mcp_server/dto"
 	"github.com/stackql/stackql/pkg/mcp_server/policy"
 	"github.com/stackql/stackql/pkg/sink"
@@ -32,6 +34,10 @@ type toolGate struct {
 	// empty string for tools that take no SQL.  Used by the classifier
 	// and by the audit event.
 	extractSQL func(any) string
+	// replaceSQL returns a copy of the typed input value bearing the given
+	// SQL, by which the policy's rewrite (eg a LIMIT cap) reaches the
+	// handler.  Nil for tools that take no SQL.
+	replaceSQL func(any, string) any
 	// extractArgs returns a key/value map suitable for the Args field on the
 	// audit event.  For hierarchy tools this carries the hierarchy fields;
 	// for query tools it carries SQL + row_limit.
@@ -46,6 +52,15 @@ func extractSQLFromQueryInput(args any) string {
 	return ""
 }
 
+// replaceSQLInQueryInput substitutes sql into the dto.QueryJSONInput shape.
+func replaceSQLInQueryInput(args any, sql string) any {
+	if v, ok := args.(dto.QueryJSONInput); ok {
+		v.SQL = sql
+		return v
+	}
+	return args
+}
+
 // extractArgsFromQueryInput returns {sql, row_limit} plus the optional query
 // library source attribution for audit recording.
 func extractArgsFromQueryInput(args any) map[string]any {
@@ -104,6 +119,25 @@ func hierarchyToMap(v dto.HierarchyInput) map[string]any {
 	return out
 }
 
+// requestPrincipal returns the caller authenticated by the HTTP transport
+// middleware, or the zero Principal for an anonymous or stdio caller.
+func requestPrincipal(req *mcp.CallToolRequest) auth.Principal {
+	if req == nil || req.Extra == nil {
+		return auth.Principal{}
+	}
+	p, _ := auth.PrincipalFromHeader(req.Extra.Header)
+	return p
+}
+
+// callerMode returns the mode assigned to the principal,
+// falling back to the server mode.
+func callerMode(cfg *Config, principal auth.Principal) string {
+	if principal.Mode != "" {
+		return principal.Mode
+	}
+	return cfg.GetMode()
+}
+
 // boolPtr returns a pointer for the *bool hint fields on mcp.ToolAnnotations.
 func boolPtr(v bool) *bool { return &v }
 
@@ -140,14 +174,15 @@ func addToolWithGate[In, Out any](
 	wrapped := func(ctx context.Context, req *mcp.CallToolRequest, args In) (*mcp.CallToolResult, Out, error) {
 		var zero Out
 		started := time.Now()
-		mode := cfg.GetMode()
+		principal := requestPrincipal(req)
+		mode := callerMode(cfg, principal)
 
 		// One call computes class + decision + reason.
 		var sql string
 		if gate.extractSQL != nil {
 			sql = gate.extractSQL(args)
 		}
-		p := policy.NewPolicy(mode, sql, gate.defaultClass)
+		p := cfg.getPolicyEvaluator().Evaluate(mode, sql, gate.defaultClass)
 		auditDecision := audit.DecisionAllow
 
 		switch p.Decision() {
@@ -155,28 +190,32 @@ func addToolWithGate[In, Out any](
 			// proceed to tool execution below
 		case policy.DecisionRefuseImmediate:
 			err := fmt.Errorf("tool %q refused: %s", t.Name, p.Reason())
-			recordAudit(ctx, auditSink, cfg, gate, args, sql, p.Class(), mode,
+			auditErr := recordAudit(ctx, auditSink, cfg, gate, args, sql, p, principal, mode,
 				audit.DecisionRefuseImmediate, started, err)
-			return nil, zero, err
+			return nil, zero, errors.Join(err, auditErr)
 		case policy.DecisionNeedsApproval:
 			outcome, err := elicitApproval(ctx, req, t.Name, p.Reason(), sql, p.Class())
 			auditDecision = outcome
 			if err != nil {
-				recordAudit(ctx, auditSink, cfg, gate, args, sql, p.Class(), mode,
+				auditErr := recordAudit(ctx, auditSink, cfg, gate, args, sql, p, principal, mode,
 					outcome, started, err)
-				return nil, zero, err
+				return nil, zero, errors.Join(err, auditErr)
 			}
 		}
 
+		if p.SQL() != sql && gate.replaceSQL != nil {
+			if replaced, isReplaced := gate.replaceSQL(args, p.SQL()).(In); isReplaced {
+				args = replaced
+			}
+		}
 		result, out, err := h(ctx, req, args)
-		recordAudit(ctx, auditSink, cfg, gate, args, sql, p.Class(), mode,
+		auditErr := recordAudit(ctx, auditSink, cfg, gate, args, sql, p, principal, mode,
 			auditDecision, started, err)
 		if err != nil {
-			return result, out, err
+			return result, out, errors.Join(err, auditErr)
 		}
-		if auditErr := finalizeAudit(cfg, p.Class()); auditErr != nil {
-			// Reserved: future strict-mode-on-audit-failure surfacing.
-			_ = auditErr
+		if auditErr != nil {
+			return nil, zero, auditErr
 		}
 		return result, out, nil
 	}
@@ -231,8 +270,9 @@ func elicitApproval(
 }
 
 // recordAudit writes one event to the configured sink.  Audit-write failures
-// are translated to client-visible errors only in strict / strict_mutations
-// modes; in best_effort mode the failure is logged to stderr and ignored.
+// are returned, to become client-visible errors, only in strict /
+// strict_mutations modes; in best_effort mode the failure is logged to stderr
+// and nil is returned.
 //
 // Sequencing note: the audit write happens AFTER the tool has executed (or
 // been skipped because it was gated out) but BEFORE the response returns to
@@ -246,14 +286,15 @@ func recordAudit(
 	gate toolGate,
 	args any,
 	sql string,
-	class policy.QueryClass,
+	p policy.Policy,
+	principal auth.Principal,
 	mode string,
 	decision string,
 	started time.Time,
 	toolErr error,
-) {
+) error {
 	if auditSink == nil {
-		return
+		return nil
 	}
 	event := audit.Event{
 		Timestamp:  started,
@@ -261,13 +302,23 @@ func recordAudit(
 		Mode:       mode,
 		Decision:   decision,
 		DurationMs: time.Since(started).Milliseconds(),
+		Principal:  principal.Subject,
+		AuthMethod: principal.Method,
 	}
+	class := p.Class()
 	if sql != "" {
 		event.SQL = sql
 		event.QueryClass = class.String()
+		if p.SQL() != sql {
+			event.RewrittenSQL = p.SQL()
+		}
 	} else if class != policy.QueryClassUnknown {
 		event.QueryClass = class.String()
 	}
+	event.PolicyRule = p.Rule()
+	if p.Rule() != "" {
+		event.PolicyReason = p.Reason()
+	}
 	if gate.extractArgs != nil {
 		event.Args = gate.extractArgs(args)
 	}
@@ -275,33 +326,33 @@ func recordAudit(
 		event.Error = toolErr.Error()
 	}
 	if err := auditSink.Record(ctx, event); err != nil {
-		handleAuditFailure(cfg, class, err)
+		return handleAuditFailure(cfg, gate.toolName, class, err)
 	}
+	return nil
 }
 
-// finalizeAudit is a placeholder hook for future strict-mode hardening.
-func finalizeAudit(_ *Config, _ policy.QueryClass) error { return nil }
-
 // handleAuditFailure decides whether an audit-sink error becomes a
 // client-visible failure or just gets logged.  The decision is per the
-// configured failure_mode.
-func handleAuditFailure(cfg *Config, class policy.QueryClass, err error) {
+// configured failure_mode; a non-nil return is the client-visible error.
+func handleAuditFailure(cfg *Config, toolName string, class policy.QueryClass, err error) error {
 	mode := cfg.Server.Audit.GetFailureMode()
 	switch mode {
 	case audit.FailureModeStrict:
 		fmt.Fprintf(stderrSink(), "audit write failed (strict): %v\n", err)
+		return fmt.Errorf("tool %q: audit write failed: %w", toolName, err)
 	case audit.FailureModeStrictMutations:
-		// SELECTs proceed silently with a stderr note; mutations would
-		// already have errored at recordAudit's caller via the returned
-		// error chain in a future revision.  For now we log uniformly.
+		// SELECTs proceed with a stderr note; anything else fails.
 		if class == policy.QueryClassSelect {
 			fmt.Fprintf(stderrSink(), "audit write failed (best-effort for select): %v\n", err)
-			return
+			return nil
 		}
 		fmt.Fprintf(stderrSink(), "audit write failed (strict_mutations): %v\n", err)
+		return fmt.Errorf("tool %q: audit write failed: %w", toolName, err)
 	case audit.FailureModeBestEffort:
 		fmt.Fprintf(stderrSink(), "audit write failed (best-effort): %v\n", err)
+		return nil
 	default:
 		fmt.Fprintf(stderrSink(), "audit write failed: %v\n", err)
+		return nil
 	}
 }
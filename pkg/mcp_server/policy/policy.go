@@ -1,9 +1,11 @@
 // Package policy classifies SQL queries and decides whether the server should
 // allow, refuse, or seek approval for a tool call based on the configured
-// server mode.  Pure functions only - no I/O, no SDK types, no logging.
+// server mode and, optionally, a rule set evaluated against the parsed
+// statement.  Pure functions only - no I/O, no SDK types, no logging.
 package policy
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
 )
@@ -187,27 +189,164 @@ type Policy interface {
 	Class() QueryClass
 	Decision() Decision
 	Reason() string
+	// Rule names the policy rule which decided, RuleNameDefault where the
+	// rule set default decided, or is empty where the server mode decided.
+	Rule() string
+	// SQL is the statement to run; it differs from that submitted only
+	// where a rule capped its LIMIT.
+	SQL() string
 }
 
 type policy struct {
 	class    QueryClass
 	decision Decision
 	reason   string
+	rule     string
+	sql      string
 }
 
 func (p policy) Class() QueryClass  { return p.class }
 func (p policy) Decision() Decision { return p.decision }
 func (p policy) Reason() string     { return p.reason }
+func (p policy) Rule() string       { return p.rule }
+func (p policy) SQL() string        { return p.sql }
 
 // NewPolicy is the factory.  When sql is empty (no SQL input on a metadata
 // tool, for example), defaultClass becomes the effective class; otherwise the
-// classifier inspects the SQL's first token.  Mode is normalised internally
-// so an empty / unknown value behaves like ModeSafe.
+// SQL is parsed and classified from its AST, falling back to the first token
+// should it not parse.  Mode is normalised internally so an empty / unknown
+// value behaves like ModeSafe.
 func NewPolicy(mode, sql string, defaultClass QueryClass) Policy {
-	class := defaultClass
-	if strings.TrimSpace(sql) != "" {
-		class = ClassifyQuery(sql)
+	return NewEvaluator(nil, nil).Evaluate(mode, sql, defaultClass)
+}
+
+// Evaluator applies an optional rule set on top of the server mode.
+type Evaluator interface {
+	Evaluate(mode, sql string, defaultClass QueryClass) Policy
+}
+
+type evaluator struct {
+	rules     *RuleSet
+	extractor TableExtractor
+}
+
+// NewEvaluator returns an Evaluator for rules, which may be nil, in which
+// case the mode alone decides.  A nil extractor means ExtractTables.
+func NewEvaluator(rules *RuleSet, extractor TableExtractor) Evaluator {
+	return &evaluator{
+		rules:     rules,
+		extractor: extractor,
+	}
+}
+
+func (e *evaluator) Evaluate(mode, sql string, defaultClass QueryClass) Policy {
+	if strings.TrimSpace(sql) == "" {
+		decision, reason := GateDecision(mode, defaultClass)
+		return policy{class: defaultClass, decision: decision, reason: reason}
+	}
+	stmt, err := ParseStatement(sql, e.extractor)
+	if err != nil {
+		// Whatever fails to parse here fails to execute too; the
+		// token scan serves only to gate and audit it sensibly.
+		class := ClassifyQuery(sql)
+		decision, reason := GateDecision(mode, class)
+		rv := policy{class: class, decision: decision, reason: reason, sql: sql}
+		if e.rules != nil && e.rules.Default != "" {
+			rv.decision = actionDecision(e.rules.Default)
+			rv.reason = fmt.Sprintf("policy default %q applies to statements that do not parse", e.rules.Default)
+			rv.rule = RuleNameDefault
+		}
+		return rv
+	}
+	decision, reason := GateDecision(mode, stmt.Class)
+	rv := policy{class: stmt.Class, decision: decision, reason: reason, sql: sql}
+	if e.rules == nil {
+		return rv
+	}
+	return e.applyRules(rv, stmt)
+}
+
+// policyTarget is a resource touched by a statement and,
+// for EXEC, the method invoked upon it.
+type policyTarget struct {
+	resource string
+	method   string
+}
+
+func statementTargets(stmt Statement) []policyTarget {
+	var rv []policyTarget
+	methodResources := make(map[string]struct{}, len(stmt.Methods))
+	for _, method := range stmt.Methods {
+		resource := method
+		if idx := strings.LastIndex(method, "."); idx >= 0 {
+			resource = method[:idx]
+		}
+		methodResources[resource] = struct{}{}
+		rv = append(rv, policyTarget{resource: resource, method: method})
+	}
+	for _, resource := range stmt.Resources {
+		if _, isMethodResource := methodResources[resource]; !isMethodResource {
+			rv = append(rv, policyTarget{resource: resource})
+		}
+	}
+	if len(rv) == 0 {
+		rv = append(rv, policyTarget{})
+	}
+	return rv
+}
+
+func describeTarget(kind string, target policyTarget) string {
+	switch {
+	case target.method != "":
+		return fmt.Sprintf("%s of '%s'", kind, target.method)
+	case target.resource != "":
+		return fmt.Sprintf("%s on '%s'", kind, target.resource)
+	default:
+		return kind
+	}
+}
+
+// applyRules decides each target by its first matching rule, or the
+// default, and retains the most restrictive decision.  Where the
+// outcome is to allow a SELECT, the smallest applicable LIMIT cap
+// is imposed.  That of an EXPLAIN ANALYZE is refused instead, since
+// rewriting its LIMIT would change the plan it reports.
+func (e *evaluator) applyRules(modePolicy policy, stmt Statement) Policy {
+	var rv policy
+	isDecided := false
+	limitCap := 0
+	for _, target := range statementTargets(stmt) {
+		candidate := modePolicy
+		if r, isMatched := e.rules.match(stmt.Kind, target.resource, target.method); isMatched {
+			candidate.decision = actionDecision(r.Action)
+			candidate.rule = r.Name
+			candidate.reason = fmt.Sprintf(
+				"policy rule %q %s %s", r.Name, r.Action, describeTarget(stmt.Kind, target))
+			if r.Action == ActionAllow && r.MaxLimit > 0 && (limitCap == 0 || r.MaxLimit < limitCap) {
+				limitCap = r.MaxLimit
+			}
+		} else if e.rules.Default != "" {
+			candidate.decision = actionDecision(e.rules.Default)
+			candidate.rule = RuleNameDefault
+			candidate.reason = fmt.Sprintf(
+				"policy default %q applies to %s", e.rules.Default, describeTarget(stmt.Kind, target))
+		}
+		if !isDecided || restrictiveness(candidate.decision) > restrictiveness(rv.decision) {
+			rv = candidate
+			isDecided = true
+		}
+	}
+	if rv.decision == DecisionAllow && stmt.Kind == StatementSelect && limitCap > 0 &&
+		(stmt.Limit < 0 || stmt.Limit > limitCap) {
+		if stmt.isAnalyzed {
+			rv.decision = DecisionRefuseImmediate
+			rv.reason = fmt.Sprintf("EXPLAIN ANALYZE of a SELECT without a LIMIT of at most %d is refused by policy", limitCap)
+			return rv
+		}
+		if capped, isCapped := stmt.withLimit(limitCap); isCapped {
+			rv.sql = capped
+			rv.reason = fmt.Sprintf("LIMIT capped at %d by policy", limitCap)
+		}
 	}
-	decision, reason := GateDecision(mode, class)
-	return policy{class: class, decision: decision, reason: reason}
+	return rv
 }
@@ -0,0 +1,204 @@
+package policy
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Rule actions.  These are the legal values for Rule.Action and
+// RuleSet.Default.
+const (
+	ActionAllow         = "allow"
+	ActionDeny          = "deny"
+	ActionNeedsApproval = "needs_approval"
+)
+
+// RuleNameDefault is reported as the deciding rule
+// where RuleSet.Default applies; no rule may take it.
+const RuleNameDefault = "default"
+
+// RuleSet is the YAML policy file.  Rules are tried in order against each
+// resource a statement touches and the first match decides for that
+// resource; the statement as a whole takes the most restrictive of those
+// decisions.  Where no rule matches, Default applies, and where Default is
+// empty the server mode decides as it would without a policy file.
+//
+//	default: needs_approval
+//	rules:
+//	  - name: deny-aws-iam
+//	    action: deny
+//	    resources: ["aws.iam.*"]
+//	  - name: read-google-compute
+//	    action: allow
+//	    statements: [select]
+//	    resources: ["google.compute.*"]
+//	    max_limit: 500
+//	  - name: approve-instance-stop
+//	    action: needs_approval
+//	    statements: [exec]
+//	    methods: ["google.compute.instances.stop"]
+type RuleSet struct {
+	Default string `json:"default,omitempty" yaml:"default,omitempty"`
+	Rules   []Rule `json:"rules" yaml:"rules"`
+}
+
+// Rule matches statements by kind, by the resources they touch and by
+// the methods they EXEC.  Patterns are case insensitive globs in which
+// `*` matches any run of characters, dots included.  An empty list
+// matches anything.
+type Rule struct {
+	Name       string   `json:"name" yaml:"name"`
+	Action     string   `json:"action" yaml:"action"`
+	Statements []string `json:"statements,omitempty" yaml:"statements,omitempty"`
+	Resources  []string `json:"resources,omitempty" yaml:"resources,omitempty"`
+	Methods    []string `json:"methods,omitempty" yaml:"methods,omitempty"`
+	// MaxLimit caps the LIMIT of a SELECT allowed by this rule; a
+	// missing or larger LIMIT is rewritten to MaxLimit.  Zero means
+	// no cap.
+	MaxLimit int `json:"max_limit,omitempty" yaml:"max_limit,omitempty"`
+}
+
+// LoadRuleSet parses and validates a YAML (or JSON) policy file.
+func LoadRuleSet(data []byte) (*RuleSet, error) {
+	rv := &RuleSet{}
+	if err := yaml.Unmarshal(data, rv); err != nil {
+		return nil, fmt.Errorf("failed to parse policy rules: %w", err)
+	}
+	if err := rv.Validate(); err != nil {
+		return nil, err
+	}
+	return rv, nil
+}
+
+func isLegalAction(action string) bool {
+	switch action {
+	case ActionAllow, ActionDeny, ActionNeedsApproval:
+		return true
+	default:
+		return false
+	}
+}
+
+func isLegalStatementKind(kind string) bool {
+	switch kind {
+	case StatementSelect, StatementInsert, StatementReplace, StatementUpdate, StatementDelete,
+		StatementExec, StatementShow, StatementDescribe, StatementExplain, StatementDDL, StatementOther:
+		return true
+	default:
+		return false
+	}
+}
+
+// Validate reports the first illegal action, statement kind,
+// pattern or limit in the rule set.
+func (rs *RuleSet) Validate() error {
+	if rs.Default != "" && !isLegalAction(rs.Default) {
+		return fmt.Errorf("invalid policy default %q (legal: allow, deny, needs_approval)", rs.Default)
+	}
+	for i, r := range rs.Rules {
+		if r.Name == "" {
+			return fmt.Errorf("policy rule %d has no name", i)
+		}
+		if r.Name == RuleNameDefault {
+			return fmt.Errorf("policy rule %d: the name %q is reserved", i, RuleNameDefault)
+		}
+		if !isLegalAction(r.Action) {
+			return fmt.Errorf("policy rule %q: invalid action %q (legal: allow, deny, needs_approval)", r.Name, r.Action)
+		}
+		for _, kind := range r.Statements {
+			if !isLegalStatementKind(strings.ToLower(kind)) {
+				return fmt.Errorf("policy rule %q: invalid statement kind %q", r.Name, kind)
+			}
+		}
+		for _, pattern := range append(append([]string{}, r.Resources...), r.Methods...) {
+			if _, err := path.Match(strings.ToLower(pattern), ""); err != nil {
+				return fmt.Errorf("policy rule %q: invalid pattern %q: %w", r.Name, pattern, err)
+			}
+		}
+		if r.MaxLimit < 0 {
+			return fmt.Errorf("policy rule %q: max_limit must not be negative", r.Name)
+		}
+	}
+	return nil
+}
+
+// matchesAny reports whether s matches any of the patterns; an
+// empty pattern list matches everything.  Since resource names
+// never contain a slash, path.Match's `*` spans dots.
+func matchesAny(patterns []string, s string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(strings.ToLower(pattern), s); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Rule) matchesKind(kind string) bool {
+	if len(r.Statements) == 0 {
+		return true
+	}
+	for _, k := range r.Statements {
+		if strings.EqualFold(k, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether the rule applies to resource (and method,
+// which is empty other than for EXEC) in a statement of kind.  A
+// rule which names resources or methods never matches a statement
+// touching none.
+func (r Rule) matches(kind, resource, method string) bool {
+	if !r.matchesKind(kind) {
+		return false
+	}
+	if len(r.Resources) > 0 && (resource == "" || !matchesAny(r.Resources, resource)) {
+		return false
+	}
+	if len(r.Methods) > 0 && (method == "" || !matchesAny(r.Methods, method)) {
+		return false
+	}
+	return true
+}
+
+// match returns the first rule matching the target, if any.
+func (rs *RuleSet) match(kind, resource, method string) (Rule, bool) {
+	for _, r := range rs.Rules {
+		if r.matches(kind, resource, method) {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
+
+func actionDecision(action string) Decision {
+	switch action {
+	case ActionAllow:
+		return DecisionAllow
+	case ActionDeny:
+		return DecisionRefuseImmediate
+	default:
+		return DecisionNeedsApproval
+	}
+}
+
+// restrictiveness orders decisions so that the
+// strictest of several may be selected.
+func restrictiveness(d Decision) int {
+	switch d {
+	case DecisionAllow:
+		return 0
+	case DecisionNeedsApproval:
+		return 1
+	default:
+		return 2 //nolint:mnd // ordinal
+	}
+}
@@ -0,0 +1,165 @@
+package policy_test
+
+import (
+	"testing"
+
+	"github.com/stackql/stackql/pkg/mcp_server/policy"
+)
+
+const testRuleSet = `
+rules:
+  - name: deny-aws-iam
+    action: deny
+    resources: ["aws.iam.*"]
+  - name: approve-instance-stop
+    action: needs_approval
+    statements: [exec]
+    methods: ["google.compute.instances.stop"]
+  - name: read-google-compute
+    action: allow
+    statements: [select]
+    resources: ["google.compute.*"]
+    max_limit: 100
+`
+
+func mustLoadRuleSet(t *testing.T, data string) *policy.RuleSet {
+	t.Helper()
+	rules, err := policy.LoadRuleSet([]byte(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return rules
+}
+
+func TestInspectStatement(t *testing.T) {
+	stmt, err := policy.ParseStatement(
+		"select i.name from google.compute.instances i where i.id in (select id from aws.iam.users)", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stmt.Kind != policy.StatementSelect || stmt.Class != policy.QueryClassSelect {
+		t.Errorf("kind = %q, class = %v, want select", stmt.Kind, stmt.Class)
+	}
+	if len(stmt.Resources) != 2 || stmt.Resources[0] != "google.compute.instances" || stmt.Resources[1] != "aws.iam.users" {
+		t.Errorf("unexpected resources: %v", stmt.Resources)
+	}
+	if stmt.Limit != -1 {
+		t.Errorf("limit = %d, want -1", stmt.Limit)
+	}
+	exec, err := policy.ParseStatement("EXEC google.compute.instances.stop @instance = 'x'", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(exec.Methods) != 1 || exec.Methods[0] != "google.compute.instances.stop" {
+		t.Errorf("unexpected methods: %v", exec.Methods)
+	}
+}
+
+func TestClassifyStatementExplainAnalyze(t *testing.T) {
+	for _, sql := range []string{"EXPLAIN ANALYZE delete from t", "EXPLAIN (ANALYZE) delete from t"} {
+		p := policy.NewPolicy(policy.ModeReadOnly, sql, policy.QueryClassUnknown)
+		if p.Class() != policy.QueryClassMutationDelete {
+			t.Errorf("%s: class = %v, want mutation_delete", sql, p.Class())
+		}
+	}
+}
+
+func TestRuleSetDecisions(t *testing.T) {
+	evaluator := policy.NewEvaluator(mustLoadRuleSet(t, testRuleSet), nil)
+	cases := []struct {
+		name     string
+		mode     string
+		sql      string
+		decision policy.Decision
+		rule     string
+	}{
+		{"allowed select", policy.ModeSafe, "select * from google.compute.instances limit 5", policy.DecisionAllow, "read-google-compute"},
+		{"denied select", policy.ModeFullAccess, "select * from aws.iam.users", policy.DecisionRefuseImmediate, "deny-aws-iam"},
+		{"denied subquery", policy.ModeFullAccess,
+			"select * from google.compute.instances where id in (select id from aws.iam.users)",
+			policy.DecisionRefuseImmediate, "deny-aws-iam"},
+		{"approval for method", policy.ModeFullAccess,
+			"EXEC google.compute.instances.stop @instance = 'x'", policy.DecisionNeedsApproval, "approve-instance-stop"},
+		{"unmatched falls back to mode", policy.ModeReadOnly,
+			"EXEC google.compute.instances.start @instance = 'x'", policy.DecisionRefuseImmediate, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p := evaluator.Evaluate(c.mode, c.sql, policy.QueryClassUnknown)
+			if p.Decision() != c.decision {
+				t.Errorf("decision = %v, want %v (reason %q)", p.Decision(), c.decision, p.Reason())
+			}
+			if p.Rule() != c.rule {
+				t.Errorf("rule = %q, want %q", p.Rule(), c.rule)
+			}
+		})
+	}
+}
+
+func TestRuleSetDefault(t *testing.T) {
+	evaluator := policy.NewEvaluator(mustLoadRuleSet(t, "default: deny\n"+testRuleSet), nil)
+	p := evaluator.Evaluate(policy.ModeFullAccess, "select * from github.repos.repos", policy.QueryClassUnknown)
+	if p.Decision() != policy.DecisionRefuseImmediate || p.Rule() != policy.RuleNameDefault {
+		t.Errorf("decision = %v, rule = %q, want refuse_immediate by default", p.Decision(), p.Rule())
+	}
+}
+
+func TestRuleSetLimitCap(t *testing.T) {
+	evaluator := policy.NewEvaluator(mustLoadRuleSet(t, testRuleSet), nil)
+	uncapped := evaluator.Evaluate(policy.ModeSafe, "select name from google.compute.instances limit 10", policy.QueryClassUnknown)
+	if uncapped.SQL() != "select name from google.compute.instances limit 10" {
+		t.Errorf("expected SQL within the cap to be untouched, got %q", uncapped.SQL())
+	}
+	for _, sql := range []string{
+		"select name from google.compute.instances",
+		"select name from google.compute.instances limit 1000",
+	} {
+		p := evaluator.Evaluate(policy.ModeSafe, sql, policy.QueryClassUnknown)
+		stmt, err := policy.ParseStatement(p.SQL(), nil)
+		if err != nil {
+			t.Fatalf("rewritten SQL %q does not parse: %v", p.SQL(), err)
+		}
+		if stmt.Limit != 100 {
+			t.Errorf("limit of %q = %d, want 100", p.SQL(), stmt.Limit)
+		}
+	}
+}
+
+func TestRuleSetExplainAnalyze(t *testing.T) {
+	evaluator := policy.NewEvaluator(mustLoadRuleSet(t, testRuleSet), nil)
+	stmt, err := policy.ParseStatement("EXPLAIN ANALYZE select name from google.compute.instances limit 5", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stmt.Kind != policy.StatementSelect || stmt.Limit != 5 ||
+		len(stmt.Resources) != 1 || stmt.Resources[0] != "google.compute.instances" {
+		t.Errorf("expected the explained select inspected, got %+v", stmt)
+	}
+	limited := evaluator.Evaluate(policy.ModeSafe, "EXPLAIN ANALYZE select name from google.compute.instances limit 5", policy.QueryClassUnknown)
+	if limited.Decision() != policy.DecisionAllow || limited.Rule() != "read-google-compute" {
+		t.Errorf("decision = %v, rule = %q, want allow by read-google-compute", limited.Decision(), limited.Rule())
+	}
+	unlimited := evaluator.Evaluate(policy.ModeSafe, "EXPLAIN ANALYZE select name from google.compute.instances", policy.QueryClassUnknown)
+	if unlimited.Decision() != policy.DecisionRefuseImmediate {
+		t.Errorf("decision = %v, want refuse_immediate for want of a LIMIT (reason %q)", unlimited.Decision(), unlimited.Reason())
+	}
+	plain := evaluator.Evaluate(policy.ModeSafe, "EXPLAIN select name from google.compute.instances", policy.QueryClassUnknown)
+	if plain.Decision() == policy.DecisionRefuseImmediate || plain.Rule() == "read-google-compute" {
+		t.Errorf("expected EXPLAIN without ANALYZE untouched by select rules, got %v by %q", plain.Decision(), plain.Rule())
+	}
+}
+
+func TestLoadRuleSetRejectsIllegalRules(t *testing.T) {
+	for _, data := range []string{
+		"default: maybe\n",
+		"rules:\n  - action: allow\n",
+		"rules:\n  - name: r\n    action: permit\n",
+		"rules:\n  - name: r\n    action: allow\n    statements: [truncate]\n",
+		"rules:\n  - name: r\n    action: allow\n    resources: [\"[\"]\n",
+		"rules:\n  - name: default\n    action: allow\n",
+	} {
+		if _, err := policy.LoadRuleSet([]byte(data)); err == nil {
+			t.Errorf("expected error loading %q", data)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package policy
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/stackql/stackql-parser/go/vt/sqlparser"
+
+	"github.com/stackql/stackql/pkg/textutil"
+)
+
+// Statement kinds, as matched by Rule.Statements.
+const (
+	StatementSelect   = "select"
+	StatementInsert   = "insert"
+	StatementReplace  = "replace"
+	StatementUpdate   = "update"
+	StatementDelete   = "delete"
+	StatementExec     = "exec"
+	StatementShow     = "show"
+	StatementDescribe = "describe"
+	StatementExplain  = "explain"
+	StatementDDL      = "ddl"
+	StatementOther    = "other"
+)
+
+// TableExtractor returns the tables cited by a statement.  Backends with a
+// planner of their own may supply one consistent with it; ExtractTables is
+// the default.
+type TableExtractor func(stmt sqlparser.Statement) (sqlparser.TableExprs, error)
+
+// Statement is the outcome of inspecting a parsed query: what it is, what it
+// touches and how many rows it asks for.  Resources are canonical lowercase
+// dotted names, eg `google.compute.instances`; Methods are the fully
+// qualified EXEC targets, eg `google.compute.instances.stop`.
+type Statement struct {
+	Kind      string
+	Class     QueryClass
+	Resources []string
+	Methods   []string
+	// Limit is the row count of a top level LIMIT, or -1 where there is
+	// none or it is not a literal.
+	Limit int
+	ast   sqlparser.Statement
+	// isAnalyzed is true of EXPLAIN ANALYZE, which runs the statement
+	// it explains and so is inspected as that statement.
+	isAnalyzed bool
+}
+
+// ParseStatement parses sql, as the query engine does, and inspects the
+// result.  A nil extractor means ExtractTables.
+func ParseStatement(sql string, extractor TableExtractor) (Statement, error) {
+	normalised, err := textutil.NormaliseExplain(sql)
+	if err != nil {
+		return Statement{}, err
+	}
+	stmt, err := sqlparser.Parse(normalised)
+	if err != nil {
+		return Statement{}, err
+	}
+	return InspectStatement(stmt, extractor)
+}
+
+// InspectStatement derives the kind, class, resources and row limit of
+// stmt.  The extractor supplies the tables as the planner sees them; the
+// whole tree is walked as well, so that neither a subquery nor the target
+// of a mutation evades a rule.  EXPLAIN ANALYZE runs the statement it
+// explains, so takes that statement's kind, resources and limit.
+func InspectStatement(stmt sqlparser.Statement, extractor TableExtractor) (Statement, error) {
+	if extractor == nil {
+		extractor = ExtractTables
+	}
+	inspected, isAnalyzed := analyzedStatement(stmt)
+	tables, err := extractor(inspected)
+	if err != nil {
+		return Statement{}, err
+	}
+	rv := Statement{
+		Kind:       statementKind(inspected),
+		Class:      ClassifyStatement(stmt),
+		Limit:      statementLimit(inspected),
+		ast:        stmt,
+		isAnalyzed: isAnalyzed,
+	}
+	seen := make(map[string]struct{})
+	addResource := func(name string) {
+		if _, isSeen := seen[name]; name == "" || name == "dual" || isSeen {
+			return
+		}
+		seen[name] = struct{}{}
+		rv.Resources = append(rv.Resources, name)
+	}
+	for _, expr := range tables {
+		if aliased, isAliased := expr.(*sqlparser.AliasedTableExpr); isAliased {
+			if tableName, isTableName := aliased.Expr.(sqlparser.TableName); isTableName {
+				addResource(resourceName(tableName))
+			}
+		}
+	}
+	walked, methods := walkStatement(inspected)
+	for _, name := range walked {
+		addResource(name)
+	}
+	for _, method := range methods {
+		addResource(methodResourceName(method))
+		rv.Methods = append(rv.Methods, resourceName(method))
+	}
+	return rv, nil
+}
+
+// ExtractTables is the default TableExtractor: every aliased table
+// expression anywhere in the tree.
+func ExtractTables(stmt sqlparser.Statement) (sqlparser.TableExprs, error) {
+	var rv sqlparser.TableExprs
+	err := sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if aliased, isAliased := node.(*sqlparser.AliasedTableExpr); isAliased {
+			rv = append(rv, aliased)
+		}
+		return true, nil
+	}, stmt)
+	return rv, err
+}
+
+// walkStatement collects the mutation targets and EXEC methods of stmt,
+// including those of nested EXEC subqueries.
+func walkStatement(stmt sqlparser.Statement) ([]string, []sqlparser.TableName) {
+	var resources []string
+	var methods []sqlparser.TableName
+	switch node := stmt.(type) {
+	case *sqlparser.Insert:
+		resources = append(resources, resourceName(node.Table))
+	case *sqlparser.Delete:
+		for _, target := range node.Targets {
+			resources = append(resources, resourceName(target))
+		}
+	case *sqlparser.RefreshMaterializedView:
+		resources = append(resources, resourceName(node.ViewName))
+	case *sqlparser.DDL:
+		for _, tableName := range append(append(sqlparser.TableNames{node.Table}, node.FromTables...), node.ToTables...) {
+			resources = append(resources, resourceName(tableName))
+		}
+	case *sqlparser.DescribeTable:
+		resources = append(resources, resourceName(node.Table))
+	}
+	//nolint:errcheck // visitor never errors
+	sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if exec, isExec := node.(*sqlparser.Exec); isExec {
+			methods = append(methods, exec.MethodName)
+		}
+		return true, nil
+	}, stmt)
+	return resources, methods
+}
+
+func joinIdents(idents ...sqlparser.TableIdent) string {
+	var parts []string
+	for _, ident := range idents {
+		if !ident.IsEmpty() {
+			parts = append(parts, strings.ToLower(ident.GetRawVal()))
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+func resourceName(tableName sqlparser.TableName) string {
+	return joinIdents(tableName.QualifierThird, tableName.QualifierSecond, tableName.Qualifier, tableName.Name)
+}
+
+// methodResourceName strips the method from an EXEC target,
+// yielding the resource upon which it acts.
+func methodResourceName(methodName sqlparser.TableName) string {
+	return joinIdents(methodName.QualifierThird, methodName.QualifierSecond, methodName.Qualifier)
+}
+
+func statementKind(stmt sqlparser.Statement) string {
+	switch node := stmt.(type) {
+	case sqlparser.SelectStatement:
+		return StatementSelect
+	case *sqlparser.Insert:
+		if node.Action == sqlparser.ReplaceStr {
+			return StatementReplace
+		}
+		return StatementInsert
+	case *sqlparser.Update:
+		return StatementUpdate
+	case *sqlparser.Delete:
+		return StatementDelete
+	case *sqlparser.Exec:
+		return StatementExec
+	case *sqlparser.Show:
+		return StatementShow
+	case *sqlparser.DescribeTable, *sqlparser.DescribeMethod:
+		return StatementDescribe
+	case *sqlparser.Explain:
+		return StatementExplain
+	case *sqlparser.DDL, *sqlparser.DBDDL:
+		return StatementDDL
+	default:
+		return StatementOther
+	}
+}
+
+// analyzedStatement returns the statement explained by an EXPLAIN
+// ANALYZE, and otherwise stmt itself.
+func analyzedStatement(stmt sqlparser.Statement) (sqlparser.Statement, bool) {
+	explain, isExplain := stmt.(*sqlparser.Explain)
+	if !isExplain || !strings.EqualFold(explain.Type, sqlparser.AnalyzeStr) || explain.Statement == nil {
+		return stmt, false
+	}
+	return explain.Statement, true
+}
+
+// ClassifyStatement is the AST counterpart of ClassifyQuery.  EXPLAIN
+// ANALYZE runs the statement it explains, so takes that statement's class.
+func ClassifyStatement(stmt sqlparser.Statement) QueryClass {
+	switch node := stmt.(type) {
+	case sqlparser.SelectStatement, *sqlparser.Show, *sqlparser.DescribeTable, *sqlparser.DescribeMethod:
+		return QueryClassSelect
+	case *sqlparser.Explain:
+		if strings.EqualFold(node.Type, sqlparser.AnalyzeStr) {
+			return ClassifyStatement(node.Statement)
+		}
+		return QueryClassSelect
+	case *sqlparser.Insert, *sqlparser.Update:
+		return QueryClassMutationCreate
+	case *sqlparser.Delete:
+		return QueryClassMutationDelete
+	case *sqlparser.Exec:
+		return QueryClassLifecycle
+	default:
+		return QueryClassUnknown
+	}
+}
+
+func selectLimit(stmt sqlparser.Statement) **sqlparser.Limit {
+	switch node := stmt.(type) {
+	case *sqlparser.Select:
+		return &node.Limit
+	case *sqlparser.Union:
+		return &node.Limit
+	case *sqlparser.ParenSelect:
+		return selectLimit(node.Select)
+	default:
+		return nil
+	}
+}
+
+func statementLimit(stmt sqlparser.Statement) int {
+	limit := selectLimit(stmt)
+	if limit == nil || *limit == nil {
+		return -1
+	}
+	val, isVal := (*limit).Rowcount.(*sqlparser.SQLVal)
+	if !isVal || val.Type != sqlparser.IntVal {
+		return -1
+	}
+	n, err := strconv.Atoi(string(val.Val))
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// withLimit returns the SQL of the statement with its
+// top level LIMIT row count replaced by rowCount.
+func (s Statement) withLimit(rowCount int) (string, bool) {
+	limit := selectLimit(s.ast)
+	if limit == nil {
+		return "", false
+	}
+	rowCountVal := sqlparser.NewIntVal([]byte(strconv.Itoa(rowCount)))
+	if *limit == nil {
+		*limit = &sqlparser.Limit{Rowcount: rowCountVal}
+	} else {
+		(*limit).Rowcount = rowCountVal
+	}
+	return sqlparser.String(s.ast), true
+}
@@ -8,7 +8,9 @@ import (
 	"io/fs"
 	"net/http"
 	"os"
+	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -125,23 +127,28 @@ type libOutput struct {
 
 // libDoc mirrors queries/<id>.json: parsed front matter plus extracted template.
 type libDoc struct {
-	ID          string      `json:"id"`
-	Title       string      `json:"title,omitempty"`
-	Description string      `json:"description,omitempty"`
-	Mutation    bool        `json:"mutation"`
-	Verb        string      `json:"verb,omitempty"` // select | mutation | lifecycle; wins over Mutation
-	Status      string      `json:"status,omitempty"`
-	Providers   []string    `json:"providers,omitempty"`
-	Services    []string    `json:"services,omitempty"`
-	Auth        []string    `json:"auth,omitempty"`
-	Permissions []string    `json:"permissions,omitempty"`
-	Params      []libParam  `json:"params,omitempty"`
-	Outputs     []libOutput `json:"outputs,omitempty"`
-	Cost        *libCost    `json:"cost,omitempty"`
-	Related     []string    `json:"related,omitempty"`
-	Template    string      `json:"template"`
-	Notes       string      `json:"notes,omitempty"`
-	DocURL      string      `json:"doc_url,omitempty"`
+	ID          string   `json:"id"`
+	Title       string   `json:"title,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Mutation    bool     `json:"mutation"`
+	Verb        string   `json:"verb,omitempty"` // select | mutation | lifecycle; wins over Mutation
+	Status      string   `json:"status,omitempty"`
+	Providers   []string `json:"providers,omitempty"`
+	Services    []string `json:"services,omitempty"`
+	// Tags, Keywords and IntentKeywords are carried into
+	// index.json when a local library is indexed.
+	Tags           []string    `json:"tags,omitempty"`
+	Keywords       []string    `json:"keywords,omitempty"`
+	IntentKeywords []string    `json:"intent_keywords,omitempty"`
+	Auth           []string    `json:"auth,omitempty"`
+	Permissions    []string    `json:"permissions,omitempty"`
+	Params         []libParam  `json:"params,omitempty"`
+	Outputs        []libOutput `json:"outputs,omitempty"`
+	Cost           *libCost    `json:"cost,omitempty"`
+	Related        []string    `json:"related,omitempty"`
+	Template       string      `json:"template"`
+	Notes          string      `json:"notes,omitempty"`
+	DocURL         string      `json:"doc_url,omitempty"`
 }
 
 // queryLibraryClient fetches and caches library content.  The manifest
@@ -162,6 +169,14 @@ type queryLibraryClient struct {
 	docURLs   map[string]string
 	tier      string
 	stale     bool
+
+	// locals are the mounted local libraries, in precedence order.
+	locals []*localQueryLibrary
+
+	// ranker scores search candidates; rankedFrom are the indexes its
+	// index was last built from.
+	ranker     queryLibraryRanker
+	rankedFrom []*libIndex
 }
 
 // queryLibrarySettings is the resolved (config > env > default) client setup.
@@ -170,6 +185,8 @@ type queryLibrarySettings struct {
 	fallbackURL string
 	offline     bool
 	ttl         time.Duration
+	localDirs   []string
+	ranker      string
 }
 
 func resolveQueryLibrarySettings(cfg QueryLibraryConfig) queryLibrarySettings {
@@ -178,6 +195,14 @@ func resolveQueryLibrarySettings(cfg QueryLibraryConfig) queryLibrarySettings {
 		fallbackURL: cfg.FallbackURL,
 		offline:     cfg.Offline,
 		ttl:         queryLibraryDefaultTTL,
+		localDirs:   cfg.LocalDirs,
+		ranker:      cfg.Ranker,
+	}
+	if s.ranker == "" {
+		s.ranker = os.Getenv("STACKQL_QUERY_LIBRARY_RANKER")
+	}
+	if len(s.localDirs) == 0 {
+		s.localDirs = filepath.SplitList(os.Getenv("STACKQL_QUERY_LIBRARY_LOCAL_DIRS"))
 	}
 	if s.baseURL == "" {
 		s.baseURL = os.Getenv("STACKQL_QUERY_LIBRARY_BASE_URL")
@@ -207,6 +232,12 @@ func resolveQueryLibrarySettings(cfg QueryLibraryConfig) queryLibrarySettings {
 
 func newQueryLibraryClient(cfg QueryLibraryConfig) *queryLibraryClient {
 	s := resolveQueryLibrarySettings(cfg)
+	locals := make([]*localQueryLibrary, 0, len(s.localDirs))
+	for _, dir := range s.localDirs {
+		if dir != "" {
+			locals = append(locals, newLocalQueryLibrary(dir))
+		}
+	}
 	return &queryLibraryClient{
 		baseURL:  strings.TrimRight(s.baseURL, "/"),
 		fallback: strings.TrimRight(s.fallbackURL, "/"),
@@ -215,6 +246,8 @@ func newQueryLibraryClient(cfg QueryLibraryConfig) *queryLibraryClient {
 		httpc:    &http.Client{Timeout: queryLibraryHTTPTimeout},
 		docs:     map[string]*libDoc{},
 		docURLs:  map[string]string{},
+		locals:   locals,
+		ranker:   newQueryLibraryRanker(s.ranker),
 	}
 }
 
@@ -333,6 +366,18 @@ func (c *queryLibraryClient) getDoc(ctx context.Context, id string) (*libDoc, st
 	}
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	if err := c.refreshLocals(); err != nil {
+		return nil, "", "", false, err
+	}
+	for _, l := range c.locals {
+		if l.has(id) {
+			doc, err := l.getDoc(id)
+			if err != nil {
+				return nil, "", "", false, err
+			}
+			return doc, l.sourceURL(l.docPath(id)), querySourceTierLocal, l.stale, nil
+		}
+	}
 	if err := c.ensureIndex(ctx); err != nil {
 		return nil, "", "", false, err
 	}
@@ -358,6 +403,36 @@ func (c *queryLibraryClient) getDoc(ctx context.Context, id string) (*libDoc, st
 	return doc, srcURL, c.tier, c.stale, nil
 }
 
+// prepareRanker (re)builds the ranker's index over the merged catalogue
+// when the central or a local index has been reloaded since it was built.
+// Called with mu held.
+func (c *queryLibraryClient) prepareRanker(merged []scoredEntry) {
+	indexes := make([]*libIndex, 0, len(c.locals)+1)
+	for _, l := range c.locals {
+		indexes = append(indexes, l.index)
+	}
+	indexes = append(indexes, c.index)
+	if slices.Equal(indexes, c.rankedFrom) {
+		return
+	}
+	entries := make([]libIndexEntry, 0, len(merged))
+	for _, m := range merged {
+		entries = append(entries, m.entry)
+	}
+	c.ranker.prepare(entries)
+	c.rankedFrom = indexes
+}
+
+// refreshLocals brings the index of every local library up to date.
+func (c *queryLibraryClient) refreshLocals() error {
+	for _, l := range c.locals {
+		if err := l.refresh(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // fetchDoc retrieves one document from the current tier, degrading to the
 // snapshot (marked stale) when both remote tiers fail.
 func (c *queryLibraryClient) fetchDoc(ctx context.Context, id string) (*libDoc, string, error) {
@@ -426,42 +501,25 @@ func anyContains(values []string, token string) bool {
 // scoreEntry is the lexical ranker: weighted field matching over title,
 // description, intent_keywords, keywords, tags and services, plus a phrase
 // bonus when the whole intent aligns with a declared intent keyword.
-func scoreEntry(e libIndexEntry, tokens []string, intentLower string) float64 {
+func scoreEntry(e libIndexEntry, tokens []string, intentLower string) (float64, []dto.QueryLibraryMatchDTO) {
 	var score float64
+	var matches []dto.QueryLibraryMatchDTO
 	for _, t := range tokens {
-		if anyContains(e.IntentKeywords, t) {
-			score += 4
-		}
-		if strings.Contains(strings.ToLower(e.Title), t) {
-			score += 3
-		}
-		if anyContains(e.Keywords, t) {
-			score += 2.5
-		}
-		if anyContains(e.Tags, t) {
-			score += 2
-		}
-		if anyContains(e.Services, t) {
-			score += 2
-		}
-		if anyContains(e.Providers, t) {
-			score += 1.5
-		}
-		if strings.Contains(strings.ToLower(e.Description), t) {
-			score++
+		for _, f := range libFields {
+			if anyContains(f.values(e), t) {
+				score += f.weight
+				matches = append(matches, dto.QueryLibraryMatchDTO{Field: f.name, Term: t, Score: f.weight})
+			}
 		}
 	}
-	for _, k := range e.IntentKeywords {
-		kl := strings.ToLower(k)
-		if strings.Contains(intentLower, kl) || strings.Contains(kl, intentLower) {
-			score += 6
-			break
-		}
+	if k, ok := phraseMatch(e, intentLower); ok {
+		score += queryLibraryPhraseBonus
+		matches = append(matches, dto.QueryLibraryMatchDTO{Field: queryLibraryPhraseField, Term: k, Score: queryLibraryPhraseBonus})
 	}
-	return score
+	return score, sortMatches(matches)
 }
 
-func entryToHit(e libIndexEntry, score float64) dto.QueryLibraryHitDTO {
+func entryToHit(e libIndexEntry, score float64, explanation []dto.QueryLibraryMatchDTO) dto.QueryLibraryHitDTO {
 	return dto.QueryLibraryHitDTO{
 		ID:             e.ID,
 		Title:          e.Title,
@@ -471,12 +529,16 @@ func entryToHit(e libIndexEntry, score float64) dto.QueryLibraryHitDTO {
 		Mutation:       e.Mutation,
 		RequiredParams: e.RequiredParams,
 		Score:          score,
+		Explanation:    explanation,
 	}
 }
 
 type scoredEntry struct {
-	entry libIndexEntry
-	score float64
+	entry       libIndexEntry
+	score       float64
+	explanation []dto.QueryLibraryMatchDTO
+	// source names the local library of the entry; empty for central.
+	source string
 }
 
 // entryPassesFilters applies the hard filters: status, mutation visibility,
@@ -511,6 +573,9 @@ func (c *queryLibraryClient) search(
 ) (dto.QueryLibrarySearchDTO, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	if err := c.refreshLocals(); err != nil {
+		return dto.QueryLibrarySearchDTO{}, err
+	}
 	if err := c.ensureIndex(ctx); err != nil {
 		return dto.QueryLibrarySearchDTO{}, err
 	}
@@ -521,24 +586,47 @@ func (c *queryLibraryClient) search(
 	if limit > queryLibraryMaxLimit {
 		limit = queryLibraryMaxLimit
 	}
-	tokens := tokenizeIntent(in.Intent)
-	intentLower := strings.ToLower(strings.TrimSpace(in.Intent))
-	candidates := make([]scoredEntry, 0, len(c.index.Entries))
-	for _, e := range c.index.Entries {
-		if !entryPassesFilters(e, in, allowMutations) {
+	// The merged catalogue is gathered in precedence order, so that the
+	// stable sort ranks equal scores likewise and a shadowed id is never a
+	// candidate.
+	var merged []scoredEntry
+	shadowed := map[string]bool{}
+	addEntries := func(entries []libIndexEntry, source string) {
+		for _, e := range entries {
+			if shadowed[e.ID] {
+				continue
+			}
+			shadowed[e.ID] = true
+			merged = append(merged, scoredEntry{entry: e, source: source})
+		}
+	}
+	for _, l := range c.locals {
+		addEntries(l.index.Entries, querySourceTierLocal+":"+l.dir)
+	}
+	addEntries(c.index.Entries, "")
+	c.prepareRanker(merged)
+	var candidates []scoredEntry
+	for _, cand := range merged {
+		if !entryPassesFilters(cand.entry, in, allowMutations) {
 			continue
 		}
-		candidates = append(candidates, scoredEntry{entry: e, score: scoreEntry(e, tokens, intentLower)})
+		cand.score, cand.explanation = c.ranker.score(cand.entry, in.Intent)
+		candidates = append(candidates, cand)
 	}
 	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
-	out := dto.QueryLibrarySearchDTO{SourceTier: c.tier, SourceURL: c.indexURL, Stale: c.stale, Hits: []dto.QueryLibraryHitDTO{}}
+	out := dto.QueryLibrarySearchDTO{
+		SourceTier: c.tier, SourceURL: c.indexURL, Stale: c.stale, Ranker: c.ranker.name(),
+		Hits: []dto.QueryLibraryHitDTO{},
+	}
 	for i, cand := range candidates {
 		if i >= limit {
 			break
 		}
-		out.Hits = append(out.Hits, entryToHit(cand.entry, cand.score))
+		hit := entryToHit(cand.entry, cand.score, cand.explanation)
+		hit.Source = cand.source
+		out.Hits = append(out.Hits, hit)
 	}
-	if len(out.Hits) == 0 || out.Hits[0].Score < queryLibraryMissThreshold {
+	if len(out.Hits) == 0 || out.Hits[0].Score < c.ranker.missThreshold() {
 		// Miss path: nearest neighbours plus a pointer to the server
 		// instructions, so the model degrades to authoring with the right
 		// rules, not guessing.  The dialect rules themselves already ship in
@@ -804,6 +892,7 @@ func searchHitsToRows(hits []dto.QueryLibraryHitDTO) []map[string]any {
 			"mutation":        h.Mutation,
 			"required_params": strings.Join(h.RequiredParams, ", "),
 			"score":           fmt.Sprintf("%.1f", h.Score),
+			"why":             explainHit(h.Explanation),
 		})
 	}
 	return rows
@@ -827,13 +916,13 @@ func registerQueryLibrarySearchTool(
 				"template ids with required param names. Consult this before composing SQL from scratch; " +
 				"follow up with query_library_get. Read-only, no credentials.",
 		},
-		func(ctx context.Context, _ *mcp.CallToolRequest, args dto.QueryLibrarySearchInput,
+		func(ctx context.Context, req *mcp.CallToolRequest, args dto.QueryLibrarySearchInput,
 		) (*mcp.CallToolResult, dto.QueryLibrarySearchDTO, error) {
 			format, formatErr := resolveRenderFormat(cfg, args.Format)
 			if formatErr != nil {
 				return nil, dto.QueryLibrarySearchDTO{}, formatErr
 			}
-			allowMutations := args.IncludeMutations && cfg.GetMode() != policy.ModeReadOnly
+			allowMutations := args.IncludeMutations && callerMode(cfg, requestPrincipal(req)) != policy.ModeReadOnly
 			out, err := client.search(ctx, args, allowMutations)
 			if err != nil {
 				return nil, dto.QueryLibrarySearchDTO{}, err
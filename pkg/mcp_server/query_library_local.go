@@ -0,0 +1,485 @@
+package mcp_server //nolint:revive // fine for now
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stackql/stackql-parser/go/vt/sqlparser"
+
+	"github.com/stackql/stackql/pkg/mcp_server/dto"
+)
+
+// Local query libraries: directories in the published URL contract layout
+// (manifest.json, index.json, queries/<id>.json) mounted alongside the
+// central library.  Precedence: a local entry shadows a central entry of
+// the same id, and an earlier directory shadows a later one; equal scores
+// rank in the same order.
+
+const (
+	querySourceTierLocal = "local"
+
+	queryLibraryManifestFile = "manifest.json"
+	queryLibraryIndexFile    = "index.json"
+	queryLibraryQueriesDir   = "queries"
+	// queryLibraryFileMode leaves a written index
+	// readable by the serving process.
+	queryLibraryFileMode = 0o644
+)
+
+//nolint:gochecknoglobals // immutable lookup
+var (
+	queryLibraryParamNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+	queryLibraryParamTypes      = map[string]bool{
+		"": true, "string": true, "number": true, "boolean": true, "identifier": true, "enum": true,
+	}
+	queryLibraryVerbs = map[string]bool{"": true, "select": true, "mutation": true, "lifecycle": true}
+)
+
+// localQueryLibrary is one mounted directory.  The index is re-read when
+// index.json changes on disk; documents are read just in time and cached
+// until then.
+type localQueryLibrary struct {
+	dir     string
+	index   *libIndex
+	modTime time.Time
+	docs    map[string]*libDoc
+	// stale is set where the last re-read failed and
+	// the previously loaded index is being served.
+	stale bool
+}
+
+func newLocalQueryLibrary(dir string) *localQueryLibrary {
+	return &localQueryLibrary{dir: dir, docs: map[string]*libDoc{}}
+}
+
+func (l *localQueryLibrary) indexPath() string {
+	return filepath.Join(l.dir, queryLibraryIndexFile)
+}
+
+func (l *localQueryLibrary) docPath(id string) string {
+	return filepath.Join(l.dir, queryLibraryQueriesDir, filepath.FromSlash(id)+".json")
+}
+
+// refresh reloads the index where it changed since last read.
+func (l *localQueryLibrary) refresh() error {
+	info, err := os.Stat(l.indexPath())
+	if err == nil && l.index != nil && info.ModTime().Equal(l.modTime) {
+		return nil
+	}
+	if err == nil {
+		var idx libIndex
+		if err = readJSONFile(l.indexPath(), &idx); err == nil {
+			l.index = &idx
+			l.modTime = info.ModTime()
+			l.docs = map[string]*libDoc{}
+			l.stale = false
+			return nil
+		}
+	}
+	if l.index != nil {
+		l.stale = true
+		return nil
+	}
+	return fmt.Errorf("local query library %s: %w", l.dir, err)
+}
+
+func (l *localQueryLibrary) has(id string) bool {
+	for i := range l.index.Entries {
+		if l.index.Entries[i].ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *localQueryLibrary) getDoc(id string) (*libDoc, error) {
+	if doc, ok := l.docs[id]; ok {
+		return doc, nil
+	}
+	var doc libDoc
+	if err := readJSONFile(l.docPath(id), &doc); err != nil {
+		return nil, fmt.Errorf("local query library %s: %w", l.dir, err)
+	}
+	l.docs[id] = &doc
+	return &doc, nil
+}
+
+func (l *localQueryLibrary) sourceURL(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return "file://" + filepath.ToSlash(path)
+}
+
+func readJSONFile(path string, v any) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// --- validation and indexing ---
+
+// QueryLibraryProblem is one defect found in a local query library, or,
+// where Note is set, an observation which does not make it invalid.
+type QueryLibraryProblem struct {
+	// Path is relative to the library directory.
+	Path    string
+	Message string
+	Note    bool
+}
+
+func (p QueryLibraryProblem) String() string {
+	if p.Note {
+		return p.Path + ": note: " + p.Message
+	}
+	return p.Path + ": " + p.Message
+}
+
+// CountQueryLibraryDefects returns the number of problems which are not
+// notes.
+func CountQueryLibraryDefects(problems []QueryLibraryProblem) int {
+	n := 0
+	for _, p := range problems {
+		if !p.Note {
+			n++
+		}
+	}
+	return n
+}
+
+// sampleParamValue returns a value exercising p, which satisfies its
+// declaration: its example, else its default, else its first enum value,
+// else a placeholder of its type.  It reports false where there is no
+// such value, as for a string param whose pattern the placeholder fails.
+func sampleParamValue(p libParam) (any, bool) {
+	switch {
+	case p.Example != nil:
+		return p.Example, true
+	case p.Default != nil:
+		return p.Default, true
+	case len(p.Enum) > 0:
+		return p.Enum[0], true
+	}
+	var placeholder any
+	switch strings.ToLower(p.Type) {
+	case "number":
+		placeholder = 0
+	case "boolean":
+		placeholder = true
+	default:
+		placeholder = "x"
+	}
+	if _, err := validateParamValue(p, placeholder); err != nil {
+		return nil, false
+	}
+	return placeholder, true
+}
+
+// validateLibParams checks the declarations of doc.Params.
+func validateLibParams(doc *libDoc) []string {
+	var problems []string
+	seen := map[string]bool{}
+	for _, p := range doc.Params {
+		if !queryLibraryParamNameRegexp.MatchString(p.Name) {
+			problems = append(problems, fmt.Sprintf("param name %q is not a legal placeholder name", p.Name))
+			continue
+		}
+		if seen[p.Name] {
+			problems = append(problems, fmt.Sprintf("param %q declared twice", p.Name))
+		}
+		seen[p.Name] = true
+		typ := strings.ToLower(p.Type)
+		if !queryLibraryParamTypes[typ] {
+			problems = append(problems, fmt.Sprintf("param %q has unknown type %q", p.Name, p.Type))
+		}
+		if typ == "enum" && len(p.Enum) == 0 {
+			problems = append(problems, fmt.Sprintf("enum param %q declares no values", p.Name))
+		}
+		enumValues := map[string]bool{}
+		for _, e := range p.Enum {
+			if enumValues[e] {
+				problems = append(problems, fmt.Sprintf("param %q repeats enum value %q", p.Name, e))
+			}
+			enumValues[e] = true
+		}
+		if p.Pattern != "" {
+			if _, err := regexp.Compile(p.Pattern); err != nil {
+				problems = append(problems, fmt.Sprintf("param %q has an invalid pattern: %v", p.Name, err))
+			}
+		}
+		if p.Default != nil {
+			if _, err := validateParamValue(p, p.Default); err != nil {
+				problems = append(problems, fmt.Sprintf("default of param %q is invalid: %v", p.Name, err))
+			}
+		}
+		if p.Example != nil {
+			if _, err := validateParamValue(p, p.Example); err != nil {
+				problems = append(problems, fmt.Sprintf("example of param %q is invalid: %v", p.Name, err))
+			}
+		}
+		if !strings.Contains(doc.Template, "{{"+p.Name+"}}") {
+			problems = append(problems, fmt.Sprintf("param %q is not used by the template", p.Name))
+		}
+	}
+	return problems
+}
+
+// validateLibDoc checks one query document: its declarations, and that its
+// template, rendered with sample values, parses with the stackql parser.
+// It returns the problems, and notes: the parse is skipped, with a note
+// saying so, where a param has no sample value satisfying its declaration.
+func validateLibDoc(doc *libDoc) ([]string, []string) {
+	var problems []string
+	if doc.Title == "" {
+		problems = append(problems, "title is required")
+	}
+	if !queryLibraryVerbs[strings.ToLower(doc.Verb)] {
+		problems = append(problems, fmt.Sprintf("unknown verb %q", doc.Verb))
+	}
+	if strings.TrimSpace(doc.Template) == "" {
+		return append(problems, "template is required"), nil
+	}
+	paramProblems := validateLibParams(doc)
+	problems = append(problems, paramProblems...)
+	if len(paramProblems) > 0 {
+		return problems, nil
+	}
+	sample := make(map[string]any, len(doc.Params))
+	var unsampled []string
+	for _, p := range doc.Params {
+		val, ok := sampleParamValue(p)
+		if !ok {
+			unsampled = append(unsampled, p.Name)
+			continue
+		}
+		sample[p.Name] = val
+	}
+	if len(unsampled) > 0 {
+		return problems, []string{fmt.Sprintf(
+			"template parse not checked: no valid sample value for param(s) %s; declare an example to check it",
+			strings.Join(unsampled, ", "))}
+	}
+	var rendered dto.QueryLibraryGetDTO
+	renderTemplate(doc, sample, &rendered)
+	if !rendered.Valid {
+		return append(problems, rendered.Errors...), nil
+	}
+	if _, err := sqlparser.Parse(rendered.SQL); err != nil {
+		problems = append(problems, fmt.Sprintf("template does not parse: %v", err))
+	}
+	return problems, nil
+}
+
+// indexEntryFor derives the index.json row of doc.
+func indexEntryFor(doc *libDoc) libIndexEntry {
+	entry := libIndexEntry{
+		ID:             doc.ID,
+		Title:          doc.Title,
+		Description:    doc.Description,
+		Providers:      doc.Providers,
+		Services:       doc.Services,
+		Tags:           doc.Tags,
+		Keywords:       doc.Keywords,
+		IntentKeywords: doc.IntentKeywords,
+		Mutation:       nextToolFor(doc) != nextToolSelect,
+		Status:         doc.Status,
+		RequiredParams: []string{},
+	}
+	for _, p := range doc.Params {
+		if p.Required && p.Default == nil {
+			entry.RequiredParams = append(entry.RequiredParams, p.Name)
+		}
+	}
+	return entry
+}
+
+// loadLibraryDocs reads and validates every document under dir/queries,
+// returning the documents sorted by id.
+func loadLibraryDocs(dir string) ([]*libDoc, []QueryLibraryProblem, error) {
+	root := filepath.Join(dir, queryLibraryQueriesDir)
+	var docs []*libDoc
+	var problems []QueryLibraryProblem
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if d.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+		rel, relErr := filepath.Rel(dir, path)
+		if relErr != nil {
+			return relErr
+		}
+		rel = filepath.ToSlash(rel)
+		var doc libDoc
+		if readErr := readJSONFile(path, &doc); readErr != nil {
+			problems = append(problems, QueryLibraryProblem{Path: rel, Message: readErr.Error()})
+			return nil
+		}
+		wantID := strings.TrimSuffix(strings.TrimPrefix(rel, queryLibraryQueriesDir+"/"), ".json")
+		switch {
+		case doc.ID != wantID:
+			problems = append(problems, QueryLibraryProblem{
+				Path: rel, Message: fmt.Sprintf("id %q does not match its path, expected %q", doc.ID, wantID),
+			})
+		case !queryLibraryIDRegexp.MatchString(doc.ID):
+			problems = append(problems, QueryLibraryProblem{
+				Path: rel, Message: fmt.Sprintf("id %q must match %s", doc.ID, queryLibraryIDRegexp.String()),
+			})
+		}
+		docProblems, docNotes := validateLibDoc(&doc)
+		for _, msg := range docProblems {
+			problems = append(problems, QueryLibraryProblem{Path: rel, Message: msg})
+		}
+		for _, msg := range docNotes {
+			problems = append(problems, QueryLibraryProblem{Path: rel, Message: msg, Note: true})
+		}
+		docs = append(docs, &doc)
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, fmt.Errorf("%s: no %s directory", dir, queryLibraryQueriesDir)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].ID < docs[j].ID })
+	return docs, problems, nil
+}
+
+// ValidateQueryLibrary checks a local query library: every document under
+// queries/ (parameters, enum values, defaults and examples, and that the
+// template parses), and that manifest.json and index.json agree with them.
+// Notes are returned among the problems.  The error is reserved for a
+// directory which cannot be read.
+func ValidateQueryLibrary(dir string) ([]QueryLibraryProblem, error) {
+	docs, problems, err := loadLibraryDocs(dir)
+	if err != nil {
+		return nil, err
+	}
+	var manifest libManifest
+	if readErr := readJSONFile(filepath.Join(dir, queryLibraryManifestFile), &manifest); readErr != nil {
+		problems = append(problems, QueryLibraryProblem{Path: queryLibraryManifestFile, Message: readErr.Error()})
+	}
+	var idx libIndex
+	if readErr := readJSONFile(filepath.Join(dir, queryLibraryIndexFile), &idx); readErr != nil {
+		return append(problems, QueryLibraryProblem{Path: queryLibraryIndexFile, Message: readErr.Error()}), nil
+	}
+	if manifest.EntryCount != 0 && manifest.EntryCount != len(idx.Entries) {
+		problems = append(problems, QueryLibraryProblem{
+			Path:    queryLibraryManifestFile,
+			Message: fmt.Sprintf("entry_count is %d, index has %d entries", manifest.EntryCount, len(idx.Entries)),
+		})
+	}
+	indexed := make(map[string]libIndexEntry, len(idx.Entries))
+	for _, e := range idx.Entries {
+		if _, dup := indexed[e.ID]; dup {
+			problems = append(problems, QueryLibraryProblem{
+				Path: queryLibraryIndexFile, Message: fmt.Sprintf("entry %q listed twice", e.ID),
+			})
+		}
+		indexed[e.ID] = e
+	}
+	for _, doc := range docs {
+		e, ok := indexed[doc.ID]
+		if !ok {
+			problems = append(problems, QueryLibraryProblem{
+				Path: queryLibraryIndexFile, Message: fmt.Sprintf("query %q is not indexed", doc.ID),
+			})
+			continue
+		}
+		delete(indexed, doc.ID)
+		want := indexEntryFor(doc)
+		if e.Mutation != want.Mutation || strings.Join(e.RequiredParams, ",") != strings.Join(want.RequiredParams, ",") {
+			problems = append(problems, QueryLibraryProblem{
+				Path:    queryLibraryIndexFile,
+				Message: fmt.Sprintf("entry %q is out of date with its document; re-index", doc.ID),
+			})
+		}
+	}
+	for id := range indexed {
+		problems = append(problems, QueryLibraryProblem{
+			Path: queryLibraryIndexFile, Message: fmt.Sprintf("entry %q has no document", id),
+		})
+	}
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].Path < problems[j].Path })
+	return problems, nil
+}
+
+// IndexQueryLibrary validates the documents of a local query library and,
+// where they are sound, writes its index.json and manifest.json.  It
+// returns the number of entries indexed, and the problems, which are only
+// notes where the entries are indexed.  The build_id is a digest of the
+// index, so an unchanged library re-indexes to the same build.
+func IndexQueryLibrary(dir string, now time.Time) (int, []QueryLibraryProblem, error) {
+	docs, problems, err := loadLibraryDocs(dir)
+	if err != nil {
+		return 0, nil, err
+	}
+	if CountQueryLibraryDefects(problems) > 0 {
+		return 0, problems, nil
+	}
+	idx := libIndex{Entries: make([]libIndexEntry, 0, len(docs))}
+	for _, doc := range docs {
+		idx.Entries = append(idx.Entries, indexEntryFor(doc))
+	}
+	entries, err := json.Marshal(idx.Entries)
+	if err != nil {
+		return 0, nil, err
+	}
+	digest := sha256.Sum256(entries)
+	idx.BuildID = "local-" + hex.EncodeToString(digest[:6]) //nolint:mnd // short build id
+	manifest := libManifest{
+		BuildID:     idx.BuildID,
+		GeneratedAt: now.UTC().Format(time.RFC3339),
+		EntryCount:  len(idx.Entries),
+	}
+	if err := writeJSONFile(filepath.Join(dir, queryLibraryIndexFile), idx); err != nil {
+		return 0, nil, err
+	}
+	if err := writeJSONFile(filepath.Join(dir, queryLibraryManifestFile), manifest); err != nil {
+		return 0, nil, err
+	}
+	return len(idx.Entries), problems, nil
+}
+
+// writeJSONFile replaces path atomically, so a serving
+// process never reads a half written index.
+func writeJSONFile(path string, v any) error {
+	raw, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-"+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck // gone after rename
+	if err := tmp.Chmod(queryLibraryFileMode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(append(raw, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
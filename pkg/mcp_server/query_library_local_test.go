@@ -0,0 +1,246 @@
+package mcp_server //nolint:testpackage,revive // exercise internal wiring
+
+import (
+	"context"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stackql/stackql/pkg/mcp_server/dto"
+)
+
+func writeLibDoc(t *testing.T, dir string, doc libDoc) {
+	t.Helper()
+	path := filepath.Join(dir, "queries", filepath.FromSlash(doc.ID)+".json")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if err := os.WriteFile(path, raw, 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+}
+
+func problemMessages(problems []QueryLibraryProblem) string {
+	msgs := make([]string, 0, len(problems))
+	for _, p := range problems {
+		msgs = append(msgs, p.String())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func TestQueryLibrary_EmbeddedSnapshotValidates(t *testing.T) {
+	dir := t.TempDir()
+	err := fs.WalkDir(embeddedContentFS, queryLibrarySnapshotDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		raw, readErr := fs.ReadFile(embeddedContentFS, path)
+		if readErr != nil {
+			return readErr
+		}
+		dest := filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(path, queryLibrarySnapshotDir+"/")))
+		if mkErr := os.MkdirAll(filepath.Dir(dest), 0o755); mkErr != nil {
+			return mkErr
+		}
+		return os.WriteFile(dest, raw, 0o600)
+	})
+	if err != nil {
+		t.Fatalf("copy snapshot: %v", err)
+	}
+	problems, err := ValidateQueryLibrary(dir)
+	if err != nil {
+		t.Fatalf("ValidateQueryLibrary: %v", err)
+	}
+	if len(problems) > 0 {
+		t.Errorf("expected the snapshot to validate, got:\n%s", problemMessages(problems))
+	}
+}
+
+func TestQueryLibrary_ValidateReportsProblems(t *testing.T) {
+	dir := t.TempDir()
+	writeLibDoc(t, dir, libDoc{
+		ID: "team/bad/enum-default", Title: "Bad enum default",
+		Params: []libParam{
+			{Name: "state", Type: "enum", Enum: []string{"RUNNING", "STOPPED"}, Default: "PAUSED"},
+		},
+		Template: "SELECT name FROM google.compute.instances WHERE status = '{{state}}';",
+	})
+	writeLibDoc(t, dir, libDoc{
+		ID: "team/bad/undeclared", Title: "Undeclared placeholder",
+		Template: "SELECT name FROM google.compute.instances WHERE project = '{{project}}';",
+	})
+	writeLibDoc(t, dir, libDoc{
+		ID: "team/bad/unparsable", Title: "Unparsable",
+		Template: "SELEKT name FROM google.compute.instances;",
+	})
+	writeLibDoc(t, dir, libDoc{
+		ID: "team/bad/elsewhere", Title: "Wrong id", Template: "SELECT 1;",
+	})
+	if err := os.Rename(
+		filepath.Join(dir, "queries", "team", "bad", "elsewhere.json"),
+		filepath.Join(dir, "queries", "team", "bad", "moved.json"),
+	); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+
+	n, problems, err := IndexQueryLibrary(dir, time.Now())
+	if err != nil {
+		t.Fatalf("IndexQueryLibrary: %v", err)
+	}
+	if n != 0 {
+		t.Errorf("a library with problems must not be indexed, indexed %d", n)
+	}
+	if _, statErr := os.Stat(filepath.Join(dir, queryLibraryIndexFile)); !os.IsNotExist(statErr) {
+		t.Errorf("index.json written despite problems: %v", statErr)
+	}
+	got := problemMessages(problems)
+	for _, want := range []string{
+		`enum-default.json: default of param "state" is invalid: param "state" must be one of`,
+		`undeclared.json: unresolved placeholder {{project}}`,
+		`unparsable.json: template does not parse`,
+		`moved.json: id "team/bad/elsewhere" does not match its path`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected problem %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestQueryLibrary_PatternParamWithoutSample(t *testing.T) {
+	dir := t.TempDir()
+	writeLibDoc(t, dir, libDoc{
+		ID: "team/zone/unsampled", Title: "Zone without example",
+		Params: []libParam{
+			{Name: "zone", Type: "string", Required: true, Pattern: `^[a-z]+-[a-z]+[0-9]-[a-z]$`},
+		},
+		Template: "SELECT name FROM google.compute.instances WHERE zone = '{{zone}}';",
+	})
+	writeLibDoc(t, dir, libDoc{
+		ID: "team/zone/sampled", Title: "Zone with example",
+		Params: []libParam{
+			{Name: "zone", Type: "string", Required: true, Pattern: `^[a-z]+-[a-z]+[0-9]-[a-z]$`, Example: "us-east1-b"},
+		},
+		Template: "SELECT name FROM google.compute.instances WHERE zone = '{{zone}}';",
+	})
+	writeLibDoc(t, dir, libDoc{
+		ID: "team/zone/bad-example", Title: "Zone with a bad example",
+		Params: []libParam{
+			{Name: "zone", Type: "string", Pattern: `^[a-z]+-[a-z]+[0-9]-[a-z]$`, Example: "nowhere"},
+		},
+		Template: "SELECT name FROM google.compute.instances WHERE zone = '{{zone}}';",
+	})
+
+	n, problems, err := IndexQueryLibrary(dir, time.Now())
+	if err != nil {
+		t.Fatalf("IndexQueryLibrary: %v", err)
+	}
+	if n != 0 {
+		t.Errorf("a library with an invalid example must not be indexed, indexed %d", n)
+	}
+	if CountQueryLibraryDefects(problems) != 1 {
+		t.Errorf("expected only the invalid example to be a defect, got:\n%s", problemMessages(problems))
+	}
+	got := problemMessages(problems)
+	for _, want := range []string{
+		`bad-example.json: example of param "zone" is invalid: param "zone" must match pattern`,
+		`unsampled.json: note: template parse not checked: no valid sample value for param(s) zone`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected problem %q, got:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "/sampled.json:") {
+		t.Errorf("expected the document with an example to validate, got:\n%s", got)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "queries", "team", "zone", "bad-example.json")); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	n, problems, err = IndexQueryLibrary(dir, time.Now())
+	if err != nil {
+		t.Fatalf("IndexQueryLibrary: %v", err)
+	}
+	if n != 2 || len(problems) != 1 || !problems[0].Note {
+		t.Errorf("expected both documents indexed with one note, indexed %d, got:\n%s", n, problemMessages(problems))
+	}
+}
+
+func TestQueryLibrary_LocalLibraryShadowsCentral(t *testing.T) {
+	dir := t.TempDir()
+	writeLibDoc(t, dir, libDoc{
+		ID: "aws/s3/bucket-detail", Title: "Team S3 bucket security detail",
+		Tags: []string{"s3", "security"}, IntentKeywords: []string{"bucket security settings"},
+		Params: []libParam{
+			{Name: "region", Type: "identifier", Required: true, Example: "us-east-1"},
+			{Name: "bucket_name", Type: "string", Required: true},
+		},
+		Template: "SELECT bucket_name, bucket_encryption FROM aws.s3.buckets " +
+			"WHERE region = '{{region}}' AND Identifier = '{{bucket_name}}';",
+	})
+	writeLibDoc(t, dir, libDoc{
+		ID: "team/compute/running-vms", Title: "Running team VMs",
+		IntentKeywords: []string{"running team vms"},
+		Params: []libParam{
+			{Name: "project", Type: "identifier", Required: true},
+			{Name: "zone", Type: "enum", Enum: []string{"us-east1-b", "us-west1-a"}, Default: "us-east1-b"},
+		},
+		Template: "SELECT name FROM google.compute.instances " +
+			"WHERE project = '{{project}}' AND zone = '{{zone}}' AND status = 'RUNNING';",
+	})
+	n, problems, err := IndexQueryLibrary(dir, time.Now())
+	if err != nil || len(problems) > 0 {
+		t.Fatalf("IndexQueryLibrary: %v\n%s", err, problemMessages(problems))
+	}
+	if n != 2 {
+		t.Fatalf("expected 2 entries indexed, got %d", n)
+	}
+	if problems, err := ValidateQueryLibrary(dir); err != nil || len(problems) > 0 {
+		t.Fatalf("a freshly indexed library should validate: %v\n%s", err, problemMessages(problems))
+	}
+
+	client := newClientForFixture(t, newTestFixture())
+	client.locals = []*localQueryLibrary{newLocalQueryLibrary(dir)}
+	ctx := context.Background()
+
+	out, err := client.search(ctx, dto.QueryLibrarySearchInput{Intent: "bucket security settings", Limit: 20}, false)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	var seen int
+	for _, h := range out.Hits {
+		if h.ID == "aws/s3/bucket-detail" {
+			seen++
+			if h.Source != querySourceTierLocal+":"+dir || h.Title != "Team S3 bucket security detail" {
+				t.Errorf("expected the local entry to shadow the central one, got %+v", h)
+			}
+		}
+	}
+	if seen != 1 {
+		t.Errorf("expected the shadowed id exactly once, got %d in %+v", seen, out.Hits)
+	}
+	out, err = client.search(ctx, dto.QueryLibrarySearchInput{Intent: "list enabled aws regions"}, false)
+	if err != nil || len(out.Hits) == 0 || out.Hits[0].ID != "aws/ec2/regions-enabled" || out.Hits[0].Source != "" {
+		t.Errorf("central entries should still be served: %v %+v", err, out.Hits)
+	}
+
+	got, err := client.get(ctx, dto.QueryLibraryGetInput{
+		ID: "team/compute/running-vms", Params: map[string]any{"project": "stackql-demo"},
+	})
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.SourceTier != querySourceTierLocal || !strings.HasPrefix(got.SourceURL, "file://") {
+		t.Errorf("expected a local citation, got tier=%q url=%q", got.SourceTier, got.SourceURL)
+	}
+	if !got.Valid || !strings.Contains(got.SQL, "zone = 'us-east1-b'") {
+		t.Errorf("expected rendering with the enum default, got %+v", got)
+	}
+}
@@ -0,0 +1,330 @@
+package mcp_server //nolint:revive // fine for now
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/stackql/stackql/pkg/mcp_server/dto"
+)
+
+// Rankers for query_library_search.  Both run offline over the cached
+// catalogue.  The lexical ranker is the original substring scorer.  The
+// bm25 ranker indexes the catalogue fields when the catalogue (re)loads and
+// matches stemmed intent terms, expanded through a small table of cloud
+// vocabulary, so that loosely phrased intents ("who can read my buckets")
+// still reach the relevant entries.  Either way the hard filters of
+// entryPassesFilters apply first, and every hit explains its score.
+
+const (
+	queryLibraryRankerLexical = "lexical"
+	queryLibraryRankerBM25    = "bm25"
+
+	// bm25K1 and bm25B are the customary BM25 saturation and length
+	// normalisation constants.
+	bm25K1 = 1.2
+	bm25B  = 0.75
+	// bm25ExpansionWeight discounts a match made through a synonym.
+	bm25ExpansionWeight = 0.5
+	// bm25MissThreshold is the bm25 counterpart of queryLibraryMissThreshold.
+	bm25MissThreshold = 2.0
+	// queryLibraryPhraseBonus rewards an intent aligned with a declared
+	// intent keyword as a whole.
+	queryLibraryPhraseBonus = 6
+
+	queryLibraryPhraseField = "phrase"
+)
+
+// queryLibraryRanker scores catalogue entries against an intent.
+type queryLibraryRanker interface {
+	name() string
+	// prepare (re)builds any index over the merged catalogue; it is called
+	// whenever the central or a local index is reloaded.
+	prepare(entries []libIndexEntry)
+	// score scores one entry, explaining each contribution.
+	score(e libIndexEntry, intent string) (float64, []dto.QueryLibraryMatchDTO)
+	// missThreshold is the top score below which the miss path fires.
+	missThreshold() float64
+}
+
+// newQueryLibraryRanker returns the named ranker, lexical by default.
+func newQueryLibraryRanker(name string) queryLibraryRanker {
+	if strings.EqualFold(name, queryLibraryRankerBM25) {
+		return &bm25Ranker{}
+	}
+	return lexicalRanker{}
+}
+
+// isLegalQueryLibraryRanker reports whether name selects a ranker.
+func isLegalQueryLibraryRanker(name string) bool {
+	switch strings.ToLower(name) {
+	case "", queryLibraryRankerLexical, queryLibraryRankerBM25:
+		return true
+	}
+	return false
+}
+
+// libField is one searchable field of a catalogue entry and its weight.
+type libField struct {
+	name   string
+	weight float64
+	values func(e libIndexEntry) []string
+}
+
+// libFields are the searchable fields, weighted alike for both rankers.
+//
+//nolint:gochecknoglobals // immutable lookup
+var libFields = []libField{
+	{"intent_keywords", 4, func(e libIndexEntry) []string { return e.IntentKeywords }},
+	{"title", 3, func(e libIndexEntry) []string { return []string{e.Title} }},
+	{"keywords", 2.5, func(e libIndexEntry) []string { return e.Keywords }},
+	{"tags", 2, func(e libIndexEntry) []string { return e.Tags }},
+	{"services", 2, func(e libIndexEntry) []string { return e.Services }},
+	{"providers", 1.5, func(e libIndexEntry) []string { return e.Providers }},
+	{"description", 1, func(e libIndexEntry) []string { return []string{e.Description} }},
+}
+
+// phraseMatch returns the intent keyword aligned with the whole intent.
+func phraseMatch(e libIndexEntry, intentLower string) (string, bool) {
+	if intentLower == "" {
+		return "", false
+	}
+	for _, k := range e.IntentKeywords {
+		kl := strings.ToLower(k)
+		if strings.Contains(intentLower, kl) || strings.Contains(kl, intentLower) {
+			return k, true
+		}
+	}
+	return "", false
+}
+
+// lexicalRanker wraps scoreEntry.
+type lexicalRanker struct{}
+
+func (lexicalRanker) name() string { return queryLibraryRankerLexical }
+
+func (lexicalRanker) prepare([]libIndexEntry) {}
+
+func (lexicalRanker) score(e libIndexEntry, intent string) (float64, []dto.QueryLibraryMatchDTO) {
+	return scoreEntry(e, tokenizeIntent(intent), strings.ToLower(strings.TrimSpace(intent)))
+}
+
+func (lexicalRanker) missThreshold() float64 { return queryLibraryMissThreshold }
+
+// stemTerm strips common English inflections, enough to fold "buckets",
+// "policies" and "running" onto the same term as their stems.
+func stemTerm(t string) string {
+	switch {
+	case len(t) > 4 && strings.HasSuffix(t, "ies"):
+		return t[:len(t)-3] + "y"
+	case len(t) > 5 && strings.HasSuffix(t, "ing"):
+		t = t[:len(t)-3]
+	case len(t) > 4 && strings.HasSuffix(t, "ed"):
+		t = t[:len(t)-2]
+	case len(t) > 3 && strings.HasSuffix(t, "s") && !strings.HasSuffix(t, "ss") && !strings.HasSuffix(t, "us"):
+		return t[:len(t)-1]
+	default:
+		return t
+	}
+	// Undouble the consonant of "running" or "tagged".
+	if n := len(t); n > 2 && t[n-1] == t[n-2] && !strings.ContainsRune("aeiouls", rune(t[n-1])) {
+		t = t[:n-1]
+	}
+	return t
+}
+
+// analyse tokenises and stems text, keeping stopwords out.
+func analyse(text string) []string {
+	tokens := tokenizeIntent(text)
+	for i, t := range tokens {
+		tokens[i] = stemTerm(t)
+	}
+	return tokens
+}
+
+// queryLibraryExpansions maps a stemmed intent term to related catalogue
+// vocabulary.  Deliberately small: it bridges everyday phrasing to the
+// words the library is written in, and is no substitute for good
+// intent_keywords.
+//
+//nolint:gochecknoglobals // immutable lookup
+var queryLibraryExpansions = map[string][]string{
+	"who":        {"access", "permission", "principal", "iam", "policy"},
+	"read":       {"access", "permission", "public", "policy"},
+	"access":     {"permission", "public", "policy", "security"},
+	"permission": {"access", "iam", "policy"},
+	"public":     {"access", "security"},
+	"secure":     {"security", "encryption"},
+	"encrypt":    {"encryption", "security"},
+	"bucket":     {"s3", "storage"},
+	"storage":    {"s3", "bucket"},
+	"vm":         {"instance", "compute", "ec2"},
+	"server":     {"instance", "compute", "ec2"},
+	"machine":    {"instance", "compute", "ec2"},
+	"account":    {"subscription", "project"},
+	"location":   {"region", "zone"},
+	"log":        {"cloudwatch", "retention"},
+	"keep":       {"retention"},
+	"retain":     {"retention"},
+	"org":        {"organization", "folder", "project"},
+	"list":       {"inventory", "enumerate"},
+	"enumerate":  {"inventory", "list"},
+	"find":       {"inventory", "list"},
+	"which":      {"list", "inventory"},
+}
+
+// queryTerm is one analysed intent term, either typed by the caller or
+// reached through an expansion of the term named by from.
+type queryTerm struct {
+	term   string
+	from   string
+	weight float64
+}
+
+// expandIntent analyses the intent into weighted, de-duplicated terms.
+func expandIntent(intent string) []queryTerm {
+	seen := map[string]bool{}
+	var rv []queryTerm
+	tokens := analyse(intent)
+	for _, t := range tokens {
+		if !seen[t] {
+			seen[t] = true
+			rv = append(rv, queryTerm{term: t, weight: 1})
+		}
+	}
+	for _, t := range tokens {
+		for _, x := range queryLibraryExpansions[t] {
+			x = stemTerm(x)
+			if !seen[x] {
+				seen[x] = true
+				rv = append(rv, queryTerm{term: x, from: t, weight: bm25ExpansionWeight})
+			}
+		}
+	}
+	return rv
+}
+
+// bm25Doc holds the analysed fields of one entry.
+type bm25Doc struct {
+	tf  []map[string]int
+	len []int
+}
+
+// bm25Ranker is a field weighted BM25 ranker.  Document frequencies and
+// average field lengths are taken over the merged catalogue by prepare.
+type bm25Ranker struct {
+	docs   map[string]bm25Doc
+	df     map[string]int
+	avgLen []float64
+	n      int
+}
+
+func (r *bm25Ranker) name() string { return queryLibraryRankerBM25 }
+
+func (r *bm25Ranker) missThreshold() float64 { return bm25MissThreshold }
+
+func analyseEntry(e libIndexEntry) bm25Doc {
+	d := bm25Doc{tf: make([]map[string]int, len(libFields)), len: make([]int, len(libFields))}
+	for i, f := range libFields {
+		d.tf[i] = map[string]int{}
+		for _, v := range f.values(e) {
+			for _, t := range analyse(v) {
+				d.tf[i][t]++
+				d.len[i]++
+			}
+		}
+	}
+	return d
+}
+
+func (r *bm25Ranker) prepare(entries []libIndexEntry) {
+	r.docs = make(map[string]bm25Doc, len(entries))
+	r.df = map[string]int{}
+	r.avgLen = make([]float64, len(libFields))
+	r.n = len(entries)
+	for _, e := range entries {
+		d := analyseEntry(e)
+		r.docs[e.ID] = d
+		terms := map[string]bool{}
+		for i := range libFields {
+			r.avgLen[i] += float64(d.len[i])
+			for t := range d.tf[i] {
+				terms[t] = true
+			}
+		}
+		for t := range terms {
+			r.df[t]++
+		}
+	}
+	for i := range r.avgLen {
+		if r.n > 0 {
+			r.avgLen[i] /= float64(r.n)
+		}
+	}
+}
+
+func (r *bm25Ranker) idf(term string) float64 {
+	df := float64(r.df[term])
+	return math.Log(1 + (float64(r.n)-df+0.5)/(df+0.5))
+}
+
+func (r *bm25Ranker) score(e libIndexEntry, intent string) (float64, []dto.QueryLibraryMatchDTO) {
+	d, ok := r.docs[e.ID]
+	if !ok {
+		d = analyseEntry(e)
+	}
+	var total float64
+	var matches []dto.QueryLibraryMatchDTO
+	for _, q := range expandIntent(intent) {
+		if r.df[q.term] == 0 {
+			continue
+		}
+		idf := r.idf(q.term)
+		for i, f := range libFields {
+			tf := float64(d.tf[i][q.term])
+			if tf == 0 {
+				continue
+			}
+			norm := 1.0
+			if r.avgLen[i] > 0 {
+				norm = 1 - bm25B + bm25B*float64(d.len[i])/r.avgLen[i]
+			}
+			s := q.weight * f.weight * idf * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+			total += s
+			m := dto.QueryLibraryMatchDTO{Field: f.name, Term: q.term, Score: roundScore(s)}
+			if q.from != "" {
+				m.Term, m.Via = q.from, q.term
+			}
+			matches = append(matches, m)
+		}
+	}
+	if k, ok := phraseMatch(e, strings.ToLower(strings.TrimSpace(intent))); ok {
+		total += queryLibraryPhraseBonus
+		matches = append(matches, dto.QueryLibraryMatchDTO{Field: queryLibraryPhraseField, Term: k, Score: queryLibraryPhraseBonus})
+	}
+	return roundScore(total), sortMatches(matches)
+}
+
+// roundScore keeps scores and explanations readable.
+func roundScore(s float64) float64 {
+	return math.Round(s*100) / 100
+}
+
+// sortMatches orders an explanation by descending contribution.
+func sortMatches(matches []dto.QueryLibraryMatchDTO) []dto.QueryLibraryMatchDTO {
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	return matches
+}
+
+// explainHit renders an explanation compactly for the text table.
+func explainHit(matches []dto.QueryLibraryMatchDTO) string {
+	parts := make([]string, 0, len(matches))
+	for _, m := range matches {
+		term := m.Term
+		if m.Via != "" {
+			term += "~" + m.Via
+		}
+		parts = append(parts, m.Field+":"+term)
+	}
+	return strings.Join(parts, ", ")
+}
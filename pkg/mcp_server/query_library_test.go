@@ -143,6 +143,64 @@ func TestQueryLibrary_SearchRanksIntent(t *testing.T) {
 	if out.SourceTier != querySourceTierPrimary {
 		t.Errorf("expected primary tier, got %q", out.SourceTier)
 	}
+	if why := explainHit(out.Hits[0].Explanation); !strings.HasPrefix(why, "phrase:list enabled aws regions") {
+		t.Errorf("expected the phrase match to lead the explanation, got %q", why)
+	}
+}
+
+func TestQueryLibrary_BM25RanksLooseIntent(t *testing.T) {
+	ctx := context.Background()
+	in := dto.QueryLibrarySearchInput{Intent: "who can read my buckets"}
+	lexical := newClientForFixture(t, newTestFixture())
+	out, err := lexical.search(ctx, in, false)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if !out.Miss || out.Ranker != queryLibraryRankerLexical {
+		t.Fatalf("expected the lexical ranker to miss the loose intent, got %+v", out)
+	}
+
+	f := newTestFixture()
+	srv := httptest.NewServer(f.handler())
+	t.Cleanup(srv.Close)
+	client := newQueryLibraryClient(QueryLibraryConfig{
+		BaseURL: srv.URL + "/docs/query-library", FallbackURL: srv.URL + "/nonexistent", Ranker: "bm25",
+	})
+	out, err = client.search(ctx, in, false)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if out.Miss || out.Ranker != queryLibraryRankerBM25 || len(out.Hits) == 0 || out.Hits[0].ID != "aws/s3/bucket-detail" {
+		t.Fatalf("expected the bucket security entry as a confident hit, got %+v", out)
+	}
+	var sum float64
+	var expanded bool
+	for _, m := range out.Hits[0].Explanation {
+		sum += m.Score
+		expanded = expanded || (m.Term == "read" && m.Via != "")
+	}
+	if !expanded {
+		t.Errorf("expected the explanation to show read matching through a related term, got %+v", out.Hits[0].Explanation)
+	}
+	if diff := sum - out.Hits[0].Score; diff > 0.05 || diff < -0.05 {
+		t.Errorf("explanation sums to %.2f, score is %.2f", sum, out.Hits[0].Score)
+	}
+
+	// The index is rebuilt when the catalogue changes build.
+	f.buildID = "build-002"
+	f.index.Entries = append(f.index.Entries, libIndexEntry{
+		ID: "aws/iam/bucket-readers", Title: "Principals with read access to a bucket",
+		Providers: []string{"aws"}, Services: []string{"iam", "s3"}, Tags: []string{"security"},
+		IntentKeywords: []string{"who can read a bucket"},
+	})
+	client.fetchedAt = time.Time{}
+	out, err = client.search(ctx, in, false)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(out.Hits) == 0 || out.Hits[0].ID != "aws/iam/bucket-readers" {
+		t.Fatalf("expected the new entry to rank first after the rebuild, got %+v", out.Hits)
+	}
 }
 
 func TestQueryLibrary_SearchExcludesMutationsByDefault(t *testing.T) {
@@ -0,0 +1,171 @@
+package mcp_server //nolint:revive // fine for now
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sirupsen/logrus"
+
+	"github.com/stackql/stackql/pkg/mcp_server/dto"
+	"github.com/stackql/stackql/pkg/mcp_server/render"
+)
+
+const relationResourceURIStem = "stackql://views/"
+
+// relationKindLabels are the human readable relation kinds.
+//
+//nolint:gochecknoglobals // immutable lookup
+var relationKindLabels = map[string]string{
+	"view":              "view",
+	"materialized_view": "materialized view",
+	"table":             "table",
+}
+
+// relationResourceURI returns the resource URI of the named relation.
+func relationResourceURI(name string) string {
+	return relationResourceURIStem + url.PathEscape(name)
+}
+
+// renderRelation renders the resource body: the DDL and column shape.
+func renderRelation(r dto.StoredRelationDTO) string {
+	label, ok := relationKindLabels[r.Kind]
+	if !ok {
+		label = r.Kind
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\nA user defined %s; query it as `SELECT * FROM %s`.\n\n", r.Name, label, r.Name)
+	b.WriteString("## DDL\n\n```sql\n")
+	b.WriteString(strings.TrimSpace(r.DDL))
+	b.WriteString("\n```\n\n## Columns\n\n")
+	if len(r.Columns) == 0 {
+		b.WriteString("The column shape could not be inferred without executing the query.\n")
+		return b.String()
+	}
+	rows := make([]map[string]any, 0, len(r.Columns))
+	for _, c := range r.Columns {
+		rows = append(rows, map[string]any{"name": c.Name, "type": c.Type})
+	}
+	b.WriteString(render.RenderTable(rows))
+	return b.String()
+}
+
+// relationPublisher keeps the stackql://views/{name} resources in step
+// with the backend's stored relations.  Adding, replacing or removing a
+// resource makes the SDK notify every session of resources/list_changed,
+// so only relations whose definition differs from that published are
+// touched.
+type relationPublisher struct {
+	server *mcp.Server
+	cfg    *Config
+	lister RelationLister
+	logger *logrus.Logger
+
+	mu        sync.Mutex
+	published map[string]dto.StoredRelationDTO
+}
+
+func newRelationPublisher(
+	server *mcp.Server, cfg *Config, lister RelationLister, logger *logrus.Logger,
+) *relationPublisher {
+	return &relationPublisher{
+		server:    server,
+		cfg:       cfg,
+		lister:    lister,
+		logger:    logger,
+		published: make(map[string]dto.StoredRelationDTO),
+	}
+}
+
+// sync reconciles the published resources with the current relations.
+func (p *relationPublisher) sync(ctx context.Context) error {
+	relations, err := p.lister.ListRelations(ctx)
+	if err != nil {
+		return fmt.Errorf("list stored relations: %w", err)
+	}
+	current := make(map[string]dto.StoredRelationDTO, len(relations))
+	for _, r := range relations {
+		if r.Name == "" || !p.cfg.IsResourceEnabled(r.Name) {
+			continue
+		}
+		current[r.Name] = r
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var removed []string
+	for name := range p.published {
+		if _, ok := current[name]; !ok {
+			removed = append(removed, relationResourceURI(name))
+			delete(p.published, name)
+		}
+	}
+	if len(removed) > 0 {
+		sort.Strings(removed)
+		p.server.RemoveResources(removed...)
+	}
+	names := make([]string, 0, len(current))
+	for name := range current {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		r := current[name]
+		if prev, ok := p.published[name]; ok && reflect.DeepEqual(prev, r) {
+			continue
+		}
+		p.publish(r)
+		p.published[name] = r
+	}
+	return nil
+}
+
+// publish adds, or replaces, the resource of r.  Called with mu held.
+func (p *relationPublisher) publish(r dto.StoredRelationDTO) {
+	uri := relationResourceURI(r.Name)
+	body := renderRelation(r)
+	label, ok := relationKindLabels[r.Kind]
+	if !ok {
+		label = r.Kind
+	}
+	p.server.AddResource(
+		&mcp.Resource{
+			Name:        r.Name,
+			Title:       r.Name,
+			Description: fmt.Sprintf("User defined %s %s: DDL and column shape.", label, r.Name),
+			URI:         uri,
+			MIMEType:    defaultResourceMIMEType,
+		},
+		func(_ context.Context, _ *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+			return &mcp.ReadResourceResult{
+				Contents: []*mcp.ResourceContents{{
+					URI:      uri,
+					MIMEType: defaultResourceMIMEType,
+					Text:     body,
+				}},
+			}, nil
+		},
+	)
+}
+
+// watch re-syncs every interval until ctx is done.  Failures are logged
+// and the published resources left as they were.
+func (p *relationPublisher) watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.sync(ctx); err != nil {
+				p.logger.Warnf("stored relation resources not refreshed: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package mcp_server //nolint:testpackage,revive // exercise internal wiring
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/stackql/stackql/pkg/mcp_server/dto"
+)
+
+type relationBackend struct {
+	testBackend
+	mu        sync.Mutex
+	relations []dto.StoredRelationDTO
+}
+
+func (b *relationBackend) ListRelations(_ context.Context) ([]dto.StoredRelationDTO, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]dto.StoredRelationDTO(nil), b.relations...), nil
+}
+
+func (b *relationBackend) set(relations ...dto.StoredRelationDTO) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.relations = relations
+}
+
+func listResourceURIs(t *testing.T, cs *mcp.ClientSession) map[string]bool {
+	t.Helper()
+	res, err := cs.ListResources(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("ListResources: %v", err)
+	}
+	rv := map[string]bool{}
+	for _, r := range res.Resources {
+		rv[r.URI] = true
+	}
+	return rv
+}
+
+func TestRelations_PublishedAndKeptInStep(t *testing.T) {
+	be := &relationBackend{}
+	be.set(
+		dto.StoredRelationDTO{
+			Name:    "running_vms",
+			Kind:    "view",
+			DDL:     "create view running_vms as select name from google.compute.instances where status = 'RUNNING'",
+			Columns: []dto.RelationColumnDTO{{Name: "name", Type: "text"}},
+		},
+		dto.StoredRelationDTO{Name: "snapshot", Kind: "table", DDL: "create table snapshot (id int)"},
+	)
+	cfg := DefaultConfig()
+	cfg.Server.Audit.Disabled = true
+	mcpSrv, err := newMCPServer(cfg, be, nil)
+	if err != nil {
+		t.Fatalf("newMCPServer: %v", err)
+	}
+	simple := mcpSrv.(*simpleMCPServer)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	t1, t2 := mcp.NewInMemoryTransports()
+	if _, err := simple.server.Connect(ctx, t1, nil); err != nil {
+		t.Fatalf("server connect: %v", err)
+	}
+	changed := make(chan struct{}, 8)
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "v0"}, &mcp.ClientOptions{
+		ResourceListChangedHandler: func(context.Context, *mcp.ResourceListChangedRequest) {
+			changed <- struct{}{}
+		},
+	})
+	cs, err := client.Connect(ctx, t2, nil)
+	if err != nil {
+		t.Fatalf("client connect: %v", err)
+	}
+	t.Cleanup(func() { _ = cs.Close() })
+
+	uris := listResourceURIs(t, cs)
+	if !uris["stackql://views/running_vms"] || !uris["stackql://views/snapshot"] {
+		t.Fatalf("expected relation resources, got %v", uris)
+	}
+	read, err := cs.ReadResource(ctx, &mcp.ReadResourceParams{URI: "stackql://views/running_vms"})
+	if err != nil {
+		t.Fatalf("ReadResource: %v", err)
+	}
+	body := read.Contents[0].Text
+	for _, want := range []string{"status = 'RUNNING'", "| name | text |"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("resource body lacks %q:\n%s", want, body)
+		}
+	}
+
+	if err := simple.relations.sync(ctx); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	select {
+	case <-changed:
+		t.Error("an unchanged catalogue must not notify")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	be.set(dto.StoredRelationDTO{
+		Name: "running_vms",
+		Kind: "view",
+		DDL:  "create view running_vms as select name, zone from google.compute.instances",
+	})
+	if err := simple.relations.sync(ctx); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	select {
+	case <-changed:
+	case <-ctx.Done():
+		t.Fatal("expected resources/list_changed after the DDL changed")
+	}
+	uris = listResourceURIs(t, cs)
+	if uris["stackql://views/snapshot"] {
+		t.Error("a dropped relation should no longer be published")
+	}
+	read, err = cs.ReadResource(ctx, &mcp.ReadResourceParams{URI: "stackql://views/running_vms"})
+	if err != nil {
+		t.Fatalf("ReadResource: %v", err)
+	}
+	if !strings.Contains(read.Contents[0].Text, "name, zone") {
+		t.Errorf("expected the redefined DDL, got:\n%s", read.Contents[0].Text)
+	}
+}
+
+func TestRelations_DisabledPublishesNothing(t *testing.T) {
+	be := &relationBackend{}
+	be.set(dto.StoredRelationDTO{Name: "v", Kind: "view", DDL: "create view v as select 1"})
+	cfg := DefaultConfig()
+	cfg.StoredRelations.Disabled = true
+	cs := connectInProcess(t, cfg, be)
+	if uris := listResourceURIs(t, cs); uris["stackql://views/v"] {
+		t.Error("relations published despite stored_relations.disabled")
+	}
+}
@@ -95,6 +95,17 @@ func firstNonValidField(rv reflect.Value) any {
 	return rv.Interface()
 }
 
+// cellValue formats one value for display: nested values, such as parsed
+// JSON columns, as compact JSON and anything else unwrapped.
+func cellValue(v any) any {
+	v = unwrap(v)
+	switch v.(type) {
+	case map[string]any, []any:
+		return JSONValue(v)
+	}
+	return v
+}
+
 // RenderTable renders a uniform multi-row result set as a markdown table.
 // Column order is stable: the union of keys across all rows, sorted alphabetically.
 func RenderTable(rows []map[string]any) string {
@@ -130,7 +141,7 @@ func RenderKV(title string, records []map[string]any) string {
 		sb.WriteString(fmt.Sprintf("## Record %d\n\n", i+1))
 		keys := sortedKeys(rec)
 		for _, k := range keys {
-			sb.WriteString(fmt.Sprintf("%s: %v\n", k, unwrap(rec[k])))
+			sb.WriteString(fmt.Sprintf("%s: %v\n", k, cellValue(rec[k])))
 		}
 		if i < len(records)-1 {
 			sb.WriteString("\n")
@@ -189,7 +200,7 @@ func dataRow(columns []string, row map[string]any) string {
 			sb.WriteString("|  ")
 			continue
 		}
-		sb.WriteString(fmt.Sprintf("| %v ", unwrap(v)))
+		sb.WriteString(fmt.Sprintf("| %v ", cellValue(v)))
 	}
 	sb.WriteString("|")
 	return sb.String()
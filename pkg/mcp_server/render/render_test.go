@@ -130,3 +130,16 @@ func TestUnwrapRows_CollapsesNullableWrappers(t *testing.T) {
 		}
 	}
 }
+
+func TestRenderTable_NestedValuesAsJSON(t *testing.T) {
+	rows := []map[string]any{{
+		"tags": map[string]any{"env": "prod"},
+		"ids":  []any{"a", "b"},
+	}}
+	got := render.RenderTable(rows)
+	for _, want := range []string{`| ["a","b"] |`, `| {"env":"prod"} |`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in %q", want, got)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package mcp_server //nolint:revive // fine for now
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stackql/stackql/pkg/mcp_server/dto"
+)
+
+// stashedResult is a materialised SELECT result awaiting fetch_more.
+type stashedResult struct {
+	sessionID string
+	rows      []map[string]any
+	schema    *dto.RowSchemaDTO
+	// totalKnown is false where row_limit cut the result short.
+	totalKnown bool
+	expires    time.Time
+}
+
+// resultStash holds the unserved remainder of paged results.  A result
+// is visible only to the session which produced it, lives for ttl after
+// last access, and the least recently used result is evicted beyond max.
+type resultStash struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	max     int
+	entries map[string]*stashedResult
+	now     func() time.Time
+}
+
+func newResultStash(ttl time.Duration, maxEntries int) *resultStash {
+	return &resultStash{
+		ttl:     ttl,
+		max:     maxEntries,
+		entries: make(map[string]*stashedResult),
+		now:     time.Now,
+	}
+}
+
+// sweep drops expired results and, where still over capacity, the least
+// recently used.  Called with mu held.
+func (s *resultStash) sweep(now time.Time) {
+	for id, e := range s.entries {
+		if !now.Before(e.expires) {
+			delete(s.entries, id)
+		}
+	}
+	for len(s.entries) > s.max {
+		var oldestID string
+		var oldest time.Time
+		for id, e := range s.entries {
+			if oldestID == "" || e.expires.Before(oldest) {
+				oldestID, oldest = id, e.expires
+			}
+		}
+		delete(s.entries, oldestID)
+	}
+}
+
+// put stashes rows for sessionID, returning the stash id.
+func (s *resultStash) put(
+	sessionID string, rows []map[string]any, schema *dto.RowSchemaDTO, totalKnown bool,
+) (string, error) {
+	raw := make([]byte, 16) //nolint:mnd // 128 bit id
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate cursor: %w", err)
+	}
+	id := base64.RawURLEncoding.EncodeToString(raw)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.entries[id] = &stashedResult{
+		sessionID:  sessionID,
+		rows:       rows,
+		schema:     schema,
+		totalKnown: totalKnown,
+		expires:    now.Add(s.ttl),
+	}
+	s.sweep(now)
+	return id, nil
+}
+
+// get returns the result stashed under id for sessionID, extending
+// its life.  Results of other sessions are indistinguishable from
+// expired ones.
+func (s *resultStash) get(sessionID, id string) (*stashedResult, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.sweep(now)
+	e, ok := s.entries[id]
+	if !ok || e.sessionID != sessionID {
+		return nil, false
+	}
+	e.expires = now.Add(s.ttl)
+	return e, true
+}
+
+// encodeCursor renders the opaque cursor for the page of
+// stashed result id starting at offset.  A cursor names a
+// fixed position, so retrying fetch_more is idempotent.
+func encodeCursor(id string, offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(id + "." + strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (string, int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", 0, fmt.Errorf("malformed cursor")
+	}
+	id, offsetStr, found := strings.Cut(string(raw), ".")
+	offset, err := strconv.Atoi(offsetStr)
+	if !found || id == "" || err != nil || offset < 0 {
+		return "", 0, fmt.Errorf("malformed cursor")
+	}
+	return id, offset, nil
+}
+
+// pageOf returns the page of rows starting at offset, with the
+// cursor of the next page where rows remain.  Every page carries
+// the schema of the result.
+func pageOf(
+	id string, rows []map[string]any, schema *dto.RowSchemaDTO, totalKnown bool, offset, pageSize int,
+) dto.QueryResultDTO {
+	end := offset + pageSize
+	if end > len(rows) {
+		end = len(rows)
+	}
+	rv := dto.QueryResultDTO{Rows: rows[offset:end], Offset: offset, Schema: schema}
+	if totalKnown {
+		total := len(rows)
+		rv.TotalRows = &total
+	}
+	if end < len(rows) {
+		rv.Cursor = encodeCursor(id, end)
+	}
+	return rv
+}
+
+// pageFooter describes the page's position in the result
+// for the markdown rendering, and how to continue.
+func pageFooter(page dto.QueryResultDTO) string {
+	if page.Cursor == "" && page.Offset == 0 {
+		return ""
+	}
+	first, last := page.Offset+1, page.Offset+len(page.Rows)
+	var b strings.Builder
+	if page.TotalRows != nil {
+		fmt.Fprintf(&b, "\n\nRows %d-%d of %d.", first, last, *page.TotalRows)
+	} else {
+		fmt.Fprintf(&b, "\n\nRows %d-%d; total unknown, the result was cut short by row_limit.", first, last)
+	}
+	if page.Cursor != "" {
+		fmt.Fprintf(&b, " Call fetch_more with cursor %q for the next page.", page.Cursor)
+	}
+	return b.String()
+}
+
+// firstPage pages a fresh result, stashing it where it spans more
+// than one page.  rowLimit is the caller's row_limit, a result of that
+// size being presumed to be cut short.  schema, where known, describes
+// the rows.
+func (s *resultStash) firstPage(
+	sessionID string, rows []map[string]any, schema *dto.RowSchemaDTO, rowLimit, pageSize int,
+) (dto.QueryResultDTO, error) {
+	totalKnown := rowLimit <= 0 || len(rows) < rowLimit
+	if len(rows) <= pageSize {
+		return pageOf("", rows, schema, totalKnown, 0, pageSize), nil
+	}
+	id, err := s.put(sessionID, rows, schema, totalKnown)
+	if err != nil {
+		return dto.QueryResultDTO{}, err
+	}
+	return pageOf(id, rows, schema, totalKnown, 0, pageSize), nil
+}
+
+// nextPage serves the page named by cursor.
+func (s *resultStash) nextPage(sessionID, cursor string, pageSize int) (dto.QueryResultDTO, error) {
+	id, offset, err := decodeCursor(cursor)
+	if err != nil {
+		return dto.QueryResultDTO{}, err
+	}
+	e, ok := s.get(sessionID, id)
+	if !ok {
+		return dto.QueryResultDTO{}, fmt.Errorf("cursor expired or unknown; rerun the query")
+	}
+	if offset > len(e.rows) {
+		return dto.QueryResultDTO{}, fmt.Errorf("malformed cursor")
+	}
+	return pageOf(id, e.rows, e.schema, e.totalKnown, offset, pageSize), nil
+}
@@ -0,0 +1,62 @@
+package mcp_server //nolint:testpackage,revive // exercise internal wiring
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResultStash_ExpiryAndSessionScope(t *testing.T) {
+	now := time.Now()
+	stash := newResultStash(time.Minute, 2)
+	stash.now = func() time.Time { return now }
+	rows := []map[string]any{{"n": 0}, {"n": 1}, {"n": 2}}
+
+	page, err := stash.firstPage("session-a", rows, nil, 0, 1)
+	if err != nil {
+		t.Fatalf("firstPage: %v", err)
+	}
+	if _, err := stash.nextPage("session-b", page.Cursor, 1); err == nil {
+		t.Error("a cursor must not resolve in another session")
+	}
+	next, err := stash.nextPage("session-a", page.Cursor, 1)
+	if err != nil {
+		t.Fatalf("nextPage: %v", err)
+	}
+	if retried, _ := stash.nextPage("session-a", page.Cursor, 1); retried.Cursor != next.Cursor {
+		t.Error("retrying a cursor should return the same page")
+	}
+
+	now = now.Add(59 * time.Second)
+	if _, err := stash.nextPage("session-a", next.Cursor, 1); err != nil {
+		t.Fatalf("access within the TTL should succeed and extend it: %v", err)
+	}
+	now = now.Add(61 * time.Second)
+	if _, err := stash.nextPage("session-a", next.Cursor, 1); err == nil {
+		t.Error("expected the stashed result to have expired")
+	}
+}
+
+func TestResultStash_EvictsLeastRecentlyUsed(t *testing.T) {
+	now := time.Now()
+	stash := newResultStash(time.Hour, 2)
+	stash.now = func() time.Time { return now }
+	rows := []map[string]any{{"n": 0}, {"n": 1}}
+
+	var cursors []string
+	for i := 0; i < 3; i++ {
+		page, err := stash.firstPage("s", rows, nil, 0, 1)
+		if err != nil {
+			t.Fatalf("firstPage: %v", err)
+		}
+		cursors = append(cursors, page.Cursor)
+		now = now.Add(time.Second)
+	}
+	if _, err := stash.nextPage("s", cursors[0], 1); err == nil {
+		t.Error("expected the oldest result to have been evicted")
+	}
+	for _, cursor := range cursors[1:] {
+		if _, err := stash.nextPage("s", cursor, 1); err != nil {
+			t.Errorf("expected recent result to survive: %v", err)
+		}
+	}
+}
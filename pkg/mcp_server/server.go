@@ -14,6 +14,8 @@ import (
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 
+	"github.com/stackql/stackql/pkg/mcp_server/audit"
+	"github.com/stackql/stackql/pkg/mcp_server/auth"
 	"github.com/stackql/stackql/pkg/mcp_server/dto"
 	"github.com/stackql/stackql/pkg/mcp_server/policy"
 	"github.com/stackql/stackql/pkg/mcp_server/render"
@@ -38,6 +40,11 @@ type simpleMCPServer struct {
 	backend   Backend
 	logger    *logrus.Logger
 	auditSink sink.Sink
+	// authenticator guards the HTTP transport; nil means unauthenticated.
+	authenticator auth.Authenticator
+	// relations publishes stored relations as resources; nil where the
+	// backend does not list them.
+	relations *relationPublisher
 
 	server *mcp.Server
 
@@ -50,20 +57,30 @@ type simpleMCPServer struct {
 	servers []io.Closer // Track all running servers for cleanup
 }
 
-func (s *simpleMCPServer) runHTTPServer(server *mcp.Server, config *Config) error {
-	address := config.GetServerAddress()
+// newHTTPHandler returns the streamable HTTP handler behind the
+// authentication and logging middleware.
+func (s *simpleMCPServer) newHTTPHandler(server *mcp.Server) http.Handler {
 	handler := mcp.NewStreamableHTTPHandler(func(req *http.Request) *mcp.Server {
 		return server
 	}, nil)
+	return loggingHandler(auth.Middleware(s.authenticator, handler), s.logger)
+}
 
-	handlerWithLogging := loggingHandler(handler, s.logger)
+func (s *simpleMCPServer) runHTTPServer(server *mcp.Server, config *Config) error {
+	address := config.GetServerAddress()
+	handlerWithLogging := s.newHTTPHandler(server)
 
 	s.logger.Debugf("MCP server listening on %s", address)
 
 	//nolint:gosec // TODO: find viable alternative to http.ListenAndServe
 	if config.Server.TLSCertFile != "" && config.Server.TLSKeyFile != "" {
 		s.logger.Infof("Starting HTTPS server on %s", address)
-		if err := http.ListenAndServeTLS(address, config.Server.TLSCertFile, config.Server.TLSKeyFile, handlerWithLogging); err != nil {
+		httpsServer := &http.Server{Addr: address, Handler: handlerWithLogging}
+		if s.authenticator != nil {
+			// Client certificates are requested only where mTLS is configured.
+			httpsServer.TLSConfig = s.authenticator.TLSConfig()
+		}
+		if err := httpsServer.ListenAndServeTLS(config.Server.TLSCertFile, config.Server.TLSKeyFile); err != nil {
 			s.logger.Errorf("HTTPS Server failed: %v", err)
 			return err
 		}
@@ -123,7 +140,8 @@ func resolveRenderFormat(cfg *Config, requested string) (string, error) {
 func textForFormat(format string, v any, markdown func() string) string {
 	if format == render.FormatJSON {
 		if q, isQueryResult := v.(dto.QueryResultDTO); isQueryResult {
-			v = dto.QueryResultDTO{Rows: render.UnwrapRows(q.Rows)}
+			q.Rows = render.UnwrapRows(q.Rows)
+			v = q
 		}
 		return render.JSONValue(v)
 	}
@@ -157,6 +175,24 @@ func initAuditSink(cfg *Config, logger *logrus.Logger) (sink.Sink, error) {
 		fileCfg.DefaultFilename = mcpDefaultAuditFilename
 	}
 	switch cfg.Server.Audit.Sink {
+	case "syslog":
+		s, err := sink.NewSyslogSink(cfg.Server.Audit.Syslog)
+		if err != nil {
+			return nil, fmt.Errorf("audit syslog sink: %w", err)
+		}
+		return s, nil
+	case "http":
+		httpCfg := cfg.Server.Audit.HTTP
+		// The strict modes report an event's audit failure on its own
+		// tool call, so each event is delivered, or spooled, at once.
+		if mode := cfg.Server.Audit.GetFailureMode(); mode == audit.FailureModeStrict || mode == audit.FailureModeStrictMutations {
+			httpCfg.Synchronous = true
+		}
+		s, err := sink.NewHTTPSink(httpCfg)
+		if err != nil {
+			return nil, fmt.Errorf("audit http sink: %w", err)
+		}
+		return s, nil
 	case "", "file":
 		s, err := sink.NewFileSink(fileCfg)
 		if err != nil {
@@ -187,12 +223,34 @@ func newMCPServer(config *Config, backend Backend, logger *logrus.Logger) (MCPSe
 		logger.SetLevel(logrus.InfoLevel)
 	}
 
+	var authenticator auth.Authenticator
+	if config.Server.Auth.IsEnabled() {
+		var authErr error
+		authenticator, authErr = auth.NewAuthenticator(config.Server.Auth, policy.IsLegalMode)
+		if authErr != nil {
+			return nil, authErr
+		}
+	}
+
 	sink, err := initAuditSink(config, logger)
 	if err != nil {
 		return nil, err
 	}
 
-	serverOpts := &mcp.ServerOptions{}
+	rules, err := config.loadPolicyRules()
+	if err != nil {
+		return nil, err
+	}
+	var extractor policy.TableExtractor
+	if backendExtractor, isExtractor := backend.(PolicyTableExtractor); isExtractor {
+		extractor = backendExtractor.ExtractPolicyTables
+	}
+	config.evaluator = policy.NewEvaluator(rules, extractor)
+
+	lister, isLister := backend.(RelationLister)
+	isLister = isLister && !config.StoredRelations.Disabled
+	// Declared up front, relations being published and dropped at runtime.
+	serverOpts := &mcp.ServerOptions{HasResources: isLister}
 	if !config.DisableInstructions {
 		instructions, instrErr := loadEmbeddedInstructions()
 		if instrErr != nil {
@@ -212,12 +270,21 @@ func newMCPServer(config *Config, backend Backend, logger *logrus.Logger) (MCPSe
 	if resourcesErr := registerEmbeddedResources(server, config); resourcesErr != nil {
 		return nil, resourcesErr
 	}
+	var relations *relationPublisher
+	if isLister {
+		relations = newRelationPublisher(server, config, lister, logger)
+		if syncErr := relations.sync(context.Background()); syncErr != nil {
+			logger.Warnf("stored relation resources not published: %v", syncErr)
+		}
+	}
 
 	return &simpleMCPServer{
 		config:           config,
 		backend:          backend,
 		logger:           logger,
 		auditSink:        sink,
+		authenticator:    authenticator,
+		relations:        relations,
 		server:           server,
 		requestSemaphore: semaphore.NewWeighted(int64(config.Server.MaxConcurrentRequests)),
 		servers:          make([]io.Closer, 0),
@@ -242,10 +309,35 @@ func queryGate(name string) toolGate {
 		toolName:     name,
 		defaultClass: policy.QueryClassUnknown,
 		extractSQL:   extractSQLFromQueryInput,
+		replaceSQL:   replaceSQLInQueryInput,
 		extractArgs:  extractArgsFromQueryInput,
 	}
 }
 
+// fetchMoreGate is the toolGate for fetch_more, which serves rows of a
+// SELECT already gated and audited by run_select_query.
+func fetchMoreGate() toolGate {
+	return toolGate{
+		toolName:     "fetch_more",
+		defaultClass: policy.QueryClassSelect,
+		extractArgs: func(args any) map[string]any {
+			v, ok := args.(dto.FetchMoreInput)
+			if !ok {
+				return nil
+			}
+			return map[string]any{"cursor": v.Cursor}
+		},
+	}
+}
+
+// sessionID identifies the session of a tool call, scoping stashed results.
+func sessionID(req *mcp.CallToolRequest) string {
+	if req == nil || req.Session == nil {
+		return ""
+	}
+	return req.Session.ID()
+}
+
 // registryGate is the toolGate for list_registry and pull_provider.  Both are
 // classified as QueryClassSelect so they Allow under every mode; pulling a
 // provider writes only to the local approot cache (no cloud control/data
@@ -261,6 +353,7 @@ func registryGate(name string) toolGate {
 
 //nolint:funlen,gocognit // tool registrations are inherently long and branchy
 func registerTools(server *mcp.Server, cfg *Config, backend Backend, logger *logrus.Logger, auditSink sink.Sink) {
+	stash := newResultStash(cfg.Pagination.GetStashTTL(), cfg.Pagination.GetMaxStashed())
 	addToolWithGate(
 		server, cfg, auditSink, selectGate("server_info"),
 		&mcp.Tool{
@@ -460,22 +553,50 @@ func registerTools(server *mcp.Server, cfg *Config, backend Backend, logger *log
 		server, cfg, auditSink, queryGate("run_select_query"),
 		&mcp.Tool{
 			Name: "run_select_query",
-			Description: "Execute a SELECT. Returns {rows}. Reads only. Consult query_library_search for a " +
+			Description: "Execute a SELECT. Returns {rows} one page at a time, with total_rows where known and, " +
+				"while rows remain, a cursor for fetch_more. Reads only. Consult query_library_search for a " +
 				"vetted template before composing SQL from scratch; when the SQL came from a library entry, " +
 				"pass its id as source.",
 		},
-		func(ctx context.Context, _ *mcp.CallToolRequest, args dto.QueryJSONInput) (*mcp.CallToolResult, dto.QueryResultDTO, error) {
+		func(ctx context.Context, req *mcp.CallToolRequest, args dto.QueryJSONInput) (*mcp.CallToolResult, dto.QueryResultDTO, error) {
 			logger.Debugf("run_select_query: %s", args.SQL)
 			format, formatErr := resolveRenderFormat(cfg, args.Format)
 			if formatErr != nil {
 				return nil, dto.QueryResultDTO{}, formatErr
 			}
-			rows, err := backend.RunQueryJSON(ctx, args)
+			rows, schema, err := runSelect(ctx, backend, args)
 			if err != nil {
 				return nil, dto.QueryResultDTO{}, err
 			}
-			out := dto.QueryResultDTO{Rows: rows}
-			text := textForFormat(format, out, func() string { return render.RenderTable(rows) })
+			out, err := stash.firstPage(
+				sessionID(req), rows, schema, args.RowLimit, cfg.Pagination.GetPageSize(args.PageSize),
+			)
+			if err != nil {
+				return nil, dto.QueryResultDTO{}, err
+			}
+			text := textForFormat(format, out, func() string { return render.RenderTable(out.Rows) + pageFooter(out) })
+			return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: text}}}, out, nil
+		},
+	)
+
+	addToolWithGate(
+		server, cfg, auditSink, fetchMoreGate(),
+		&mcp.Tool{
+			Name: "fetch_more",
+			Description: "Fetch the next page of a run_select_query result by its cursor. Returns {rows} and, " +
+				"while rows remain, the next cursor. Cursors are scoped to the session and expire when unused; " +
+				"on expiry, rerun the query.",
+		},
+		func(_ context.Context, req *mcp.CallToolRequest, args dto.FetchMoreInput) (*mcp.CallToolResult, dto.QueryResultDTO, error) {
+			format, formatErr := resolveRenderFormat(cfg, args.Format)
+			if formatErr != nil {
+				return nil, dto.QueryResultDTO{}, formatErr
+			}
+			out, err := stash.nextPage(sessionID(req), args.Cursor, cfg.Pagination.GetPageSize(args.PageSize))
+			if err != nil {
+				return nil, dto.QueryResultDTO{}, err
+			}
+			text := textForFormat(format, out, func() string { return render.RenderTable(out.Rows) + pageFooter(out) })
 			return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: text}}}, out, nil
 		},
 	)
@@ -629,6 +750,11 @@ func (s *simpleMCPServer) Start(ctx context.Context) error {
 		return fmt.Errorf("server is already running")
 	}
 	s.running = true
+	if s.relations != nil {
+		watchCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go s.relations.watch(watchCtx, s.config.StoredRelations.GetPollInterval())
+	}
 	return s.run(ctx)
 }
 
@@ -0,0 +1,203 @@
+package mcp_server //nolint:revive // package name is established
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sirupsen/logrus"
+)
+
+// stdioMCPClient talks to an MCP server which it spawns as a subprocess,
+// over the server's stdin and stdout.  The server is spawned, and the
+// handshake run, on first use; the session then lasts until Close.
+//
+// The client config nominates the server:
+//
+//	{"command": "./build/stackql", "args": ["mcp", "--mcp.server.type=stdio"], "env": {"KEY": "value"}}
+//
+// env is added to the environment of this process.  The server's stderr
+// is passed through, so that its logs stay visible.
+type stdioMCPClient struct {
+	logger     *logrus.Logger
+	clientCfg  map[string]any
+	command    string
+	args       []string
+	env        []string
+	clientOpts *mcp.ClientOptions
+
+	mu      sync.Mutex
+	session *mcp.ClientSession
+}
+
+func newStdioMCPClient(
+	clientCfgMap map[string]any,
+	logger *logrus.Logger,
+	elicit ElicitationHandler,
+) (MCPClient, error) {
+	if logger == nil {
+		logger = logrus.New()
+		logger.SetLevel(logrus.InfoLevel)
+	}
+	command, isString := clientCfgMap["command"].(string)
+	if !isString || command == "" {
+		return nil, fmt.Errorf("stdio client requires a command in its config")
+	}
+	args, argsErr := stringSliceFromConfig(clientCfgMap, "args")
+	if argsErr != nil {
+		return nil, argsErr
+	}
+	env, envErr := envFromConfig(clientCfgMap)
+	if envErr != nil {
+		return nil, envErr
+	}
+	clientOpts, optsErr := newClientOptions(clientCfgMap, logger, elicit)
+	if optsErr != nil {
+		return nil, optsErr
+	}
+	return &stdioMCPClient{
+		logger:     logger,
+		clientCfg:  clientCfgMap,
+		command:    command,
+		args:       args,
+		env:        env,
+		clientOpts: clientOpts,
+	}, nil
+}
+
+func stringSliceFromConfig(clientCfgMap map[string]any, key string) ([]string, error) {
+	raw, isPresent := clientCfgMap[key]
+	if !isPresent {
+		return nil, nil
+	}
+	items, isSlice := raw.([]any)
+	if !isSlice {
+		return nil, fmt.Errorf("%s must be an array of strings", key)
+	}
+	rv := make([]string, 0, len(items))
+	for _, item := range items {
+		s, isString := item.(string)
+		if !isString {
+			return nil, fmt.Errorf("%s must be an array of strings", key)
+		}
+		rv = append(rv, s)
+	}
+	return rv, nil
+}
+
+func envFromConfig(clientCfgMap map[string]any) ([]string, error) {
+	raw, isPresent := clientCfgMap["env"]
+	if !isPresent {
+		return nil, nil
+	}
+	vars, isMap := raw.(map[string]any)
+	if !isMap {
+		return nil, fmt.Errorf("env must be an object of strings")
+	}
+	rv := make([]string, 0, len(vars))
+	for k, v := range vars {
+		s, isString := v.(string)
+		if !isString {
+			return nil, fmt.Errorf("env var %s must be a string", k)
+		}
+		rv = append(rv, k+"="+s)
+	}
+	sort.Strings(rv)
+	return rv, nil
+}
+
+// connect returns the session with the server, spawning the server and
+// running the handshake the first time.
+func (c *stdioMCPClient) connect(ctx context.Context) (*mcp.ClientSession, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.session != nil {
+		return c.session, nil
+	}
+	cmd := exec.Command(c.command, c.args...) //nolint:gosec // the operator nominates the server
+	cmd.Env = append(os.Environ(), c.env...)
+	cmd.Stderr = os.Stderr
+	c.logger.Infof("Spawning MCP server %s %v", c.command, c.args)
+	client := mcp.NewClient(&mcp.Implementation{
+		Name:    "stackql-client",
+		Version: "1.0.0",
+	}, c.clientOpts)
+	session, err := client.Connect(ctx, &mcp.CommandTransport{Command: cmd}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to MCP server %s: %w", c.command, err)
+	}
+	c.session = session
+	return session, nil
+}
+
+func (c *stdioMCPClient) InspectTools() ([]map[string]any, error) {
+	ctx := context.Background()
+	session, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.logger.Infof("Listing available tools...")
+	toolsResult, err := session.ListTools(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tools: %w", err)
+	}
+	rv := make([]map[string]any, 0, len(toolsResult.Tools))
+	for _, tool := range toolsResult.Tools {
+		rv = append(rv, toolInfo(tool))
+	}
+	return rv, nil
+}
+
+// CallToolText returns the tool's output formatted for a scripting client.
+// See formatToolResult for the contract.
+func (c *stdioMCPClient) CallToolText(toolName string, args map[string]any) (string, error) {
+	ctx := context.Background()
+	session, err := c.connect(ctx)
+	if err != nil {
+		return "", err
+	}
+	c.logger.Infof("Calling tool %s...", toolName)
+	result, err := session.CallTool(ctx, &mcp.CallToolParams{
+		Name:      toolName,
+		Arguments: args,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to call tool %s: %w", toolName, err)
+	}
+	preferText, isBool := c.clientCfg["prefer_text"].(bool)
+	return formatToolResult(toolName, result, isBool && preferText)
+}
+
+func (c *stdioMCPClient) ListPrompts() ([]map[string]any, error) {
+	ctx := context.Background()
+	session, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return listPrompts(ctx, session)
+}
+
+func (c *stdioMCPClient) ListResources() ([]map[string]any, error) {
+	ctx := context.Background()
+	session, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return listResources(ctx, session)
+}
+
+// Close ends the session, closing the server's stdin and awaiting its exit.
+func (c *stdioMCPClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.session == nil {
+		return nil
+	}
+	err := c.session.Close()
+	c.session = nil
+	return err
+}
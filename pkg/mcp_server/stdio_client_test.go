@@ -0,0 +1,151 @@
+package mcp_server //nolint:testpackage,revive // exercise internal wiring
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/sirupsen/logrus"
+)
+
+// stdioHelperServerEnv makes the test binary serve MCP over stdio, so that
+// the stdio client can spawn it as its server.
+const stdioHelperServerEnv = "STACKQL_TEST_STDIO_SERVER"
+
+// TestStdioHelperServer is not a test: it is the server process spawned by
+// the stdio client tests.  It exits without reporting, so that nothing but
+// JSON-RPC reaches stdout.
+func TestStdioHelperServer(t *testing.T) {
+	if os.Getenv(stdioHelperServerEnv) == "" {
+		t.Skip("server process of the stdio client tests")
+	}
+	cfg := DefaultConfig()
+	cfg.Server.Transport = serverTransportStdIO
+	cfg.Server.Audit.Disabled = true
+	be := &testBackend{
+		listProvidersOut: []map[string]any{{"name": os.Getenv("STACKQL_TEST_PROVIDER")}},
+		execOut:          map[string]any{"timestamp": "now"},
+	}
+	srv, err := newMCPServer(cfg, be, nil)
+	if err != nil {
+		t.Fatalf("newMCPServer: %v", err)
+	}
+	_ = srv.(*simpleMCPServer).run(context.Background()) //nolint:errcheck,forcetypeassert // exits either way
+	os.Exit(0)
+}
+
+func newTestStdioClient(t *testing.T, extraCfg map[string]any) MCPClient {
+	t.Helper()
+	return newTestStdioClientWithElicitation(t, extraCfg, nil)
+}
+
+func newTestStdioClientWithElicitation(t *testing.T, extraCfg map[string]any, elicit ElicitationHandler) MCPClient {
+	t.Helper()
+	clientCfg := map[string]any{
+		"command": os.Args[0],
+		"args":    []any{"-test.run=^TestStdioHelperServer$"},
+		"env": map[string]any{
+			stdioHelperServerEnv:    "1",
+			"STACKQL_TEST_PROVIDER": "from-env",
+		},
+	}
+	for k, v := range extraCfg {
+		clientCfg[k] = v
+	}
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+	client, err := NewMCPClientWithElicitation(MCPClientTypeSTDIO, "", clientCfg, logger, elicit)
+	if err != nil {
+		t.Fatalf("NewMCPClient: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func TestStdioClient_ToolsPromptsAndResources(t *testing.T) {
+	client := newTestStdioClient(t, nil)
+
+	tools, err := client.InspectTools()
+	if err != nil {
+		t.Fatalf("InspectTools: %v", err)
+	}
+	var names []string
+	for _, tool := range tools {
+		names = append(names, tool["name"].(string)) //nolint:errcheck,forcetypeassert // test
+		if _, hasSchema := tool["input_schema"].(map[string]any); !hasSchema {
+			t.Errorf("expected tool %v to carry its input schema", tool["name"])
+		}
+	}
+	if !strings.Contains(strings.Join(names, ","), "list_providers") {
+		t.Errorf("expected list_providers among the tools, got %v", names)
+	}
+
+	out, err := client.CallToolText("list_providers", map[string]any{})
+	if err != nil {
+		t.Fatalf("CallToolText: %v", err)
+	}
+	if !strings.Contains(out, "from-env") {
+		t.Errorf("expected the server to see the configured env, got %s", out)
+	}
+
+	prompts, err := client.ListPrompts()
+	if err != nil || len(prompts) == 0 {
+		t.Errorf("expected embedded prompts, got %v %v", prompts, err)
+	}
+	resources, err := client.ListResources()
+	if err != nil || len(resources) == 0 {
+		t.Errorf("expected embedded resources, got %v %v", resources, err)
+	}
+}
+
+func TestStdioClient_ElicitationResponses(t *testing.T) {
+	refusing := newTestStdioClient(t, nil)
+	if _, err := refusing.CallToolText("run_mutation_query", map[string]any{"sql": "delete from t"}); err == nil ||
+		!strings.Contains(err.Error(), "does not support elicitation") {
+		t.Errorf("expected a client without an elicitation response to be refused, got %v", err)
+	}
+
+	declining := newTestStdioClient(t, map[string]any{"elicitation": map[string]any{"action": "decline"}})
+	if _, err := declining.CallToolText("run_mutation_query", map[string]any{"sql": "delete from t"}); err == nil ||
+		!strings.Contains(err.Error(), "declined approval") {
+		t.Errorf("expected a declined mutation to be refused, got %v", err)
+	}
+
+	accepting := newTestStdioClient(t, map[string]any{"elicitation": map[string]any{"action": "accept"}})
+	if _, err := accepting.CallToolText("run_mutation_query", map[string]any{"sql": "delete from t"}); err != nil {
+		t.Errorf("expected an accepted mutation to proceed, got %v", err)
+	}
+}
+
+func TestStdioClient_ElicitationHandler(t *testing.T) {
+	var asked string
+	client := newTestStdioClientWithElicitation(
+		t,
+		map[string]any{"elicitation": map[string]any{"action": "decline"}},
+		func(_ context.Context, req *mcp.ElicitRequest) (*mcp.ElicitResult, error) {
+			asked = req.Params.Message
+			return &mcp.ElicitResult{Action: "accept"}, nil
+		},
+	)
+	if _, err := client.CallToolText("run_mutation_query", map[string]any{"sql": "delete from t"}); err != nil {
+		t.Errorf("expected the handler to override the configured response, got %v", err)
+	}
+	if asked == "" {
+		t.Errorf("expected the handler to be asked")
+	}
+}
+
+func TestStdioClient_Config(t *testing.T) {
+	for _, cfg := range []map[string]any{
+		{},
+		{"command": "x", "args": "not-an-array"},
+		{"command": "x", "env": map[string]any{"K": 1}},
+		{"command": "x", "elicitation": map[string]any{"action": "maybe"}},
+	} {
+		if _, err := NewMCPClient(MCPClientTypeSTDIO, "", cfg, nil); err == nil {
+			t.Errorf("expected config %v to be rejected", cfg)
+		}
+	}
+}
@@ -3,13 +3,17 @@ package mcp_server //nolint:testpackage,revive // exercise internal wiring
 import (
 	"context"
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stackql/stackql/pkg/mcp_server/auth"
 	"github.com/stackql/stackql/pkg/mcp_server/dto"
+	"github.com/stackql/stackql/pkg/sink"
 )
 
 // testBackend is a controllable Backend used to assert tool wiring end-to-end.
@@ -331,6 +335,146 @@ func TestTool_ValidateSelectQuery_SuccessAndFailure(t *testing.T) {
 	}
 }
 
+func TestTool_RunSelectQuery_PagesWithFetchMore(t *testing.T) {
+	rows := make([]map[string]any, 5)
+	for i := range rows {
+		rows[i] = map[string]any{"n": i}
+	}
+	be := &testBackend{runJSONOut: rows}
+	cfg := DefaultConfig()
+	cfg.Pagination.PageSize = 2
+	cs := connectInProcess(t, cfg, be)
+
+	res := callTool(t, cs, "run_select_query", map[string]any{"sql": "select n from t"})
+	page := structuredAs[dto.QueryResultDTO](t, res)
+	if len(page.Rows) != 2 || page.TotalRows == nil || *page.TotalRows != 5 || page.Cursor == "" {
+		t.Fatalf("unexpected first page %+v", page)
+	}
+	if !strings.Contains(firstText(t, res), "Rows 1-2 of 5.") {
+		t.Errorf("markdown lacks page footer: %q", firstText(t, res))
+	}
+	var seen []any
+	for _, r := range page.Rows {
+		seen = append(seen, r["n"])
+	}
+	cursor := page.Cursor
+	for cursor != "" {
+		res = callTool(t, cs, "fetch_more", map[string]any{"cursor": cursor})
+		page = structuredAs[dto.QueryResultDTO](t, res)
+		for _, r := range page.Rows {
+			seen = append(seen, r["n"])
+		}
+		cursor = page.Cursor
+	}
+	if len(seen) != 5 || seen[4] != float64(4) {
+		t.Errorf("expected every row once, in order, got %v", seen)
+	}
+
+	callExpectingError(t, cs, "fetch_more", map[string]any{"cursor": "bm90LWEtY3Vyc29y"}, "cursor")
+}
+
+func TestTool_RunSelectQuery_TotalUnknownWhenRowLimitReached(t *testing.T) {
+	be := &testBackend{runJSONOut: []map[string]any{{"n": 0}, {"n": 1}, {"n": 2}}}
+	cfg := DefaultConfig()
+	cfg.Pagination.PageSize = 2
+	cs := connectInProcess(t, cfg, be)
+
+	res := callTool(t, cs, "run_select_query", map[string]any{"sql": "select n from t", "row_limit": 3})
+	page := structuredAs[dto.QueryResultDTO](t, res)
+	if page.TotalRows != nil || page.Cursor == "" {
+		t.Errorf("expected a cursor and no total for a row_limit-bounded result, got %+v", page)
+	}
+	res = callTool(t, cs, "run_select_query", map[string]any{"sql": "select n from t", "page_size": 10})
+	page = structuredAs[dto.QueryResultDTO](t, res)
+	if len(page.Rows) != 2 {
+		t.Errorf("page_size above the server page size should be capped, got %d rows", len(page.Rows))
+	}
+}
+
+// typedBackend reports column types, as the stackql backends do.
+type typedBackend struct {
+	testBackend
+	result QueryResult
+}
+
+func (b *typedBackend) RunQueryTyped(_ context.Context, in dto.QueryJSONInput) (QueryResult, error) {
+	b.lastQueryJSON = in
+	return b.result, nil
+}
+
+func TestTool_RunSelectQuery_TypedColumns(t *testing.T) {
+	be := &typedBackend{result: NewQueryResult(
+		[]ColumnInfo{
+			NewColumnInfo("id", ColumnTypeInt64, false),
+			NewColumnInfo("price", ColumnTypeFloat64, true),
+			NewColumnInfo("active", ColumnTypeBool, true),
+			NewColumnInfo("created", ColumnTypeTimestamp, true),
+			NewColumnInfo("tags", ColumnTypeJSON, true),
+			NewColumnInfo("name", ColumnTypeString, true),
+		},
+		[][]any{
+			{"42", "1.5", "t", "2024-03-01 10:00:00+00", `{"env":"prod","n":[1,2]}`, "a"},
+			{"2", nil, "false", nil, "null", "b"},
+		},
+		0, 0,
+	)}
+	cfg := DefaultConfig()
+	cfg.Pagination.PageSize = 1
+	cs := connectInProcess(t, cfg, be)
+
+	res := callTool(t, cs, "run_select_query", map[string]any{"sql": "select * from t"})
+	if res.IsError {
+		t.Fatalf("run_select_query failed: %s", firstText(t, res))
+	}
+	type rawPage struct {
+		Rows   []map[string]json.RawMessage `json:"rows"`
+		Cursor string                       `json:"cursor"`
+		Schema *dto.RowSchemaDTO            `json:"schema"`
+	}
+	page := structuredAs[rawPage](t, res)
+	if len(page.Rows) != 1 || page.Schema == nil {
+		t.Fatalf("expected one typed row and a schema, got %+v", page)
+	}
+	row := page.Rows[0]
+	for col, want := range map[string]string{
+		"id":      `42`,
+		"price":   `1.5`,
+		"active":  `true`,
+		"created": `"2024-03-01T10:00:00Z"`,
+		"tags":    `{"env":"prod","n":[1,2]}`,
+		"name":    `"a"`,
+	} {
+		if string(row[col]) != want {
+			t.Errorf("column %s: expected %s, got %s", col, want, row[col])
+		}
+	}
+	if got := strings.Join(page.Schema.ColumnOrder, ","); got != "id,price,active,created,tags,name" {
+		t.Errorf("unexpected column order %q", got)
+	}
+	if id := page.Schema.Properties["id"]; strings.Join(id.Type, ",") != "integer" || id.ColumnType != ColumnTypeInt64 {
+		t.Errorf("unexpected id schema %+v", id)
+	}
+	if created := page.Schema.Properties["created"]; strings.Join(created.Type, ",") != "string,null" ||
+		created.Format != "date-time" {
+		t.Errorf("unexpected created schema %+v", created)
+	}
+	if tags := page.Schema.Properties["tags"]; len(tags.Type) != 0 || tags.ColumnType != ColumnTypeJSON {
+		t.Errorf("a json column should admit any JSON value, got %+v", tags)
+	}
+	if !strings.Contains(firstText(t, res), `{"env":"prod","n":[1,2]}`) {
+		t.Errorf("markdown should render nested JSON compactly: %q", firstText(t, res))
+	}
+
+	res = callTool(t, cs, "fetch_more", map[string]any{"cursor": page.Cursor})
+	next := structuredAs[dto.QueryResultDTO](t, res)
+	if next.Schema == nil || len(next.Rows) != 1 {
+		t.Fatalf("expected the schema on every page, got %+v", next)
+	}
+	if next.Rows[0]["price"] != nil || next.Rows[0]["tags"] != nil || next.Rows[0]["active"] != false {
+		t.Errorf("expected nulls and a false bool, got %+v", next.Rows[0])
+	}
+}
+
 func TestTool_RunSelectQuery_ForwardsRowLimit(t *testing.T) {
 	be := &testBackend{runJSONOut: []map[string]any{{"a": 1}}}
 	cs := connectInProcess(t, DefaultConfig(), be)
@@ -836,6 +980,135 @@ func TestAudit_RecordsAllToolCalls(t *testing.T) {
 	}
 }
 
+func TestAudit_StrictMutationsSurfacesSinkFailure(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(collector.Close)
+	noRetries := 0
+
+	cfg := fullAccessConfig()
+	cfg.Server.Audit.FailureMode = "strict_mutations"
+	cfg.Server.Audit.Sink = "http"
+	cfg.Server.Audit.HTTP = sink.HTTPConfig{URL: collector.URL, BatchSize: 1, MaxRetries: &noRetries}
+
+	be := &testBackend{execOut: map[string]any{"timestamp": "now"}}
+	mcpSrv, err := newMCPServer(cfg, be, nil)
+	if err != nil {
+		t.Fatalf("newMCPServer: %v", err)
+	}
+	t.Cleanup(func() { _ = mcpSrv.(*simpleMCPServer).auditSink.Close() })
+	rawServer := mcpSrv.(*simpleMCPServer).server
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	t1, t2 := mcp.NewInMemoryTransports()
+	if _, err := rawServer.Connect(ctx, t1, nil); err != nil {
+		t.Fatalf("server connect: %v", err)
+	}
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "v0"}, nil)
+	cs, err := client.Connect(ctx, t2, nil)
+	if err != nil {
+		t.Fatalf("client connect: %v", err)
+	}
+	t.Cleanup(func() { _ = cs.Close() })
+
+	if res := callTool(t, cs, "run_select_query", map[string]any{"sql": "select 1"}); res.IsError {
+		t.Errorf("strict_mutations should let a select through despite the audit failure: %+v", res)
+	}
+	callExpectingError(t, cs, "run_mutation_query",
+		map[string]any{"sql": "insert into t values (1)"}, "audit write failed")
+}
+
+// bearerRoundTripper adds a bearer token to every request.
+type bearerRoundTripper struct{ token string }
+
+func (b bearerRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.Header.Set("Authorization", "Bearer "+b.token)
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestAuth_PrincipalSelectsModeAndIsAudited(t *testing.T) {
+	logPath := t.TempDir() + "/audit.log"
+	cfg := DefaultConfig()
+	cfg.Server.Transport = serverTransportHTTP
+	cfg.Server.Mode = "full_access"
+	cfg.Server.Audit.File.Path = logPath
+	cfg.Server.Auth = auth.Config{
+		Bearer: auth.BearerConfig{Tokens: []auth.BearerToken{
+			{Subject: "ci-bot", Token: "ci-secret", Mode: "read_only"},
+			{Subject: "alice", Token: "alice-secret"},
+		}},
+	}
+	be := &testBackend{execOut: map[string]any{"timestamp": "now"}}
+	mcpSrv, err := newMCPServer(cfg, be, nil)
+	if err != nil {
+		t.Fatalf("newMCPServer: %v", err)
+	}
+	simple := mcpSrv.(*simpleMCPServer)
+	httpSrv := httptest.NewServer(simple.newHTTPHandler(simple.server))
+	t.Cleanup(httpSrv.Close)
+
+	connect := func(token string) (*mcp.ClientSession, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		t.Cleanup(cancel)
+		client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "v0"}, nil)
+		return client.Connect(ctx, &mcp.StreamableClientTransport{
+			Endpoint:   httpSrv.URL,
+			HTTPClient: &http.Client{Transport: bearerRoundTripper{token: token}},
+			MaxRetries: -1,
+		}, nil)
+	}
+	if _, err := connect("wrong"); err == nil {
+		t.Fatal("expected connection with an unknown token to fail")
+	}
+	ci, err := connect("ci-secret")
+	if err != nil {
+		t.Fatalf("connect ci-bot: %v", err)
+	}
+	t.Cleanup(func() { _ = ci.Close() })
+	alice, err := connect("alice-secret")
+	if err != nil {
+		t.Fatalf("connect alice: %v", err)
+	}
+	t.Cleanup(func() { _ = alice.Close() })
+
+	mutation := map[string]any{"sql": "insert into t values (1)"}
+	callExpectingError(t, ci, "run_mutation_query", mutation, "refused")
+	if res := callTool(t, alice, "run_mutation_query", mutation); res.IsError {
+		t.Errorf("alice should inherit full_access: %+v", res)
+	}
+	if err := simple.auditSink.Close(); err != nil {
+		t.Fatalf("close sink: %v", err)
+	}
+
+	lines, err := readAllJSONLines(t, logPath)
+	if err != nil {
+		t.Fatalf("read audit log: %v", err)
+	}
+	decisions := map[string]string{}
+	for _, line := range lines {
+		var ev map[string]any
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("unmarshal audit line: %v", err)
+		}
+		if ev["tool"] != "run_mutation_query" {
+			continue
+		}
+		if ev["auth_method"] != auth.MethodBearer {
+			t.Errorf("auth_method = %v", ev["auth_method"])
+		}
+		principal, _ := ev["principal"].(string)
+		decisions[principal], _ = ev["decision"].(string)
+		if principal == "ci-bot" && ev["mode"] != "read_only" {
+			t.Errorf("ci-bot mode = %v, want read_only", ev["mode"])
+		}
+	}
+	if decisions["ci-bot"] != "refuse_immediate" || decisions["alice"] != "allow" {
+		t.Errorf("unexpected per-principal decisions: %v", decisions)
+	}
+}
+
 // readAllJSONLines is a small helper used only by TestAudit_RecordsAllToolCalls.
 func readAllJSONLines(t *testing.T, path string) ([]string, error) {
 	t.Helper()
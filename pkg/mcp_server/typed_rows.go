@@ -0,0 +1,177 @@
+package mcp_server //nolint:revive // fine for now
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stackql/stackql/pkg/mcp_server/dto"
+)
+
+// timestampLayouts are the text encodings of timestamps accepted from
+// backends: postgres text output, sqlite's and RFC 3339.
+//
+//nolint:gochecknoglobals // immutable lookup
+var timestampLayouts = []string{
+	"2006-01-02 15:04:05.999999999Z07:00:00",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z07",
+	"2006-01-02 15:04:05.999999999",
+	time.RFC3339Nano,
+}
+
+// columnSchemas maps column types onto their JSON Schema.
+//
+//nolint:gochecknoglobals // immutable lookup
+var columnSchemas = map[string]dto.ColumnSchemaDTO{
+	ColumnTypeString:    {Type: []string{"string"}},
+	ColumnTypeInt64:     {Type: []string{"integer"}},
+	ColumnTypeFloat64:   {Type: []string{"number"}},
+	ColumnTypeBool:      {Type: []string{"boolean"}},
+	ColumnTypeTimestamp: {Type: []string{"string"}, Format: "date-time"},
+	ColumnTypeDate:      {Type: []string{"string"}, Format: "date"},
+	ColumnTypeJSON:      {},
+	ColumnTypeBytes:     {Type: []string{"string"}, ContentEncoding: "base64"},
+}
+
+// columnSchema returns the JSON Schema of a column.  Unknown types are
+// described, and carried, as strings.
+func columnSchema(col ColumnInfo) dto.ColumnSchemaDTO {
+	s, ok := columnSchemas[col.GetType()]
+	if !ok {
+		s = columnSchemas[ColumnTypeString]
+	}
+	s.ColumnType = col.GetType()
+	if col.IsNullable() && len(s.Type) > 0 {
+		s.Type = append(append([]string(nil), s.Type...), "null")
+	}
+	return s
+}
+
+// rowSchema describes the rows of a typed result.
+func rowSchema(columns []ColumnInfo) *dto.RowSchemaDTO {
+	rv := &dto.RowSchemaDTO{
+		Type:        "object",
+		Properties:  make(map[string]dto.ColumnSchemaDTO, len(columns)),
+		ColumnOrder: make([]string, 0, len(columns)),
+	}
+	for _, col := range columns {
+		rv.Properties[col.GetName()] = columnSchema(col)
+		rv.ColumnOrder = append(rv.ColumnOrder, col.GetName())
+	}
+	return rv
+}
+
+// typedRows converts a typed result to rows of typed values, with the
+// schema describing them.
+func typedRows(qr QueryResult) ([]map[string]any, *dto.RowSchemaDTO, error) {
+	columns := qr.GetColumns()
+	rows := make([]map[string]any, 0, len(qr.GetRows()))
+	for i, raw := range qr.GetRows() {
+		if len(raw) != len(columns) {
+			return nil, nil, fmt.Errorf("row %d has %d values for %d columns", i, len(raw), len(columns))
+		}
+		row := make(map[string]any, len(columns))
+		for j, col := range columns {
+			row[col.GetName()] = coerceValue(col.GetType(), raw[j])
+		}
+		rows = append(rows, row)
+	}
+	return rows, rowSchema(columns), nil
+}
+
+// coerceValue converts a value to the representation of its column type.
+// Values arriving in their text encoding are parsed; a value which does
+// not parse is passed through as text rather than failing the result.
+func coerceValue(colType string, v any) any {
+	if b, ok := v.([]byte); ok && colType != ColumnTypeBytes {
+		v = string(b)
+	}
+	s, isText := v.(string)
+	if v == nil || (isText && s == "null" && colType != ColumnTypeString) {
+		return nil
+	}
+	if t, ok := v.(time.Time); ok {
+		if colType == ColumnTypeDate {
+			return t.Format(time.DateOnly)
+		}
+		return t.Format(time.RFC3339Nano)
+	}
+	if !isText {
+		return coerceNonText(colType, v)
+	}
+	switch colType {
+	case ColumnTypeInt64:
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n
+		}
+	case ColumnTypeFloat64:
+		if f, err := strconv.ParseFloat(s, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+			return f
+		}
+	case ColumnTypeBool:
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	case ColumnTypeTimestamp:
+		for _, layout := range timestampLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t.Format(time.RFC3339Nano)
+			}
+		}
+	case ColumnTypeJSON:
+		return parseJSONText(s)
+	case ColumnTypeBytes:
+		if decoded, err := hex.DecodeString(strings.TrimPrefix(s, `\x`)); err == nil && strings.HasPrefix(s, `\x`) {
+			return decoded
+		}
+		return []byte(s)
+	}
+	return s
+}
+
+// coerceNonText handles values a backend has already decoded.  Only JSON
+// columns need attention: a driver may hand them over as raw bytes.
+func coerceNonText(colType string, v any) any {
+	if raw, ok := v.([]byte); ok && colType == ColumnTypeBytes {
+		return raw
+	}
+	if raw, ok := v.(json.RawMessage); ok {
+		return parseJSONText(string(raw))
+	}
+	return v
+}
+
+// parseJSONText parses a JSON column value, keeping large integers exact.
+func parseJSONText(s string) any {
+	dec := json.NewDecoder(bytes.NewReader([]byte(s)))
+	dec.UseNumber()
+	var parsed any
+	if err := dec.Decode(&parsed); err != nil || dec.More() {
+		return s
+	}
+	return parsed
+}
+
+// runSelect runs a SELECT for run_select_query, typed where the backend
+// reports column types.
+func runSelect(
+	ctx context.Context, backend Backend, input dto.QueryJSONInput,
+) ([]map[string]any, *dto.RowSchemaDTO, error) {
+	typed, ok := backend.(TypedQueryRunner)
+	if !ok {
+		rows, err := backend.RunQueryJSON(ctx, input)
+		return rows, nil, err
+	}
+	qr, err := typed.RunQueryTyped(ctx, input)
+	if err != nil {
+		return nil, nil, err
+	}
+	return typedRows(qr)
+}
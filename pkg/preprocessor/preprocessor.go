@@ -138,6 +138,7 @@ type Preprocessor struct {
 	declarationBlockStartToken []byte
 	declarationBlockEndToken   []byte
 	contents                   printableMap
+	vars                       map[string]string
 }
 
 func (pp *Preprocessor) inferBlock(block []byte, filename string, varList []string) (*DeclarationBlock, error) {
@@ -153,8 +154,23 @@ func (pp *Preprocessor) inferBlock(block []byte, filename string, varList []stri
 	return newDeclarationBlock(typeStr, block[i+1:], filename, varList)
 }
 
+// WithVars sets variables which templates read directly, where no
+// declaration block defines the same key.
+func (pp *Preprocessor) WithVars(vars map[string]string) *Preprocessor {
+	pp.vars = vars
+	return pp
+}
+
+func (pp *Preprocessor) varContents() map[string]interface{} {
+	contents := make(map[string]interface{}, len(pp.vars))
+	for k, v := range pp.vars {
+		contents[k] = v
+	}
+	return contents
+}
+
 func (pp *Preprocessor) mergeContents(declarationBlocks []DeclarationBlock) {
-	contents := make(map[string]interface{})
+	contents := pp.varContents()
 	for _, block := range declarationBlocks {
 		for k, v := range block.Contents {
 			contents[k] = v
@@ -240,6 +256,10 @@ func (pp *Preprocessor) PrepareExternal(
 	if err != nil {
 		return err
 	}
-	pp.contents = printableMapFromMap(db.Contents)
+	contents := pp.varContents()
+	for k, v := range db.Contents {
+		contents[k] = v
+	}
+	pp.contents = printableMapFromMap(contents)
 	return err
 }
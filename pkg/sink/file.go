@@ -40,6 +40,12 @@ type FileConfig struct {
 	// MaxAgeDays is the maximum age in days for rotated files.
 	// Zero means no age-based deletion (lumberjack default).
 	MaxAgeDays int `json:"max_age_days,omitempty" yaml:"max_age_days,omitempty"`
+	// RotateEvery triggers time-based rotation, in addition to size-based,
+	// once this long has elapsed since the file was opened or last rotated.
+	// A Go duration string, eg "24h".  Empty means size-based only.
+	RotateEvery string `json:"rotate_every,omitempty" yaml:"rotate_every,omitempty"`
+	// Compress gzips rotated files.
+	Compress bool `json:"compress,omitempty" yaml:"compress,omitempty"`
 
 	// DefaultFilename is consulted when Path is empty.  It returns just the
 	// basename; the sink joins it with Dir.  When nil, a generic
@@ -55,6 +61,12 @@ type fileSink struct {
 	mu   sync.Mutex
 	w    io.WriteCloser
 	path string
+	// rotate forces a rotation; rotateEvery, when positive, is
+	// the interval at which Record invokes it, nextRotation
+	// being the time at or after which it next does.
+	rotate       func() error
+	rotateEvery  time.Duration
+	nextRotation time.Time
 }
 
 // NewFileSink constructs a file-backed sink.  Exactly one of cfg.Path or
@@ -72,6 +84,10 @@ func NewFileSink(cfg FileConfig) (Sink, error) {
 	if err != nil {
 		return nil, err
 	}
+	rotateEvery, err := parseDuration("rotate_every", cfg.RotateEvery, 0)
+	if err != nil {
+		return nil, err
+	}
 	abs, absErr := filepath.Abs(resolvedPath)
 	if absErr != nil {
 		return nil, fmt.Errorf("resolve sink file path %q: %w", resolvedPath, absErr)
@@ -94,9 +110,16 @@ func NewFileSink(cfg FileConfig) (Sink, error) {
 		MaxSize:    cfg.MaxSizeMB,
 		MaxBackups: cfg.MaxBackups,
 		MaxAge:     cfg.MaxAgeDays,
+		Compress:   cfg.Compress,
 	}
 	fmt.Fprintf(os.Stderr, "sink file: %s\n", abs)
-	return &fileSink{w: lj, path: abs}, nil
+	return &fileSink{
+		w:            lj,
+		path:         abs,
+		rotate:       lj.Rotate,
+		rotateEvery:  rotateEvery,
+		nextRotation: time.Now().Add(rotateEvery),
+	}, nil
 }
 
 // resolvePath enforces the Path-or-Dir contract and returns the (possibly
@@ -127,6 +150,12 @@ func (s *fileSink) Record(_ context.Context, payload any) error {
 	line = append(line, '\n')
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if now := time.Now(); s.rotateEvery > 0 && !now.Before(s.nextRotation) {
+		if rotateErr := s.rotate(); rotateErr != nil {
+			return fmt.Errorf("rotate sink file: %w", rotateErr)
+		}
+		s.nextRotation = now.Add(s.rotateEvery)
+	}
 	if _, writeErr := s.w.Write(line); writeErr != nil {
 		return fmt.Errorf("write sink payload: %w", writeErr)
 	}
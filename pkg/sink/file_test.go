@@ -159,3 +159,34 @@ func TestNopSink_RecordIsHarmless(t *testing.T) {
 		t.Fatalf("nop sink Close should not error: %v", err)
 	}
 }
+
+func TestFileSink_RotateEvery(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.log")
+	s, err := sink.NewFileSink(sink.FileConfig{Path: path, RotateEvery: "1ms"})
+	if err != nil {
+		t.Fatalf("NewFileSink: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+
+	for i := 0; i < 2; i++ {
+		time.Sleep(5 * time.Millisecond)
+		if err := s.Record(context.Background(), map[string]any{"n": i}); err != nil {
+			t.Fatalf("Record %d: %v", i, err)
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+	if len(entries) < 2 {
+		t.Fatalf("expected rotated backups alongside out.log, got %v", entries)
+	}
+}
+
+func TestFileSink_RotateEveryRejectsBadDuration(t *testing.T) {
+	cfg := sink.FileConfig{Path: filepath.Join(t.TempDir(), "out.log"), RotateEvery: "daily"}
+	if _, err := sink.NewFileSink(cfg); err == nil {
+		t.Fatal("expected NewFileSink to reject an unparseable rotate_every")
+	}
+}
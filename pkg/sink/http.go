@@ -0,0 +1,345 @@
+package sink
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	httpDefaultBatchSize     = 100
+	httpDefaultFlushInterval = 5 * time.Second
+	httpDefaultTimeout       = 10 * time.Second
+	httpDefaultMaxRetries    = 3
+	httpDefaultRetryBackoff  = 500 * time.Millisecond
+	httpSpoolFileSuffix      = ".jsonl"
+)
+
+// HTTPConfig is the HTTP sink configuration.  Payloads are batched and
+// POSTed as a JSON array; a batch which cannot be delivered after the
+// retries is spooled to disk, and spooled batches are resent, oldest
+// first, ahead of the next delivery.  A synchronous sink delivers, or
+// spools, each payload before Record returns, so that its failure is
+// reported to the caller which recorded it.
+type HTTPConfig struct {
+	// URL is the collector endpoint.  Required.
+	URL string `json:"url,omitempty" yaml:"url,omitempty"`
+	// Headers are added to every request, eg an Authorization header.
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	// BatchSize is the number of payloads which triggers a delivery;
+	// default 100.
+	BatchSize int `json:"batch_size,omitempty" yaml:"batch_size,omitempty"`
+	// FlushInterval is the longest a payload waits for a full batch;
+	// a Go duration string, default "5s".
+	FlushInterval string `json:"flush_interval,omitempty" yaml:"flush_interval,omitempty"`
+	// Timeout bounds each request; a Go duration string, default "10s".
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	// MaxRetries is the number of retries after a failed request;
+	// default 3.  Retries back off exponentially from RetryBackoff.
+	MaxRetries *int `json:"max_retries,omitempty" yaml:"max_retries,omitempty"`
+	// RetryBackoff is the delay before the first retry; a Go duration
+	// string, default "500ms".
+	RetryBackoff string `json:"retry_backoff,omitempty" yaml:"retry_backoff,omitempty"`
+	// SpoolDir is the directory in which undeliverable batches are kept.
+	// Empty means no spool: an undeliverable batch is an error.
+	SpoolDir string `json:"spool_dir,omitempty" yaml:"spool_dir,omitempty"`
+	// Synchronous delivers each payload as it is recorded, foregoing
+	// batching, so that Record reports whether it was delivered or
+	// spooled.  Default false.
+	Synchronous bool `json:"synchronous,omitempty" yaml:"synchronous,omitempty"`
+}
+
+type httpSink struct {
+	// mu guards batch alone, and is never held across I/O.
+	mu sync.Mutex
+	// sendMu serialises deliveries, so that batches and the spool
+	// are sent in the order recorded; it guards spoolSequence.
+	sendMu        sync.Mutex
+	client        *http.Client
+	url           string
+	headers       map[string]string
+	batchSize     int
+	maxRetries    int
+	retryBackoff  time.Duration
+	spoolDir      string
+	spoolSequence int
+	isSynchronous bool
+	batch         []json.RawMessage
+	done          chan struct{}
+	wg            sync.WaitGroup
+}
+
+// NewHTTPSink constructs a batching HTTP sink and starts its flush timer.
+func NewHTTPSink(cfg HTTPConfig) (Sink, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("sink http: url is required")
+	}
+	flushInterval, err := parseDuration("http flush_interval", cfg.FlushInterval, httpDefaultFlushInterval)
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := parseDuration("http timeout", cfg.Timeout, httpDefaultTimeout)
+	if err != nil {
+		return nil, err
+	}
+	retryBackoff, err := parseDuration("http retry_backoff", cfg.RetryBackoff, httpDefaultRetryBackoff)
+	if err != nil {
+		return nil, err
+	}
+	s := &httpSink{
+		client:        &http.Client{Timeout: timeout},
+		url:           cfg.URL,
+		headers:       cfg.Headers,
+		batchSize:     cfg.BatchSize,
+		maxRetries:    httpDefaultMaxRetries,
+		retryBackoff:  retryBackoff,
+		spoolDir:      cfg.SpoolDir,
+		isSynchronous: cfg.Synchronous,
+		done:          make(chan struct{}),
+	}
+	if s.batchSize <= 0 {
+		s.batchSize = httpDefaultBatchSize
+	}
+	if cfg.MaxRetries != nil {
+		if *cfg.MaxRetries < 0 {
+			return nil, fmt.Errorf("sink http: max_retries must not be negative")
+		}
+		s.maxRetries = *cfg.MaxRetries
+	}
+	if s.spoolDir != "" {
+		if mkdirErr := os.MkdirAll(s.spoolDir, 0o700); mkdirErr != nil {
+			return nil, fmt.Errorf("create sink spool dir %q: %w", s.spoolDir, mkdirErr)
+		}
+	}
+	if flushInterval > 0 && !s.isSynchronous {
+		s.wg.Add(1)
+		go s.flushPeriodically(flushInterval)
+	}
+	return s, nil
+}
+
+func (s *httpSink) flushPeriodically(interval time.Duration) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			// There is no caller to report to: the payloads of a failed
+			// background flush are lost, where there is no spool.
+			if err := s.flush(context.Background()); err != nil {
+				fmt.Fprintf(os.Stderr, "sink http: background flush failed: %v\n", err)
+			}
+		}
+	}
+}
+
+// Record queues payload for the next batch, delivering the batch once
+// full.  A synchronous sink delivers it at once.  The error is that of
+// this call's delivery alone.
+func (s *httpSink) Record(ctx context.Context, payload any) error {
+	line, marshalErr := json.Marshal(payload)
+	if marshalErr != nil {
+		return fmt.Errorf("marshal sink payload: %w", marshalErr)
+	}
+	if s.isSynchronous {
+		s.sendMu.Lock()
+		defer s.sendMu.Unlock()
+		return s.deliver(ctx, []json.RawMessage{line})
+	}
+	s.mu.Lock()
+	s.batch = append(s.batch, line)
+	isFull := len(s.batch) >= s.batchSize
+	s.mu.Unlock()
+	if !isFull {
+		return nil
+	}
+	return s.flush(ctx)
+}
+
+// flush delivers the current batch, taken under mu, which is released
+// for the delivery.
+func (s *httpSink) flush(ctx context.Context) error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	s.mu.Lock()
+	batch := s.batch
+	s.batch = nil
+	s.mu.Unlock()
+	return s.deliver(ctx, batch)
+}
+
+// deliver sends the spool and then batch, spooling the latter should
+// delivery fail.  An error means that payloads have been neither
+// delivered nor spooled.  Called with sendMu held.
+func (s *httpSink) deliver(ctx context.Context, batch []json.RawMessage) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("marshal sink batch: %w", err)
+	}
+	if spoolErr := s.drainSpool(ctx); spoolErr != nil {
+		return s.spool(batch, spoolErr)
+	}
+	if postErr := s.post(ctx, body); postErr != nil {
+		return s.spool(batch, postErr)
+	}
+	return nil
+}
+
+// post delivers body, retrying failures with exponential backoff.
+func (s *httpSink) post(ctx context.Context, body []byte) error {
+	backoff := s.retryBackoff
+	var err error
+	for attempt := 0; attempt <= s.maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return errors.Join(err, ctx.Err())
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+		if err = s.postOnce(ctx, body); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("post sink batch to %s after %d attempts: %w", s.url, s.maxRetries+1, err)
+}
+
+func (s *httpSink) postOnce(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body) //nolint:errcheck // drained for connection reuse
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// spool writes an undeliverable batch to a new spool file, synced before
+// it is reported spooled; cause is returned where there is no spool or
+// writing it fails.  Called with sendMu held.
+func (s *httpSink) spool(batch []json.RawMessage, cause error) error {
+	if s.spoolDir == "" {
+		return cause
+	}
+	s.spoolSequence++
+	name := fmt.Sprintf("batch_%s_%06d%s",
+		time.Now().UTC().Format("20060102T150405.000000000Z"), s.spoolSequence, httpSpoolFileSuffix)
+	var buf bytes.Buffer
+	for _, line := range batch {
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	path := filepath.Join(s.spoolDir, name)
+	if err := writeFileSync(path, buf.Bytes()); err != nil {
+		return errors.Join(cause, fmt.Errorf("spool sink batch to %q: %w", path, err))
+	}
+	return nil
+}
+
+func writeFileSync(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(data); err == nil {
+		err = f.Sync()
+	}
+	return errors.Join(err, f.Close())
+}
+
+func (s *httpSink) spoolFiles() ([]string, error) {
+	entries, err := os.ReadDir(s.spoolDir)
+	if err != nil {
+		return nil, err
+	}
+	var rv []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), httpSpoolFileSuffix) {
+			rv = append(rv, filepath.Join(s.spoolDir, e.Name()))
+		}
+	}
+	// Names embed a UTC timestamp, so lexical order is age order.
+	sort.Strings(rv)
+	return rv, nil
+}
+
+func readSpoolFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var batch []json.RawMessage
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024) //nolint:mnd // generous line bound
+	for scanner.Scan() {
+		if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+			batch = append(batch, json.RawMessage(append([]byte(nil), line...)))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(batch)
+}
+
+// drainSpool resends spooled batches, oldest first, removing
+// each once delivered and stopping at the first failure.
+func (s *httpSink) drainSpool(ctx context.Context) error {
+	if s.spoolDir == "" {
+		return nil
+	}
+	files, err := s.spoolFiles()
+	if err != nil {
+		return fmt.Errorf("list sink spool: %w", err)
+	}
+	for _, path := range files {
+		body, readErr := readSpoolFile(path)
+		if readErr != nil {
+			return fmt.Errorf("read sink spool file %q: %w", path, readErr)
+		}
+		if postErr := s.post(ctx, body); postErr != nil {
+			return postErr
+		}
+		if removeErr := os.Remove(path); removeErr != nil {
+			return fmt.Errorf("remove sink spool file %q: %w", path, removeErr)
+		}
+	}
+	return nil
+}
+
+// Close stops the flush timer and flushes the final batch.
+func (s *httpSink) Close() error {
+	close(s.done)
+	s.wg.Wait()
+	return s.flush(context.Background())
+}
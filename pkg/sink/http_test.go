@@ -0,0 +1,272 @@
+package sink_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stackql/stackql/pkg/sink"
+)
+
+type batchCollector struct {
+	mu      sync.Mutex
+	batches [][]map[string]any
+	headers []http.Header
+}
+
+func (c *batchCollector) handle(w http.ResponseWriter, r *http.Request) {
+	var batch []map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.batches = append(c.batches, batch)
+	c.headers = append(c.headers, r.Header.Clone())
+}
+
+func (c *batchCollector) snapshot() [][]map[string]any {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([][]map[string]any(nil), c.batches...)
+}
+
+func intPtr(i int) *int { return &i }
+
+func TestHTTPSink_BatchesAndFlushesOnClose(t *testing.T) {
+	collector := &batchCollector{}
+	srv := httptest.NewServer(http.HandlerFunc(collector.handle))
+	t.Cleanup(srv.Close)
+
+	s, err := sink.NewHTTPSink(sink.HTTPConfig{
+		URL:           srv.URL,
+		Headers:       map[string]string{"Authorization": "Bearer token"},
+		BatchSize:     2,
+		FlushInterval: "1h",
+	})
+	if err != nil {
+		t.Fatalf("NewHTTPSink: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := s.Record(context.Background(), map[string]any{"n": i}); err != nil {
+			t.Fatalf("Record %d: %v", i, err)
+		}
+	}
+	if got := len(collector.snapshot()); got != 1 {
+		t.Fatalf("expected 1 batch before Close, got %d", got)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	batches := collector.snapshot()
+	if len(batches) != 2 || len(batches[0]) != 2 || len(batches[1]) != 1 {
+		t.Fatalf("unexpected batches: %v", batches)
+	}
+	if got := collector.headers[0].Get("Authorization"); got != "Bearer token" {
+		t.Errorf("Authorization = %q", got)
+	}
+}
+
+func TestHTTPSink_RetriesTransientFailure(t *testing.T) {
+	collector := &batchCollector{}
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		collector.handle(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	s, err := sink.NewHTTPSink(sink.HTTPConfig{URL: srv.URL, BatchSize: 1, RetryBackoff: "1ms"})
+	if err != nil {
+		t.Fatalf("NewHTTPSink: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Record(context.Background(), map[string]any{"n": 1}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if calls.Load() != 2 || len(collector.snapshot()) != 1 {
+		t.Fatalf("expected delivery on the retry, calls = %d", calls.Load())
+	}
+}
+
+func TestHTTPSink_FailureWithoutSpoolIsError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(srv.Close)
+
+	s, err := sink.NewHTTPSink(sink.HTTPConfig{URL: srv.URL, BatchSize: 1, MaxRetries: intPtr(0)})
+	if err != nil {
+		t.Fatalf("NewHTTPSink: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Record(context.Background(), map[string]any{"n": 1}); err == nil {
+		t.Fatal("expected Record to report an undeliverable batch")
+	}
+}
+
+func TestHTTPSink_SpoolsAndDrainsInOrder(t *testing.T) {
+	collector := &batchCollector{}
+	var down atomic.Bool
+	down.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		collector.handle(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	spoolDir := t.TempDir()
+	s, err := sink.NewHTTPSink(sink.HTTPConfig{
+		URL:        srv.URL,
+		BatchSize:  1,
+		MaxRetries: intPtr(0),
+		SpoolDir:   spoolDir,
+	})
+	if err != nil {
+		t.Fatalf("NewHTTPSink: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	for i := 0; i < 2; i++ {
+		if err := s.Record(context.Background(), map[string]any{"n": i}); err != nil {
+			t.Fatalf("Record %d should spool rather than fail: %v", i, err)
+		}
+	}
+	entries, err := os.ReadDir(spoolDir)
+	if err != nil {
+		t.Fatalf("read spool: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 spooled batches, got %v", entries)
+	}
+
+	down.Store(false)
+	if err := s.Record(context.Background(), map[string]any{"n": 2}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	batches := collector.snapshot()
+	if len(batches) != 3 {
+		t.Fatalf("expected spool drained ahead of new batch, got %v", batches)
+	}
+	for i, batch := range batches {
+		if len(batch) != 1 || batch[0]["n"] != float64(i) {
+			t.Errorf("batch %d out of order: %v", i, batch)
+		}
+	}
+	if entries, _ = os.ReadDir(spoolDir); len(entries) != 0 {
+		t.Errorf("expected empty spool, got %v", entries)
+	}
+}
+
+func TestHTTPSink_SynchronousReportsEachRecord(t *testing.T) {
+	collector := &batchCollector{}
+	var down atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		collector.handle(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	s, err := sink.NewHTTPSink(sink.HTTPConfig{URL: srv.URL, MaxRetries: intPtr(0), Synchronous: true})
+	if err != nil {
+		t.Fatalf("NewHTTPSink: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Record(context.Background(), map[string]any{"n": 0}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if got := len(collector.snapshot()); got != 1 {
+		t.Fatalf("expected delivery before Record returned, got %d batches", got)
+	}
+	down.Store(true)
+	if err := s.Record(context.Background(), map[string]any{"n": 1}); err == nil {
+		t.Fatal("expected Record to report its own undeliverable payload")
+	}
+	down.Store(false)
+	if err := s.Record(context.Background(), map[string]any{"n": 2}); err != nil {
+		t.Fatalf("expected an earlier failure not to be reported again: %v", err)
+	}
+}
+
+func TestHTTPSink_SynchronousSpoolsBeforeReturning(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	t.Cleanup(srv.Close)
+
+	spoolDir := t.TempDir()
+	s, err := sink.NewHTTPSink(sink.HTTPConfig{
+		URL:         srv.URL,
+		MaxRetries:  intPtr(0),
+		SpoolDir:    spoolDir,
+		Synchronous: true,
+	})
+	if err != nil {
+		t.Fatalf("NewHTTPSink: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Record(context.Background(), map[string]any{"n": 0}); err != nil {
+		t.Fatalf("Record should spool rather than fail: %v", err)
+	}
+	if entries, _ := os.ReadDir(spoolDir); len(entries) != 1 {
+		t.Fatalf("expected the payload spooled before Record returned, got %v", entries)
+	}
+}
+
+func TestHTTPSink_BackgroundFlushNeitherBlocksNorLeaksErrors(t *testing.T) {
+	entered := make(chan struct{}, 1)
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		select {
+		case entered <- struct{}{}:
+		default:
+		}
+		<-release
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(srv.Close)
+
+	s, err := sink.NewHTTPSink(sink.HTTPConfig{
+		URL:           srv.URL,
+		FlushInterval: "10ms",
+		MaxRetries:    intPtr(0),
+	})
+	if err != nil {
+		t.Fatalf("NewHTTPSink: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	t.Cleanup(func() { close(release) })
+	if err := s.Record(context.Background(), map[string]any{"n": 0}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	select {
+	case <-entered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("background flush never reached the collector")
+	}
+	recorded := make(chan error, 1)
+	go func() { recorded <- s.Record(context.Background(), map[string]any{"n": 1}) }()
+	select {
+	case err := <-recorded:
+		if err != nil {
+			t.Errorf("expected the background failure not to be reported to Record: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Record blocked behind a background delivery")
+	}
+}
@@ -1,6 +1,6 @@
 // Package sink provides a generic record-and-close interface for writing
 // JSON-marshalable payloads to a destination, and the concrete implementations
-// (file, nop) the rest of the codebase consumes.
+// (file, syslog, HTTP, nop) the rest of the codebase consumes.
 //
 // The interface is deliberately payload-agnostic: callers pass any value that
 // json.Marshal can handle, and the sink takes responsibility for serialisation,
@@ -9,7 +9,11 @@
 // rotation, GC, alternate transports plug in once and benefit everyone.
 package sink
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"time"
+)
 
 // Sink is the generic destination contract.  Implementations must be safe for
 // concurrent calls from multiple goroutines.
@@ -30,3 +34,19 @@ func NewNopSink() Sink { return &nopSink{} }
 
 func (*nopSink) Record(_ context.Context, _ any) error { return nil }
 func (*nopSink) Close() error                          { return nil }
+
+// parseDuration parses the Go duration string value of the named
+// configuration field, substituting fallback for the empty string.
+func parseDuration(field, value string, fallback time.Duration) (time.Duration, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("sink %s: %w", field, err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("sink %s: must not be negative", field)
+	}
+	return d, nil
+}
@@ -0,0 +1,218 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	syslogVersion        = 1
+	syslogNilValue       = "-"
+	syslogDefaultAppName = "stackql"
+	syslogDefaultTimeout = 5 * time.Second
+	// syslogMaxFieldLen is the RFC 5424 bound on APP-NAME;
+	// HOSTNAME allows more, but this suffices in practice.
+	syslogMaxFieldLen = 48
+)
+
+//nolint:gochecknoglobals // immutable lookup
+var (
+	syslogFacilities = map[string]int{
+		"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5,
+		"lpr": 6, "news": 7, "uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+		"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+		"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+	}
+	syslogSeverities = map[string]int{
+		"emerg": 0, "alert": 1, "crit": 2, "err": 3,
+		"warning": 4, "notice": 5, "info": 6, "debug": 7,
+	}
+)
+
+// SyslogConfig is the syslog sink configuration.  Messages are formatted
+// per RFC 5424, the payload JSON forming the MSG part, and sent one per
+// datagram over UDP or octet-counted (RFC 6587) over TCP.
+type SyslogConfig struct {
+	// Network is "udp" (default) or "tcp".
+	Network string `json:"network,omitempty" yaml:"network,omitempty"`
+	// Address is the collector's host:port.  Required.
+	Address string `json:"address,omitempty" yaml:"address,omitempty"`
+	// Facility is a facility keyword, eg "auth" or "local0" (default).
+	Facility string `json:"facility,omitempty" yaml:"facility,omitempty"`
+	// Severity is a severity keyword, eg "notice" or "info" (default).
+	Severity string `json:"severity,omitempty" yaml:"severity,omitempty"`
+	// AppName is the APP-NAME header field; default "stackql".
+	AppName string `json:"app_name,omitempty" yaml:"app_name,omitempty"`
+	// MsgID is the MSGID header field; empty means the nil value.
+	MsgID string `json:"msg_id,omitempty" yaml:"msg_id,omitempty"`
+	// Hostname overrides the HOSTNAME header field, which
+	// otherwise is that of the local machine.
+	Hostname string `json:"hostname,omitempty" yaml:"hostname,omitempty"`
+	// Timeout bounds dialling and each write; a Go duration
+	// string, default "5s".
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
+
+type syslogSink struct {
+	mu       sync.Mutex
+	network  string
+	address  string
+	priority int
+	appName  string
+	msgID    string
+	hostname string
+	procID   string
+	timeout  time.Duration
+	conn     net.Conn
+}
+
+// NewSyslogSink constructs a sink writing to a remote syslog collector.
+// The connection is established eagerly, so that misconfiguration is
+// reported at startup, and re-established upon a failed write.
+func NewSyslogSink(cfg SyslogConfig) (Sink, error) {
+	network := strings.ToLower(cfg.Network)
+	switch network {
+	case "":
+		network = "udp"
+	case "udp", "tcp":
+	default:
+		return nil, fmt.Errorf("sink syslog: unsupported network %q (legal: udp, tcp)", cfg.Network)
+	}
+	if cfg.Address == "" {
+		return nil, fmt.Errorf("sink syslog: address is required")
+	}
+	facility, ok := syslogFacilities[strings.ToLower(defaultString(cfg.Facility, "local0"))]
+	if !ok {
+		return nil, fmt.Errorf("sink syslog: unknown facility %q", cfg.Facility)
+	}
+	severity, ok := syslogSeverities[strings.ToLower(defaultString(cfg.Severity, "info"))]
+	if !ok {
+		return nil, fmt.Errorf("sink syslog: unknown severity %q", cfg.Severity)
+	}
+	timeout, err := parseDuration("syslog timeout", cfg.Timeout, syslogDefaultTimeout)
+	if err != nil {
+		return nil, err
+	}
+	hostname := cfg.Hostname
+	if hostname == "" {
+		hostname, _ = os.Hostname()
+	}
+	s := &syslogSink{
+		network:  network,
+		address:  cfg.Address,
+		priority: facility*8 + severity, //nolint:mnd // RFC 5424 PRI
+		appName:  syslogHeaderField(defaultString(cfg.AppName, syslogDefaultAppName)),
+		msgID:    syslogHeaderField(cfg.MsgID),
+		hostname: syslogHeaderField(hostname),
+		procID:   strconv.Itoa(os.Getpid()),
+		timeout:  timeout,
+	}
+	if dialErr := s.dial(); dialErr != nil {
+		return nil, dialErr
+	}
+	return s, nil
+}
+
+func defaultString(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
+
+// syslogHeaderField renders a header field as printable ASCII
+// without spaces, substituting the nil value for the empty string.
+func syslogHeaderField(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r > ' ' && r < 0x7f {
+			b.WriteRune(r)
+		}
+	}
+	rv := b.String()
+	if len(rv) > syslogMaxFieldLen {
+		rv = rv[:syslogMaxFieldLen]
+	}
+	if rv == "" {
+		return syslogNilValue
+	}
+	return rv
+}
+
+func (s *syslogSink) dial() error {
+	conn, err := net.DialTimeout(s.network, s.address, s.timeout)
+	if err != nil {
+		return fmt.Errorf("sink syslog: dial %s %s: %w", s.network, s.address, err)
+	}
+	s.conn = conn
+	return nil
+}
+
+// format renders one RFC 5424 message with no structured data.
+func (s *syslogSink) format(t time.Time, msg []byte) []byte {
+	header := fmt.Sprintf("<%d>%d %s %s %s %s %s %s ",
+		s.priority,
+		syslogVersion,
+		t.UTC().Format(time.RFC3339Nano),
+		s.hostname,
+		s.appName,
+		s.procID,
+		s.msgID,
+		syslogNilValue,
+	)
+	rv := append([]byte(header), msg...)
+	if s.network == "tcp" {
+		rv = append([]byte(fmt.Sprintf("%d ", len(rv))), rv...)
+	}
+	return rv
+}
+
+func (s *syslogSink) write(frame []byte) error {
+	if s.conn == nil {
+		if err := s.dial(); err != nil {
+			return err
+		}
+	}
+	s.conn.SetWriteDeadline(time.Now().Add(s.timeout)) //nolint:errcheck // a failed deadline shows up on write
+	_, err := s.conn.Write(frame)
+	return err
+}
+
+func (s *syslogSink) Record(_ context.Context, payload any) error {
+	msg, marshalErr := json.Marshal(payload)
+	if marshalErr != nil {
+		return fmt.Errorf("marshal sink payload: %w", marshalErr)
+	}
+	frame := s.format(time.Now(), msg)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.write(frame); err != nil {
+		// One reconnect attempt covers a collector restart.
+		if s.conn != nil {
+			s.conn.Close() //nolint:errcheck // replacing a broken connection
+			s.conn = nil
+		}
+		if retryErr := s.write(frame); retryErr != nil {
+			return fmt.Errorf("write syslog payload: %w", retryErr)
+		}
+	}
+	return nil
+}
+
+func (s *syslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
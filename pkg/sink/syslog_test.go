@@ -0,0 +1,138 @@
+package sink_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stackql/stackql/pkg/sink"
+)
+
+//nolint:gochecknoglobals // test fixture
+var rfc5424Header = regexp.MustCompile(`^<(\d+)>1 \S+ testhost stackql \d+ audit - `)
+
+func TestSyslogSink_UDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	s, err := sink.NewSyslogSink(sink.SyslogConfig{
+		Address:  conn.LocalAddr().String(),
+		Facility: "auth",
+		Severity: "notice",
+		MsgID:    "audit",
+		Hostname: "testhost",
+	})
+	if err != nil {
+		t.Fatalf("NewSyslogSink: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+
+	if err := s.Record(context.Background(), map[string]any{"k": "v"}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	buf := make([]byte, 4096)
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	msg := string(buf[:n])
+	m := rfc5424Header.FindStringSubmatch(msg)
+	if m == nil {
+		t.Fatalf("not an RFC 5424 message: %q", msg)
+	}
+	// auth (4) * 8 + notice (5)
+	if m[1] != "37" {
+		t.Errorf("PRI = %s, want 37", m[1])
+	}
+	if !strings.HasSuffix(msg, `{"k":"v"}`) {
+		t.Errorf("expected JSON payload as MSG, got %q", msg)
+	}
+}
+
+func TestSyslogSink_TCPOctetCounting(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	frames := make(chan string, 2)
+	go func() {
+		c, acceptErr := ln.Accept()
+		if acceptErr != nil {
+			return
+		}
+		defer c.Close()
+		r := bufio.NewReader(c)
+		for {
+			prefix, readErr := r.ReadString(' ')
+			if readErr != nil {
+				return
+			}
+			length, _ := strconv.Atoi(strings.TrimSpace(prefix))
+			frame := make([]byte, length)
+			if _, readErr = io.ReadFull(r, frame); readErr != nil {
+				return
+			}
+			frames <- string(frame)
+		}
+	}()
+
+	s, err := sink.NewSyslogSink(sink.SyslogConfig{
+		Network:  "tcp",
+		Address:  ln.Addr().String(),
+		MsgID:    "audit",
+		Hostname: "testhost",
+	})
+	if err != nil {
+		t.Fatalf("NewSyslogSink: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+
+	for i := 0; i < 2; i++ {
+		if err := s.Record(context.Background(), map[string]any{"n": i}); err != nil {
+			t.Fatalf("Record %d: %v", i, err)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case frame := <-frames:
+			m := rfc5424Header.FindStringSubmatch(frame)
+			if m == nil {
+				t.Fatalf("not an RFC 5424 message: %q", frame)
+			}
+			// local0 (16) * 8 + info (6)
+			if m[1] != "134" {
+				t.Errorf("PRI = %s, want 134", m[1])
+			}
+			if !strings.HasSuffix(frame, `{"n":`+strconv.Itoa(i)+`}`) {
+				t.Errorf("frame %d has unexpected payload: %q", i, frame)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out awaiting frame %d", i)
+		}
+	}
+}
+
+func TestSyslogSink_RejectsIllegalConfig(t *testing.T) {
+	for _, cfg := range []sink.SyslogConfig{
+		{},
+		{Address: "127.0.0.1:514", Network: "unix"},
+		{Address: "127.0.0.1:514", Facility: "nope"},
+		{Address: "127.0.0.1:514", Severity: "nope"},
+		{Address: "127.0.0.1:514", Timeout: "soon"},
+	} {
+		if _, err := sink.NewSyslogSink(cfg); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package sqldatasource
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/stackql/any-sdk/pkg/dto"
+)
+
+const (
+	mySQLTableColumnsQuery = `
+	SELECT
+		column_name,
+		data_type
+	FROM
+		information_schema.columns
+	WHERE
+		table_schema = COALESCE(NULLIF(?, ''), DATABASE())
+		AND
+		table_name = ?
+	ORDER BY ordinal_position ASC
+	`
+	sqLiteDefaultSchema     = "main"
+	sqLiteTableColumnsQuery = `
+	SELECT
+		name,
+		type
+	FROM
+		pragma_table_info(?, ?)
+	ORDER BY cid ASC
+	`
+)
+
+// builtinDrivers are registered as this package is initialised, so
+// that they take their names before those of embedders.
+//
+//nolint:gochecknoglobals // registry pattern
+var builtinDrivers = map[string]Driver{
+	"snowflake": newGenericDriver("snowflake", "snowflake"),
+	"postgres":  newGenericDriver("pgx", "postgres"),
+	"mysql":     newMySQLDriver,
+	"sqlite":    newSQLiteDriver,
+}
+
+//nolint:gochecknoinits // builtin drivers take their names before those of embedders
+func init() {
+	for name, driver := range builtinDrivers {
+		driverRegistry[name] = driver
+	}
+}
+
+func newGenericDriver(sqlDriverName string, dbName string) Driver {
+	return func(authCtx *dto.AuthCtx, open OpenFunc) (DataSource, error) {
+		return open(authCtx, sqlDriverName, dbName)
+	}
+}
+
+type mySQLDataSource struct {
+	DataSource
+}
+
+func newMySQLDriver(authCtx *dto.AuthCtx, open OpenFunc) (DataSource, error) {
+	ds, err := open(authCtx, "mysql", "mysql")
+	if err != nil {
+		return nil, err
+	}
+	return &mySQLDataSource{DataSource: ds}, nil
+}
+
+// GetTableColumns reads information_schema;
+// absent a schema, that of the connection is used.
+func (ds *mySQLDataSource) GetTableColumns(args ...string) ([]Column, error) {
+	schemaName, tableName, err := splitTableColumnsArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := ds.Query(mySQLTableColumnsQuery, schemaName, tableName) //nolint:rowserrcheck // checked by callee
+	if err != nil {
+		return nil, err
+	}
+	return scanColumns(rows, tableName)
+}
+
+// sqLiteDataSource is a local SQLite database file,
+// addressed by a `dsn` such as `file:/path/to/cmdb.db?mode=ro`.
+type sqLiteDataSource struct {
+	DataSource
+}
+
+func newSQLiteDriver(authCtx *dto.AuthCtx, open OpenFunc) (DataSource, error) {
+	ds, err := open(authCtx, "sqlite3", "sqlite")
+	if err != nil {
+		return nil, err
+	}
+	return &sqLiteDataSource{DataSource: ds}, nil
+}
+
+// GetTableColumns reads the declared column types;
+// absent a schema, that of the main database is used.
+func (ds *sqLiteDataSource) GetTableColumns(args ...string) ([]Column, error) {
+	schemaName, tableName, err := splitTableColumnsArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	if schemaName == "" {
+		schemaName = sqLiteDefaultSchema
+	}
+	rows, err := ds.Query(sqLiteTableColumnsQuery, tableName, schemaName) //nolint:rowserrcheck // checked by callee
+	if err != nil {
+		return nil, err
+	}
+	return scanColumns(rows, tableName)
+}
+
+// splitTableColumnsArgs interprets GetTableColumns()
+// args as either `{schema}, {table}` or `{table}`.
+func splitTableColumnsArgs(args []string) (string, string, error) {
+	switch len(args) {
+	case 1:
+		return "", args[0], nil
+	case 2: //nolint:mnd // schema and table
+		return args[0], args[1], nil
+	default:
+		return "", "", fmt.Errorf("could not obtain sql data source table metadata for args = '%v'", args)
+	}
+}
+
+// scanColumns reads rows of column name and type,
+// in ordinal order.
+func scanColumns(rows *sql.Rows, tableName string) ([]Column, error) {
+	defer rows.Close()
+	var columns []Column
+	for rows.Next() {
+		var col Column
+		if err := rows.Scan(&col.Name, &col.Type); err != nil {
+			return nil, err
+		}
+		columns = append(columns, col)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("could not obtain sql data source table metadata: table '%s' not found", tableName)
+	}
+	return columns, nil
+}
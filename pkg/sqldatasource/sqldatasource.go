@@ -0,0 +1,90 @@
+// Package sqldatasource is the registry of SQL data source drivers.  A
+// provider addresses a SQL data source through an auth context of type
+// `sql_data_source::{driver}`, which selects the driver registered under
+// that name.  The builtin drivers, `mysql`, `postgres`, `snowflake` and
+// `sqlite`, are registered as this package is initialised; those
+// embedding stackql register drivers of their own here.
+package sqldatasource
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/stackql/any-sdk/pkg/dto"
+)
+
+// Column is a column of a table of a data source,
+// its type as the data source declares it.
+type Column struct {
+	Name string
+	Type string
+}
+
+type DataSource interface {
+	Begin() (*sql.Tx, error)
+	Exec(string, ...interface{}) (sql.Result, error)
+	Query(string, ...interface{}) (*sql.Rows, error)
+	QueryRow(string, ...any) *sql.Row
+	// GetTableColumns describes, in ordinal order, the
+	// columns of the table identified by args:
+	// `{schema}, {table}` or simply `{table}`.
+	GetTableColumns(...string) ([]Column, error)
+	GetSchemaType() string
+	GetDBName() string
+}
+
+// OpenFunc opens a data source of the supplied database/sql
+// driver name and database name, eg `pgx` and `postgres`, from
+// the SQL config of the auth context, as stackql does for its
+// builtin drivers.
+type OpenFunc func(authCtx *dto.AuthCtx, sqlDriverName string, dbName string) (DataSource, error)
+
+// Driver constructs a data source for an auth context whose type
+// selects the driver.  Drivers backed by a database/sql driver
+// would typically delegate to open, and describe tables themselves.
+type Driver func(authCtx *dto.AuthCtx, open OpenFunc) (DataSource, error)
+
+//nolint:gochecknoglobals // registry pattern
+var (
+	driverRegistryMutex sync.RWMutex
+	driverRegistry      = map[string]Driver{}
+)
+
+// RegisterDriver makes a data source driver available to auth
+// contexts of type `sql_data_source::{name}`.  It is intended to be
+// called at initialisation time and fails if name is already taken,
+// the builtin drivers included.
+func RegisterDriver(name string, driver Driver) error {
+	if name == "" || driver == nil {
+		return fmt.Errorf("cannot register sql data source driver with empty name or nil driver")
+	}
+	driverRegistryMutex.Lock()
+	defer driverRegistryMutex.Unlock()
+	if _, exists := driverRegistry[name]; exists {
+		return fmt.Errorf("sql data source driver '%s' is already registered", name)
+	}
+	driverRegistry[name] = driver
+	return nil
+}
+
+// GetDriver returns the driver registered as name.
+func GetDriver(name string) (Driver, bool) {
+	driverRegistryMutex.RLock()
+	defer driverRegistryMutex.RUnlock()
+	driver, ok := driverRegistry[name]
+	return driver, ok
+}
+
+// GetRegisteredDrivers returns the sorted names of all drivers.
+func GetRegisteredDrivers() []string {
+	driverRegistryMutex.RLock()
+	defer driverRegistryMutex.RUnlock()
+	rv := make([]string, 0, len(driverRegistry))
+	for name := range driverRegistry {
+		rv = append(rv, name)
+	}
+	sort.Strings(rv)
+	return rv
+}
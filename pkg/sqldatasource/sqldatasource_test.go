@@ -0,0 +1,85 @@
+package sqldatasource_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stackql/any-sdk/pkg/dto"
+
+	"github.com/stackql/stackql/pkg/sqldatasource"
+)
+
+func TestRegisterDriver(t *testing.T) {
+	driver := func(*dto.AuthCtx, sqldatasource.OpenFunc) (sqldatasource.DataSource, error) {
+		return nil, nil
+	}
+	if err := sqldatasource.RegisterDriver("test_embedded", driver); err != nil {
+		t.Fatalf("RegisterDriver: %v", err)
+	}
+	if _, ok := sqldatasource.GetDriver("test_embedded"); !ok {
+		t.Errorf("expected the registered driver to be found")
+	}
+	if err := sqldatasource.RegisterDriver("test_embedded", driver); err == nil {
+		t.Errorf("expected a duplicate registration to fail")
+	}
+	if err := sqldatasource.RegisterDriver("", driver); err == nil {
+		t.Errorf("expected a registration without a name to fail")
+	}
+	found := false
+	for _, name := range sqldatasource.GetRegisteredDrivers() {
+		found = found || name == "test_embedded"
+	}
+	if !found {
+		t.Errorf("expected the registered driver to be listed")
+	}
+}
+
+// sqlDataSource opens a mocked database, as stackql
+// opens that of a builtin driver.
+type sqlDataSource struct {
+	sqldatasource.DataSource
+	db *sql.DB
+}
+
+func (ds *sqlDataSource) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return ds.db.Query(query, args...)
+}
+
+func TestBuiltinDrivers(t *testing.T) {
+	for _, name := range []string{"mysql", "postgres", "snowflake", "sqlite"} {
+		if _, ok := sqldatasource.GetDriver(name); !ok {
+			t.Errorf("expected the builtin driver %q registered by this package alone", name)
+		}
+	}
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+	driver, _ := sqldatasource.GetDriver("sqlite")
+	var sqlDriverName string
+	ds, err := driver(&dto.AuthCtx{}, func(_ *dto.AuthCtx, d string, _ string) (sqldatasource.DataSource, error) {
+		sqlDriverName = d
+		return &sqlDataSource{db: db}, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sqlDriverName != "sqlite3" {
+		t.Errorf("expected the sqlite3 database/sql driver, got %q", sqlDriverName)
+	}
+	mock.ExpectQuery("FROM\\s+pragma_table_info").
+		WithArgs("hosts", "main").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "type"}).AddRow("hostname", "TEXT"))
+	columns, err := ds.GetTableColumns("hosts")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(columns) != 1 || columns[0] != (sqldatasource.Column{Name: "hostname", Type: "TEXT"}) {
+		t.Errorf("unexpected columns: %v", columns)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
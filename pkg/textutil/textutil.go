@@ -1,6 +1,7 @@
 package textutil
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
 )
@@ -11,7 +12,8 @@ const (
 )
 
 var (
-	namespaceLikeStringRegex *regexp.Regexp = regexp.MustCompile(`{{.*?}}`)
+	namespaceLikeStringRegex  *regexp.Regexp = regexp.MustCompile(`{{.*?}}`)
+	parenthesisedExplainRegex *regexp.Regexp = regexp.MustCompile(`(?is)^(\s*(?:explain|describe|desc))\s*\(([^)]*)\)`)
 )
 
 func GetTemplateLikeString(templateString string) string {
@@ -36,3 +38,26 @@ func ExpandPlaceholders(template string, placeholder string, replacements []stri
 	expanded.WriteString(remainder)
 	return expanded.String()
 }
+
+// NormaliseExplain rewrites the postgres style parenthesised
+// option list, eg `EXPLAIN (FORMAT JSON) SELECT ...`, into the
+// form understood by the underlying parser, eg
+// `EXPLAIN FORMAT = JSON SELECT ...`.
+func NormaliseExplain(cmd string) (string, error) {
+	matches := parenthesisedExplainRegex.FindStringSubmatchIndex(cmd)
+	if matches == nil {
+		return cmd, nil
+	}
+	prefix := cmd[matches[2]:matches[3]]
+	options := strings.Fields(cmd[matches[4]:matches[5]])
+	var rewritten string
+	switch {
+	case len(options) == 2 && strings.EqualFold(options[0], "format"): //nolint:mnd // option and value
+		rewritten = fmt.Sprintf("format = %s", options[1])
+	case len(options) == 1 && strings.EqualFold(options[0], "analyze"):
+		rewritten = "analyze"
+	default:
+		return cmd, fmt.Errorf("unsupported explain options '%s'", cmd[matches[4]:matches[5]])
+	}
+	return fmt.Sprintf("%s %s%s", prefix, rewritten, cmd[matches[1]:]), nil
+}
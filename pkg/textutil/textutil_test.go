@@ -93,3 +93,45 @@ func TestExpandPlaceholders(t *testing.T) {
 		})
 	}
 }
+
+func TestNormaliseExplain(t *testing.T) {
+	cases := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "parenthesised format",
+			input: "EXPLAIN (FORMAT JSON) SELECT a FROM t",
+			want:  "EXPLAIN format = JSON SELECT a FROM t",
+		},
+		{
+			name:  "parenthesised analyze",
+			input: "explain (analyze) delete from t",
+			want:  "explain analyze delete from t",
+		},
+		{
+			name:  "native syntax unchanged",
+			input: "EXPLAIN FORMAT = JSON SELECT a FROM t",
+			want:  "EXPLAIN FORMAT = JSON SELECT a FROM t",
+		},
+		{
+			name:    "unsupported options refused",
+			input:   "EXPLAIN (VERBOSE, COSTS) SELECT a FROM t",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := textutil.NormaliseExplain(tc.input)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("NormaliseExplain(%q) error = %v, wantErr %v", tc.input, err, tc.wantErr)
+			}
+			if !tc.wantErr && got != tc.want {
+				t.Errorf("NormaliseExplain(%q) = %q, want %q", tc.input, got, tc.want)
+			}
+		})
+	}
+}